@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/haukened/gone/internal/archive"
+	"github.com/haukened/gone/internal/config"
+	"github.com/haukened/gone/internal/store"
+)
+
+// runExportCmd implements `gone export --out archive.tar`, writing every
+// stored secret (still encrypted) to a portable archive.
+func runExportCmd(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(out)
+	path := fs.String("out", "", "archive file to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("export: --out is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return exportArchive(context.Background(), cfg, *path, out)
+}
+
+// runImportCmd implements `gone import --in archive.tar`, loading an archive
+// produced by export into the configured backend. Expired secrets are skipped.
+func runImportCmd(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(out)
+	path := fs.String("in", "", "archive file to read")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("import: --in is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return importArchive(context.Background(), cfg, *path, out)
+}
+
+// openOfflineStore opens the configured backend for maintenance commands
+// (export, import, cleanup) without starting the server. The returned func releases the database.
+func openOfflineStore(cfg *config.Config) (*store.Store, func(), error) {
+	dataDir, blobDir, err := ensureDataDir(cfg.DataDir, cfg.RejectNetworkFS)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, idx, err := openDatabase(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	blobs, err := newBlobStorage(blobDir, cfg)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return newStore(idx, blobs, cfg, realClock{}, nil), func() { db.Close() }, nil
+}
+
+func exportArchive(ctx context.Context, cfg *config.Config, path string, out io.Writer) error {
+	st, closeDB, err := openOfflineStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 operator-supplied path
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	n, err := archive.Export(ctx, st, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	fmt.Fprintf(out, "exported %d secrets to %s\n", n, path)
+	return nil
+}
+
+func importArchive(ctx context.Context, cfg *config.Config, path string, out io.Writer) error {
+	st, closeDB, err := openOfflineStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	f, err := os.Open(path) // #nosec G304 operator-supplied path
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	imported, skipped, err := archive.Import(ctx, f, st, realClock{}.Now())
+	if err != nil {
+		return fmt.Errorf("import (after %d secrets): %w", imported, err)
+	}
+	fmt.Fprintf(out, "imported %d secrets, skipped %d expired\n", imported, skipped)
+	return nil
+}
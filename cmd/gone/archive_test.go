@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/config"
+)
+
+// TestExportImportArchive round-trips a secret between two data directories
+// through the CLI helpers.
+func TestExportImportArchive(t *testing.T) {
+	ctx := context.Background()
+	srcCfg := &config.Config{DataDir: filepath.Join(t.TempDir(), "src"), BlobFsync: "always"}
+	dstCfg := &config.Config{DataDir: filepath.Join(t.TempDir(), "dst"), BlobFsync: "always"}
+	st, closeSrc, err := openOfflineStore(srcCfg)
+	if err != nil {
+		t.Fatalf("open src: %v", err)
+	}
+	id := "abcdefabcdefabcdefabcdefabcdefab"
+	data := strings.Repeat("x", 5000) // larger than the inline threshold
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, strings.NewReader(data), int64(len(data)), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	closeSrc()
+
+	archivePath := filepath.Join(t.TempDir(), "gone.tar")
+	var out bytes.Buffer
+	if err := exportArchive(ctx, srcCfg, archivePath, &out); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !strings.Contains(out.String(), "exported 1 secrets") {
+		t.Fatalf("unexpected export output %q", out.String())
+	}
+	if err := exportArchive(ctx, srcCfg, archivePath, io.Discard); err == nil {
+		t.Fatalf("expected export to refuse overwriting an existing archive")
+	}
+	out.Reset()
+	if err := importArchive(ctx, dstCfg, archivePath, &out); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !strings.Contains(out.String(), "imported 1 secrets, skipped 0") {
+		t.Fatalf("unexpected import output %q", out.String())
+	}
+
+	dst, closeDst, err := openOfflineStore(dstCfg)
+	if err != nil {
+		t.Fatalf("open dst: %v", err)
+	}
+	defer closeDst()
+	_, rc, _, err := dst.Consume(ctx, id)
+	if err != nil {
+		t.Fatalf("consume imported: %v", err)
+	}
+	defer rc.Close()
+	if b, _ := io.ReadAll(rc); string(b) != data {
+		t.Fatalf("payload mismatch")
+	}
+}
+
+func TestArchiveCmdsRequirePath(t *testing.T) {
+	if err := runExportCmd(nil, io.Discard); err == nil {
+		t.Fatalf("expected --out required")
+	}
+	if err := runImportCmd(nil, io.Discard); err == nil {
+		t.Fatalf("expected --in required")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/haukened/gone/internal/config"
+	"github.com/haukened/gone/internal/janitor"
+)
+
+// runCleanupCmd implements `gone cleanup`, running a single janitor cycle
+// (expiry sweep plus orphan blob reconcile) for cron-driven deployments.
+func runCleanupCmd(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(out)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return cleanup(context.Background(), cfg, out)
+}
+
+func cleanup(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	st, closeDB, err := openOfflineStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	jan := janitor.New(st, nil, janitor.Config{Interval: time.Minute, Logger: slog.Default()})
+	n, err := jan.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("cleanup (after %d expired secrets): %w", n, err)
+	}
+	fmt.Fprintf(out, "deleted %d expired secrets, orphan blobs reconciled\n", n)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/config"
+)
+
+// TestCleanupOneShot seeds expired, live and orphaned data, then checks a
+// single cleanup pass removes only what the janitor would.
+func TestCleanupOneShot(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{DataDir: filepath.Join(t.TempDir(), "data"), BlobFsync: "always"}
+	st, closeDB, err := openOfflineStore(cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	const (
+		expired = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		live    = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+		small   = "cccccccccccccccccccccccccccccccc"
+	)
+	data := strings.Repeat("x", 5000) // larger than the inline threshold
+	for _, s := range []struct {
+		id, data string
+		expires  time.Time
+	}{
+		{expired, data, time.Now().Add(-time.Minute)},
+		{small, "tiny", time.Now().Add(-time.Minute)},
+		{live, data, time.Now().Add(time.Hour)},
+	} {
+		if err := st.Save(ctx, s.id, app.Meta{Version: 1, NonceB64u: "n"}, strings.NewReader(s.data), int64(len(s.data)), s.expires); err != nil {
+			t.Fatalf("seed %s: %v", s.id, err)
+		}
+	}
+	closeDB()
+	blobDir := filepath.Join(cfg.DataDir, "blobs")
+	orphan := filepath.Join(blobDir, "dddddddddddddddddddddddddddddddd.blob")
+	if err := os.WriteFile(orphan, []byte("orphan"), 0o600); err != nil {
+		t.Fatalf("orphan: %v", err)
+	}
+	// Reconcile skips blobs younger than a second.
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(orphan, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := cleanup(ctx, cfg, &out); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if !strings.Contains(out.String(), "deleted 2 expired secrets") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	for _, p := range []string{orphan, filepath.Join(blobDir, expired+".blob")} {
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s survived cleanup: %v", filepath.Base(p), err)
+		}
+	}
+
+	st, closeDB, err = openOfflineStore(cfg)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer closeDB()
+	_, rc, _, err := st.Consume(ctx, live)
+	if err != nil {
+		t.Fatalf("live secret removed: %v", err)
+	}
+	rc.Close()
+
+	out.Reset()
+	if err := cleanup(ctx, cfg, &out); err != nil || !strings.Contains(out.String(), "deleted 0 expired secrets") {
+		t.Fatalf("second pass: %q %v", out.String(), err)
+	}
+}
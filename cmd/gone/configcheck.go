@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/haukened/gone/internal/config"
+)
+
+// exitError carries a process exit status other than 1 out of a subcommand.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// exitCode returns the status main exits with after a subcommand fails.
+func exitCode(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return 1
+}
+
+// redactedFields are Config fields whose values never appear in output.
+var redactedFields = map[string]bool{"MetricsToken": true, "BlobEncryptionKey": true}
+
+// urlFields are Config fields holding URLs, which may embed credentials in
+// their userinfo or query string.
+var urlFields = map[string]bool{"ExpiryWebhook": true, "OTelEndpoint": true, "UIRedirectURL": true, "PublicBaseURL": true}
+
+// runConfigCmd implements `gone config --check`: it loads and validates the
+// configuration, prints the effective values and exits without touching the
+// data directory or binding a port. Validation failures exit with status 2.
+func runConfigCmd(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(out)
+	check := fs.Bool("check", false, "validate the GONE_* configuration and print the effective values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*check {
+		return errors.New("usage: gone config --check")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return &exitError{code: 2, err: err}
+	}
+	printConfig(cfg, out)
+	return nil
+}
+
+// printConfig writes one "Field: value" line per Config field, redacting
+// secrets that are set and any credentials embedded in URLs.
+func printConfig(cfg *config.Config, out io.Writer) {
+	v := reflect.ValueOf(*cfg)
+	for i := range v.NumField() {
+		name, val := v.Type().Field(i).Name, v.Field(i).Interface()
+		if redactedFields[name] && !v.Field(i).IsZero() {
+			val = "[redacted]"
+		} else if urlFields[name] {
+			val = redactURL(v.Field(i).String())
+		}
+		fmt.Fprintf(out, "%s: %v\n", name, val)
+	}
+}
+
+// redactURL masks the userinfo and query string of s, keeping the scheme,
+// host and path so operators can still see where it points. A value that
+// does not parse is withheld entirely.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "[redacted]"
+	}
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "REDACTED"
+	}
+	return u.String()
+}
@@ -10,6 +10,11 @@
 //  5. Configure and start the HTTP server.
 //
 // It blocks until the server exits with an error (other than http.ErrServerClosed).
+//
+// `gone metrics [--json]` instead prints the persisted metrics from the data
+// directory's database (opened read-only) and exits without serving.
+// `gone export --out FILE` and `gone import --in FILE` move secrets between
+// instances via a portable archive.
 // main is the program entry point; it orchestrates configuration loading,
 // validation, HTTP mux setup, and starts the HTTP server using the resolved
 // configuration. It exits the process with a non-zero status code on
@@ -18,27 +23,41 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"database/sql"
 
+	"github.com/haukened/gone/docs"
 	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/audit"
 	"github.com/haukened/gone/internal/config"
+	"github.com/haukened/gone/internal/domain"
 	"github.com/haukened/gone/internal/httpx"
 	"github.com/haukened/gone/internal/janitor"
 	"github.com/haukened/gone/internal/metrics"
+	"github.com/haukened/gone/internal/notify"
 	"github.com/haukened/gone/internal/store"
 	"github.com/haukened/gone/internal/store/filesystem"
 	"github.com/haukened/gone/internal/store/sqlite"
+	"github.com/haukened/gone/internal/store/tiered"
+	"github.com/haukened/gone/internal/tracing"
 	wembed "github.com/haukened/gone/web"
+	"golang.org/x/net/netutil"
 )
 
 // realClock implements app.Clock using time.Now.
@@ -46,15 +65,44 @@ type realClock struct{}
 
 func (realClock) Now() time.Time { return time.Now().UTC() }
 
+// Build information, overridden at link time, e.g.
+// -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.built=$(date -u +%FT%TZ)".
+var (
+	version = "dev"
+	commit  = "unknown"
+	built   = "unknown"
+)
+
+// loadConfig loads the configuration and installs the configured logger as
+// slog.Default so every component created afterwards inherits it.
 func loadConfig() (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("load config: %w", err)
 	}
+	slog.SetDefault(newLogger(cfg, os.Stderr))
 	return cfg, nil
 }
 
-func ensureDataDir(dir string) (string, string, error) {
+// newLogger builds a logger writing to w at cfg.LogLevel in cfg.LogFormat.
+func newLogger(cfg *config.Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.LogLevel)) // validated by config.Load
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// networkFSType reports the network filesystem holding a directory ("" when
+// local); a variable so tests can simulate one.
+var networkFSType = statfsNetworkType
+
+// ensureDataDir creates the data and blob directories as needed. With
+// rejectNetworkFS it refuses a data dir on NFS, CIFS and similar, where SQLite
+// locking is unreliable; the check is skipped where the type cannot be read.
+func ensureDataDir(dir string, rejectNetworkFS bool) (string, string, error) {
 	if st, err := os.Stat(dir); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			if mkErr := os.MkdirAll(dir, 0o700); mkErr != nil {
@@ -66,6 +114,11 @@ func ensureDataDir(dir string) (string, string, error) {
 	} else if !st.IsDir() {
 		return "", "", fmt.Errorf("data path not directory: %s", dir)
 	}
+	if rejectNetworkFS {
+		if err := checkLocalFS(dir); err != nil {
+			return "", "", err
+		}
+	}
 	blobDir := filepath.Join(dir, "blobs")
 	if err := os.MkdirAll(blobDir, 0o700); err != nil {
 		return "", "", fmt.Errorf("create blobs dir: %w", err)
@@ -73,39 +126,110 @@ func ensureDataDir(dir string) (string, string, error) {
 	return dir, blobDir, nil
 }
 
-func openDatabase(dataDir string) (*sql.DB, store.Index, error) {
+// checkLocalFS fails when dir is on a network filesystem. Platforms without
+// filesystem type detection pass with a warning.
+func checkLocalFS(dir string) error {
+	name, err := networkFSType(dir)
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		slog.Warn("cannot detect data dir filesystem type", "domain", "startup", "dir", dir)
+		return nil
+	case err != nil:
+		return fmt.Errorf("statfs data dir: %w", err)
+	case name != "":
+		return fmt.Errorf("data dir %s is on a network filesystem (%s); SQLite needs local storage", dir, name)
+	}
+	return nil
+}
+
+// auditDataDir checks that each directory is owner-traversable and not
+// accessible to group/other. Loose permissions are logged, or returned as an
+// error when strict is set.
+func auditDataDir(strict bool, dirs ...string) error {
+	for _, dir := range dirs {
+		st, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("stat data dir: %w", err)
+		}
+		perm := st.Mode().Perm()
+		if perm&0o700 != 0o700 {
+			return fmt.Errorf("data dir %s not accessible by owner: %v", dir, perm)
+		}
+		if perm&0o077 == 0 {
+			continue
+		}
+		if strict {
+			return fmt.Errorf("data dir %s permissions too open: %v", dir, perm)
+		}
+		slog.Warn("data dir permissions too open", "domain", "startup", "dir", dir, "mode", perm.String())
+	}
+	return nil
+}
+
+func openDatabase(dataDir string, opts ...sqlite.Option) (*sql.DB, store.Index, error) {
 	dbPath := filepath.Join(dataDir, "gone.db")
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open sqlite driver: %w", err)
 	}
-	idx, err := sqlite.New(db)
+	idx, err := sqlite.New(db, opts...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("init sqlite schema: %w", err)
 	}
 	return db, idx, nil
 }
 
-func newBlobStorage(blobDir string) (store.BlobStorage, error) {
-	blobs, err := filesystem.New(blobDir)
+func newBlobStorage(blobDir string, cfg *config.Config) (store.BlobStorage, error) {
+	opts := []filesystem.Option{filesystem.WithFsync(filesystem.FsyncPolicy(cfg.BlobFsync)), filesystem.WithShardDepth(cfg.BlobShardDepth)}
+	if cfg.BlobEncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.BlobEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("decode blob encryption key: %w", err)
+		}
+		opts = append(opts, filesystem.WithEncryptionKey(key))
+	}
+	primary, err := filesystem.New(blobDir, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("init blob storage: %w", err)
 	}
+	var blobs interface {
+		store.BlobStorage
+		store.SpaceReporter
+	} = primary
+	if cfg.BlobOverflowSize > 0 {
+		if err := os.MkdirAll(cfg.BlobOverflowDir, 0o700); err != nil {
+			return nil, fmt.Errorf("create overflow blobs dir: %w", err)
+		}
+		overflow, err := filesystem.New(cfg.BlobOverflowDir, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("init overflow blob storage: %w", err)
+		}
+		blobs = tiered.New(primary, overflow, cfg.BlobOverflowSize)
+	}
+	if cfg.MinFreeBytes > 0 {
+		if _, err := blobs.FreeBytes(); err != nil {
+			return nil, fmt.Errorf("check blob free space: %w", err)
+		}
+	}
 	return blobs, nil
 }
 
-type templates struct{ index, about, secret, errorPage *template.Template }
+type templates struct {
+	index, about, secret, errorPage *template.Template
+	aboutContent                    template.HTML // sanitized GONE_ABOUT_FILE content, if any
+	assets                          fs.FS         // filesystem the templates came from; also serves /static/
+}
 
 // parsePage parses the base partials plus a single page template.
 // Parameters:
 //
 //	base: the already-read partials template content as a string
 //	name: the name to assign to the page template
-//	file: the filename of the page template inside the embedded FS
+//	file: the filename of the page template inside fsys
 //
 // Returns the composed *template.Template or an error.
-func parsePage(base, name, file string) (*template.Template, error) {
-	pageBytes, err := fs.ReadFile(wembed.Assets, file)
+func parsePage(fsys fs.FS, base, name, file string) (*template.Template, error) {
+	pageBytes, err := fs.ReadFile(fsys, file)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +242,7 @@ func parsePage(base, name, file string) (*template.Template, error) {
 
 // parseAllPages parses all known page templates returning individual templates.
 // Splitting this out allows loadTemplates to remain very small and simple.
-func parseAllPages(base string) (idx, about, secret, errorPage *template.Template, err error) {
+func parseAllPages(fsys fs.FS, base string) (idx, about, secret, errorPage *template.Template, err error) {
 	pages := []struct {
 		name string
 		file string
@@ -131,7 +255,7 @@ func parseAllPages(base string) (idx, about, secret, errorPage *template.Templat
 	}
 	for _, p := range pages {
 		var t *template.Template
-		t, err = parsePage(base, p.name, p.file)
+		t, err = parsePage(fsys, base, p.name, p.file)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
@@ -148,23 +272,93 @@ func loadTemplatesFrom(fsys fs.FS) (*templates, error) {
 	if err != nil {
 		return nil, err
 	}
-	idx, about, secret, errorPage, err := parseAllPages(string(partialsBytes))
+	idx, about, secret, errorPage, err := parseAllPages(fsys, string(partialsBytes))
 	if err != nil {
 		return nil, err
 	}
-	return &templates{index: idx, about: about, secret: secret, errorPage: errorPage}, nil
+	return &templates{index: idx, about: about, secret: secret, errorPage: errorPage, assets: fsys}, nil
 }
 
 func loadTemplates() (*templates, error) { // retained for existing callers
 	return loadTemplatesFrom(wembed.Assets)
 }
 
-func buildService(idx store.Index, blobs store.BlobStorage, cfg *config.Config, clock app.Clock) *app.Service {
-	st := store.New(idx, blobs, clock, 1024*4)
-	return &app.Service{Store: st, Clock: clock, MaxBytes: cfg.MaxBytes, MinTTL: cfg.MinTTL, MaxTTL: cfg.MaxTTL}
+// webTemplateFiles are the templates a GONE_WEB_DIR must provide.
+var webTemplateFiles = []string{"partials.tmpl.html", "index.tmpl.html", "about.tmpl.html", "secret.tmpl.html", "error.tmpl.html"}
+
+// webAssets returns the filesystem serving templates and static assets: the
+// embedded web assets, or dir when set. A dir missing any template file is
+// an error naming every missing file.
+func webAssets(dir string) (fs.FS, error) {
+	if dir == "" {
+		return wembed.Assets, nil
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("web dir: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("web dir %s is not a directory", dir)
+	}
+	fsys := os.DirFS(dir)
+	var missing []string
+	for _, name := range webTemplateFiles {
+		if _, err := fs.Stat(fsys, name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("web dir %s missing %s", dir, strings.Join(missing, ", "))
+	}
+	return fsys, nil
+}
+
+// tenantNames returns the configured tenant names for store namespace registration.
+func tenantNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Tenants))
+	for _, t := range cfg.Tenants {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// newStore constructs the composite secret store with tenant namespaces registered.
+func newStore(idx store.Index, blobs store.BlobStorage, cfg *config.Config, clock app.Clock, tracer app.Tracer) *store.Store {
+	opts := []store.Option{store.WithTenants(tenantNames(cfg)...), store.WithTracer(tracer), store.WithMaxBlobBytes(cfg.MaxBlobBytes), store.WithClockSkew(cfg.ClockSkew), store.WithMinFreeBytes(cfg.MinFreeBytes), store.WithExpireBatch(cfg.ExpireBatchSize), store.WithExistsCache(cfg.ExistsCacheSize, cfg.ExistsCacheTTL), store.WithInlineMemBudget(cfg.InlineMemBudget)}
+	if cfg.ExpiryWebhook != "" {
+		opts = append(opts, store.WithExpiryNotifier(notify.NewWebhook(cfg.ExpiryWebhook)))
+	}
+	return store.New(idx, blobs, clock, 1024*4, opts...)
+}
+
+func buildService(idx store.Index, blobs store.BlobStorage, cfg *config.Config, clock app.Clock, tracer app.Tracer) *app.Service {
+	st := newStore(idx, blobs, cfg, clock, tracer)
+	svc := &app.Service{Store: st, Clock: clock, MaxBytes: cfg.MaxBytes, MinTTL: cfg.MinTTL, MaxTTL: cfg.MaxTTL, Tracer: tracer, ClampTTL: cfg.TTLOverflow == "clamp", MaxReads: cfg.MaxReadsLimit, PassphraseAttempts: cfg.PassphraseAttempts, InlineMax: st.InlineMax(), MaxTTLExternal: cfg.MaxTTLExternal, IDs: domain.CryptoIDs{}, ClientIDs: cfg.AllowClientIDs, AllowEmpty: cfg.AllowEmpty, MaxConsumeAttempts: cfg.MaxConsumeAttempts, PadSizes: cfg.PadSizes, MinBytes: cfg.MinBytes, TTLSnap: cfg.TTLSnap, TTLPresets: ttlPresets(cfg), TTLJitter: cfg.TTLJitter}
+	for _, v := range cfg.SupportedVersions {
+		svc.Versions = append(svc.Versions, uint8(v)) // config validates 1..255
+	}
+	if len(cfg.Tenants) > 0 {
+		svc.Tenants = make(map[string]domain.Tenant, len(cfg.Tenants))
+		for _, t := range cfg.Tenants {
+			svc.Tenants[t.Name] = t
+		}
+	}
+	return svc
 }
 
-func buildHandler(cfg *config.Config, svc *app.Service, db *sql.DB, blobDir string, tmpls *templates) http.Handler {
+// healthChecker is a component with its own readiness self-check, such as
+// the metrics manager.
+type healthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+// liveCounter is implemented by stores that can count readable secrets for
+// the verbose /readyz body.
+type liveCounter interface {
+	CountLive(ctx context.Context) (int64, error)
+}
+
+func buildHandler(cfg *config.Config, svc *app.Service, db *sql.DB, blobDir string, tmpls *templates, checks ...healthChecker) http.Handler {
 	readiness := func(ctx context.Context) error {
 		if err := db.PingContext(ctx); err != nil {
 			return err
@@ -172,24 +366,159 @@ func buildHandler(cfg *config.Config, svc *app.Service, db *sql.DB, blobDir stri
 		if _, err := os.ReadDir(blobDir); err != nil {
 			return err
 		}
+		for _, c := range checks {
+			if err := c.Healthy(ctx); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 	h := httpx.New(svc, cfg.MaxBytes, readiness)
 	h.IndexTmpl = httpx.TemplateRenderer{T: tmpls.index}
 	h.AboutTmpl = httpx.AboutTemplateRenderer{T: tmpls.about}
+	h.AboutContent = tmpls.aboutContent
 	h.SecretTmpl = httpx.TemplateRenderer{T: tmpls.secret}
 	if tmpls.errorPage != nil {
 		h.ErrorTmpl = httpx.TemplateRenderer{T: tmpls.errorPage}
 	}
-	h.Assets = http.FS(wembed.Assets)
+	h.Assets = http.FS(tmpls.assets)
 	h.MinTTL = cfg.MinTTL
 	h.MaxTTL = cfg.MaxTTL
 	h.TTLOptions = cfg.TTLOptions
+	h.TTLRange = cfg.TTLMode == "range"
+	h.Tenants = cfg.Tenants
+	h.NotFoundFloor = cfg.NotFoundFloor
+	h.UI = httpx.UIMode(cfg.UI)
+	h.UIRedirectURL = cfg.UIRedirectURL
+	h.OpTimeout = cfg.OpTimeout
+	h.UploadIdle = cfg.UploadIdleTimeout
+	h.AllowedContentTypes = cfg.AllowedContentTypes
+	h.MaxCreates = cfg.MaxConcurrentCreates
+	h.RevealHints = cfg.RevealHints
+	h.StrictHeaders = cfg.StrictHeaders
+	h.MaxNonceLen = cfg.MaxNonceLen
+	h.AllowEmpty = cfg.AllowEmpty
+	h.CSPNonce = cfg.CSPNonce
+	h.PublicBaseURL = cfg.PublicBaseURL
+	h.EchoRequestID = cfg.EchoRequestID
+	h.DirectRouting = cfg.DirectRouting
+	h.StaticMaxAge = cfg.StaticMaxAge
+	h.StaticImmutable = cfg.StaticImmutable
+	h.ReadyVerbose = cfg.ReadyVerbose
+	h.FlushChunk = cfg.ConsumeFlushBytes
+	h.RequireHTTPS = cfg.RequireHTTPS
+	h.AllowExtend = cfg.AllowExtend
+	h.ReadOnly = cfg.ReadOnly
+	h.Started = time.Now()
+	if lc, ok := svc.Store.(liveCounter); ok {
+		h.SecretCount = lc.CountLive
+	}
+	h.TrustedProxies, _ = httpx.ParseTrustedProxies(cfg.TrustedProxies) // validated as CIDRs by config
+	h.Build = httpx.BuildInfo{Version: version, Commit: commit, Built: built}
+	if spec, err := docs.OpenAPIJSON(); err == nil {
+		h.OpenAPI = spec
+	} else {
+		slog.Error("openapi document unavailable", "err", err)
+	}
+	if cfg.OTelEndpoint != "" {
+		h.Tracer = svc.Tracer
+	}
+	if obs, ok := svc.Metrics.(app.Observer); ok {
+		h.Latency = obs
+	}
 	return h.Router()
 }
 
+// newMetricsHandler serves the metrics snapshot, the expiry histogram under
+// /admin/expiry and, when a token is set, net/http/pprof under /debug/pprof/,
+// POST /metrics/rotate-token and POST /metrics/reset behind the same bearer
+// token. Profiling, rotation and reset are never exposed without
+// authentication.
+func newMetricsHandler(provider metrics.SnapshotProvider, secret string, expiry metrics.ExpirySource, buckets []time.Duration) http.Handler {
+	token := metrics.NewToken(secret)
+	mux := http.NewServeMux()
+	mux.Handle("/", metrics.Handler(provider, token))
+	mux.Handle("/admin/expiry", metrics.ExpiryHandler(expiry, buckets, token))
+	if secret != "" {
+		mux.Handle("/metrics/rotate-token", metrics.RotateTokenHandler(token))
+		if r, ok := provider.(metrics.Resetter); ok {
+			mux.Handle("/metrics/reset", metrics.ResetHandler(r, token))
+		}
+		pp := http.NewServeMux()
+		pp.HandleFunc("/debug/pprof/", pprof.Index)
+		pp.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		pp.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		pp.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		pp.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.Handle("/debug/pprof/", metrics.RequireToken(token, pp))
+	}
+	return mux
+}
+
+// expiryBuckets returns the configured histogram bounds, defaulting to one
+// bucket per TTL option.
+func expiryBuckets(cfg *config.Config) []time.Duration {
+	if len(cfg.ExpiryBuckets) > 0 {
+		return cfg.ExpiryBuckets
+	}
+	out := make([]time.Duration, 0, len(cfg.TTLOptions))
+	for _, opt := range cfg.TTLOptions {
+		out = append(out, opt.Duration)
+	}
+	return out
+}
+
+// ttlPresets returns the TTL option durations in ascending order, the grid
+// GONE_TTL_SNAP rounds client TTLs onto.
+func ttlPresets(cfg *config.Config) []time.Duration {
+	out := make([]time.Duration, 0, len(cfg.TTLOptions))
+	for _, opt := range cfg.TTLOptions {
+		out = append(out, opt.Duration)
+	}
+	slices.Sort(out)
+	return out
+}
+
 func newServer(cfg *config.Config, handler http.Handler) *http.Server {
-	return &http.Server{Addr: cfg.Addr, Handler: handler, ReadTimeout: 5 * time.Second, WriteTimeout: 10 * time.Second, IdleTimeout: 120 * time.Second}
+	srv := &http.Server{Addr: cfg.Addr, Handler: handler, ReadTimeout: 5 * time.Second, WriteTimeout: 10 * time.Second, IdleTimeout: 120 * time.Second, MaxHeaderBytes: cfg.MaxHeaderBytes}
+	if cfg.TLSEnabled() {
+		srv.TLSConfig = &tls.Config{MinVersion: tlsMinVersion(cfg.TLSMinVersion)}
+	}
+	return srv
+}
+
+// tlsMinVersion maps the configured minimum ("1.2" or "1.3") to a tls constant.
+func tlsMinVersion(v string) uint16 {
+	if v == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// listen binds addr, capping simultaneously open connections at maxConns
+// when positive. Connections beyond the cap wait in the kernel backlog until
+// a slot frees up.
+func listen(addr string, maxConns int) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if maxConns > 0 {
+		ln = netutil.LimitListener(ln, maxConns)
+	}
+	return ln, nil
+}
+
+// serve runs srv until it fails, terminating TLS itself when configured.
+func serve(srv *http.Server, cfg *config.Config) error {
+	ln, err := listen(srv.Addr, cfg.MaxConnections)
+	if err != nil {
+		return err
+	}
+	if cfg.TLSEnabled() {
+		return srv.ServeTLS(ln, cfg.TLSCert, cfg.TLSKey)
+	}
+	return srv.Serve(ln)
 }
 
 func run() error {
@@ -197,28 +526,99 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	dataDir, blobDir, err := ensureDataDir(cfg.DataDir)
+	dataDir, blobDir, err := ensureDataDir(cfg.DataDir, cfg.RejectNetworkFS)
 	if err != nil {
 		return err
 	}
-	db, idx, err := openDatabase(dataDir)
+	if err := auditDataDir(cfg.StrictPerms, dataDir, blobDir); err != nil {
+		return err
+	}
+	opts := []sqlite.Option{sqlite.WithBusyRetries(cfg.DBBusyRetries), sqlite.WithConsumeGrace(cfg.ConsumeGrace)}
+	var ro *sql.DB
+	if cfg.DBReadHandle {
+		if ro, err = openDatabaseReadOnly(dataDir); err != nil {
+			return err
+		}
+		defer ro.Close()
+		opts = append(opts, sqlite.WithReadDB(ro))
+	}
+	db, idx, err := openDatabase(dataDir, opts...)
 	if err != nil {
 		return err
 	}
+	if ro != nil {
+		// Readers on the second handle would otherwise block the writer.
+		if _, err := db.Exec(`PRAGMA journal_mode=WAL`); err != nil {
+			return fmt.Errorf("enable wal: %w", err)
+		}
+	}
+	if cfg.ConsumeGrace > 0 {
+		slog.Warn("consume grace enabled; external secrets can be read more than once", "domain", "startup", "grace", cfg.ConsumeGrace)
+	}
+	if cfg.AllowClientIDs {
+		slog.Warn("client-chosen secret ids enabled; ids may be predictable", "domain", "startup")
+	}
+	if cfg.PadSizes && cfg.UI == "enabled" {
+		slog.Warn("size padding enabled; the bundled web UI cannot decrypt padded secrets", "domain", "startup")
+	}
 	defer db.Close()
 	// Initialize metrics manager & schema early so other components can emit metrics.
 	ctx := context.Background()
-	mgr := metrics.New(db, metrics.Config{FlushInterval: 5 * time.Second, Logger: slog.Default()})
+	mgr := metrics.New(db, metrics.Config{FlushInterval: 5 * time.Second, Logger: slog.Default(), InMemory: cfg.MetricsPersist == "off", ReadDB: ro, LogInterval: cfg.MetricsLogInterval, BufferSize: cfg.MetricsBuffer, BlockTimeout: cfg.MetricsBlockTimeout})
 	if err := mgr.InitSchema(ctx); err != nil {
 		return err
 	}
 	mgr.Start(ctx)
 	defer mgr.Stop(context.Background())
 
+	blobs, err := newBlobStorage(blobDir, cfg)
+	if err != nil {
+		return err
+	}
+	tracer, shutdownTracing, err := tracing.Setup(ctx, cfg.OTelEndpoint)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+	clock := realClock{}
+	svc := buildService(idx, blobs, cfg, clock, tracer)
+	// Inject metrics into service (optional interface already defined)
+	svc.Metrics = mgr
+	if cfg.DailyCreateQuota > 0 {
+		quota, err := metrics.NewQuota(ctx, db, cfg.DailyCreateQuota)
+		if err != nil {
+			return err
+		}
+		svc.Quota = quota
+	}
+	if cfg.AuditLog != "" {
+		sink, err := audit.NewFileSink(cfg.AuditLog)
+		if err != nil {
+			return fmt.Errorf("open audit log: %w", err)
+		}
+		defer sink.Close()
+		svc.Auditor = sink
+	}
+	assets, err := webAssets(cfg.WebDir)
+	if err != nil {
+		return err
+	}
+	if cfg.WebDir != "" {
+		slog.Info("serving web assets from directory", "domain", "startup", "dir", cfg.WebDir)
+	}
+	tmpls, err := loadTemplatesFrom(assets)
+	if err != nil {
+		return err
+	}
+	if tmpls.aboutContent, err = httpx.LoadAboutContent(cfg.AboutFile); err != nil {
+		return fmt.Errorf("load about file: %w", err)
+	}
 	// Optional metrics server (separate listener) if configured.
 	var metricsSrv *http.Server
 	if cfg.MetricsAddr != "" {
-		metricsSrv = &http.Server{Addr: cfg.MetricsAddr, Handler: metrics.Handler(mgr, cfg.MetricsToken), ReadTimeout: 5 * time.Second, WriteTimeout: 5 * time.Second, IdleTimeout: 30 * time.Second}
+		expiry, _ := svc.Store.(metrics.ExpirySource)
+		// WriteTimeout leaves room for CPU profiles of up to a minute.
+		metricsSrv = &http.Server{Addr: cfg.MetricsAddr, Handler: newMetricsHandler(mgr, cfg.MetricsToken, expiry, expiryBuckets(cfg)), ReadTimeout: 5 * time.Second, WriteTimeout: 65 * time.Second, IdleTimeout: 30 * time.Second}
 		go func() {
 			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				slog.Error("metrics server error", "err", err)
@@ -226,27 +626,27 @@ func run() error {
 		}()
 		slog.Info("metrics server started", "addr", cfg.MetricsAddr)
 	}
-	blobs, err := newBlobStorage(blobDir)
-	if err != nil {
-		return err
+	// Start janitor with metrics.
+	janCfg := janitor.Config{
+		Interval:          cfg.JanitorInterval,
+		MinInterval:       cfg.JanitorMinInterval,
+		Logger:            slog.Default(),
+		IntegrityInterval: cfg.IntegrityScan,
+		IntegrityPace:     time.Second / time.Duration(cfg.IntegrityRate),
+		IntegrityRepair:   cfg.IntegrityRepair,
 	}
-	clock := realClock{}
-	svc := buildService(idx, blobs, cfg, clock)
-	// Inject metrics into service (optional interface already defined)
-	svc.Metrics = mgr
-	tmpls, err := loadTemplates()
-	if err != nil {
-		return err
+	jan := janitor.New(svc.Store, mgr, janCfg) // share the service store so its cached blob usage sees expiries
+	if cfg.ReadOnly {
+		// Expired secrets and orphan blobs stay on disk until the freeze lifts.
+		slog.Warn("read-only mode: creates, consumes and the janitor are paused")
+	} else {
+		jan.Start(ctx)
+		defer jan.Stop()
 	}
-	// Start janitor with metrics.
-	janCfg := janitor.Config{Interval: time.Minute, Logger: slog.Default()}
-	jan := janitor.New(store.New(idx, blobs, clock, 1024*4), mgr, janCfg) // reuse underlying components
-	jan.Start(ctx)
-	defer jan.Stop()
-
-	srv := newServer(cfg, buildHandler(cfg, svc, db, blobDir, tmpls))
-	slog.Info("starting server", "addr", cfg.Addr, "pid", os.Getpid())
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+
+	srv := newServer(cfg, buildHandler(cfg, svc, db, blobDir, tmpls, mgr))
+	slog.Info("starting server", "addr", cfg.Addr, "pid", os.Getpid(), "tls", cfg.TLSEnabled(), "version", version, "commit", commit)
+	if err := serve(srv, cfg); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	if metricsSrv != nil {
@@ -256,7 +656,26 @@ func run() error {
 	return nil
 }
 
+// subcommands maps `gone <name>` to maintenance commands that run instead of
+// the server.
+var subcommands = map[string]func(args []string, out io.Writer) error{
+	"metrics": runMetricsCmd,
+	"export":  runExportCmd,
+	"import":  runImportCmd,
+	"cleanup": runCleanupCmd,
+	"config":  runConfigCmd,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:], os.Stdout); err != nil {
+				slog.Error(os.Args[1]+" error", "err", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		}
+	}
 	if err := run(); err != nil {
 		slog.Error("server error", "err", err)
 		os.Exit(1)
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"html/template"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -16,6 +21,8 @@ import (
 	"github.com/haukened/gone/internal/app"
 	"github.com/haukened/gone/internal/config"
 	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/metrics"
 	"github.com/haukened/gone/internal/store"
 	"github.com/haukened/gone/internal/store/sqlite"
 	_ "github.com/mattn/go-sqlite3"
@@ -34,6 +41,10 @@ func (stubIndex) DeleteExpired(context.Context, time.Time) ([]store.ExpiredRecor
 	return nil, nil
 }
 func (stubIndex) ListExternalIDs(context.Context) ([]string, error) { return nil, nil }
+func (stubIndex) ExternalBytes(context.Context) (int64, error)      { return 0, nil }
+func (stubIndex) ExpiryHistogram(context.Context, time.Time, []time.Duration) (map[string]int64, error) {
+	return nil, nil
+}
 
 // stubBlobStorage implements store.BlobStorage.
 type stubBlobStorage struct{}
@@ -47,7 +58,7 @@ func (stubBlobStorage) List() ([]string, error)               { return nil, nil
 func TestEnsureDataDir(t *testing.T) {
 	tmp := t.TempDir()
 	data := filepath.Join(tmp, "data-root")
-	gotData, gotBlob, err := ensureDataDir(data)
+	gotData, gotBlob, err := ensureDataDir(data, false)
 	if err != nil {
 		t.Fatalf("ensureDataDir error: %v", err)
 	}
@@ -63,6 +74,39 @@ func TestEnsureDataDir(t *testing.T) {
 	if _, err := os.Stat(gotBlob); err != nil {
 		t.Fatalf("blob dir stat: %v", err)
 	}
+	// Directories must be traversable: creating a file inside blobs requires +x on both.
+	if err := os.WriteFile(filepath.Join(gotBlob, "probe"), []byte("x"), 0o600); err != nil {
+		t.Fatalf("blob dir not traversable: %v", err)
+	}
+	for _, d := range []string{gotData, gotBlob} {
+		st, _ := os.Stat(d)
+		if st.Mode().Perm()&0o700 != 0o700 {
+			t.Fatalf("dir %s mode %v lacks owner rwx", d, st.Mode().Perm())
+		}
+	}
+}
+
+// TestAuditDataDir covers the permission audit in lenient and strict modes.
+func TestAuditDataDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := auditDataDir(true, dir); err != nil {
+		t.Fatalf("0700 dir should pass strict audit: %v", err)
+	}
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := auditDataDir(false, dir); err != nil {
+		t.Fatalf("lenient audit should only warn: %v", err)
+	}
+	if err := auditDataDir(true, dir); err == nil {
+		t.Fatalf("expected strict audit to reject world-readable dir")
+	}
+	if err := auditDataDir(false, filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("expected error for missing dir")
+	}
 }
 
 // TestParseAllTemplates ensures embedded templates can be loaded.
@@ -78,15 +122,21 @@ func TestLoadTemplates(t *testing.T) {
 
 // TestBuildService validates service field propagation.
 func TestBuildService(t *testing.T) {
-	cfg := &config.Config{MaxBytes: 1234, MinTTL: time.Minute, MaxTTL: 2 * time.Minute}
+	cfg := &config.Config{MaxBytes: 1234, MinTTL: time.Minute, MaxTTL: 2 * time.Minute, SupportedVersions: []int{1, 2}}
 	// Build service using stub index/blob implementations by wrapping underlying store.New expectations.
-	s := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{})
+	s := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil)
 	if s.MaxBytes != 1234 {
 		t.Fatalf("MaxBytes mismatch got %d", s.MaxBytes)
 	}
 	if s.MinTTL != time.Minute || s.MaxTTL != 2*time.Minute {
 		t.Fatalf("TTL mismatch")
 	}
+	if s.InlineMax <= 0 {
+		t.Fatalf("InlineMax not propagated from store")
+	}
+	if len(s.Versions) != 2 || s.Versions[0] != 1 || s.Versions[1] != 2 {
+		t.Fatalf("Versions mismatch got %v", s.Versions)
+	}
 }
 
 // TestNewServer ensures timeouts and addr applied.
@@ -101,6 +151,103 @@ func TestNewServer(t *testing.T) {
 	}
 }
 
+// TestNewServerMaxHeaderBytes checks GONE_MAX_HEADER_BYTES reaches the
+// server: an oversized header block is refused with 431 before the handler.
+func TestNewServerMaxHeaderBytes(t *testing.T) {
+	var served int
+	srv := newServer(&config.Config{MaxHeaderBytes: 1024}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { served++ }))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+	send := func(pad int) int {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/", nil)
+		req.Header.Set("X-Pad", strings.Repeat("a", pad))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := send(100); code != http.StatusOK {
+		t.Fatalf("small headers: got %d", code)
+	}
+	// net/http allows 4 KiB of slack on top of MaxHeaderBytes.
+	if code := send(16 << 10); code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("oversized headers: got %d", code)
+	}
+	if served != 1 {
+		t.Fatalf("handler ran %d times, want 1", served)
+	}
+}
+
+// TestListenMaxConnections checks GONE_MAX_CONNECTIONS caps accepted
+// connections: once the cap is held, Accept waits until one is closed.
+func TestListenMaxConnections(t *testing.T) {
+	ln, err := listen("127.0.0.1:0", 2)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 3)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial %d: %v", i, err)
+		}
+		defer c.Close()
+	}
+	first := <-accepted
+	<-accepted
+	select {
+	case <-accepted:
+		t.Fatalf("third connection accepted beyond the cap")
+	case <-time.After(100 * time.Millisecond):
+	}
+	first.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatalf("third connection not accepted after a slot freed")
+	}
+
+	unlimited, err := listen("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatalf("listen unlimited: %v", err)
+	}
+	unlimited.Close()
+}
+
+// TestNewServerTLS ensures a TLS config with the requested floor is attached
+// when a certificate and key are configured (no listener is bound).
+func TestNewServerTLS(t *testing.T) {
+	if srv := newServer(&config.Config{Addr: ":9999"}, http.NewServeMux()); srv.TLSConfig != nil {
+		t.Fatalf("expected no TLS config without cert/key")
+	}
+	cfg := &config.Config{Addr: ":9443", TLSCert: "cert.pem", TLSKey: "key.pem", TLSMinVersion: "1.2"}
+	srv := newServer(cfg, http.NewServeMux())
+	if srv.TLSConfig == nil || srv.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected TLS 1.2 floor, got %+v", srv.TLSConfig)
+	}
+	cfg.TLSMinVersion = "1.3"
+	if srv = newServer(cfg, http.NewServeMux()); srv.TLSConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected TLS 1.3 floor")
+	}
+}
+
 // TestBuildHandler exercises basic route wiring for index template.
 func TestBuildHandler_IndexRoute(t *testing.T) {
 	// Prepare temp DB for sqlite index.
@@ -127,7 +274,7 @@ func TestBuildHandler_IndexRoute(t *testing.T) {
 		errorPage: template.Must(template.New("error").Parse("error")),
 	}
 	cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: 2 * time.Minute, TTLOptions: []domain.TTLOption{{Duration: time.Minute, Label: "1m"}}}
-	svc := buildService(idx, stubBlobStorage{}, cfg, realClock{})
+	svc := buildService(idx, stubBlobStorage{}, cfg, realClock{}, nil)
 	h := buildHandler(cfg, svc, db, blobDir, tmpls)
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rr := httptest.NewRecorder()
@@ -140,6 +287,41 @@ func TestBuildHandler_IndexRoute(t *testing.T) {
 	}
 }
 
+// A broken metrics schema fails /readyz once the manager is wired in.
+func TestBuildHandler_ReadinessMetrics(t *testing.T) {
+	tmp := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(tmp, "gone.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mgr := metrics.New(db, metrics.Config{})
+	if err := mgr.InitSchema(context.Background()); err != nil {
+		t.Fatalf("metrics schema: %v", err)
+	}
+	tmpls := &templates{
+		index:  template.Must(template.New("index").Parse("index")),
+		about:  template.Must(template.New("about").Parse("about")),
+		secret: template.Must(template.New("secret").Parse("secret")),
+	}
+	cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: 2 * time.Minute}
+	h := buildHandler(cfg, buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil), db, tmp, tmpls, mgr)
+	ready := func() int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rr.Code
+	}
+	if code := ready(); code != http.StatusOK {
+		t.Fatalf("readyz status %d, want 200", code)
+	}
+	if _, err := db.Exec(`DROP TABLE metrics_counters`); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz status %d, want 503", code)
+	}
+}
+
 // Failure path: ensureDataDir where path exists as file.
 func TestEnsureDataDir_FilePathError(t *testing.T) {
 	tmp := t.TempDir()
@@ -147,11 +329,30 @@ func TestEnsureDataDir_FilePathError(t *testing.T) {
 	if err := os.WriteFile(filePath, []byte("x"), 0o600); err != nil {
 		t.Fatalf("write file: %v", err)
 	}
-	if _, _, err := ensureDataDir(filePath); err == nil {
+	if _, _, err := ensureDataDir(filePath, false); err == nil {
 		t.Fatalf("expected error for file path")
 	}
 }
 
+// TestEnsureDataDir_RejectNetworkFS simulates a data dir on NFS: startup must
+// fail only when the check is enabled, and unsupported platforms must pass.
+func TestEnsureDataDir_RejectNetworkFS(t *testing.T) {
+	orig := networkFSType
+	t.Cleanup(func() { networkFSType = orig })
+	networkFSType = func(string) (string, error) { return "nfs", nil }
+	dir := filepath.Join(t.TempDir(), "data")
+	if _, _, err := ensureDataDir(dir, false); err != nil {
+		t.Fatalf("check disabled: %v", err)
+	}
+	if _, _, err := ensureDataDir(dir, true); err == nil || !strings.Contains(err.Error(), "network filesystem (nfs)") {
+		t.Fatalf("expected network filesystem error, got %v", err)
+	}
+	networkFSType = func(string) (string, error) { return "", errors.ErrUnsupported }
+	if _, _, err := ensureDataDir(dir, true); err != nil {
+		t.Fatalf("unsupported platform should pass: %v", err)
+	}
+}
+
 // Failure path: openDatabase with directory lacking permissions (simulate by using dir path as file).
 func TestOpenDatabase_Error(t *testing.T) {
 	tmp := t.TempDir()
@@ -174,3 +375,261 @@ func TestLoadTemplatesFrom_Error(t *testing.T) {
 		t.Fatalf("expected error due to missing partials template")
 	}
 }
+
+// TestMetricsHandlerPprof verifies pprof is mounted on the metrics listener
+// behind the bearer token, and absent when no token is configured.
+func TestMetricsHandlerPprof(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "m.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mgr := metrics.New(db, metrics.Config{})
+	if err := mgr.InitSchema(context.Background()); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	get := func(h http.Handler, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	h := newMetricsHandler(mgr, "tok", nil, nil)
+	if code := get(h, "/debug/pprof/", ""); code != http.StatusUnauthorized {
+		t.Fatalf("pprof without token: got %d", code)
+	}
+	if code := get(h, "/debug/pprof/", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("pprof with wrong token: got %d", code)
+	}
+	if code := get(h, "/debug/pprof/", "tok"); code != http.StatusOK {
+		t.Fatalf("pprof with token: got %d", code)
+	}
+	if code := get(h, "/metrics", "tok"); code != http.StatusOK {
+		t.Fatalf("metrics with token: got %d", code)
+	}
+	// Rotation swaps the token for every route on the listener.
+	rot := httptest.NewRequest(http.MethodPost, "/metrics/rotate-token", strings.NewReader("tok2"))
+	rot.Header.Set("Authorization", "Bearer tok")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, rot)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("rotate: got %d", rr.Code)
+	}
+	if code := get(h, "/debug/pprof/", "tok"); code != http.StatusUnauthorized {
+		t.Fatalf("pprof with rotated-out token: got %d", code)
+	}
+	if code := get(h, "/debug/pprof/", "tok2"); code != http.StatusOK {
+		t.Fatalf("pprof with new token: got %d", code)
+	}
+	reset := httptest.NewRequest(http.MethodPost, "/metrics/reset", nil)
+	reset.Header.Set("Authorization", "Bearer tok2")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, reset)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("reset: got %d", rr.Code)
+	}
+	// Without a token pprof is not mounted; the path falls through to the
+	// metrics snapshot instead of exposing profiles.
+	open := newMetricsHandler(mgr, "", nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	rr = httptest.NewRecorder()
+	open.ServeHTTP(rr, req)
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected metrics JSON without token, got %q", ct)
+	}
+}
+
+// TestMetricsHandlerExpiry checks the histogram endpoint is mounted and that
+// buckets default to the TTL options.
+func TestMetricsHandlerExpiry(t *testing.T) {
+	cfg := &config.Config{TTLOptions: []domain.TTLOption{{Duration: 5 * time.Minute}, {Duration: time.Hour}}}
+	if got := expiryBuckets(cfg); len(got) != 2 || got[0] != 5*time.Minute || got[1] != time.Hour {
+		t.Fatalf("default buckets: %v", got)
+	}
+	cfg.ExpiryBuckets = []time.Duration{time.Minute}
+	if got := expiryBuckets(cfg); len(got) != 1 || got[0] != time.Minute {
+		t.Fatalf("configured buckets: %v", got)
+	}
+	svc := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil)
+	expiry, ok := svc.Store.(metrics.ExpirySource)
+	if !ok {
+		t.Fatalf("service store does not provide expiry histogram")
+	}
+	h := newMetricsHandler(nil, "", expiry, expiryBuckets(cfg))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/expiry", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expiry endpoint: got %d", rr.Code)
+	}
+}
+
+// TestBuildHandlerTTLMode renders the real index template in both TTL modes.
+func TestBuildHandlerTTLMode(t *testing.T) {
+	tmpls, err := loadTemplates()
+	if err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+	for _, mode := range []string{"preset", "range"} {
+		t.Run(mode, func(t *testing.T) {
+			cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: 2 * time.Hour, TTLMode: mode, TTLOptions: []domain.TTLOption{{Duration: time.Hour, Label: "1h"}}}
+			svc := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil)
+			h := buildHandler(cfg, svc, nil, t.TempDir(), tmpls)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("index status %d", rr.Code)
+			}
+			body := rr.Body.String()
+			hasInput := strings.Contains(body, `<input id="ttl"`)
+			hasSelect := strings.Contains(body, `<select id="ttl"`)
+			if (mode == "range") != hasInput || (mode == "preset") != hasSelect {
+				t.Fatalf("mode %s: input=%v select=%v", mode, hasInput, hasSelect)
+			}
+		})
+	}
+}
+
+// TestBuildHandlerAboutFile renders an operator about page through the real
+// template and falls back to the embedded text without one.
+func TestBuildHandlerAboutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "about.md")
+	if err := os.WriteFile(path, []byte("## Acme internal sharing\n\n<script>alert(1)</script>\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: time.Hour, TTLOptions: []domain.TTLOption{{Duration: time.Hour, Label: "1h"}}}
+	svc := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil)
+	get := func(tmpls *templates) string {
+		rr := httptest.NewRecorder()
+		buildHandler(cfg, svc, nil, t.TempDir(), tmpls).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/about", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("about status %d", rr.Code)
+		}
+		return rr.Body.String()
+	}
+	tmpls, err := loadTemplates()
+	if err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+	if body := get(tmpls); !strings.Contains(body, "What is Gone?") {
+		t.Fatalf("expected embedded about text")
+	}
+	if tmpls.aboutContent, err = httpx.LoadAboutContent(path); err != nil {
+		t.Fatalf("LoadAboutContent: %v", err)
+	}
+	body := get(tmpls)
+	if !strings.Contains(body, "<h2>Acme internal sharing</h2>") || strings.Contains(body, "What is Gone?") {
+		t.Fatalf("override not rendered: %s", body)
+	}
+	if strings.Contains(body, "alert(1)") {
+		t.Fatalf("script survived sanitization")
+	}
+}
+
+// TestBuildHandlerRevealHints renders the real secret page with a hint.
+func TestBuildHandlerRevealHints(t *testing.T) {
+	tmpls, err := loadTemplates()
+	if err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+	for _, hints := range []bool{false, true} {
+		cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: time.Hour, RevealHints: hints, TTLOptions: []domain.TTLOption{{Duration: time.Hour, Label: "1h"}}}
+		svc := buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil)
+		rr := httptest.NewRecorder()
+		buildHandler(cfg, svc, nil, t.TempDir(), tmpls).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/secret/not-an-id", nil))
+		body := rr.Body.String()
+		if rr.Code != http.StatusOK {
+			t.Fatalf("hints=%v: status %d", hints, rr.Code)
+		}
+		if got := strings.Contains(body, "Malformed Link"); got != hints {
+			t.Fatalf("hints=%v: malformed message shown=%v", hints, got)
+		}
+		if got := strings.Contains(body, "consume.js"); got == hints {
+			t.Fatalf("hints=%v: consume script included=%v", hints, got)
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf strings.Builder
+	log := newLogger(&config.Config{LogLevel: "warn", LogFormat: "json"}, &buf)
+	log.Info("dropped")
+	log.Warn("kept", "k", "v")
+	out := buf.String()
+	if strings.Contains(out, "dropped") {
+		t.Fatalf("info line logged at warn level: %s", out)
+	}
+	if !strings.HasPrefix(out, "{") || !strings.Contains(out, `"msg":"kept"`) || !strings.Contains(out, `"k":"v"`) {
+		t.Fatalf("expected JSON warn line, got %s", out)
+	}
+
+	buf.Reset()
+	newLogger(&config.Config{LogLevel: "debug", LogFormat: "text"}, &buf).Debug("hello")
+	if !strings.Contains(buf.String(), "level=DEBUG msg=hello") {
+		t.Fatalf("expected text debug line, got %s", buf.String())
+	}
+}
+
+// TestWebAssetsDir loads templates and static files from a GONE_WEB_DIR copy
+// of the embedded assets, and rejects a directory missing templates.
+func TestWebAssetsDir(t *testing.T) {
+	if fsys, err := webAssets(""); err != nil || fsys == nil {
+		t.Fatalf("embedded default: %v", err)
+	}
+	dir := t.TempDir()
+	if _, err := webAssets(filepath.Join(dir, "nope")); err == nil {
+		t.Fatalf("expected error for missing dir")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.tmpl.html"), []byte("x"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, err := webAssets(dir)
+	if err == nil || !strings.Contains(err.Error(), "partials.tmpl.html") || !strings.Contains(err.Error(), "error.tmpl.html") || strings.Contains(err.Error(), "index.tmpl.html") {
+		t.Fatalf("expected missing template list, got %v", err)
+	}
+
+	embedded, err := webAssets("")
+	if err != nil {
+		t.Fatalf("embedded: %v", err)
+	}
+	for _, name := range webTemplateFiles {
+		b, err := fs.ReadFile(embedded, name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if name == "index.tmpl.html" {
+			b = []byte(strings.Replace(string(b), "</body>", "<p>forked ui</p></body>", 1))
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "css"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "css", "fork.css"), []byte("body{}"), 0o600); err != nil {
+		t.Fatalf("write css: %v", err)
+	}
+	fsys, err := webAssets(dir)
+	if err != nil {
+		t.Fatalf("webAssets: %v", err)
+	}
+	tmpls, err := loadTemplatesFrom(fsys)
+	if err != nil {
+		t.Fatalf("loadTemplatesFrom: %v", err)
+	}
+	cfg := &config.Config{MaxBytes: 2048, MinTTL: time.Minute, MaxTTL: time.Hour, TTLOptions: []domain.TTLOption{{Duration: time.Hour, Label: "1h"}}}
+	h := buildHandler(cfg, buildService(stubIndex{}, stubBlobStorage{}, cfg, realClock{}, nil), nil, t.TempDir(), tmpls)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "forked ui") {
+		t.Fatalf("index from web dir: status %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/css/fork.css", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "body{}" {
+		t.Fatalf("static from web dir: status %d body %q", rr.Code, rr.Body)
+	}
+}
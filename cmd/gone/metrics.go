@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/haukened/gone/internal/metrics"
+)
+
+// runMetricsCmd implements `gone metrics [--json]`. It prints the persisted
+// metrics from the data directory's SQLite database without starting the
+// server, janitor or metrics flush loop.
+func runMetricsCmd(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	fs.SetOutput(out)
+	asJSON := fs.Bool("json", false, "print metrics as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return printMetrics(context.Background(), cfg.DataDir, *asJSON, out)
+}
+
+// openDatabaseReadOnly opens the data directory's database in read-only mode
+// so it can be inspected alongside a live server.
+func openDatabaseReadOnly(dataDir string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", filepath.Join(dataDir, "gone.db"))
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite driver: %w", err)
+	}
+	return db, nil
+}
+
+// printMetrics writes a snapshot of the persisted metrics to out as JSON or
+// as an aligned table.
+func printMetrics(ctx context.Context, dataDir string, asJSON bool, out io.Writer) error {
+	db, err := openDatabaseReadOnly(dataDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// The manager is never started: Snapshot only reads persisted state.
+	rep, err := metrics.BuildReport(ctx, metrics.New(db, metrics.Config{}))
+	if err != nil {
+		return fmt.Errorf("read metrics: %w", err)
+	}
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	return writeMetricsTable(out, rep)
+}
+
+// writeMetricsTable renders counters and summaries sorted by name.
+func writeMetricsTable(out io.Writer, rep metrics.Report) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNTER\tVALUE")
+	for _, name := range sortedKeys(rep.Counters) {
+		fmt.Fprintf(tw, "%s\t%d\n", name, rep.Counters[name])
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "SUMMARY\tCOUNT\tSUM\tMIN\tMAX")
+	for _, name := range sortedKeys(rep.Summaries) {
+		s := rep.Summaries[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", name, s["count"], s["sum"], s["min"], s["max"])
+	}
+	return tw.Flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
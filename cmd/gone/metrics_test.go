@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haukened/gone/internal/metrics"
+)
+
+// seedMetrics writes a persisted counter and summary to dir/gone.db.
+func seedMetrics(t *testing.T, dir string) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "gone.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	if err := metrics.New(db, metrics.Config{}).InitSchema(context.Background()); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO metrics_counters(name,value) VALUES(?,3)`, metrics.CounterSecretsCreated); err != nil {
+		t.Fatalf("seed counter: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO metrics_summaries(name,count,sum,min,max) VALUES(?,1,4,4,4)`, metrics.SummaryJanitorDeletedPerCycle); err != nil {
+		t.Fatalf("seed summary: %v", err)
+	}
+}
+
+func TestPrintMetricsJSON(t *testing.T) {
+	dir := t.TempDir()
+	seedMetrics(t, dir)
+	var out bytes.Buffer
+	if err := printMetrics(context.Background(), dir, true, &out); err != nil {
+		t.Fatalf("printMetrics: %v", err)
+	}
+	var rep metrics.Report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode: %v (%s)", err, out.String())
+	}
+	if rep.Counters[metrics.CounterSecretsCreated] != 3 {
+		t.Fatalf("counter mismatch: %v", rep.Counters)
+	}
+	if rep.Summaries[metrics.SummaryJanitorDeletedPerCycle]["max"] != 4 {
+		t.Fatalf("summary mismatch: %v", rep.Summaries)
+	}
+}
+
+func TestPrintMetricsTable(t *testing.T) {
+	dir := t.TempDir()
+	seedMetrics(t, dir)
+	var out bytes.Buffer
+	if err := printMetrics(context.Background(), dir, false, &out); err != nil {
+		t.Fatalf("printMetrics: %v", err)
+	}
+	s := out.String()
+	if !strings.Contains(s, "COUNTER") || !strings.Contains(s, metrics.CounterSecretsCreated) || !strings.Contains(s, metrics.SummaryJanitorDeletedPerCycle) {
+		t.Fatalf("unexpected table output:\n%s", s)
+	}
+}
+
+func TestPrintMetricsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	seedMetrics(t, dir)
+	db, err := openDatabaseReadOnly(dir)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`DELETE FROM metrics_counters`); err == nil {
+		t.Fatalf("expected write to fail on read-only connection")
+	}
+}
+
+func TestPrintMetricsMissingDB(t *testing.T) {
+	if err := printMetrics(context.Background(), t.TempDir(), false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected error for missing database")
+	}
+}
+
+func TestRunMetricsCmdBadFlag(t *testing.T) {
+	if err := runMetricsCmd([]string{"--bogus"}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected flag parse error")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// networkMagic maps statfs f_type magic numbers of network and cluster
+// filesystems (see statfs(2)) to display names.
+var networkMagic = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x01021997: "9p",
+	0x5346414f: "afs",
+	0x6b414653: "afs",
+	0x00c36400: "ceph",
+	0x73757245: "coda",
+	0x564c:     "ncp",
+	0x01161970: "gfs2",
+	0x7461636f: "ocfs2",
+}
+
+// statfsNetworkType returns the name of the network filesystem holding dir,
+// or "" when it is local.
+func statfsNetworkType(dir string) (string, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return "", err
+	}
+	// f_type is signed and 32 bits wide on some architectures.
+	return networkMagic[uint32(st.Type)], nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// statfsNetworkType cannot tell filesystem types apart on this platform.
+func statfsNetworkType(string) (string, error) {
+	return "", errors.ErrUnsupported
+}
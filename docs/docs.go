@@ -0,0 +1,47 @@
+// Package docs embeds the OpenAPI specification so the server can publish
+// the same document that lives in the repository.
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+// OpenAPIJSON returns the embedded openapi.yaml converted to JSON.
+func OpenAPIJSON() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(openAPIYAML, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi.yaml: %w", err)
+	}
+	return json.Marshal(jsonable(doc))
+}
+
+// jsonable converts YAML mappings with non-string keys (e.g. unquoted status
+// codes) into string-keyed maps that encoding/json accepts.
+func jsonable(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = jsonable(e)
+		}
+		return t
+	case map[any]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			out[fmt.Sprint(k)] = jsonable(e)
+		}
+		return out
+	case []any:
+		for i, e := range t {
+			t[i] = jsonable(e)
+		}
+		return t
+	}
+	return v
+}
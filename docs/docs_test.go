@@ -0,0 +1,49 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestOpenAPIJSONHeaders checks the spec documents the X-Gone-* headers the
+// handlers actually read.
+func TestOpenAPIJSONHeaders(t *testing.T) {
+	raw, err := OpenAPIJSON()
+	if err != nil {
+		t.Fatalf("OpenAPIJSON: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				In   string `json:"in"`
+				Name string `json:"name"`
+			} `json:"parameters"`
+			Responses map[string]any `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	type op struct{ method, path string }
+	want := map[op][]string{
+		{"post", "/api/secret"}:       {"X-Gone-Version", "X-Gone-Nonce", "X-Gone-TTL", "X-Gone-Max-Reads", "X-Gone-Passphrase-Hash"},
+		{"get", "/api/secret/{id}"}:   {"X-Gone-Download", "X-Gone-Passphrase"},
+		{"patch", "/api/secret/{id}"}: {"X-Gone-TTL", "X-Gone-Passphrase"},
+	}
+	for o, headers := range want {
+		have := map[string]bool{}
+		for _, p := range doc.Paths[o.path][o.method].Parameters {
+			if p.In == "header" {
+				have[p.Name] = true
+			}
+		}
+		for _, h := range headers {
+			if !have[h] {
+				t.Fatalf("%s %s missing header %s", o.method, o.path, h)
+			}
+		}
+	}
+	if _, ok := doc.Paths["/api/secret"]["post"].Responses["201"]; !ok {
+		t.Fatalf("create missing 201 response")
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// Audit event names.
+const (
+	AuditCreate  = "create"
+	AuditConsume = "consume"
+	AuditDestroy = "destroy" // removed by Service.MaxConsumeAttempts
+)
+
+// AuditEvent describes a completed create, consume or destroy. It never carries
+// ciphertext, nonces or the full secret ID; IDHash is the HashID fingerprint.
+type AuditEvent struct {
+	Event         string    `json:"event"`
+	IDHash        string    `json:"id_hash"`
+	Time          time.Time `json:"time"`
+	Size          int64     `json:"size"`
+	TTLSecs       int64     `json:"ttl_secs,omitempty"`
+	Tenant        string    `json:"tenant,omitempty"`
+	CorrelationID string    `json:"cid,omitempty"`
+	ClientIP      string    `json:"client_ip,omitempty"`
+}
+
+// Auditor receives audit events after successful operations. Implementations
+// must not block for long and handle (log) their own failures: an audit
+// problem never fails the operation being audited.
+type Auditor interface {
+	Record(ctx context.Context, ev AuditEvent)
+}
+
+// correlationIDCtxKey is the unexported context key type for the request's
+// correlation ID.
+type correlationIDCtxKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the delivery layer's
+// correlation ID so core events can be tied back to request logs.
+func WithCorrelationID(ctx context.Context, cid string) context.Context {
+	return context.WithValue(ctx, correlationIDCtxKey{}, cid)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx, or "".
+func CorrelationIDFromContext(ctx context.Context) string {
+	cid, _ := ctx.Value(correlationIDCtxKey{}).(string)
+	return cid
+}
+
+// clientIPCtxKey is the unexported context key type for the requesting
+// client's address.
+type clientIPCtxKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the client address resolved by
+// the delivery layer.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPCtxKey{}, ip)
+}
+
+// ClientIPFromContext returns the client address carried by ctx, or "".
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPCtxKey{}).(string)
+	return ip
+}
+
+// audit forwards ev to the configured Auditor, filling in request-scoped
+// fields from ctx. It is a no-op when auditing is disabled.
+func (s *Service) audit(ctx context.Context, ev AuditEvent) {
+	if s.Auditor == nil {
+		return
+	}
+	ev.Time = s.Clock.Now().UTC()
+	ev.Tenant = TenantFromContext(ctx)
+	ev.CorrelationID = CorrelationIDFromContext(ctx)
+	ev.ClientIP = ClientIPFromContext(ctx)
+	s.Auditor.Record(ctx, ev)
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientIDNotAllowed indicates a create carried a caller-chosen ID while
+// Service.ClientIDs is off.
+var ErrClientIDNotAllowed = errors.New("client ids not allowed")
+
+// clientIDCtxKey is the unexported context key type for a caller-chosen ID.
+type clientIDCtxKey struct{}
+
+// WithClientID returns a copy of ctx asking that the secret being created be
+// stored under id instead of a generated one. Service.ClientIDs must be set
+// for CreateSecret to honor it.
+func WithClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDCtxKey{}, id)
+}
+
+// ClientIDFromContext returns the caller-chosen ID carried by ctx, or "".
+func ClientIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(clientIDCtxKey{}).(string)
+	return id
+}
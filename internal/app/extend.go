@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+)
+
+// AuditExtend is the audit event for a secret whose expiry was moved.
+const AuditExtend = "extend"
+
+// ErrExtendUnsupported indicates the store cannot change a stored secret's
+// expiry.
+var ErrExtendUnsupported = errors.New("extend unsupported")
+
+// Extender is optionally implemented by a SecretStore that can move the
+// expiry of a stored secret.
+type Extender interface {
+	// Touch sets the expiry of the live secret id, within the tenant carried
+	// by ctx, to expiresAt. It returns ErrNotFound when the secret is absent,
+	// expired or already consumed, and domain.ErrTTLInvalid when expiresAt
+	// would shorten its life or not clear its not-before time.
+	Touch(ctx context.Context, id string, expiresAt time.Time) error
+}
+
+// Extend gives an unread secret a new lifetime of ttl from now; it only ever
+// moves the expiry later, never earlier or onto an embargo. The TTL is
+// snapped and validated (or clamped, with ClampTTL) like a create's. The secret's size is
+// not known here, so MaxTTLExternal, when set, caps every extension. A
+// passphrase-gated secret needs its passphrase, as for Consume, so the ID
+// alone cannot keep it alive.
+func (s *Service) Extend(ctx context.Context, idStr string, ttl time.Duration) (expiresAt time.Time, err error) {
+	ctx, span := TracerOrNoop(s.Tracer).Start(ctx, "service.Extend")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+	if _, err := domain.ParseID(idStr); err != nil {
+		return time.Time{}, domain.ErrInvalidID
+	}
+	ext, ok := s.Store.(Extender)
+	if !ok {
+		return time.Time{}, ErrExtendUnsupported
+	}
+	_, maxTTL := s.limits(ctx, 0)
+	if s.MaxTTLExternal > 0 && s.MaxTTLExternal < maxTTL {
+		maxTTL = s.MaxTTLExternal
+	}
+	ttl = snapTTL(ttl, s.TTLPresets, s.TTLSnap, maxTTL)
+	if s.ClampTTL {
+		ttl = clampTTL(ttl, s.MinTTL, maxTTL)
+	}
+	if err := validateTTL(ttl, s.MinTTL, maxTTL); err != nil {
+		return time.Time{}, domain.ErrTTLInvalid
+	}
+	span.SetAttrs(Attr{Key: "secret.id_hash", Value: HashID(idStr)})
+	if err = s.checkPassphrase(ctx, idStr); err != nil {
+		return time.Time{}, err
+	}
+	expiresAt = s.Clock.Now().Add(ttl)
+	if err := ext.Touch(ctx, idStr, expiresAt); err != nil {
+		return time.Time{}, err
+	}
+	s.audit(ctx, AuditEvent{Event: AuditExtend, IDHash: HashID(idStr), TTLSecs: int64(ttl.Seconds())})
+	return expiresAt, nil
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// FreshIDSaver is optionally implemented by a SecretStore that can retry an
+// ID collision itself. The payload has already been read by the time a
+// collision shows up, so only the store, which holds it, can store it again
+// without the service keeping a second copy.
+type FreshIDSaver interface {
+	// SaveFresh stores the secret like Save under an ID taken from next. On
+	// ErrDuplicateID it takes another ID, up to retries more times, and
+	// returns the ID the secret was stored under. Stores may give up earlier
+	// when the payload can no longer be replayed.
+	SaveFresh(ctx context.Context, next func() (string, error), retries int, meta Meta, r io.Reader, size int64, expiresAt time.Time) (string, error)
+}
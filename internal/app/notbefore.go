@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTooEarly indicates the secret exists but its embargo (not-before time)
+// has not passed yet. Nothing is consumed.
+var ErrTooEarly = errors.New("secret not yet available")
+
+// ErrNotBeforeInvalid indicates a create asked for a not-before time at or
+// after the secret's expiry, so it could never be read.
+var ErrNotBeforeInvalid = errors.New("not before invalid")
+
+// notBeforeCtxKey is the unexported context key type for a create's embargo.
+type notBeforeCtxKey struct{}
+
+// WithNotBefore returns a copy of ctx asking that the secret being created
+// stay unreadable until t. Storage adapters read it back via
+// NotBeforeFromContext when inserting the record.
+func WithNotBefore(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, notBeforeCtxKey{}, t)
+}
+
+// NotBeforeFromContext returns the embargo carried by ctx, or the zero time
+// when the secret is readable immediately.
+func NotBeforeFromContext(ctx context.Context) time.Time {
+	t, _ := ctx.Value(notBeforeCtxKey{}).(time.Time)
+	return t
+}
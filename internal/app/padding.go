@@ -0,0 +1,69 @@
+package app
+
+import "io"
+
+// MinPaddedSize is the smallest bucket PaddedSize rounds up to.
+const MinPaddedSize = 1024
+
+// PaddedSize returns the bucket a ciphertext of n bytes is padded to: the next
+// power of two, and never less than MinPaddedSize.
+func PaddedSize(n int64) int64 {
+	p := int64(MinPaddedSize)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// paddedReader yields the underlying ciphertext followed by zero bytes up to
+// a fixed total; Close closes the underlying reader.
+type paddedReader struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (p *paddedReader) Close() error { return p.rc.Close() }
+
+// padConsumed wraps rc so it is read as size bytes of ciphertext followed by
+// zero bytes up to PaddedSize(size), and returns the padded size. If rc ends
+// before size bytes the read fails with io.ErrUnexpectedEOF rather than
+// zero-filling a truncated ciphertext.
+func padConsumed(rc io.ReadCloser, size int64) (io.ReadCloser, int64) {
+	padded := PaddedSize(size)
+	pad := io.LimitReader(zeroReader{}, padded-size)
+	body := &exactReader{r: rc, left: size}
+	return &paddedReader{Reader: io.MultiReader(body, pad), rc: rc}, padded
+}
+
+// exactReader yields exactly left bytes from r, failing with
+// io.ErrUnexpectedEOF if r ends sooner.
+type exactReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (e *exactReader) Read(b []byte) (int, error) {
+	if e.left <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > e.left {
+		b = b[:e.left]
+	}
+	n, err := e.r.Read(b)
+	e.left -= int64(n)
+	if err == io.EOF {
+		if e.left > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// zeroReader is an endless source of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
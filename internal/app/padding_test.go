@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+)
+
+func TestPaddedSize(t *testing.T) {
+	cases := []struct{ n, want int64 }{
+		{0, 1024},
+		{1, 1024},
+		{1024, 1024},
+		{1025, 2048},
+		{2048, 2048},
+		{3000, 4096},
+		{1 << 20, 1 << 20},
+		{1<<20 + 1, 1 << 21},
+	}
+	for _, c := range cases {
+		if got := PaddedSize(c.n); got != c.want {
+			t.Errorf("PaddedSize(%d) = %d, want %d", c.n, got, c.want)
+		}
+	}
+}
+
+func TestServiceConsumePadSizes(t *testing.T) {
+	data := "ciphertext"
+	ms := &mockStore{consumeMeta: Meta{Version: 1, NonceB64u: "n"}, consumeData: data, consumeSize: int64(len(data))}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 100, MinTTL: time.Minute, MaxTTL: 5 * time.Minute, PadSizes: true}
+	id, _ := domain.NewID()
+	_, rc, size, err := svc.Consume(context.Background(), id.String())
+	if err != nil {
+		t.Fatalf("Consume error: %v", err)
+	}
+	defer rc.Close()
+	if size != 1024 {
+		t.Fatalf("size = %d, want 1024", size)
+	}
+	b, _ := io.ReadAll(rc)
+	if int64(len(b)) != size {
+		t.Fatalf("read %d bytes, want %d", len(b), size)
+	}
+	if !bytes.HasPrefix(b, []byte(data)) || !bytes.Equal(b[len(data):], make([]byte, 1024-len(data))) {
+		t.Fatal("expected ciphertext followed by zero padding")
+	}
+}
+
+func TestServiceConsumePadSizesTruncated(t *testing.T) {
+	data := "ciphertext"
+	ms := &mockStore{consumeMeta: Meta{Version: 1, NonceB64u: "n"}, consumeData: data, consumeSize: int64(len(data)) + 5}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 100, MinTTL: time.Minute, MaxTTL: 5 * time.Minute, PadSizes: true}
+	id, _ := domain.NewID()
+	_, rc, _, err := svc.Consume(context.Background(), id.String())
+	if err != nil {
+		t.Fatalf("Consume error: %v", err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ReadAll error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if string(b) != data {
+		t.Fatalf("read %q, want only the stored bytes", b)
+	}
+}
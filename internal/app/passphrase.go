@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPassphraseHashInvalid indicates the create-time passphrase hash is not a
+// bcrypt hash or its cost exceeds MaxPassphraseCost.
+var ErrPassphraseHashInvalid = errors.New("passphrase hash invalid")
+
+// ErrPassphraseMismatch indicates a gated secret was requested without the
+// correct passphrase. The secret is not consumed.
+var ErrPassphraseMismatch = errors.New("passphrase mismatch")
+
+// ErrTooManyAttempts indicates a gated secret has seen too many wrong
+// passphrases recently and is temporarily locked.
+var ErrTooManyAttempts = errors.New("too many attempts")
+
+// DefaultPassphraseAttempts is the number of wrong passphrases tolerated per
+// secret within PassphraseWindow when Service.PassphraseAttempts is unset.
+const DefaultPassphraseAttempts = 5
+
+// MaxPassphraseCost is the highest bcrypt cost accepted for a passphrase
+// hash. Each consume attempt runs a comparison at the stored cost, so an
+// unbounded cost would let a client make every attempt burn minutes of CPU.
+const MaxPassphraseCost = 12
+
+// PassphraseWindow is how long wrong passphrase attempts count against a secret.
+const PassphraseWindow = 15 * time.Minute
+
+// PassphraseGate is optionally implemented by a SecretStore that records a
+// passphrase hash per secret. The hash is checked before Consume so a wrong
+// passphrase never spends a read.
+type PassphraseGate interface {
+	// PassphraseHash returns the stored hash for a live secret ("" when the
+	// secret is not gated) or ErrNotFound.
+	PassphraseHash(ctx context.Context, id string) (string, error)
+}
+
+type passphraseHashCtxKey struct{}
+type passphraseCtxKey struct{}
+
+// WithPassphraseHash returns a copy of ctx carrying the bcrypt hash that the
+// secret being created must be unlocked with.
+func WithPassphraseHash(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, passphraseHashCtxKey{}, hash)
+}
+
+// PassphraseHashFromContext returns the create-time passphrase hash, or "".
+func PassphraseHashFromContext(ctx context.Context) string {
+	h, _ := ctx.Value(passphraseHashCtxKey{}).(string)
+	return h
+}
+
+// WithPassphrase returns a copy of ctx carrying the passphrase offered to
+// unlock the secret being consumed.
+func WithPassphrase(ctx context.Context, passphrase string) context.Context {
+	return context.WithValue(ctx, passphraseCtxKey{}, passphrase)
+}
+
+// PassphraseFromContext returns the consume-time passphrase, or "".
+func PassphraseFromContext(ctx context.Context) string {
+	p, _ := ctx.Value(passphraseCtxKey{}).(string)
+	return p
+}
+
+// validPassphraseHash reports whether h is a well-formed bcrypt hash with a
+// cost of at most MaxPassphraseCost.
+func validPassphraseHash(h string) bool {
+	cost, err := bcrypt.Cost([]byte(h))
+	return err == nil && cost <= MaxPassphraseCost
+}
+
+// checkPassphrase verifies the passphrase carried by ctx against the secret's
+// stored hash, if any. Wrong attempts are counted per secret and lock it once
+// the limit is reached; a missing passphrase is rejected without counting.
+// Each comparison reserves its attempt before running, so concurrent guesses
+// cannot outnumber the limit; only a correct passphrase gives it back.
+func (s *Service) checkPassphrase(ctx context.Context, id string) error {
+	gate, ok := s.Store.(PassphraseGate)
+	if !ok {
+		return nil
+	}
+	hash, err := gate.PassphraseHash(ctx, id)
+	if err != nil || hash == "" {
+		return err
+	}
+	now := s.Clock.Now()
+	offered := PassphraseFromContext(ctx)
+	if offered == "" {
+		if s.attempts.locked(id, now, s.maxAttempts()) {
+			return ErrTooManyAttempts
+		}
+		return ErrPassphraseMismatch
+	}
+	if !s.attempts.tryAcquire(id, now, s.maxAttempts()) {
+		return ErrTooManyAttempts
+	}
+	// Hashes that bypassed create validation (e.g. archive imports) are not
+	// worth the CPU of a comparison at an unbounded cost.
+	if !validPassphraseHash(hash) || bcrypt.CompareHashAndPassword([]byte(hash), []byte(offered)) != nil {
+		s.failAttempt(ctx, id)
+		return ErrPassphraseMismatch
+	}
+	s.attempts.clear(id)
+	return nil
+}
+
+// AttemptRecorder is optionally implemented by a SecretStore that keeps a
+// persistent count of failed consumes per secret. It backs
+// Service.MaxConsumeAttempts.
+type AttemptRecorder interface {
+	// FailAttempt atomically counts one failed consume of id and destroys the
+	// secret once limit failures are reached, reporting whether it did.
+	FailAttempt(ctx context.Context, id string, limit int) (bool, error)
+}
+
+// failAttempt charges a wrong passphrase against id when MaxConsumeAttempts
+// is set. Unlike the in-memory lockout the count survives restarts and is
+// never reset, so the secret self-destructs at the limit. Recording problems
+// are ignored; the caller still reports the mismatch.
+func (s *Service) failAttempt(ctx context.Context, id string) {
+	rec, ok := s.Store.(AttemptRecorder)
+	if !ok || s.MaxConsumeAttempts <= 0 {
+		return
+	}
+	destroyed, err := rec.FailAttempt(ctx, id, s.MaxConsumeAttempts)
+	if err != nil || !destroyed {
+		return
+	}
+	s.attempts.clear(id)
+	if s.Metrics != nil {
+		s.Metrics.Inc("secrets_destroyed_total", 1)
+	}
+	s.audit(ctx, AuditEvent{Event: AuditDestroy, IDHash: HashID(id)})
+}
+
+// maxAttempts returns the configured per-secret attempt limit.
+func (s *Service) maxAttempts() int {
+	if s.PassphraseAttempts > 0 {
+		return s.PassphraseAttempts
+	}
+	return DefaultPassphraseAttempts
+}
+
+// attemptLimiter counts wrong passphrases per secret ID over a fixed window.
+// The zero value is ready to use.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	failures map[string]attemptWindow
+}
+
+type attemptWindow struct {
+	start time.Time
+	n     int
+}
+
+// pruneAt bounds the limiter map; beyond it stale windows are swept on write.
+const pruneAt = 1024
+
+func (l *attemptLimiter) locked(id string, now time.Time, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.failures[id]
+	return ok && now.Sub(w.start) < PassphraseWindow && w.n >= limit
+}
+
+// tryAcquire counts one attempt against id unless the window already holds
+// limit of them, reporting whether the attempt may proceed. The attempt stays
+// counted until clear.
+func (l *attemptLimiter) tryAcquire(id string, now time.Time, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failures == nil {
+		l.failures = make(map[string]attemptWindow)
+	}
+	if len(l.failures) >= pruneAt {
+		for k, w := range l.failures {
+			if now.Sub(w.start) >= PassphraseWindow {
+				delete(l.failures, k)
+			}
+		}
+	}
+	w, ok := l.failures[id]
+	if !ok || now.Sub(w.start) >= PassphraseWindow {
+		w = attemptWindow{start: now}
+	}
+	if w.n >= limit {
+		return false
+	}
+	w.n++
+	l.failures[id] = w
+	return true
+}
+
+func (l *attemptLimiter) clear(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, id)
+}
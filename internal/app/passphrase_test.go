@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// gatedStore adds PassphraseGate to mockStore.
+type gatedStore struct {
+	mockStore
+	hash string
+}
+
+func (g *gatedStore) PassphraseHash(context.Context, string) (string, error) { return g.hash, nil }
+
+func TestServiceCreateSecretPassphraseHash(t *testing.T) {
+	ms := &mockStore{}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: time.Hour}
+	ctx := WithPassphraseHash(context.Background(), "not-bcrypt")
+	if _, _, err := svc.CreateSecret(ctx, nil, 1, 1, "n", time.Minute, CreateOptions{}); !errors.Is(err, ErrPassphraseHashInvalid) {
+		t.Fatalf("expected ErrPassphraseHashInvalid, got %v", err)
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	ctx = WithPassphraseHash(context.Background(), string(hash))
+	if _, _, err := svc.CreateSecret(ctx, nil, 1, 1, "n", time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if ms.savedMeta.PassphraseHash != string(hash) {
+		t.Fatalf("hash not persisted in meta")
+	}
+}
+
+func TestValidPassphraseHashCost(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	// Only the cost field is parsed, so the cost can be rewritten in place.
+	withCost := func(cost int) string {
+		return fmt.Sprintf("%s%02d%s", hash[:4], cost, hash[6:])
+	}
+	for cost, want := range map[int]bool{bcrypt.MinCost: true, MaxPassphraseCost: true, MaxPassphraseCost + 1: false, bcrypt.MaxCost: false} {
+		if got := validPassphraseHash(withCost(cost)); got != want {
+			t.Fatalf("cost %d: valid = %v, want %v", cost, got, want)
+		}
+	}
+}
+
+func TestServiceConsumePassphraseGate(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	id := "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name     string
+		offered  string
+		wantErr  error
+		consumed bool
+	}{
+		{name: "missing", wantErr: ErrPassphraseMismatch},
+		{name: "wrong", offered: "nope", wantErr: ErrPassphraseMismatch},
+		{name: "correct", offered: "hunter2", consumed: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gs := &gatedStore{hash: string(hash)}
+			svc := &Service{Store: gs, Clock: fixedClock{now: time.Now()}}
+			ctx := context.Background()
+			if tc.offered != "" {
+				ctx = WithPassphrase(ctx, tc.offered)
+			}
+			_, rc, _, err := svc.Consume(ctx, id)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v want %v", err, tc.wantErr)
+			}
+			if rc != nil {
+				rc.Close()
+			}
+			if gs.consumeCalled != tc.consumed {
+				t.Fatalf("consumeCalled=%v want %v", gs.consumeCalled, tc.consumed)
+			}
+		})
+	}
+}
+
+func TestServiceConsumePassphraseLockout(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	id := "0123456789abcdef0123456789abcdef"
+	clk := &stepClock{now: time.Unix(1700000000, 0)}
+	gs := &gatedStore{hash: string(hash)}
+	svc := &Service{Store: gs, Clock: clk, PassphraseAttempts: 2}
+	wrong := WithPassphrase(context.Background(), "nope")
+	right := WithPassphrase(context.Background(), "hunter2")
+	for i := 0; i < 2; i++ {
+		if _, _, _, err := svc.Consume(wrong, id); !errors.Is(err, ErrPassphraseMismatch) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	// Locked: even the right passphrase is refused until the window passes.
+	if _, _, _, err := svc.Consume(right, id); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("expected lockout, got %v", err)
+	}
+	if gs.consumeCalled {
+		t.Fatalf("locked secret must not be consumed")
+	}
+	clk.now = clk.now.Add(PassphraseWindow)
+	if _, rc, _, err := svc.Consume(right, id); err != nil {
+		t.Fatalf("expected unlock after window, got %v", err)
+	} else {
+		rc.Close()
+	}
+}
+
+func TestServiceConsumePassphraseConcurrentGuesses(t *testing.T) {
+	// A cost well above MinCost keeps every guess inside bcrypt long enough
+	// for all of them to overlap.
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), 8)
+	id := "0123456789abcdef0123456789abcdef"
+	svc := &Service{Store: &gatedStore{hash: string(hash)}, Clock: fixedClock{now: time.Now()}, PassphraseAttempts: 3}
+	wrong := WithPassphrase(context.Background(), "nope")
+	const guesses = 20
+	errs := make(chan error, guesses)
+	var wg sync.WaitGroup
+	for range guesses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _, err := svc.Consume(wrong, id)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	compared := 0
+	for err := range errs {
+		switch {
+		case errors.Is(err, ErrPassphraseMismatch):
+			compared++
+		case !errors.Is(err, ErrTooManyAttempts):
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if compared != 3 {
+		t.Fatalf("%d guesses were compared, want the limit of 3", compared)
+	}
+}
+
+// stepClock is a Clock whose time tests can advance.
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+// countingGate is a gatedStore that destroys the secret at the limit.
+type countingGate struct {
+	gatedStore
+	failures int
+}
+
+func (c *countingGate) FailAttempt(_ context.Context, _ string, limit int) (bool, error) {
+	c.failures++
+	if c.failures >= limit {
+		c.hash, c.consumeErr = "", ErrNotFound
+		return true, nil
+	}
+	return false, nil
+}
+
+func TestServiceConsumeSelfDestruct(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	id := "0123456789abcdef0123456789abcdef"
+	cg := &countingGate{gatedStore: gatedStore{hash: string(hash)}}
+	aud := &recordingAuditor{}
+	svc := &Service{Store: cg, Clock: fixedClock{now: time.Now()}, PassphraseAttempts: 10, MaxConsumeAttempts: 3, Auditor: aud}
+	// A missing passphrase (the UI's first probe) is not charged.
+	if _, _, _, err := svc.Consume(context.Background(), id); !errors.Is(err, ErrPassphraseMismatch) || cg.failures != 0 {
+		t.Fatalf("missing passphrase: err=%v failures=%d", err, cg.failures)
+	}
+	wrong := WithPassphrase(context.Background(), "nope")
+	for i := 0; i < 3; i++ {
+		if _, _, _, err := svc.Consume(wrong, id); !errors.Is(err, ErrPassphraseMismatch) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if len(aud.events) != 1 || aud.events[0].Event != AuditDestroy || aud.events[0].IDHash != HashID(id) {
+		t.Fatalf("expected one destroy event, got %+v", aud.events)
+	}
+	if _, _, _, err := svc.Consume(WithPassphrase(context.Background(), "hunter2"), id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after destruction, got %v", err)
+	}
+
+	// Disabled by default.
+	cg = &countingGate{gatedStore: gatedStore{hash: string(hash)}}
+	svc = &Service{Store: cg, Clock: fixedClock{now: time.Now()}}
+	_, _, _, _ = svc.Consume(wrong, id)
+	if cg.failures != 0 {
+		t.Fatalf("failures counted with MaxConsumeAttempts unset")
+	}
+}
@@ -10,13 +10,18 @@ import (
 	"context"
 	"io"
 	"time"
+
+	"github.com/haukened/gone/internal/domain"
 )
 
 // Meta carries minimal per-secret encryption metadata required for clients to
 // decrypt the ciphertext. Fields are intentionally small and stable.
 type Meta struct {
-	Version   uint8  // encryption scheme version negotiated client-side
-	NonceB64u string // base64url-encoded nonce provided by the client
+	Version        uint8  // encryption scheme version negotiated client-side
+	NonceB64u      string // base64url-encoded nonce provided by the client
+	PassphraseHash string // optional bcrypt hash gating consumption ("" = none)
+	Params         string // optional opaque client parameters, e.g. AEAD or KDF settings ("" = none)
+	ContentType    string // optional MIME hint for the recipient's viewer, never interpreted ("" = none)
 }
 
 // Clock abstracts time to enable deterministic testing of TTL / expiry logic.
@@ -25,6 +30,21 @@ type Clock interface {
 	Now() time.Time
 }
 
+// IDGenerator abstracts secret ID creation so tests can inject predictable
+// IDs. Production uses domain.CryptoIDs.
+type IDGenerator interface {
+	// NewID returns a fresh, valid SecretID.
+	NewID() (domain.SecretID, error)
+}
+
+// CreateQuota caps how many secrets a namespace ("" for the root API, else
+// the tenant name) may create per UTC day.
+type CreateQuota interface {
+	// Take claims one create for namespace in the day containing now and
+	// reports whether it fit under the cap.
+	Take(ctx context.Context, namespace string, now time.Time) (bool, error)
+}
+
 // SecretStore is the storage port for secrets. Implementations must provide
 // durability and the single-consume invariant. They typically coordinate an
 // index (e.g. SQLite) with blob storage (filesystem) but those details are
@@ -33,6 +53,7 @@ type SecretStore interface {
 	// Save persists a new secret blob with metadata and an absolute expiry.
 	// 'r' streams exactly 'size' bytes of ciphertext. The call MUST return
 	// only after the data and metadata are crash-safe (fsync / committed).
+	// Operators may knowingly relax this for blobs via the blob fsync policy.
 	Save(ctx context.Context, id string, meta Meta, r io.Reader, size int64, expiresAt time.Time) error
 
 	// Consume atomically retrieves the secret and hard-deletes its record so it
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haukened/gone/internal/domain"
+)
+
+// ErrProbeUnsupported indicates the store cannot check for a secret without
+// consuming it.
+var ErrProbeUnsupported = errors.New("probe unsupported")
+
+// Prober is optionally implemented by a SecretStore that can report whether
+// a secret is live without consuming or otherwise touching it.
+type Prober interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// Exists reports whether idStr names a live secret in the tenant carried by
+// ctx. Malformed IDs return domain.ErrInvalidID and stores without probe
+// support return ErrProbeUnsupported. Nothing is consumed, counted or audited.
+func (s *Service) Exists(ctx context.Context, idStr string) (bool, error) {
+	if _, err := domain.ParseID(idStr); err != nil {
+		return false, domain.ErrInvalidID
+	}
+	p, ok := s.Store.(Prober)
+	if !ok {
+		return false, ErrProbeUnsupported
+	}
+	return p.Exists(ctx, idStr)
+}
@@ -0,0 +1,22 @@
+package app
+
+import "context"
+
+// maxReadsCtxKey is the unexported context key type for a create's read allowance.
+type maxReadsCtxKey struct{}
+
+// WithMaxReads returns a copy of ctx requesting that the secret being created
+// may be consumed n times before it is deleted. Storage adapters read it back
+// via MaxReadsFromContext when inserting the record.
+func WithMaxReads(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxReadsCtxKey{}, n)
+}
+
+// MaxReadsFromContext returns the read allowance carried by ctx, defaulting
+// to 1 (classic one-time secret) when unset or non-positive.
+func MaxReadsFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(maxReadsCtxKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return 1
+}
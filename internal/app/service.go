@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"slices"
 	"time"
 
 	"github.com/haukened/gone/internal/domain"
@@ -15,17 +17,74 @@ import (
 // ErrNotFound indicates the secret was not found or already consumed/expired.
 var ErrNotFound = errors.New("secret not found")
 
-// ErrSizeExceeded indicates the provided ciphertext size is zero or exceeds the configured maximum.
+// ErrSizeExceeded indicates the provided ciphertext size is zero (unless
+// Service.AllowEmpty) or exceeds the configured maximum.
 var ErrSizeExceeded = errors.New("size exceeded")
 
+// ErrTooSmall indicates the ciphertext is shorter than Service.MinBytes.
+var ErrTooSmall = errors.New("size too small")
+
+// ErrMaxReadsInvalid indicates the requested read count exceeds the configured limit.
+var ErrMaxReadsInvalid = errors.New("max reads invalid")
+
+// ErrVersionUnsupported indicates the secret's protocol version is zero or not
+// in Service.Versions.
+var ErrVersionUnsupported = errors.New("unsupported version")
+
+// ErrStorageFull indicates the blob storage byte budget has no room for the secret.
+var ErrStorageFull = errors.New("storage full")
+
+// ErrDuplicateID indicates a secret with the same ID is already stored. Stores
+// return it from Save so CreateSecret can retry with a fresh ID.
+var ErrDuplicateID = errors.New("duplicate secret id")
+
+// ErrQuotaExceeded indicates the namespace has used up its daily create quota.
+var ErrQuotaExceeded = errors.New("create quota exceeded")
+
+// QuotaExceededError is the ErrQuotaExceeded returned by CreateSecret. It
+// carries how long until the quota's UTC day rolls over, measured on the
+// service clock, so callers can advertise a retry time.
+type QuotaExceededError struct {
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string { return ErrQuotaExceeded.Error() }
+func (e *QuotaExceededError) Unwrap() error { return ErrQuotaExceeded }
+
+// DefaultIDRetries is how many fresh IDs CreateSecret tries after a collision
+// when Service.IDRetries is zero.
+const DefaultIDRetries = 3
+
 // Service orchestrates secret creation and one-time consumption using the injected store and clock.
 type Service struct {
-	Store    SecretStore
-	Clock    Clock
-	MaxBytes int64
-	MinTTL   time.Duration
-	MaxTTL   time.Duration
-	Metrics  Metrics // optional metrics collector (may be nil)
+	Store     SecretStore
+	Clock     Clock
+	MaxBytes  int64
+	MinTTL    time.Duration
+	MaxTTL    time.Duration
+	Metrics   Metrics                  // optional metrics collector (may be nil)
+	Tenants   map[string]domain.Tenant // optional per-tenant limits keyed by name
+	Tracer    Tracer                   // optional tracer (may be nil)
+	ClampTTL  bool                     // clamp out-of-range TTLs into [MinTTL,MaxTTL] instead of rejecting
+	MaxReads  int                      // upper bound on per-secret read count (<=1 = one-time only)
+	Auditor   Auditor                  // optional audit sink (may be nil)
+	IDs       IDGenerator              // secret ID source (nil = domain.CryptoIDs)
+	IDRetries int                      // extra IDs tried after ErrDuplicateID (0 = DefaultIDRetries, <0 = none)
+	Quota     CreateQuota              // optional daily create cap per namespace (nil = unlimited)
+	ClientIDs bool                     // honor ClientIDFromContext; a taken ID fails with ErrDuplicateID
+
+	PassphraseAttempts int             // wrong passphrases allowed per secret per PassphraseWindow (0 = DefaultPassphraseAttempts)
+	attempts           attemptLimiter  // per-secret wrong passphrase counts
+	InlineMax          int64           // largest size the store keeps inline; bigger secrets are external blobs
+	MaxTTLExternal     time.Duration   // TTL ceiling for secrets larger than InlineMax (0 = same as inline)
+	AllowEmpty         bool            // accept zero-length secrets (presence tokens)
+	Versions           []uint8         // accepted Meta.Version values (empty = any nonzero)
+	MaxConsumeAttempts int             // wrong passphrases before a secret is destroyed (0 = never)
+	PadSizes           bool            // pad consumed ciphertext with zeros up to PaddedSize
+	MinBytes           int64           // smallest accepted ciphertext; empty secrets under AllowEmpty are exempt (0 = no floor)
+	TTLSnap            string          // round client TTLs onto TTLPresets: TTLSnapNearest, TTLSnapUp or off ("" = off)
+	TTLPresets         []time.Duration // ascending TTL options used by TTLSnap
+	TTLJitter          time.Duration   // spread each expiry by a random offset within ±TTLJitter, kept in [MinTTL,max] (0 = exact)
 }
 
 // Metrics defines the minimal counter interface the Service depends on.
@@ -35,6 +94,19 @@ type Metrics interface {
 	Inc(name string, delta int64)
 }
 
+// Observer is optionally implemented by a Metrics collector that also records
+// summary observations (count/sum/min/max).
+type Observer interface {
+	Observe(name string, value int64)
+}
+
+// CreateOptions carries the optional per-secret metadata a create stores
+// verbatim alongside the ciphertext. The service never interprets it.
+type CreateOptions struct {
+	Params      string // opaque client parameters, e.g. AEAD settings or KDF salts ("" = none)
+	ContentType string // MIME hint for the recipient's viewer ("" = none)
+}
+
 // CreateSecret validates inputs, assigns a new ID, determines expiry, and persists the secret.
 // Returns the generated ID and its expiration timestamp.
 // ctx - the http request context for cancellation and deadlines
@@ -43,40 +115,191 @@ type Metrics interface {
 // version - the version of the secret
 // nonce - the nonce used for encryption
 // ttl - the time-to-live for the secret
-func (s *Service) CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration) (id domain.SecretID, expiresAt time.Time, err error) {
-	if err := validateTTL(ttl, s.MinTTL, s.MaxTTL); err != nil {
+// opts - optional metadata stored with the secret
+func (s *Service) CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration, opts CreateOptions) (id domain.SecretID, expiresAt time.Time, err error) {
+	ctx, span := TracerOrNoop(s.Tracer).Start(ctx, "service.CreateSecret", Attr{Key: "secret.size", Value: size}, Attr{Key: "secret.ttl_secs", Value: int64(ttl.Seconds())})
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+	maxBytes, maxTTL := s.limits(ctx, size)
+	ttl = snapTTL(ttl, s.TTLPresets, s.TTLSnap, maxTTL)
+	if s.ClampTTL {
+		ttl = clampTTL(ttl, s.MinTTL, maxTTL)
+	}
+	if err := validateTTL(ttl, s.MinTTL, maxTTL); err != nil {
 		return "", time.Time{}, domain.ErrTTLInvalid
 	}
-	if size <= 0 || size > s.MaxBytes {
+	ttl = jitterTTL(ttl, s.TTLJitter, s.MinTTL, maxTTL)
+	if size < 0 || (size == 0 && !s.AllowEmpty) || size > maxBytes {
 		return "", time.Time{}, ErrSizeExceeded
 	}
-	id, genErr := domain.NewID()
-	if genErr != nil { // extremely unlikely, but propagate
-		return "", time.Time{}, genErr
+	if size > 0 && size < s.MinBytes {
+		return "", time.Time{}, ErrTooSmall
+	}
+	if !s.versionSupported(version) {
+		return "", time.Time{}, ErrVersionUnsupported
+	}
+	if n := MaxReadsFromContext(ctx); n > 1 && n > s.MaxReads {
+		return "", time.Time{}, ErrMaxReadsInvalid
+	}
+	hash := PassphraseHashFromContext(ctx)
+	if hash != "" && !validPassphraseHash(hash) {
+		return "", time.Time{}, ErrPassphraseHashInvalid
+	}
+	clientID := ClientIDFromContext(ctx)
+	if clientID != "" {
+		if !s.ClientIDs {
+			return "", time.Time{}, ErrClientIDNotAllowed
+		}
+		if _, err := domain.ParseID(clientID); err != nil {
+			return "", time.Time{}, err
+		}
 	}
 	now := s.Clock.Now()
+	if nb := NotBeforeFromContext(ctx); !nb.IsZero() && !nb.Before(now.Add(ttl)) {
+		return "", time.Time{}, ErrNotBeforeInvalid
+	}
+	if s.Quota != nil {
+		// The slot is spent even if the save below fails, so retrying a
+		// broken upload cannot be used to dodge the cap.
+		ok, err := s.Quota.Take(ctx, TenantFromContext(ctx), now)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if !ok {
+			reset := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+			return "", time.Time{}, &QuotaExceededError{RetryAfter: reset.Sub(now)}
+		}
+	}
 	expiresAt = now.Add(ttl)
-	meta := Meta{Version: version, NonceB64u: nonce}
-	if err = s.Store.Save(ctx, id.String(), meta, ct, size, expiresAt); err != nil {
+	meta := Meta{Version: version, NonceB64u: nonce, PassphraseHash: hash, Params: opts.Params, ContentType: opts.ContentType}
+	if id, err = s.save(ctx, meta, ct, size, expiresAt); err != nil {
 		return id, expiresAt, err
 	}
+	span.SetAttrs(Attr{Key: "secret.id_hash", Value: HashID(id.String())})
 	if s.Metrics != nil {
 		// Assumes metric name constant defined in metrics package; hard-code string to avoid import.
 		s.Metrics.Inc("secrets_created_total", 1)
+		if o, ok := s.Metrics.(Observer); ok {
+			o.Observe("secret_size_bytes", size)
+		}
 	}
+	s.audit(ctx, AuditEvent{Event: AuditCreate, IDHash: HashID(id.String()), Size: size, TTLSecs: int64(ttl.Seconds())})
 	return id, expiresAt, nil
 }
 
 // Consume validates the provided ID then delegates to the store for one-time retrieval.
-func (s *Service) Consume(ctx context.Context, idStr string) (Meta, io.ReadCloser, int64, error) {
+func (s *Service) Consume(ctx context.Context, idStr string) (meta Meta, rc io.ReadCloser, size int64, err error) {
+	ctx, span := TracerOrNoop(s.Tracer).Start(ctx, "service.Consume")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
 	if _, err := domain.ParseID(idStr); err != nil {
 		return Meta{}, nil, 0, domain.ErrInvalidID
 	}
-	meta, rc, size, err := s.Store.Consume(ctx, idStr)
-	if err == nil && s.Metrics != nil {
+	span.SetAttrs(Attr{Key: "secret.id_hash", Value: HashID(idStr)})
+	if err = s.checkPassphrase(ctx, idStr); err != nil {
+		return Meta{}, nil, 0, err
+	}
+	meta, rc, size, err = s.Store.Consume(ctx, idStr)
+	if err != nil {
+		return meta, rc, size, err
+	}
+	if s.Metrics != nil {
 		s.Metrics.Inc("secrets_consumed_total", 1)
 	}
-	return meta, rc, size, err
+	s.audit(ctx, AuditEvent{Event: AuditConsume, IDHash: HashID(idStr), Size: size})
+	if s.PadSizes {
+		rc, size = padConsumed(rc, size)
+	}
+	return meta, rc, size, nil
+}
+
+// save stores the secret under a freshly generated ID. A store implementing
+// FreshIDSaver retries ErrDuplicateID with a new ID up to IDRetries times;
+// any other store gets a single attempt. A caller-chosen ID (already
+// validated) is tried once and a collision is returned as ErrDuplicateID.
+func (s *Service) save(ctx context.Context, meta Meta, ct io.Reader, size int64, expiresAt time.Time) (domain.SecretID, error) {
+	if id := domain.SecretID(ClientIDFromContext(ctx)); id != "" {
+		return id, s.Store.Save(ctx, id.String(), meta, ct, size, expiresAt)
+	}
+	next := func() (string, error) {
+		id, err := s.idGenerator().NewID()
+		return id.String(), err
+	}
+	if fs, ok := s.Store.(FreshIDSaver); ok {
+		retries := max(s.IDRetries, 0)
+		if s.IDRetries == 0 {
+			retries = DefaultIDRetries
+		}
+		id, err := fs.SaveFresh(ctx, next, retries, meta, ct, size, expiresAt)
+		return domain.SecretID(id), err
+	}
+	id, err := next()
+	if err != nil { // extremely unlikely, but propagate
+		return "", err
+	}
+	return domain.SecretID(id), s.Store.Save(ctx, id, meta, ct, size, expiresAt)
+}
+
+// idGenerator returns the configured ID source, defaulting to crypto/rand.
+func (s *Service) idGenerator() IDGenerator {
+	if s.IDs == nil {
+		return domain.CryptoIDs{}
+	}
+	return s.IDs
+}
+
+// limits returns the effective size and TTL caps for a secret of size bytes in
+// the tenant carried by ctx. Tenant limits only apply when set; otherwise the
+// global values are used.
+func (s *Service) limits(ctx context.Context, size int64) (maxBytes int64, maxTTL time.Duration) {
+	maxBytes, maxTTL = s.MaxBytes, s.MaxTTL
+	if t, ok := s.Tenants[TenantFromContext(ctx)]; ok {
+		if t.MaxBytes > 0 {
+			maxBytes = t.MaxBytes
+		}
+		if t.MaxTTL > 0 {
+			maxTTL = t.MaxTTL
+		}
+	}
+	// External blobs hold disk for their whole lifetime, so they may be
+	// capped more tightly than inline secrets.
+	if s.MaxTTLExternal > 0 && s.InlineMax > 0 && size > s.InlineMax && s.MaxTTLExternal < maxTTL {
+		maxTTL = s.MaxTTLExternal
+	}
+	return maxBytes, maxTTL
+}
+
+// clampTTL moves a positive ttl into the inclusive [min,max] range. Zero or
+// negative values are returned unchanged so validateTTL still rejects them.
+func clampTTL(ttl, min, max time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	if min > 0 && ttl < min {
+		return min
+	}
+	if max > 0 && ttl > max {
+		return max
+	}
+	return ttl
+}
+
+// jitterTTL offsets a validated ttl by a uniform random amount in
+// [-jitter,+jitter] so secrets created in a burst do not all expire together.
+// The result stays within [min,max], so jitter never takes a TTL below min.
+func jitterTTL(ttl, jitter, min, max time.Duration) time.Duration {
+	if jitter <= 0 {
+		return ttl
+	}
+	return clampTTL(ttl+rand.N(2*jitter+1)-jitter, min, max)
 }
 
 // validateTTL ensures the provided ttl falls within the inclusive [min,max] range.
@@ -93,3 +316,12 @@ func validateTTL(ttl, min, max time.Duration) error {
 	}
 	return nil
 }
+
+// versionSupported reports whether CreateSecret accepts version. Zero is
+// never a valid protocol version.
+func (s *Service) versionSupported(version uint8) bool {
+	if version == 0 {
+		return false
+	}
+	return len(s.Versions) == 0 || slices.Contains(s.Versions, version)
+}
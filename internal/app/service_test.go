@@ -1,6 +1,7 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -68,7 +69,7 @@ func TestServiceCreateSecretSuccess(t *testing.T) {
 	svc := &Service{Store: ms, Clock: fixedClock{now: now}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 10 * time.Minute}
 	data := "ciphertext"
 	ttl := 2 * time.Minute
-	id, exp, err := svc.CreateSecret(context.Background(), strings.NewReader(data), int64(len(data)), 1, "nonce123", ttl)
+	id, exp, err := svc.CreateSecret(context.Background(), strings.NewReader(data), int64(len(data)), 1, "nonce123", ttl, CreateOptions{})
 	if err != nil {
 		t.Fatalf("CreateSecret error: %v", err)
 	}
@@ -99,31 +100,225 @@ func TestServiceCreateSecretTTLInvalid(t *testing.T) {
 	ms := &mockStore{}
 	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
 	// below min
-	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", 30*time.Second); err != domain.ErrTTLInvalid {
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", 30*time.Second, CreateOptions{}); err != domain.ErrTTLInvalid {
 		t.Fatalf("expected ErrTTLInvalid for below min, got %v", err)
 	}
 	// above max
-	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", 10*time.Minute); err != domain.ErrTTLInvalid {
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", 10*time.Minute, CreateOptions{}); err != domain.ErrTTLInvalid {
 		t.Fatalf("expected ErrTTLInvalid for above max, got %v", err)
 	}
 }
 
+func TestServiceCreateSecretTTLClamp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		clamp   bool
+		want    time.Duration
+		wantErr error
+	}{
+		{name: "clamp up to min", ttl: 30 * time.Second, clamp: true, want: time.Minute},
+		{name: "clamp down to max", ttl: 10 * time.Minute, clamp: true, want: 5 * time.Minute},
+		{name: "in range untouched", ttl: 2 * time.Minute, clamp: true, want: 2 * time.Minute},
+		{name: "non-positive still rejected", ttl: 0, clamp: true, wantErr: domain.ErrTTLInvalid},
+		{name: "reject below min", ttl: 30 * time.Second, wantErr: domain.ErrTTLInvalid},
+		{name: "reject above max", ttl: 10 * time.Minute, wantErr: domain.ErrTTLInvalid},
+		{name: "reject mode in range", ttl: 2 * time.Minute, want: 2 * time.Minute},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ms := &mockStore{}
+			svc := &Service{Store: ms, Clock: fixedClock{now: now}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 5 * time.Minute, ClampTTL: tc.clamp}
+			_, exp, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", tc.ttl, CreateOptions{})
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if exp != now.Add(tc.want) || ms.savedExpires != exp {
+				t.Fatalf("expiry = %v (saved %v), want %v", exp, ms.savedExpires, now.Add(tc.want))
+			}
+		})
+	}
+}
+
+func TestServiceCreateSecretTTLClampTenantMax(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, ClampTTL: true,
+		Tenants: map[string]domain.Tenant{"acme": {Name: "acme", MaxTTL: 10 * time.Minute}}}
+	_, exp, err := svc.CreateSecret(WithTenant(context.Background(), "acme"), strings.NewReader("a"), 1, 1, "n", time.Hour, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateSecret error: %v", err)
+	}
+	if exp != now.Add(10*time.Minute) {
+		t.Fatalf("expected clamp to tenant max, got %v", exp.Sub(now))
+	}
+}
+
+func TestServiceCreateSecretMaxTTLExternal(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 1 << 20, MinTTL: time.Minute, MaxTTL: 24 * time.Hour, InlineMax: 4096, MaxTTLExternal: time.Hour}
+	small := strings.Repeat("a", 4096)
+	large := strings.Repeat("a", 4097)
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(small), int64(len(small)), 1, "n", 24*time.Hour, CreateOptions{}); err != nil {
+		t.Fatalf("inline secret with long TTL rejected: %v", err)
+	}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(large), int64(len(large)), 1, "n", 24*time.Hour, CreateOptions{}); !errors.Is(err, domain.ErrTTLInvalid) {
+		t.Fatalf("expected ErrTTLInvalid for large secret, got %v", err)
+	}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(large), int64(len(large)), 1, "n", time.Hour, CreateOptions{}); err != nil {
+		t.Fatalf("large secret within external cap rejected: %v", err)
+	}
+	// Clamping uses the external ceiling too.
+	svc.ClampTTL = true
+	_, exp, err := svc.CreateSecret(context.Background(), strings.NewReader(large), int64(len(large)), 1, "n", 24*time.Hour, CreateOptions{})
+	if err != nil || exp != now.Add(time.Hour) {
+		t.Fatalf("expected clamp to external max, got %v %v", exp.Sub(now), err)
+	}
+	// A tighter tenant cap still wins.
+	svc.Tenants = map[string]domain.Tenant{"acme": {Name: "acme", MaxTTL: 10 * time.Minute}}
+	_, exp, err = svc.CreateSecret(WithTenant(context.Background(), "acme"), strings.NewReader(large), int64(len(large)), 1, "n", 24*time.Hour, CreateOptions{})
+	if err != nil || exp != now.Add(10*time.Minute) {
+		t.Fatalf("expected clamp to tenant max, got %v %v", exp.Sub(now), err)
+	}
+}
+
+func TestServiceCreateSecretMaxReads(t *testing.T) {
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
+	ctx := WithMaxReads(context.Background(), 2)
+	if _, _, err := svc.CreateSecret(ctx, strings.NewReader("a"), 1, 1, "n", time.Minute, CreateOptions{}); err != ErrMaxReadsInvalid {
+		t.Fatalf("expected ErrMaxReadsInvalid when multi-read disabled, got %v", err)
+	}
+	svc.MaxReads = 3
+	if _, _, err := svc.CreateSecret(ctx, strings.NewReader("a"), 1, 1, "n", time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("expected success within limit, got %v", err)
+	}
+	if _, _, err := svc.CreateSecret(WithMaxReads(context.Background(), 4), strings.NewReader("a"), 1, 1, "n", time.Minute, CreateOptions{}); err != ErrMaxReadsInvalid {
+		t.Fatalf("expected ErrMaxReadsInvalid above limit, got %v", err)
+	}
+	if MaxReadsFromContext(context.Background()) != 1 {
+		t.Fatalf("default max reads should be 1")
+	}
+}
+
+func TestServiceCreateSecretNotBefore(t *testing.T) {
+	now := time.Now()
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: time.Hour}
+	create := func(nb time.Time) error {
+		_, _, err := svc.CreateSecret(WithNotBefore(context.Background(), nb), strings.NewReader("a"), 1, 1, "n", 10*time.Minute, CreateOptions{})
+		return err
+	}
+	if err := create(now.Add(5 * time.Minute)); err != nil {
+		t.Fatalf("expected success before expiry, got %v", err)
+	}
+	if err := create(now.Add(10 * time.Minute)); err != ErrNotBeforeInvalid {
+		t.Fatalf("expected ErrNotBeforeInvalid at expiry, got %v", err)
+	}
+	if !NotBeforeFromContext(context.Background()).IsZero() {
+		t.Fatalf("default not before should be zero")
+	}
+}
+
+func TestServiceCreateSecretVersion(t *testing.T) {
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
+	create := func(v uint8) error {
+		_, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, v, "n", time.Minute, CreateOptions{})
+		return err
+	}
+	if err := create(200); err != nil {
+		t.Fatalf("expected any nonzero version by default, got %v", err)
+	}
+	if err := create(0); err != ErrVersionUnsupported {
+		t.Fatalf("expected ErrVersionUnsupported for version 0, got %v", err)
+	}
+	svc.Versions = []uint8{1, 2}
+	for v, want := range map[uint8]error{1: nil, 2: nil, 3: ErrVersionUnsupported, 0: ErrVersionUnsupported} {
+		if err := create(v); err != want {
+			t.Fatalf("version %d: expected %v, got %v", v, want, err)
+		}
+	}
+}
+
 func TestServiceCreateSecretSizeValidation(t *testing.T) {
 	ms := &mockStore{}
 	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
-	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(""), 0, 1, "n", time.Minute); err != ErrSizeExceeded {
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(""), 0, 1, "n", time.Minute, CreateOptions{}); err != ErrSizeExceeded {
 		t.Fatalf("expected ErrSizeExceeded for size 0, got %v", err)
 	}
-	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("01234567890"), 11, 1, "n", time.Minute); err != ErrSizeExceeded {
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("01234567890"), 11, 1, "n", time.Minute, CreateOptions{}); err != ErrSizeExceeded {
 		t.Fatalf("expected ErrSizeExceeded for oversize, got %v", err)
 	}
+	svc.AllowEmpty = true
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(""), 0, 1, "n", time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("AllowEmpty: size 0 rejected: %v", err)
+	}
+	if !ms.saveCalled || ms.savedSize != 0 {
+		t.Fatalf("AllowEmpty: save called=%v size=%d", ms.saveCalled, ms.savedSize)
+	}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader(""), -1, 1, "n", time.Minute, CreateOptions{}); err != ErrSizeExceeded {
+		t.Fatalf("AllowEmpty: expected ErrSizeExceeded for negative size, got %v", err)
+	}
+}
+
+func TestServiceCreateSecretMinBytes(t *testing.T) {
+	ms := &mockStore{}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinBytes: 4, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
+	create := func(size int64) error {
+		_, _, err := svc.CreateSecret(context.Background(), strings.NewReader(strings.Repeat("a", int(max(size, 0)))), size, 1, "n", time.Minute, CreateOptions{})
+		return err
+	}
+	for size, want := range map[int64]error{3: ErrTooSmall, 4: nil, 10: nil, 11: ErrSizeExceeded, 0: ErrSizeExceeded} {
+		if err := create(size); err != want {
+			t.Fatalf("size %d: expected %v, got %v", size, want, err)
+		}
+	}
+	svc.AllowEmpty = true
+	if err := create(0); err != nil {
+		t.Fatalf("AllowEmpty: size 0 rejected by floor: %v", err)
+	}
+	svc.MinBytes = 0
+	if err := create(1); err != nil {
+		t.Fatalf("no floor: size 1 rejected: %v", err)
+	}
+}
+
+func TestServiceCreateSecretTTLJitter(t *testing.T) {
+	now := time.Now()
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 10, MinTTL: 5 * time.Minute, MaxTTL: time.Hour, TTLJitter: time.Minute}
+	spread := func(ttl time.Duration) (lo, hi time.Duration) {
+		lo, hi = time.Duration(1<<62), 0
+		for range 200 {
+			_, exp, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", ttl, CreateOptions{})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			got := exp.Sub(now)
+			lo, hi = min(lo, got), max(hi, got)
+		}
+		return lo, hi
+	}
+	lo, hi := spread(30 * time.Minute)
+	if lo < 29*time.Minute || hi > 31*time.Minute {
+		t.Fatalf("expiries [%v,%v] outside the jitter window", lo, hi)
+	}
+	if hi-lo < 30*time.Second {
+		t.Fatalf("expiries [%v,%v] not spread", lo, hi)
+	}
+	if lo, _ := spread(5 * time.Minute); lo < 5*time.Minute {
+		t.Fatalf("jittered ttl %v below min", lo)
+	}
+	if _, hi := spread(time.Hour); hi > time.Hour {
+		t.Fatalf("jittered ttl %v above max", hi)
+	}
 }
 
 func TestServiceCreateSecretStoreError(t *testing.T) {
 	boom := errors.New("boom")
 	ms := &mockStore{saveErr: boom}
 	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 100, MinTTL: time.Minute, MaxTTL: 5 * time.Minute}
-	_, _, err := svc.CreateSecret(context.Background(), strings.NewReader("abc"), 3, 1, "n", 2*time.Minute)
+	_, _, err := svc.CreateSecret(context.Background(), strings.NewReader("abc"), 3, 1, "n", 2*time.Minute, CreateOptions{})
 	if err != boom {
 		t.Fatalf("expected store error propagation, got %v", err)
 	}
@@ -177,3 +372,413 @@ func TestServiceConsumeStoreError(t *testing.T) {
 		t.Fatalf("expected store consume error, got %v", err)
 	}
 }
+
+func TestServiceCreateSecretTenantLimits(t *testing.T) {
+	ms := &mockStore{}
+	svc := &Service{
+		Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 100, MinTTL: time.Minute, MaxTTL: 10 * time.Minute,
+		Tenants: map[string]domain.Tenant{"acme": {Name: "acme", MaxBytes: 5, MaxTTL: 2 * time.Minute}},
+	}
+	ctxA := WithTenant(context.Background(), "acme")
+	if _, _, err := svc.CreateSecret(ctxA, strings.NewReader("123456"), 6, 1, "n", time.Minute, CreateOptions{}); err != ErrSizeExceeded {
+		t.Fatalf("expected tenant size limit, got %v", err)
+	}
+	if _, _, err := svc.CreateSecret(ctxA, strings.NewReader("a"), 1, 1, "n", 5*time.Minute, CreateOptions{}); err != domain.ErrTTLInvalid {
+		t.Fatalf("expected tenant ttl limit, got %v", err)
+	}
+	// Default namespace keeps global limits.
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("123456"), 6, 1, "n", 5*time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("global limits should allow, got %v", err)
+	}
+}
+
+// recordingTracer captures span names and attributes for assertions.
+type recordingTracer struct {
+	spans []string
+	attrs []Attr
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	r.spans = append(r.spans, name)
+	r.attrs = append(r.attrs, attrs...)
+	return ctx, recordingSpan{r}
+}
+
+type recordingSpan struct{ r *recordingTracer }
+
+func (s recordingSpan) SetAttrs(attrs ...Attr) { s.r.attrs = append(s.r.attrs, attrs...) }
+func (recordingSpan) RecordError(error)        {}
+func (recordingSpan) End()                     {}
+
+func TestServiceTracingOmitsSensitiveValues(t *testing.T) {
+	ms := &mockStore{consumeData: "x", consumeSize: 1}
+	tr := &recordingTracer{}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Unix(1700000000, 0)}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, Tracer: tr}
+	id, _, err := svc.CreateSecret(context.Background(), strings.NewReader("ciphertext"), 10, 1, "nonce123", 5*time.Minute, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateSecret error: %v", err)
+	}
+	if _, _, _, err := svc.Consume(context.Background(), id.String()); err != nil {
+		t.Fatalf("Consume error: %v", err)
+	}
+	if len(tr.spans) != 2 || tr.spans[0] != "service.CreateSecret" || tr.spans[1] != "service.Consume" {
+		t.Fatalf("unexpected spans: %v", tr.spans)
+	}
+	for _, a := range tr.attrs {
+		if v, ok := a.Value.(string); ok && (strings.Contains(v, id.String()) || strings.Contains(v, "nonce123")) {
+			t.Fatalf("attribute %s leaks sensitive value", a.Key)
+		}
+	}
+	if HashID(id.String()) == id.String() || len(HashID(id.String())) != 16 {
+		t.Fatalf("unexpected id hash")
+	}
+}
+
+func TestServiceCreateSecretStoresOptions(t *testing.T) {
+	ms := &mockStore{}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Now()}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: time.Hour}
+	opts := CreateOptions{Params: "eyJrZGYiOiJhcmdvbjJpZCJ9", ContentType: "image/png"}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", time.Minute, opts); err != nil {
+		t.Fatalf("CreateSecret error: %v", err)
+	}
+	if ms.savedMeta.Params != opts.Params || ms.savedMeta.ContentType != opts.ContentType {
+		t.Fatalf("stored meta %+v, want options %+v", ms.savedMeta, opts)
+	}
+}
+
+type recordingAuditor struct{ events []AuditEvent }
+
+func (r *recordingAuditor) Record(_ context.Context, ev AuditEvent) { r.events = append(r.events, ev) }
+
+func TestServiceAuditEvents(t *testing.T) {
+	data := "ciphertext"
+	ms := &mockStore{consumeData: data, consumeSize: int64(len(data))}
+	aud := &recordingAuditor{}
+	now := time.Unix(1700000000, 0)
+	svc := &Service{Store: ms, Clock: fixedClock{now: now}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 10 * time.Minute, Auditor: aud}
+	ctx := WithClientIP(WithCorrelationID(WithTenant(context.Background(), "acme"), "cid-1"), "198.51.100.9")
+	id, _, err := svc.CreateSecret(ctx, strings.NewReader(data), int64(len(data)), 1, "nonce", 2*time.Minute, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateSecret error: %v", err)
+	}
+	if _, _, _, err := svc.Consume(ctx, id.String()); err != nil {
+		t.Fatalf("Consume error: %v", err)
+	}
+	// Failed operations are not audited.
+	ms.consumeErr = ErrNotFound
+	_, _, _, _ = svc.Consume(ctx, id.String())
+	_, _, _ = svc.CreateSecret(ctx, strings.NewReader(data), int64(len(data)), 1, "nonce", time.Hour, CreateOptions{})
+
+	if len(aud.events) != 2 {
+		t.Fatalf("expected 2 audit events got %d", len(aud.events))
+	}
+	want := AuditEvent{Event: AuditCreate, IDHash: HashID(id.String()), Time: now.UTC(), Size: int64(len(data)), TTLSecs: 120, Tenant: "acme", CorrelationID: "cid-1", ClientIP: "198.51.100.9"}
+	if aud.events[0] != want {
+		t.Fatalf("create event got %+v want %+v", aud.events[0], want)
+	}
+	if ev := aud.events[1]; ev.Event != AuditConsume || ev.IDHash != want.IDHash || ev.Size != want.Size || ev.TTLSecs != 0 {
+		t.Fatalf("unexpected consume event %+v", ev)
+	}
+	if strings.Contains(aud.events[0].IDHash, id.String()) {
+		t.Fatalf("audit event leaked full id")
+	}
+}
+
+// countingMetrics implements Metrics; observingMetrics adds Observer.
+type countingMetrics struct{ incs map[string]int64 }
+
+func (c *countingMetrics) Inc(name string, delta int64) { c.incs[name] += delta }
+
+type observingMetrics struct {
+	countingMetrics
+	observed map[string][]int64
+}
+
+func (o *observingMetrics) Observe(name string, v int64) {
+	o.observed[name] = append(o.observed[name], v)
+}
+
+func TestServiceCreateSecretObservesSize(t *testing.T) {
+	m := &observingMetrics{countingMetrics: countingMetrics{incs: map[string]int64{}}, observed: map[string][]int64{}}
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: time.Now()}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 10 * time.Minute, Metrics: m}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("abc"), 3, 1, "n", time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("CreateSecret: %v", err)
+	}
+	// Rejected creates are not observed.
+	_, _, _ = svc.CreateSecret(context.Background(), strings.NewReader("abc"), 3, 1, "n", time.Hour, CreateOptions{})
+	if got := m.observed["secret_size_bytes"]; len(got) != 1 || got[0] != 3 {
+		t.Fatalf("unexpected observations %v", got)
+	}
+	if m.incs["secrets_created_total"] != 1 {
+		t.Fatalf("expected created counter 1, got %d", m.incs["secrets_created_total"])
+	}
+
+	// A collector without Observe still counts creates.
+	c := &countingMetrics{incs: map[string]int64{}}
+	svc.Metrics = c
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("abc"), 3, 1, "n", time.Minute, CreateOptions{}); err != nil {
+		t.Fatalf("CreateSecret: %v", err)
+	}
+	if c.incs["secrets_created_total"] != 1 {
+		t.Fatalf("expected created counter without Observer")
+	}
+}
+
+// TestServiceCreateSecretArbitraryTTL confirms any TTL within the bounds is
+// accepted, not just preset values (GONE_TTL_MODE=range relies on this).
+func TestServiceCreateSecretArbitraryTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: 48 * time.Hour}
+	for _, ttl := range []time.Duration{time.Minute, 17 * time.Minute, time.Hour + 7*time.Minute + 13*time.Second, 48 * time.Hour} {
+		_, exp, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", ttl, CreateOptions{})
+		if err != nil {
+			t.Fatalf("ttl %v rejected: %v", ttl, err)
+		}
+		if !exp.Equal(now.Add(ttl)) {
+			t.Fatalf("ttl %v expiry %v", ttl, exp)
+		}
+	}
+}
+
+type probeStore struct {
+	mockStore
+	live map[string]bool
+}
+
+func (p *probeStore) Exists(_ context.Context, id string) (bool, error) { return p.live[id], nil }
+
+func TestServiceExists(t *testing.T) {
+	id := "0123456789abcdef0123456789abcdef"
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: time.Now()}}
+	if _, err := svc.Exists(context.Background(), id); !errors.Is(err, ErrProbeUnsupported) {
+		t.Fatalf("expected ErrProbeUnsupported, got %v", err)
+	}
+	ps := &probeStore{live: map[string]bool{id: true}}
+	svc.Store = ps
+	if _, err := svc.Exists(context.Background(), "bad"); !errors.Is(err, domain.ErrInvalidID) {
+		t.Fatalf("expected ErrInvalidID, got %v", err)
+	}
+	if ok, err := svc.Exists(context.Background(), id); err != nil || !ok {
+		t.Fatalf("expected live secret, got %v %v", ok, err)
+	}
+	if ok, err := svc.Exists(context.Background(), "ffffffffffffffffffffffffffffffff"); err != nil || ok {
+		t.Fatalf("expected missing secret, got %v %v", ok, err)
+	}
+	if ps.consumeCalled {
+		t.Fatalf("Exists must not consume")
+	}
+}
+
+// seqIDs is a deterministic IDGenerator handing out ids in order.
+type seqIDs struct {
+	ids []domain.SecretID
+	err error
+}
+
+func (s *seqIDs) NewID() (domain.SecretID, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	id := s.ids[0]
+	s.ids = s.ids[1:]
+	return id, nil
+}
+
+func TestServiceCreateSecretIDGenerator(t *testing.T) {
+	ms := &mockStore{}
+	gen := &seqIDs{ids: []domain.SecretID{"00000000000000000000000000000001", "00000000000000000000000000000002"}}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Unix(1700000000, 0)}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, IDs: gen}
+	for _, want := range []string{"00000000000000000000000000000001", "00000000000000000000000000000002"} {
+		id, _, err := svc.CreateSecret(context.Background(), strings.NewReader("x"), 1, 1, "n", time.Minute, CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateSecret: %v", err)
+		}
+		if id.String() != want || ms.savedID != want {
+			t.Fatalf("id=%s saved=%s want %s", id, ms.savedID, want)
+		}
+	}
+
+	genErr := errors.New("entropy exhausted")
+	ms = &mockStore{}
+	svc.Store, svc.IDs = ms, &seqIDs{err: genErr}
+	if _, _, err := svc.CreateSecret(context.Background(), strings.NewReader("x"), 1, 1, "n", time.Minute, CreateOptions{}); !errors.Is(err, genErr) {
+		t.Fatalf("expected generator error, got %v", err)
+	}
+	if ms.saveCalled {
+		t.Fatal("Save must not run when ID generation fails")
+	}
+}
+
+// collidingStore reports ErrDuplicateID for the first dups Saves, draining
+// the reader first as a store that buffers inline data would.
+type collidingStore struct {
+	mockStore
+	dups  int
+	ids   []string
+	datas []string
+}
+
+func (c *collidingStore) Save(ctx context.Context, id string, meta Meta, r io.Reader, size int64, expiresAt time.Time) error {
+	b, _ := io.ReadAll(r)
+	c.ids = append(c.ids, id)
+	c.datas = append(c.datas, string(b))
+	if len(c.ids) <= c.dups {
+		return ErrDuplicateID
+	}
+	return nil
+}
+
+// freshStore is a collidingStore that retries collisions itself, reusing the
+// payload it read on the first attempt.
+type freshStore struct {
+	collidingStore
+	retries int
+}
+
+func (f *freshStore) SaveFresh(ctx context.Context, next func() (string, error), retries int, meta Meta, r io.Reader, size int64, expiresAt time.Time) (string, error) {
+	f.retries = retries
+	b, _ := io.ReadAll(r)
+	for attempt := 0; ; attempt++ {
+		id, err := next()
+		if err != nil {
+			return "", err
+		}
+		err = f.Save(ctx, id, meta, bytes.NewReader(b), size, expiresAt)
+		if !errors.Is(err, ErrDuplicateID) || attempt >= retries {
+			return id, err
+		}
+	}
+}
+
+func TestServiceCreateSecretRetriesDuplicateID(t *testing.T) {
+	ids := []domain.SecretID{"00000000000000000000000000000001", "00000000000000000000000000000002", "00000000000000000000000000000003"}
+	newSvc := func(st SecretStore, retries int) *Service {
+		return &Service{Store: st, Clock: fixedClock{now: time.Unix(1700000000, 0)}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, IDs: &seqIDs{ids: append([]domain.SecretID(nil), ids...)}, IDRetries: retries, InlineMax: 64}
+	}
+
+	st := &freshStore{collidingStore: collidingStore{dups: 1}}
+	id, _, err := newSvc(st, 0).CreateSecret(context.Background(), strings.NewReader("cipher"), 6, 1, "n", time.Minute, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateSecret: %v", err)
+	}
+	if id != ids[1] || len(st.ids) != 2 || st.ids[1] != ids[1].String() || st.datas[1] != "cipher" {
+		t.Fatalf("expected retry with second id, got id=%s saves=%v", id, st.ids)
+	}
+	if st.retries != DefaultIDRetries {
+		t.Fatalf("retries = %d, want DefaultIDRetries", st.retries)
+	}
+
+	st = &freshStore{collidingStore: collidingStore{dups: 3}}
+	if _, _, err := newSvc(st, 1).CreateSecret(context.Background(), strings.NewReader("cipher"), 6, 1, "n", time.Minute, CreateOptions{}); !errors.Is(err, ErrDuplicateID) {
+		t.Fatalf("expected ErrDuplicateID once retries are spent, got %v", err)
+	}
+	if len(st.ids) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(st.ids))
+	}
+
+	// A store that cannot retry itself gets a single attempt; the service
+	// never buffers the ciphertext to replay it.
+	plain := &collidingStore{dups: 1}
+	if _, _, err := newSvc(plain, 0).CreateSecret(context.Background(), strings.NewReader("cipher"), 6, 1, "n", time.Minute, CreateOptions{}); !errors.Is(err, ErrDuplicateID) {
+		t.Fatalf("expected ErrDuplicateID without retry, got %v", err)
+	}
+	if len(plain.ids) != 1 {
+		t.Fatalf("expected no retry, got %d attempts", len(plain.ids))
+	}
+}
+
+// fakeQuota admits limit creates per namespace and records who was charged.
+type fakeQuota struct {
+	limit int
+	used  map[string]int
+	err   error
+}
+
+func (f *fakeQuota) Take(_ context.Context, ns string, _ time.Time) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.used[ns] >= f.limit {
+		return false, nil
+	}
+	f.used[ns]++
+	return true, nil
+}
+
+func TestServiceCreateSecretQuota(t *testing.T) {
+	ms := &mockStore{}
+	q := &fakeQuota{limit: 2, used: map[string]int{}}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Unix(1700000000, 0)}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, Quota: q}
+	create := func(ctx context.Context) error {
+		_, _, err := svc.CreateSecret(ctx, strings.NewReader("x"), 1, 1, "n", time.Minute, CreateOptions{})
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := create(context.Background()); err != nil {
+			t.Fatalf("create %d: %v", i+1, err)
+		}
+	}
+	ms.saveCalled = false
+	err := create(context.Background())
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	// 1700000000 is 22:13:20 UTC, so the quota resets 1h46m40s later.
+	var qe *QuotaExceededError
+	if !errors.As(err, &qe) || qe.RetryAfter != time.Hour+46*time.Minute+40*time.Second {
+		t.Fatalf("expected reset at next UTC midnight on the service clock, got %v", err)
+	}
+	if ms.saveCalled {
+		t.Fatal("Save must not run once the quota is used up")
+	}
+	if err := create(WithTenant(context.Background(), "acme")); err != nil {
+		t.Fatalf("tenant charged against root quota: %v", err)
+	}
+	if q.used["acme"] != 1 {
+		t.Fatalf("tenant namespace not passed to quota: %v", q.used)
+	}
+	// Invalid requests are rejected before they spend a slot.
+	if _, _, err := svc.CreateSecret(WithTenant(context.Background(), "acme"), strings.NewReader("x"), 1, 1, "n", time.Second, CreateOptions{}); err == nil {
+		t.Fatal("expected ttl error")
+	}
+	if q.used["acme"] != 1 {
+		t.Fatalf("invalid create spent a slot: %v", q.used)
+	}
+
+	q.err = errors.New("db down")
+	if err := create(WithTenant(context.Background(), "acme")); !errors.Is(err, q.err) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+}
+
+func TestServiceCreateSecretClientID(t *testing.T) {
+	const want = "00000000000000000000000000000abc"
+	ms := &mockStore{}
+	gen := &seqIDs{err: errors.New("generator must not be used")}
+	svc := &Service{Store: ms, Clock: fixedClock{now: time.Unix(1700000000, 0)}, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, IDs: gen, ClientIDs: true}
+	create := func(id string) (domain.SecretID, error) {
+		id2, _, err := svc.CreateSecret(WithClientID(context.Background(), id), strings.NewReader("x"), 1, 1, "n", time.Minute, CreateOptions{})
+		return id2, err
+	}
+	if id, err := create(want); err != nil || id.String() != want || ms.savedID != want {
+		t.Fatalf("id=%s saved=%s err=%v", id, ms.savedID, err)
+	}
+
+	ms.saveCalled = false
+	if _, err := create("not-an-id"); !errors.Is(err, domain.ErrInvalidID) || ms.saveCalled {
+		t.Fatalf("expected ErrInvalidID without Save, got %v saved=%v", err, ms.saveCalled)
+	}
+
+	// A collision is reported, not retried under another ID.
+	cs := &collidingStore{dups: 5}
+	svc.Store = cs
+	if _, err := create(want); !errors.Is(err, ErrDuplicateID) || len(cs.ids) != 1 {
+		t.Fatalf("expected single ErrDuplicateID attempt, got %v after %d saves", err, len(cs.ids))
+	}
+
+	svc.Store, svc.ClientIDs = ms, false
+	ms.saveCalled = false
+	if _, err := create(want); !errors.Is(err, ErrClientIDNotAllowed) || ms.saveCalled {
+		t.Fatalf("expected ErrClientIDNotAllowed without Save, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package app
+
+import "context"
+
+// tenantCtxKey is the unexported context key type for the active tenant.
+type tenantCtxKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the named tenant. Storage
+// adapters read the tenant back via TenantFromContext so that every
+// per-secret operation is confined to that namespace.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx. The empty string is
+// the default (untenanted) namespace used when multi-tenancy is disabled.
+func TenantFromContext(ctx context.Context) string {
+	t, _ := ctx.Value(tenantCtxKey{}).(string)
+	return t
+}
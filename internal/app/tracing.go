@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Attr is a single span attribute. Values should be strings, integers or
+// booleans. Attributes must never carry plaintext, nonces or full secret IDs;
+// use HashID when a secret needs to be correlated across spans.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Span is the minimal span surface used by the core packages.
+type Span interface {
+	// SetAttrs attaches attributes to the span.
+	SetAttrs(attrs ...Attr)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	// End finishes the span.
+	End()
+}
+
+// Tracer starts spans. It is implemented by an OpenTelemetry adapter outside
+// the core so that app, store and httpx do not depend on the OTel SDK.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+}
+
+// NoopTracer is a Tracer that records nothing. It is used when tracing is
+// not configured.
+type NoopTracer struct{}
+
+// Start returns ctx unchanged and a span that discards everything.
+func (NoopTracer) Start(ctx context.Context, _ string, _ ...Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttrs(...Attr)  {}
+func (noopSpan) RecordError(error) {}
+func (noopSpan) End()              {}
+
+// TracerOrNoop returns t, or NoopTracer when t is nil.
+func TracerOrNoop(t Tracer) Tracer {
+	if t == nil {
+		return NoopTracer{}
+	}
+	return t
+}
+
+// HashID returns a short, non-reversible fingerprint of a secret ID suitable
+// for span attributes. The full ID is a bearer credential and never leaves
+// the process in telemetry.
+func HashID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,47 @@
+package app
+
+import "time"
+
+// TTL snap modes for Service.TTLSnap.
+const (
+	TTLSnapOff     = "off"     // keep the client's exact TTL
+	TTLSnapNearest = "nearest" // round to the closest preset (ties round up)
+	TTLSnapUp      = "up"      // round up to the next preset
+)
+
+// snapTTL rounds a positive ttl onto the ascending presets according to mode.
+// Presets above a positive max are never chosen, so snapping cannot push a
+// valid ttl past the effective cap. A ttl above every usable preset is left
+// for clamping or validation, as are zero or negative values and an unknown
+// mode.
+func snapTTL(ttl time.Duration, presets []time.Duration, mode string, max time.Duration) time.Duration {
+	if max > 0 {
+		n := len(presets)
+		for n > 0 && presets[n-1] > max {
+			n--
+		}
+		presets = presets[:n]
+	}
+	if ttl <= 0 || len(presets) == 0 {
+		return ttl
+	}
+	for i, p := range presets {
+		if p < ttl {
+			continue
+		}
+		switch mode {
+		case TTLSnapUp:
+			return p
+		case TTLSnapNearest:
+			if i > 0 && ttl-presets[i-1] < p-ttl {
+				return presets[i-1]
+			}
+			return p
+		}
+		return ttl
+	}
+	if mode == TTLSnapNearest {
+		return presets[len(presets)-1]
+	}
+	return ttl
+}
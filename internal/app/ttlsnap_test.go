@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+)
+
+func TestSnapTTL(t *testing.T) {
+	presets := []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour}
+	for _, tc := range []struct {
+		mode string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{TTLSnapOff, 17 * time.Minute, 17 * time.Minute},
+		{TTLSnapUp, 17 * time.Minute, 30 * time.Minute},
+		{TTLSnapNearest, 17 * time.Minute, 5 * time.Minute},
+		{TTLSnapNearest, 18 * time.Minute, 30 * time.Minute},
+		{TTLSnapNearest, 17*time.Minute + 30*time.Second, 30 * time.Minute}, // tie rounds up
+		{TTLSnapUp, 30 * time.Minute, 30 * time.Minute},
+		{TTLSnapUp, time.Minute, 5 * time.Minute},
+		{TTLSnapUp, 2 * time.Hour, 2 * time.Hour},
+		{TTLSnapNearest, 2 * time.Hour, time.Hour},
+		{TTLSnapUp, 0, 0},
+	} {
+		if got := snapTTL(tc.ttl, presets, tc.mode, 0); got != tc.want {
+			t.Fatalf("snapTTL(%v, %s) = %v, want %v", tc.ttl, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestSnapTTLMax(t *testing.T) {
+	presets := []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour}
+	max := 20 * time.Minute
+	for _, tc := range []struct {
+		mode string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{TTLSnapUp, 17 * time.Minute, 17 * time.Minute},     // 30m is above the cap
+		{TTLSnapUp, 3 * time.Minute, 5 * time.Minute},       // usable preset still applies
+		{TTLSnapNearest, 18 * time.Minute, 5 * time.Minute}, // nearest usable preset
+		{TTLSnapUp, 25 * time.Minute, 25 * time.Minute},     // over the cap: left for validation
+	} {
+		if got := snapTTL(tc.ttl, presets, tc.mode, max); got != tc.want {
+			t.Fatalf("snapTTL(%v, %s, max %v) = %v, want %v", tc.ttl, tc.mode, max, got, tc.want)
+		}
+	}
+}
+
+func TestServiceCreateSecretTTLSnap(t *testing.T) {
+	now := time.Now()
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: time.Hour,
+		TTLPresets: []time.Duration{5 * time.Minute, 30 * time.Minute}}
+	for mode, want := range map[string]time.Duration{TTLSnapOff: 17 * time.Minute, TTLSnapUp: 30 * time.Minute, TTLSnapNearest: 5 * time.Minute} {
+		svc.TTLSnap = mode
+		_, exp, err := svc.CreateSecret(context.Background(), strings.NewReader("a"), 1, 1, "n", 17*time.Minute, CreateOptions{})
+		if err != nil {
+			t.Fatalf("%s: create: %v", mode, err)
+		}
+		if got := exp.Sub(now); got != want {
+			t.Fatalf("%s: ttl = %v, want %v", mode, got, want)
+		}
+	}
+}
+
+func TestServiceTTLSnapRespectsTenantMax(t *testing.T) {
+	now := time.Now()
+	svc := &Service{Store: &mockStore{}, Clock: fixedClock{now: now}, MaxBytes: 10, MinTTL: time.Minute, MaxTTL: time.Hour,
+		TTLPresets: []time.Duration{5 * time.Minute, 30 * time.Minute}, TTLSnap: TTLSnapUp,
+		Tenants: map[string]domain.Tenant{"acme": {Name: "acme", MaxTTL: 20 * time.Minute}}}
+	_, exp, err := svc.CreateSecret(WithTenant(context.Background(), "acme"), strings.NewReader("a"), 1, 1, "n", 17*time.Minute, CreateOptions{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := exp.Sub(now); got != 17*time.Minute {
+		t.Fatalf("ttl = %v, want 17m", got)
+	}
+}
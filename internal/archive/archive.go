@@ -0,0 +1,206 @@
+// Package archive moves secrets between instances as a portable tar stream.
+// Each secret is a JSON metadata entry ("<id>.json") optionally followed by
+// its ciphertext ("<id>.blob") for payloads kept outside the index. The
+// stream is produced from, and replayed into, the store ports, so the source
+// and destination backends need not match. Payloads are streamed; at most one
+// secret's metadata is held in memory at a time.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/store"
+)
+
+// Format identifies the archive layout in the manifest.
+const (
+	Format        = "gone-export"
+	FormatVersion = 1
+)
+
+const manifestName = "manifest.json"
+
+// Source enumerates stored secrets for export; implemented by *store.Store.
+type Source interface {
+	Export(ctx context.Context, fn func(rec store.Record, blob io.Reader) error) error
+}
+
+// ErrInvalidArchive reports a malformed or unsupported archive.
+var ErrInvalidArchive = errors.New("archive: invalid archive")
+
+type manifest struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// entry is the portable JSON form of a secret's index record.
+type entry struct {
+	ID             string    `json:"id"`
+	Tenant         string    `json:"tenant,omitempty"`
+	Version        uint8     `json:"version"`
+	Nonce          string    `json:"nonce"`
+	PassphraseHash string    `json:"passphrase_hash,omitempty"`
+	Size           int64     `json:"size"`
+	Inline         []byte    `json:"inline,omitempty"`
+	External       bool      `json:"external"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	ReadsRemaining int       `json:"reads_remaining"`
+	NotBefore      time.Time `json:"not_before,omitzero"`
+	Params         string    `json:"params,omitempty"`
+	ContentType    string    `json:"content_type,omitempty"`
+}
+
+// Export writes every secret from src to w and returns how many were written.
+func Export(ctx context.Context, src Source, w io.Writer) (n int, err error) {
+	tw := tar.NewWriter(w)
+	if err := writeJSON(tw, manifestName, manifest{Format: Format, Version: FormatVersion}); err != nil {
+		return 0, err
+	}
+	err = src.Export(ctx, func(rec store.Record, blob io.Reader) error {
+		e := entry{
+			ID: rec.ID, Tenant: rec.Tenant, Version: rec.Meta.Version, Nonce: rec.Meta.NonceB64u,
+			PassphraseHash: rec.Meta.PassphraseHash,
+			Size:           rec.Size, Inline: rec.Inline, External: rec.External,
+			CreatedAt: rec.CreatedAt, ExpiresAt: rec.ExpiresAt, ReadsRemaining: rec.ReadsRemaining, NotBefore: rec.NotBefore,
+			Params: rec.Meta.Params, ContentType: rec.Meta.ContentType,
+		}
+		if err := writeJSON(tw, rec.ID+".json", e); err != nil {
+			return err
+		}
+		if rec.External {
+			if err := tw.WriteHeader(&tar.Header{Name: rec.ID + ".blob", Mode: 0o600, Size: rec.Size, ModTime: rec.CreatedAt}); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(tw, blob, rec.Size); err != nil {
+				return fmt.Errorf("export blob %s: %w", rec.ID, err)
+			}
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, tw.Close()
+}
+
+func writeJSON(tw *tar.Writer, name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(b))}); err != nil {
+		return err
+	}
+	_, err = tw.Write(b)
+	return err
+}
+
+// Import replays the archive in r into dst. Secrets already expired at now
+// are skipped. Each secret keeps its tenant, expiry and remaining reads;
+// whether it is stored inline is decided by dst.
+func Import(ctx context.Context, r io.Reader, dst app.SecretStore, now time.Time) (imported, skipped int, err error) {
+	tr := tar.NewReader(r)
+	if err := readManifest(tr); err != nil {
+		return 0, 0, err
+	}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return imported, skipped, nil
+		}
+		if err != nil {
+			return imported, skipped, err
+		}
+		e, err := readEntry(tr, hdr)
+		if err != nil {
+			return imported, skipped, err
+		}
+		payload := io.Reader(bytes.NewReader(e.Inline))
+		if e.External {
+			if payload, err = nextBlob(tr, e); err != nil {
+				return imported, skipped, err
+			}
+		}
+		if !e.ExpiresAt.After(now) {
+			skipped++ // the tar reader discards any unread blob on Next
+			continue
+		}
+		sctx := app.WithMaxReads(app.WithTenant(ctx, e.Tenant), e.ReadsRemaining)
+		if !e.NotBefore.IsZero() {
+			sctx = app.WithNotBefore(sctx, e.NotBefore)
+		}
+		meta := app.Meta{Version: e.Version, NonceB64u: e.Nonce, PassphraseHash: e.PassphraseHash, Params: e.Params, ContentType: e.ContentType}
+		if err := dst.Save(sctx, e.ID, meta, payload, e.Size, e.ExpiresAt); err != nil {
+			return imported, skipped, fmt.Errorf("import %s: %w", e.ID, err)
+		}
+		imported++
+	}
+}
+
+func readManifest(tr *tar.Reader) error {
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if m.Format != Format || m.Version != FormatVersion {
+		return fmt.Errorf("%w: unsupported format %s v%d", ErrInvalidArchive, m.Format, m.Version)
+	}
+	return nil
+}
+
+// readEntry decodes and validates a metadata entry. IDs and tenants become
+// file names in blob storage, so they are checked before use.
+func readEntry(tr *tar.Reader, hdr *tar.Header) (entry, error) {
+	var e entry
+	if !strings.HasSuffix(hdr.Name, ".json") {
+		return e, fmt.Errorf("%w: unexpected entry %q", ErrInvalidArchive, hdr.Name)
+	}
+	if err := json.NewDecoder(tr).Decode(&e); err != nil {
+		return e, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, hdr.Name, err)
+	}
+	if _, err := domain.ParseID(e.ID); err != nil || hdr.Name != e.ID+".json" {
+		return e, fmt.Errorf("%w: bad id in %q", ErrInvalidArchive, hdr.Name)
+	}
+	if e.Tenant != "" && !domain.ValidTenantName(e.Tenant) {
+		return e, fmt.Errorf("%w: bad tenant %q", ErrInvalidArchive, e.Tenant)
+	}
+	if e.Size <= 0 || (!e.External && int64(len(e.Inline)) != e.Size) {
+		return e, fmt.Errorf("%w: bad size for %s", ErrInvalidArchive, e.ID)
+	}
+	// The nonce, params and content type are echoed in consume response
+	// headers, so hold them to the limits a create enforces.
+	if !domain.IsBase64URL(e.Nonce) || !domain.ValidParams(e.Params) || !domain.ValidContentType(e.ContentType) {
+		return e, fmt.Errorf("%w: bad metadata for %s", ErrInvalidArchive, e.ID)
+	}
+	return e, nil
+}
+
+// nextBlob advances to the blob entry belonging to e and returns a reader
+// over exactly its payload.
+func nextBlob(tr *tar.Reader, e entry) (io.Reader, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing blob for %s", ErrInvalidArchive, e.ID)
+	}
+	if hdr.Name != e.ID+".blob" || hdr.Size != e.Size {
+		return nil, fmt.Errorf("%w: blob mismatch for %s", ErrInvalidArchive, e.ID)
+	}
+	return tr, nil
+}
@@ -0,0 +1,160 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+type fixedClock struct{ now time.Time }
+
+func (f fixedClock) Now() time.Time { return f.now }
+
+func newStore(t *testing.T, now time.Time) *store.Store {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "gone.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	return store.New(ix, bs, fixedClock{now: now}, 8)
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+	src := newStore(t, now)
+	ctx := context.Background()
+	secrets := []struct {
+		id, tenant, data string
+		reads            int
+		expires          time.Time
+	}{
+		{id: "11111111111111111111111111111111", data: "inline", reads: 1, expires: now.Add(time.Hour)},
+		{id: "22222222222222222222222222222222", data: "external payload", reads: 1, expires: now.Add(time.Hour)},
+		{id: "33333333333333333333333333333333", tenant: "acme", data: "tenant external", reads: 2, expires: now.Add(time.Hour)},
+		{id: "44444444444444444444444444444444", data: "expired external", reads: 1, expires: now.Add(-time.Minute)},
+	}
+	for _, s := range secrets {
+		sctx := app.WithMaxReads(app.WithTenant(ctx, s.tenant), s.reads)
+		if err := src.Save(sctx, s.id, app.Meta{Version: 1, NonceB64u: "n" + s.id[:1]}, bytes.NewReader([]byte(s.data)), int64(len(s.data)), s.expires); err != nil {
+			t.Fatalf("seed %s: %v", s.id, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := Export(ctx, src, &buf)
+	if err != nil || n != len(secrets) {
+		t.Fatalf("Export n=%d err=%v", n, err)
+	}
+	// Export is non-destructive.
+	if _, rc, _, err := src.Consume(ctx, secrets[1].id); err != nil {
+		t.Fatalf("source secret gone after export: %v", err)
+	} else {
+		rc.Close()
+	}
+
+	dst := newStore(t, now)
+	imported, skipped, err := Import(ctx, &buf, dst, now)
+	if err != nil || imported != 3 || skipped != 1 {
+		t.Fatalf("Import imported=%d skipped=%d err=%v", imported, skipped, err)
+	}
+	for _, s := range secrets[:3] {
+		sctx := app.WithTenant(ctx, s.tenant)
+		for read := 0; read < s.reads; read++ {
+			meta, rc, size, err := dst.Consume(sctx, s.id)
+			if err != nil {
+				t.Fatalf("consume %s read %d: %v", s.id, read, err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(b) != s.data || size != int64(len(s.data)) || meta.NonceB64u != "n"+s.id[:1] {
+				t.Fatalf("%s: got %q size=%d meta=%+v", s.id, b, size, meta)
+			}
+		}
+		if _, _, _, err := dst.Consume(sctx, s.id); !errors.Is(err, app.ErrNotFound) {
+			t.Fatalf("%s: expected reads exhausted, got %v", s.id, err)
+		}
+	}
+	if _, _, _, err := dst.Consume(ctx, secrets[3].id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expired secret imported: %v", err)
+	}
+}
+
+func TestExportImportKeepsNotBefore(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+	src := newStore(t, now)
+	ctx := context.Background()
+	const id = "55555555555555555555555555555555"
+	if err := src.Save(app.WithNotBefore(ctx, now.Add(time.Minute)), id, app.Meta{Version: 1, NonceB64u: "n"}, bytes.NewReader([]byte("later")), 5, now.Add(time.Hour)); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := Export(ctx, src, &buf); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	dst := newStore(t, now)
+	if imported, _, err := Import(ctx, &buf, dst, now); err != nil || imported != 1 {
+		t.Fatalf("Import imported=%d err=%v", imported, err)
+	}
+	if _, _, _, err := dst.Consume(ctx, id); !errors.Is(err, app.ErrTooEarly) {
+		t.Fatalf("expected embargo to survive import, got %v", err)
+	}
+}
+
+func TestImportRejectsInvalidArchive(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+	build := func(entries map[string]string, order ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, name := range order {
+			body := entries[name]
+			_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(body))})
+			_, _ = tw.Write([]byte(body))
+		}
+		_ = tw.Close()
+		return &buf
+	}
+	const good = `{"format":"gone-export","version":1}`
+	tests := []struct {
+		name    string
+		entries map[string]string
+		order   []string
+	}{
+		{name: "no manifest", entries: map[string]string{"x.json": "{}"}, order: []string{"x.json"}},
+		{name: "wrong version", entries: map[string]string{manifestName: `{"format":"gone-export","version":9}`}, order: []string{manifestName}},
+		{name: "path traversal id", entries: map[string]string{manifestName: good, "../evil.json": `{"id":"../evil","size":1,"inline":"eA=="}`}, order: []string{manifestName, "../evil.json"}},
+		{name: "params not base64url", entries: map[string]string{manifestName: good, "55555555555555555555555555555555.json": `{"id":"55555555555555555555555555555555","nonce":"n","size":1,"inline":"eA==","params":"a\r\nSet-Cookie: x=1","expires_at":"2030-01-01T00:00:00Z"}`}, order: []string{manifestName, "55555555555555555555555555555555.json"}},
+		{name: "content type control", entries: map[string]string{manifestName: good, "55555555555555555555555555555555.json": `{"id":"55555555555555555555555555555555","nonce":"n","size":1,"inline":"eA==","content_type":"text/plain\r\nX: y","expires_at":"2030-01-01T00:00:00Z"}`}, order: []string{manifestName, "55555555555555555555555555555555.json"}},
+		{name: "missing blob", entries: map[string]string{manifestName: good, "55555555555555555555555555555555.json": `{"id":"55555555555555555555555555555555","size":4,"external":true,"expires_at":"2030-01-01T00:00:00Z"}`}, order: []string{manifestName, "55555555555555555555555555555555.json"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := Import(context.Background(), build(tc.entries, tc.order...), newStore(t, now), now)
+			if !errors.Is(err, ErrInvalidArchive) {
+				t.Fatalf("expected ErrInvalidArchive, got %v", err)
+			}
+		})
+	}
+}
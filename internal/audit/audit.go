@@ -0,0 +1,56 @@
+// Package audit provides app.Auditor adapters that persist an append-only
+// record of secret lifecycle events. Records never contain secret contents.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+var _ app.Auditor = (*FileSink)(nil)
+
+// FileSink appends one JSON object per event to a file (JSON lines).
+// It is safe for concurrent use.
+type FileSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	log *slog.Logger
+}
+
+// NewFileSink opens (creating if needed) path for appending with 0600
+// permissions.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) // #nosec G304 operator-supplied path
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f, log: slog.Default().With("domain", "audit")}, nil
+}
+
+// Record writes ev as a single line. Failures are logged and otherwise
+// ignored so auditing never fails the audited operation.
+func (s *FileSink) Record(_ context.Context, ev app.AuditEvent) {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		s.log.Error("encode audit event", "event", ev.Event, "error", err)
+		return
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(line); err != nil {
+		s.log.Error("write audit event", "event", ev.Event, "error", err)
+	}
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+func TestFileSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	now := time.Unix(1000, 0).UTC()
+	s.Record(context.Background(), app.AuditEvent{Event: app.AuditCreate, IDHash: "abc", Time: now, Size: 10, TTLSecs: 300, CorrelationID: "cid"})
+	s.Record(context.Background(), app.AuditEvent{Event: app.AuditConsume, IDHash: "abc", Time: now, Size: 10})
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	// Reopening appends rather than truncating.
+	s, err = NewFileSink(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	s.Record(context.Background(), app.AuditEvent{Event: app.AuditCreate, IDHash: "def", Time: now, Size: 1})
+	_ = s.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	var events []map[string]any
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var m map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("line not JSON: %q", sc.Text())
+		}
+		events = append(events, m)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events got %d", len(events))
+	}
+	if events[0]["event"] != "create" || events[0]["ttl_secs"] != float64(300) || events[0]["cid"] != "cid" {
+		t.Fatalf("unexpected create event %v", events[0])
+	}
+	if events[1]["event"] != "consume" {
+		t.Fatalf("unexpected consume event %v", events[1])
+	}
+	if st, _ := os.Stat(path); st.Mode().Perm() != 0o600 {
+		t.Fatalf("audit log mode %v", st.Mode().Perm())
+	}
+}
+
+func TestNewFileSinkError(t *testing.T) {
+	if _, err := NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.log")); err == nil {
+		t.Fatalf("expected error for missing directory")
+	}
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,7 +14,11 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env/v2"
+	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 )
@@ -29,6 +34,86 @@ type Config struct {
 	TTLOptions     []domain.TTLOption `koanf:"ttl_options" validate:"required"`
 	MetricsAddr    string             `koanf:"metrics_addr" validate:"omitempty,ip_port"`
 	MetricsToken   string             `koanf:"metrics_token"`
+	Tenants        []domain.Tenant    `koanf:"tenants"`
+	BlobFsync      string             `koanf:"blob_fsync" validate:"oneof=always none dir"`
+	MinTTLOverride time.Duration      `koanf:"min_ttl" validate:"gte=0"` // explicit TTL floor (0 = derive from TTLOptions)
+	MaxTTLOverride time.Duration      `koanf:"max_ttl" validate:"gte=0"` // explicit TTL ceiling (0 = derive from TTLOptions)
+	OTelEndpoint   string             `koanf:"otel_endpoint"`            // OTLP/HTTP trace collector (empty = tracing off)
+	NotFoundFloor  time.Duration      `koanf:"not_found_floor" validate:"gte=0"`
+	TTLOverflow    string             `koanf:"ttl_overflow" validate:"oneof=reject clamp"`
+	MaxBlobBytes   int64              `koanf:"max_blob_bytes" validate:"gte=0"`  // total external blob budget (0 = unlimited)
+	MaxReadsLimit  int                `koanf:"max_reads_limit" validate:"gte=1"` // highest X-Gone-Max-Reads accepted (1 = one-time only)
+	TLSCert        string             `koanf:"tls_cert" validate:"required_with=TLSKey"`
+	TLSKey         string             `koanf:"tls_key" validate:"required_with=TLSCert"`
+	TLSMinVersion  string             `koanf:"tls_min_version" validate:"oneof=1.2 1.3"`
+	StrictPerms    bool               `koanf:"strict_perms"`                     // refuse to start when the data dir is group/world accessible
+	DBBusyRetries  int                `koanf:"db_busy_retries" validate:"gte=0"` // extra attempts after SQLITE_BUSY/LOCKED
+	UI             string             `koanf:"ui" validate:"oneof=enabled disabled redirect"`
+	UIRedirectURL  string             `koanf:"ui_redirect_url" validate:"required_if=UI redirect,omitempty,url"`
+	OpTimeout      time.Duration      `koanf:"op_timeout" validate:"gte=0"` // deadline for create/consume service calls (0 = none)
+	AuditLog       string             `koanf:"audit_log"`                   // JSON-lines audit file (empty = auditing off)
+	ClockSkew      time.Duration      `koanf:"clock_skew" validate:"gte=0"` // expiry grace for clock drift between nodes
+
+	AllowedContentTypes  []string        `koanf:"allowed_content_types"`                            // create Content-Type allowlist (empty = any)
+	IntegrityScan        time.Duration   `koanf:"integrity_scan" validate:"gte=0"`                  // interval between blob integrity scans (0 = off)
+	IntegrityRate        int             `koanf:"integrity_rate" validate:"gte=1"`                  // blobs checked per second during a scan
+	IntegrityRepair      bool            `koanf:"integrity_repair"`                                 // tombstone entries with missing/truncated blobs
+	PassphraseAttempts   int             `koanf:"passphrase_attempts" validate:"gte=1"`             // wrong passphrases allowed per secret per 15m
+	TTLMode              string          `koanf:"ttl_mode" validate:"oneof=preset range"`           // UI offers TTLOptions (preset) or any TTL in bounds (range)
+	MaxConcurrentCreates int             `koanf:"max_concurrent_creates" validate:"gte=0"`          // simultaneous create uploads (0 = unlimited)
+	ExpiryBuckets        []time.Duration `koanf:"expiry_buckets" validate:"dive,gt=0"`              // expiry histogram bounds (empty = TTLOptions durations)
+	AboutFile            string          `koanf:"about_file"`                                       // HTML or Markdown about page override (empty = built-in)
+	RevealHints          bool            `koanf:"reveal_hints"`                                     // secret page distinguishes malformed from unavailable links
+	TrustedProxies       []string        `koanf:"trusted_proxies" validate:"dive,cidr"`             // proxy CIDRs whose forwarding headers carry the client IP
+	MaxTTLExternal       time.Duration   `koanf:"max_ttl_external" validate:"gte=0"`                // TTL ceiling for secrets stored as external blobs (0 = MaxTTL)
+	BlobShardDepth       int             `koanf:"blob_shard_depth" validate:"gte=0,lte=3"`          // two-hex-char directory levels under blobs/ (0 = flat)
+	UploadIdleTimeout    time.Duration   `koanf:"upload_idle_timeout" validate:"gte=0"`             // max stall between create body reads (0 = server ReadTimeout)
+	ConsumeGrace         time.Duration   `koanf:"consume_grace" validate:"gte=0"`                   // external secrets stay re-readable this long after the final read (0 = off)
+	LogLevel             string          `koanf:"log_level" validate:"oneof=debug info warn error"` // minimum slog level written
+	LogFormat            string          `koanf:"log_format" validate:"oneof=text json"`            // slog handler: human-readable text or one JSON object per line
+	MetricsPersist       string          `koanf:"metrics_persist" validate:"oneof=on off"`          // off keeps metrics in memory only (reset on restart)
+	DBReadHandle         bool            `koanf:"db_read_handle"`                                   // serve metrics/admin reads from a second read-only handle (enables WAL)
+	MaxHeaderBytes       int             `koanf:"max_header_bytes" validate:"gte=0"`                // request header block limit (0 = net/http default, 1 MiB)
+	StrictHeaders        bool            `koanf:"strict_headers"`                                   // reject an overlong X-Gone-TTL on create
+	DailyCreateQuota     int64           `koanf:"daily_create_quota" validate:"gte=0"`              // creates per namespace per UTC day (0 = unlimited)
+	AllowClientIDs       bool            `koanf:"allow_client_ids"`                                 // accept caller-chosen IDs via X-Gone-ID (predictable IDs)
+	BlobEncryptionKey    string          `koanf:"blob_encryption_key" validate:"omitempty,base64"`  // base64 32-byte key encrypting external blobs at rest (empty = off)
+	AllowEmpty           bool            `koanf:"allow_empty"`                                      // accept zero-length secrets (presence tokens)
+	WebDir               string          `koanf:"web_dir"`                                          // serve templates and static assets from this directory (empty = embedded)
+	ExpiryWebhook        string          `koanf:"expiry_webhook" validate:"omitempty,url"`          // POST expired_unread events here after janitor sweeps (empty = off)
+	MaxConnections       int             `koanf:"max_connections" validate:"gte=0"`                 // open TCP connections on the main listener (0 = unlimited)
+	MinFreeBytes         int64           `koanf:"min_free_bytes" validate:"gte=0"`                  // reject creates below this much free blob-volume space (0 = off)
+	CSPNonce             bool            `koanf:"csp_nonce"`                                        // per-request script-src nonce for inline <script nonce> in templates
+	SupportedVersions    []int           `koanf:"supported_versions" validate:"dive,gt=0,lt=256"`   // X-Gone-Version values accepted on create (empty = any nonzero)
+	MaxNonceLen          int             `koanf:"max_nonce_len" validate:"gte=0"`                   // longest X-Gone-Nonce on create (0 = 64)
+	MaxConsumeAttempts   int             `koanf:"max_consume_attempts" validate:"gte=0"`            // wrong passphrases before a gated secret is deleted (0 = never)
+	PadSizes             bool            `koanf:"pad_sizes"`                                        // pad consumed ciphertext to power-of-two buckets (min 1 KiB)
+	MaxTTLOptions        int             `koanf:"max_ttl_options" validate:"gte=1"`                 // most GONE_TTL_OPTIONS entries accepted
+	PublicBaseURL        string          `koanf:"public_base_url" validate:"omitempty,base_url"`    // origin for share URLs in create responses (empty = omit, auto = from request)
+	ExpireBatchSize      int             `koanf:"expire_batch_size" validate:"gte=0"`               // expired rows deleted per janitor transaction (0 = all in one)
+	ExistsCacheSize      int             `koanf:"exists_cache_size" validate:"gte=0"`               // recent secret-page probe results kept in memory (0 = off)
+	ExistsCacheTTL       time.Duration   `koanf:"exists_cache_ttl" validate:"gte=0"`                // how long a cached probe result is trusted
+	BlobOverflowSize     int64           `koanf:"blob_overflow_threshold" validate:"gte=0"`         // blobs larger than this many bytes go to BlobOverflowDir (0 = off)
+	BlobOverflowDir      string          `koanf:"blob_overflow_dir"`                                // second blob root for overflow blobs
+	EchoRequestID        bool            `koanf:"echo_request_id"`                                  // include the correlation ID as "request_id" in JSON API bodies
+	DirectRouting        bool            `koanf:"direct_routing"`                                   // route 404s via a catch-all instead of wrapping every response
+	JanitorInterval      time.Duration   `koanf:"janitor_interval" validate:"gt=0"`                 // time between janitor cycles (the longest wait with a min interval)
+	JanitorMinInterval   time.Duration   `koanf:"janitor_min_interval" validate:"gte=0"`            // schedule cycles just after the next expiry, no more often than this (0 = fixed)
+	StaticMaxAge         int             `koanf:"static_max_age" validate:"gt=0"`                   // Cache-Control max-age for /static/ assets, in seconds
+	StaticImmutable      bool            `koanf:"static_immutable"`                                 // mark /static/ assets immutable (for fingerprinted filenames)
+	RejectNetworkFS      bool            `koanf:"reject_network_fs"`                                // refuse to start when the data dir is on NFS/CIFS/etc. (Linux only)
+	ReadyVerbose         bool            `koanf:"ready_verbose"`                                    // /readyz reports secret count and uptime as JSON
+	ConsumeFlushBytes    int             `koanf:"consume_flush_bytes" validate:"gte=0"`             // flush raw consume downloads every this many bytes (0 = no explicit flushes)
+	RequireHTTPS         bool            `koanf:"require_https"`                                    // reject create/consume unless over TLS or a trusted proxy's X-Forwarded-Proto: https
+	AllowExtend          bool            `koanf:"allow_extend"`                                     // anyone holding a secret's ID may reset its TTL via PATCH
+	InlineMemBudget      int64           `koanf:"inline_mem_budget" validate:"gte=0"`               // in-flight inline bytes before creates spill to blob storage (0 = unlimited)
+	MetricsLogInterval   time.Duration   `koanf:"metrics_log_interval" validate:"gte=0"`            // cadence of the periodic metrics summary log line (0 = off)
+	MinBytes             int64           `koanf:"min_bytes" validate:"gte=0,ltefield=MaxBytes"`     // smallest accepted ciphertext (0 = no floor)
+	TTLSnap              string          `koanf:"ttl_snap" validate:"oneof=off nearest up"`         // round client TTLs onto TTLOptions
+	ReadOnly             bool            `koanf:"read_only"`                                        // refuse creates, consumes and extends and pause the janitor
+	TTLJitter            time.Duration   `koanf:"ttl_jitter" validate:"gte=0"`                      // random ± offset on each new secret's expiry (0 = exact)
+	MetricsBuffer        int             `koanf:"metrics_buffer" validate:"gt=0"`                   // metrics event channel capacity
+	MetricsBlockTimeout  time.Duration   `koanf:"metrics_block_timeout" validate:"gte=0"`           // wait for room in a full metrics channel before dropping (0 = drop at once)
 }
 
 // DefaultAppConfig provides the default app configuration values.
@@ -69,7 +154,29 @@ var DefaultAppConfig = Config{
 			Label:    "24h",
 		},
 	},
-	MetricsAddr: "", // disabled by default
+	MetricsAddr:   "",                    // disabled by default
+	Tenants:       []domain.Tenant{},     // multi-tenancy disabled by default
+	BlobFsync:     "always",              // fsync every blob before acknowledging a create
+	NotFoundFloor: 50 * time.Millisecond, // pad consume not-found responses to blunt timing probes
+	TTLOverflow:   "reject",              // out-of-range TTLs are rejected rather than clamped
+	MaxReadsLimit: 1,                     // multi-read secrets disabled by default
+	TLSMinVersion: "1.2",                 // only used when TLSCert/TLSKey are set
+	DBBusyRetries: 3,                     // matches sqlite.DefaultBusyRetries
+	UI:            "enabled",             // serve the web UI alongside the API
+	IntegrityRate: 50,                    // throttle integrity scans to avoid I/O storms
+
+	PassphraseAttempts: 5,        // matches app.DefaultPassphraseAttempts
+	TTLMode:            "preset", // UI offers only the TTLOptions dropdown
+	LogLevel:           "info",
+	LogFormat:          "text",
+	MetricsPersist:     "on",
+	MaxTTLOptions:      32, // keeps the index dropdown short
+	ExistsCacheTTL:     2 * time.Second,
+	JanitorInterval:    time.Minute,
+	StaticMaxAge:       300, // matches httpx.DefaultStaticMaxAge
+	ConsumeFlushBytes:  64 * 1024,
+	TTLSnap:            "off", // keep the client's exact TTL
+	MetricsBuffer:      1024,  // matches metrics.DefaultBufferSize
 }
 
 // defaultLoader loads default configuration values into the provided Koanf instance
@@ -79,6 +186,32 @@ var defaultLoader = func(k *koanf.Koanf) error {
 	return k.Load(structs.Provider(DefaultAppConfig, "koanf"), nil)
 }
 
+// ConfigFileEnv names the environment variable holding an optional config file path.
+const ConfigFileEnv = "GONE_CONFIG_FILE"
+
+// fileLoader loads the optional YAML or TOML file named by GONE_CONFIG_FILE.
+// Keys match the lowercase env names without the prefix (e.g. "data_dir").
+// It is a no-op when the variable is unset and can be mocked in tests.
+var fileLoader = func(k *koanf.Koanf) error {
+	path := strings.TrimSpace(os.Getenv(ConfigFileEnv))
+	if path == "" {
+		return nil
+	}
+	var parser koanf.Parser
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parser = yaml.Parser()
+	case ".toml":
+		parser = toml.Parser()
+	default:
+		return fmt.Errorf("unsupported config file type %q (want .yaml, .yml or .toml)", path)
+	}
+	if err := k.Load(file.Provider(path), parser); err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	return nil
+}
+
 // envLoader is a function that loads environment variables with the prefix "GONE_".
 // It transforms the keys to lowercase and removes the prefix.
 // and can be mocked in tests.
@@ -113,6 +246,16 @@ func validIPPort(fl validator.FieldLevel) bool {
 	return err == nil && portNum > 0 && portNum < 65536
 }
 
+// validBaseURL accepts "auto" or an absolute URL with a scheme and host.
+func validBaseURL(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "auto" {
+		return true
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
 // validDirNotExists checks that the provided value is a directory path, but does not ensure it exists.
 // It disallows empty paths, ".", the root directory, and paths that traverse upwards (contain "..").
 func validDirNotExists(fl validator.FieldLevel) bool {
@@ -139,12 +282,16 @@ var registerValidators = func(v *validator.Validate) error {
 	if err != nil {
 		return err
 	}
+	if err = v.RegisterValidation("base_url", validBaseURL); err != nil {
+		return err
+	}
 	return v.RegisterValidation("custom_path", validDirNotExists)
 }
 
-// Load loads the configuration by applying default values and overriding them
-// with environment variables. It validates the final configuration and returns
-// a Config instance or an error if validation fails.
+// Load loads the configuration by applying default values, then the optional
+// config file, then environment variables (defaults < file < env). It validates
+// the final configuration and returns a Config instance or an error if
+// validation fails.
 func Load() (*Config, error) {
 	k := koanf.New(".")
 
@@ -154,6 +301,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	// Layer the optional config file over the defaults.
+	if err = fileLoader(k); err != nil {
+		return nil, err
+	}
+
 	// Override with environment variables.
 	if err = envLoader(k); err != nil {
 		return nil, err
@@ -169,7 +321,9 @@ func Load() (*Config, error) {
 			TagName:          "koanf",
 			WeaklyTypedInput: true,
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				StringToDuration(),
 				StringToTTLOptions(),
+				StringToTenants(),
 			),
 		},
 	})
@@ -196,14 +350,157 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Explicit bounds take precedence over the values derived from TTLOptions.
+	if cfg.MinTTLOverride > 0 {
+		cfg.MinTTL = cfg.MinTTLOverride
+	}
+	if cfg.MaxTTLOverride > 0 {
+		cfg.MaxTTL = cfg.MaxTTLOverride
+	}
+
 	// Validate the config
 	if err = validate.Struct(&cfg); err != nil {
 		return nil, err
 	}
 
+	if err = validateTTLOptionsList(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err = validateTTLOptionsInRange(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxTTLExternal > 0 && cfg.MaxTTLExternal < cfg.MinTTL {
+		return nil, fmt.Errorf("max_ttl_external %v below min ttl %v", cfg.MaxTTLExternal, cfg.MinTTL)
+	}
+
+	if cfg.TTLJitter >= cfg.MinTTL {
+		return nil, fmt.Errorf("ttl_jitter %v must be below min ttl %v", cfg.TTLJitter, cfg.MinTTL)
+	}
+
+	if cfg.JanitorMinInterval > cfg.JanitorInterval {
+		return nil, fmt.Errorf("janitor_min_interval %v above janitor_interval %v", cfg.JanitorMinInterval, cfg.JanitorInterval)
+	}
+
+	if cfg.BlobOverflowSize > 0 && cfg.BlobOverflowDir == "" {
+		return nil, fmt.Errorf("blob_overflow_threshold %d requires blob_overflow_dir", cfg.BlobOverflowSize)
+	}
+
+	if err = validateOverflowDir(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err = validateTenants(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err = validateTLSFiles(&cfg); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// validateTTLOptionsList caps the number of TTL options at MaxTTLOptions and
+// rejects options with the same duration (e.g. "60m" and "1h"), which would
+// render as redundant dropdown entries.
+func validateTTLOptionsList(cfg *Config) error {
+	if len(cfg.TTLOptions) > cfg.MaxTTLOptions {
+		return fmt.Errorf("%d ttl options exceed max %d", len(cfg.TTLOptions), cfg.MaxTTLOptions)
+	}
+	seen := make(map[time.Duration]string, len(cfg.TTLOptions))
+	for _, opt := range cfg.TTLOptions {
+		if prev, dup := seen[opt.Duration]; dup {
+			return fmt.Errorf("duplicate ttl option %s (same as %s)", opt.Label, prev)
+		}
+		seen[opt.Duration] = opt.Label
+	}
+	return nil
+}
+
+// validateTTLOptionsInRange ensures every offered TTL option lies within the
+// effective [MinTTL, MaxTTL] policy bounds so the UI never offers a value the
+// service would reject.
+func validateTTLOptionsInRange(cfg *Config) error {
+	for _, opt := range cfg.TTLOptions {
+		if opt.Duration < cfg.MinTTL || opt.Duration > cfg.MaxTTL {
+			return fmt.Errorf("ttl option %s outside [%v,%v]", opt.Label, cfg.MinTTL, cfg.MaxTTL)
+		}
+	}
+	return nil
+}
+
+// validateOverflowDir rejects a BlobOverflowDir that overlaps the primary
+// blob directory (<data_dir>/blobs): the same directory, one inside it, or
+// one containing it. Overlapping roots would list each other's blobs as
+// orphans. Paths are compared lexically; symlinks are not resolved.
+func validateOverflowDir(cfg *Config) error {
+	if cfg.BlobOverflowDir == "" {
+		return nil
+	}
+	primary, err := filepath.Abs(filepath.Join(cfg.DataDir, "blobs"))
+	if err != nil {
+		return fmt.Errorf("blob dir: %w", err)
+	}
+	overflow, err := filepath.Abs(cfg.BlobOverflowDir)
+	if err != nil {
+		return fmt.Errorf("blob_overflow_dir: %w", err)
+	}
+	if pathWithin(overflow, primary) || pathWithin(primary, overflow) {
+		return fmt.Errorf("blob_overflow_dir %q overlaps the primary blob dir %q", cfg.BlobOverflowDir, primary)
+	}
+	return nil
+}
+
+// pathWithin reports whether the clean absolute path p is dir or lies below it.
+func pathWithin(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// validateTenants rejects duplicate tenant names and per-tenant limits that
+// exceed the global bounds. Tenants may only tighten the global limits since
+// the HTTP layer enforces MaxBytes before the tenant is consulted.
+func validateTenants(cfg *Config) error {
+	seen := make(map[string]struct{}, len(cfg.Tenants))
+	for _, t := range cfg.Tenants {
+		if _, dup := seen[t.Name]; dup {
+			return fmt.Errorf("duplicate tenant %q", t.Name)
+		}
+		seen[t.Name] = struct{}{}
+		if cfg.BlobShardDepth > 0 && filesystem.IsShardName(t.Name) {
+			return fmt.Errorf("tenant %q collides with a blob shard directory; rename it or set blob_shard_depth=0", t.Name)
+		}
+		if t.MaxBytes > cfg.MaxBytes {
+			return fmt.Errorf("tenant %q max bytes %d exceeds global max %d", t.Name, t.MaxBytes, cfg.MaxBytes)
+		}
+		if t.MaxTTL > 0 && (t.MaxTTL < cfg.MinTTL || t.MaxTTL > cfg.MaxTTL) {
+			return fmt.Errorf("tenant %q max ttl %v outside [%v,%v]", t.Name, t.MaxTTL, cfg.MinTTL, cfg.MaxTTL)
+		}
+	}
+	return nil
+}
+
+// validateTLSFiles ensures the configured certificate and key are readable so
+// a misconfiguration fails at startup rather than on the first handshake.
+func validateTLSFiles(cfg *Config) error {
+	if !cfg.TLSEnabled() {
+		return nil
+	}
+	for _, p := range []string{cfg.TLSCert, cfg.TLSKey} {
+		f, err := os.Open(p) // #nosec G304 operator-supplied path
+		if err != nil {
+			return fmt.Errorf("tls file: %w", err)
+		}
+		_ = f.Close()
+	}
+	return nil
+}
+
+// TLSEnabled reports whether the server should terminate TLS itself.
+func (c *Config) TLSEnabled() bool { return c.TLSCert != "" && c.TLSKey != "" }
+
 // SQLiteDSN returns a fixed hardened SQLite DSN derived from DataDir.
 // WAL mode, foreign keys, busy timeout, and FULL synchronous are enforced.
 func (c *Config) SQLiteDSN() string {
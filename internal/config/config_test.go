@@ -3,6 +3,9 @@ package config
 import (
 	"errors"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +26,85 @@ func cleanEnvVars(t *testing.T) map[string]string {
 		"GONE_INLINE_MAX_BYTES",
 		"GONE_MAX_BYTES",
 		"GONE_TTL_OPTIONS",
+		"GONE_TENANTS",
+		"GONE_BLOB_FSYNC",
+		"GONE_MIN_TTL",
+		"GONE_MAX_TTL",
+		"GONE_OTEL_ENDPOINT",
+		"GONE_NOT_FOUND_FLOOR",
+		"GONE_CONFIG_FILE",
+		"GONE_TTL_OVERFLOW",
+		"GONE_MAX_READS_LIMIT",
+		"GONE_TLS_CERT",
+		"GONE_TLS_KEY",
+		"GONE_TLS_MIN_VERSION",
+		"GONE_STRICT_PERMS",
+		"GONE_DB_BUSY_RETRIES",
+		"GONE_UI",
+		"GONE_UI_REDIRECT_URL",
+		"GONE_OP_TIMEOUT",
+		"GONE_AUDIT_LOG",
+		"GONE_CLOCK_SKEW",
+		"GONE_ALLOWED_CONTENT_TYPES",
+		"GONE_INTEGRITY_SCAN",
+		"GONE_INTEGRITY_RATE",
+		"GONE_INTEGRITY_REPAIR",
+		"GONE_PASSPHRASE_ATTEMPTS",
+		"GONE_TTL_MODE",
+		"GONE_MAX_CONCURRENT_CREATES",
+		"GONE_EXPIRY_BUCKETS",
+		"GONE_ABOUT_FILE",
+		"GONE_REVEAL_HINTS",
+		"GONE_TRUSTED_PROXIES",
+		"GONE_MAX_TTL_EXTERNAL",
+		"GONE_BLOB_SHARD_DEPTH",
+		"GONE_UPLOAD_IDLE_TIMEOUT",
+		"GONE_CONSUME_GRACE",
+		"GONE_LOG_LEVEL",
+		"GONE_LOG_FORMAT",
+		"GONE_METRICS_PERSIST",
+		"GONE_DB_READ_HANDLE",
+		"GONE_MAX_HEADER_BYTES",
+		"GONE_STRICT_HEADERS",
+		"GONE_DAILY_CREATE_QUOTA",
+		"GONE_ALLOW_CLIENT_IDS",
+		"GONE_BLOB_ENCRYPTION_KEY",
+		"GONE_ALLOW_EMPTY",
+		"GONE_WEB_DIR",
+		"GONE_EXPIRY_WEBHOOK",
+		"GONE_MAX_CONNECTIONS",
+		"GONE_MIN_FREE_BYTES",
+		"GONE_CSP_NONCE",
+		"GONE_SUPPORTED_VERSIONS",
+		"GONE_MAX_NONCE_LEN",
+		"GONE_MAX_CONSUME_ATTEMPTS",
+		"GONE_PAD_SIZES",
+		"GONE_MAX_TTL_OPTIONS",
+		"GONE_PUBLIC_BASE_URL",
+		"GONE_EXPIRE_BATCH_SIZE",
+		"GONE_EXISTS_CACHE_SIZE",
+		"GONE_EXISTS_CACHE_TTL",
+		"GONE_BLOB_OVERFLOW_THRESHOLD",
+		"GONE_BLOB_OVERFLOW_DIR",
+		"GONE_ECHO_REQUEST_ID",
+		"GONE_DIRECT_ROUTING",
+		"GONE_JANITOR_INTERVAL",
+		"GONE_JANITOR_MIN_INTERVAL",
+		"GONE_STATIC_MAX_AGE",
+		"GONE_STATIC_IMMUTABLE",
+		"GONE_REJECT_NETWORK_FS",
+		"GONE_READY_VERBOSE",
+		"GONE_CONSUME_FLUSH_BYTES",
+		"GONE_REQUIRE_HTTPS",
+		"GONE_ALLOW_EXTEND",
+		"GONE_INLINE_MEM_BUDGET",
+		"GONE_METRICS_LOG_INTERVAL",
+		"GONE_MIN_BYTES",
+		"GONE_TTL_SNAP",
+		"GONE_READ_ONLY",
+		"GONE_TTL_JITTER",
+		"GONE_METRICS_BUFFER",
+		"GONE_METRICS_BLOCK_TIMEOUT",
 	}
 	for _, v := range vars {
 		val := os.Getenv(v)
@@ -339,3 +421,444 @@ func TestNumericEnvCoercion(t *testing.T) {
 		t.Fatalf("expected InlineMaxBytes 4096 got %d", cfg.InlineMaxBytes)
 	}
 }
+
+// TestLoadEnv covers the single-setting env overrides. Each case starts from a
+// clean environment, sets env, and either compares get(cfg) with want or
+// expects Load to fail (with errContains in the message, when set).
+func TestLoadEnv(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	type kv = map[string]string
+	tests := []struct {
+		name        string
+		env         kv
+		get         func(*Config) any
+		want        any
+		wantErr     bool
+		errContains string
+	}{
+		// tenants
+		{name: "tenants list", env: kv{"GONE_TENANTS": "acme:2048:1h,globex"}, get: func(c *Config) any { return c.Tenants },
+			want: []domain.Tenant{{Name: "acme", MaxBytes: 2048, MaxTTL: time.Hour}, {Name: "globex"}}},
+		{name: "tenants single", env: kv{"GONE_TENANTS": "solo"}, get: func(c *Config) any { return c.Tenants }, want: []domain.Tenant{{Name: "solo"}}},
+		{name: "tenants empty", env: kv{"GONE_TENANTS": ""}, get: func(c *Config) any { return len(c.Tenants) }, want: 0},
+		{name: "tenants bad name", env: kv{"GONE_TENANTS": "Bad Name"}, wantErr: true},
+		{name: "tenants duplicate", env: kv{"GONE_TENANTS": "acme,acme"}, wantErr: true},
+		{name: "tenants above max bytes", env: kv{"GONE_TENANTS": "acme:99999999999"}, wantErr: true},
+		{name: "tenants above max ttl", env: kv{"GONE_TENANTS": "acme::48h"}, wantErr: true},
+		{name: "tenants below min ttl", env: kv{"GONE_TENANTS": "acme::1m"}, wantErr: true},
+		// A shard-shaped name is only a problem once blobs are sharded.
+		{name: "tenants shard name flat", env: kv{"GONE_TENANTS": "ab"}, get: func(c *Config) any { return c.Tenants }, want: []domain.Tenant{{Name: "ab"}}},
+		{name: "tenants shard name sharded", env: kv{"GONE_TENANTS": "ab", "GONE_BLOB_SHARD_DEPTH": "1"}, wantErr: true},
+
+		// blob fsync
+		{name: "blob fsync always", env: kv{"GONE_BLOB_FSYNC": "always"}, get: func(c *Config) any { return c.BlobFsync }, want: "always"},
+		{name: "blob fsync none", env: kv{"GONE_BLOB_FSYNC": "none"}, get: func(c *Config) any { return c.BlobFsync }, want: "none"},
+		{name: "blob fsync dir", env: kv{"GONE_BLOB_FSYNC": "dir"}, get: func(c *Config) any { return c.BlobFsync }, want: "dir"},
+		{name: "blob fsync invalid", env: kv{"GONE_BLOB_FSYNC": "sometimes"}, wantErr: true},
+
+		// ttl options, overflow, mode, snap and jitter
+		{name: "ttl options duplicate", env: kv{"GONE_TTL_OPTIONS": "5m,1h,60m"}, wantErr: true, errContains: "duplicate ttl option"},
+		{name: "ttl options repeated", env: kv{"GONE_TTL_OPTIONS": "5m,5m"}, wantErr: true},
+		{name: "ttl overflow default", get: func(c *Config) any { return c.TTLOverflow }, want: "reject"},
+		{name: "ttl overflow clamp", env: kv{"GONE_TTL_OVERFLOW": "clamp"}, get: func(c *Config) any { return c.TTLOverflow }, want: "clamp"},
+		{name: "ttl overflow invalid", env: kv{"GONE_TTL_OVERFLOW": "truncate"}, wantErr: true},
+		{name: "ttl mode default", get: func(c *Config) any { return c.TTLMode }, want: "preset"},
+		{name: "ttl mode range", env: kv{"GONE_TTL_MODE": "range", "GONE_MIN_TTL": "1m", "GONE_MAX_TTL": "48h"},
+			get: func(c *Config) any { return []any{c.TTLMode, c.MinTTL, c.MaxTTL} }, want: []any{"range", time.Minute, 48 * time.Hour}},
+		{name: "ttl mode invalid", env: kv{"GONE_TTL_MODE": "freeform"}, wantErr: true},
+		{name: "ttl snap default", get: func(c *Config) any { return c.TTLSnap }, want: "off"},
+		{name: "ttl snap nearest", env: kv{"GONE_TTL_SNAP": "nearest"}, get: func(c *Config) any { return c.TTLSnap }, want: "nearest"},
+		{name: "ttl snap up", env: kv{"GONE_TTL_SNAP": "up"}, get: func(c *Config) any { return c.TTLSnap }, want: "up"},
+		{name: "ttl snap invalid", env: kv{"GONE_TTL_SNAP": "down"}, wantErr: true},
+		{name: "ttl jitter default", get: func(c *Config) any { return c.TTLJitter }, want: time.Duration(0)},
+		{name: "ttl jitter", env: kv{"GONE_TTL_JITTER": "30s"}, get: func(c *Config) any { return c.TTLJitter }, want: 30 * time.Second},
+		{name: "ttl jitter negative", env: kv{"GONE_TTL_JITTER": "-1s"}, wantErr: true},
+		{name: "ttl jitter at min ttl", env: kv{"GONE_TTL_JITTER": "5m"}, wantErr: true}, // 5m is the default min TTL
+		{name: "max ttl external default", get: func(c *Config) any { return c.MaxTTLExternal }, want: time.Duration(0)},
+		{name: "max ttl external", env: kv{"GONE_MAX_TTL_EXTERNAL": "1h"}, get: func(c *Config) any { return c.MaxTTLExternal }, want: time.Hour},
+		{name: "max ttl external below min ttl", env: kv{"GONE_MAX_TTL_EXTERNAL": "1m"}, wantErr: true},
+
+		// UI and web
+		{name: "ui default", get: func(c *Config) any { return c.UI }, want: "enabled"},
+		{name: "ui redirect without url", env: kv{"GONE_UI": "redirect"}, wantErr: true},
+		{name: "ui redirect", env: kv{"GONE_UI": "redirect", "GONE_UI_REDIRECT_URL": "https://example.com/"},
+			get: func(c *Config) any { return c.UIRedirectURL }, want: "https://example.com/"},
+		{name: "ui invalid", env: kv{"GONE_UI": "bogus", "GONE_UI_REDIRECT_URL": "https://example.com/"}, wantErr: true},
+		{name: "web dir default", get: func(c *Config) any { return c.WebDir }, want: ""},
+		{name: "web dir", env: kv{"GONE_WEB_DIR": "/srv/gone-ui"}, get: func(c *Config) any { return c.WebDir }, want: "/srv/gone-ui"},
+		{name: "csp nonce default", get: func(c *Config) any { return c.CSPNonce }, want: false},
+		{name: "csp nonce", env: kv{"GONE_CSP_NONCE": "true"}, get: func(c *Config) any { return c.CSPNonce }, want: true},
+		{name: "reveal hints default", get: func(c *Config) any { return c.RevealHints }, want: false},
+		{name: "reveal hints", env: kv{"GONE_REVEAL_HINTS": "true"}, get: func(c *Config) any { return c.RevealHints }, want: true},
+		{name: "static cache default", get: func(c *Config) any { return []any{c.StaticMaxAge, c.StaticImmutable} }, want: []any{300, false}},
+		{name: "static cache", env: kv{"GONE_STATIC_MAX_AGE": "31536000", "GONE_STATIC_IMMUTABLE": "true"},
+			get: func(c *Config) any { return []any{c.StaticMaxAge, c.StaticImmutable} }, want: []any{31536000, true}},
+		{name: "static max age zero", env: kv{"GONE_STATIC_MAX_AGE": "0"}, wantErr: true},
+		{name: "public base url", env: kv{"GONE_PUBLIC_BASE_URL": "https://gone.example.com"},
+			get: func(c *Config) any { return c.PublicBaseURL }, want: "https://gone.example.com"},
+		{name: "public base url auto", env: kv{"GONE_PUBLIC_BASE_URL": "auto"}, get: func(c *Config) any { return c.PublicBaseURL }, want: "auto"},
+		{name: "public base url invalid", env: kv{"GONE_PUBLIC_BASE_URL": "not a url"}, wantErr: true},
+
+		// request handling
+		{name: "allowed content types default", get: func(c *Config) any { return len(c.AllowedContentTypes) }, want: 0},
+		{name: "allowed content types single", env: kv{"GONE_ALLOWED_CONTENT_TYPES": "application/octet-stream"},
+			get: func(c *Config) any { return c.AllowedContentTypes }, want: []string{"application/octet-stream"}},
+		{name: "allowed content types list", env: kv{"GONE_ALLOWED_CONTENT_TYPES": "application/octet-stream, application/x-gone"},
+			get: func(c *Config) any { return c.AllowedContentTypes }, want: []string{"application/octet-stream", "application/x-gone"}},
+		{name: "passphrase attempts default", get: func(c *Config) any { return c.PassphraseAttempts }, want: 5},
+		{name: "passphrase attempts zero", env: kv{"GONE_PASSPHRASE_ATTEMPTS": "0"}, wantErr: true},
+		{name: "trusted proxies default", get: func(c *Config) any { return len(c.TrustedProxies) }, want: 0},
+		{name: "trusted proxies", env: kv{"GONE_TRUSTED_PROXIES": "10.0.0.0/8,fd00::/8"},
+			get: func(c *Config) any { return c.TrustedProxies }, want: []string{"10.0.0.0/8", "fd00::/8"}},
+		{name: "trusted proxies without prefix", env: kv{"GONE_TRUSTED_PROXIES": "10.0.0.1"}, wantErr: true},
+		{name: "upload idle timeout default", get: func(c *Config) any { return c.UploadIdleTimeout }, want: time.Duration(0)},
+		{name: "upload idle timeout", env: kv{"GONE_UPLOAD_IDLE_TIMEOUT": "3s"}, get: func(c *Config) any { return c.UploadIdleTimeout }, want: 3 * time.Second},
+		{name: "consume grace default", get: func(c *Config) any { return c.ConsumeGrace }, want: time.Duration(0)},
+		{name: "consume grace", env: kv{"GONE_CONSUME_GRACE": "30s"}, get: func(c *Config) any { return c.ConsumeGrace }, want: 30 * time.Second},
+		{name: "consume grace negative", env: kv{"GONE_CONSUME_GRACE": "-1s"}, wantErr: true},
+		{name: "header limits", env: kv{"GONE_MAX_HEADER_BYTES": "8192", "GONE_STRICT_HEADERS": "true"},
+			get: func(c *Config) any { return []any{c.MaxHeaderBytes, c.StrictHeaders} }, want: []any{8192, true}},
+		{name: "max header bytes negative", env: kv{"GONE_MAX_HEADER_BYTES": "-1"}, wantErr: true},
+		{name: "daily create quota", env: kv{"GONE_DAILY_CREATE_QUOTA": "500"}, get: func(c *Config) any { return c.DailyCreateQuota }, want: int64(500)},
+		{name: "daily create quota negative", env: kv{"GONE_DAILY_CREATE_QUOTA": "-1"}, wantErr: true},
+		{name: "allow client ids default", get: func(c *Config) any { return c.AllowClientIDs }, want: false},
+		{name: "allow client ids", env: kv{"GONE_ALLOW_CLIENT_IDS": "true"}, get: func(c *Config) any { return c.AllowClientIDs }, want: true},
+		{name: "allow empty default", get: func(c *Config) any { return c.AllowEmpty }, want: false},
+		{name: "allow empty", env: kv{"GONE_ALLOW_EMPTY": "true"}, get: func(c *Config) any { return c.AllowEmpty }, want: true},
+		{name: "max connections default", get: func(c *Config) any { return c.MaxConnections }, want: 0},
+		{name: "max connections", env: kv{"GONE_MAX_CONNECTIONS": "512"}, get: func(c *Config) any { return c.MaxConnections }, want: 512},
+		{name: "max connections negative", env: kv{"GONE_MAX_CONNECTIONS": "-1"}, wantErr: true},
+		{name: "supported versions default", get: func(c *Config) any { return len(c.SupportedVersions) }, want: 0},
+		{name: "supported versions single", env: kv{"GONE_SUPPORTED_VERSIONS": "1"}, get: func(c *Config) any { return c.SupportedVersions }, want: []int{1}},
+		{name: "supported versions list", env: kv{"GONE_SUPPORTED_VERSIONS": "1, 2"}, get: func(c *Config) any { return c.SupportedVersions }, want: []int{1, 2}},
+		{name: "supported versions zero", env: kv{"GONE_SUPPORTED_VERSIONS": "0"}, wantErr: true},
+		{name: "supported versions too large", env: kv{"GONE_SUPPORTED_VERSIONS": "256"}, wantErr: true},
+		{name: "supported versions junk", env: kv{"GONE_SUPPORTED_VERSIONS": "1,x"}, wantErr: true},
+		{name: "max nonce len default", get: func(c *Config) any { return c.MaxNonceLen }, want: 0},
+		{name: "max nonce len", env: kv{"GONE_MAX_NONCE_LEN": "24"}, get: func(c *Config) any { return c.MaxNonceLen }, want: 24},
+		{name: "max nonce len negative", env: kv{"GONE_MAX_NONCE_LEN": "-1"}, wantErr: true},
+		{name: "max consume attempts default", get: func(c *Config) any { return c.MaxConsumeAttempts }, want: 0},
+		{name: "max consume attempts", env: kv{"GONE_MAX_CONSUME_ATTEMPTS": "10"}, get: func(c *Config) any { return c.MaxConsumeAttempts }, want: 10},
+		{name: "max consume attempts negative", env: kv{"GONE_MAX_CONSUME_ATTEMPTS": "-1"}, wantErr: true},
+		{name: "min bytes default", get: func(c *Config) any { return c.MinBytes }, want: int64(0)},
+		{name: "min bytes", env: kv{"GONE_MIN_BYTES": "28"}, get: func(c *Config) any { return c.MinBytes }, want: int64(28)},
+		{name: "min bytes negative", env: kv{"GONE_MIN_BYTES": "-1"}, wantErr: true},
+		{name: "min bytes above max bytes", env: kv{"GONE_MIN_BYTES": "2097152"}, wantErr: true},
+		{name: "consume flush bytes default", get: func(c *Config) any { return c.ConsumeFlushBytes }, want: 64 * 1024},
+		{name: "consume flush bytes off", env: kv{"GONE_CONSUME_FLUSH_BYTES": "0"}, get: func(c *Config) any { return c.ConsumeFlushBytes }, want: 0},
+		{name: "consume flush bytes negative", env: kv{"GONE_CONSUME_FLUSH_BYTES": "-1"}, wantErr: true},
+		{name: "pad sizes default", get: func(c *Config) any { return c.PadSizes }, want: false},
+		{name: "pad sizes", env: kv{"GONE_PAD_SIZES": "true"}, get: func(c *Config) any { return c.PadSizes }, want: true},
+		{name: "echo request id default", get: func(c *Config) any { return c.EchoRequestID }, want: false},
+		{name: "echo request id", env: kv{"GONE_ECHO_REQUEST_ID": "true"}, get: func(c *Config) any { return c.EchoRequestID }, want: true},
+		{name: "direct routing default", get: func(c *Config) any { return c.DirectRouting }, want: false},
+		{name: "direct routing", env: kv{"GONE_DIRECT_ROUTING": "true"}, get: func(c *Config) any { return c.DirectRouting }, want: true},
+		{name: "require https default", get: func(c *Config) any { return c.RequireHTTPS }, want: false},
+		{name: "require https", env: kv{"GONE_REQUIRE_HTTPS": "true"}, get: func(c *Config) any { return c.RequireHTTPS }, want: true},
+		{name: "allow extend default", get: func(c *Config) any { return c.AllowExtend }, want: false},
+		{name: "allow extend", env: kv{"GONE_ALLOW_EXTEND": "true"}, get: func(c *Config) any { return c.AllowExtend }, want: true},
+		{name: "read only default", get: func(c *Config) any { return c.ReadOnly }, want: false},
+		{name: "read only", env: kv{"GONE_READ_ONLY": "true"}, get: func(c *Config) any { return c.ReadOnly }, want: true},
+		{name: "ready verbose default", get: func(c *Config) any { return c.ReadyVerbose }, want: false},
+		{name: "ready verbose", env: kv{"GONE_READY_VERBOSE": "true"}, get: func(c *Config) any { return c.ReadyVerbose }, want: true},
+
+		// storage
+		{name: "blob shard depth default", get: func(c *Config) any { return c.BlobShardDepth }, want: 0},
+		{name: "blob shard depth", env: kv{"GONE_BLOB_SHARD_DEPTH": "2"}, get: func(c *Config) any { return c.BlobShardDepth }, want: 2},
+		{name: "blob shard depth too deep", env: kv{"GONE_BLOB_SHARD_DEPTH": "4"}, wantErr: true},
+		{name: "blob encryption key default", get: func(c *Config) any { return c.BlobEncryptionKey }, want: ""},
+		{name: "blob encryption key", env: kv{"GONE_BLOB_ENCRYPTION_KEY": "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc="},
+			get: func(c *Config) any { return c.BlobEncryptionKey }, want: "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc="},
+		{name: "blob encryption key invalid", env: kv{"GONE_BLOB_ENCRYPTION_KEY": "not base64!"}, wantErr: true},
+		{name: "min free bytes default", get: func(c *Config) any { return c.MinFreeBytes }, want: int64(0)},
+		{name: "min free bytes", env: kv{"GONE_MIN_FREE_BYTES": "1073741824"}, get: func(c *Config) any { return c.MinFreeBytes }, want: int64(1 << 30)},
+		{name: "min free bytes negative", env: kv{"GONE_MIN_FREE_BYTES": "-1"}, wantErr: true},
+		{name: "inline mem budget default", get: func(c *Config) any { return c.InlineMemBudget }, want: int64(0)},
+		{name: "inline mem budget", env: kv{"GONE_INLINE_MEM_BUDGET": "16777216"}, get: func(c *Config) any { return c.InlineMemBudget }, want: int64(16777216)},
+		{name: "inline mem budget negative", env: kv{"GONE_INLINE_MEM_BUDGET": "-1"}, wantErr: true},
+		{name: "db read handle", env: kv{"GONE_DB_READ_HANDLE": "true"}, get: func(c *Config) any { return c.DBReadHandle }, want: true},
+		{name: "reject network fs default", get: func(c *Config) any { return c.RejectNetworkFS }, want: false},
+		{name: "reject network fs", env: kv{"GONE_REJECT_NETWORK_FS": "true"}, get: func(c *Config) any { return c.RejectNetworkFS }, want: true},
+		{name: "exists cache default", get: func(c *Config) any { return []any{c.ExistsCacheSize, c.ExistsCacheTTL} }, want: []any{0, 2 * time.Second}},
+		{name: "exists cache", env: kv{"GONE_EXISTS_CACHE_SIZE": "1000", "GONE_EXISTS_CACHE_TTL": "5s"},
+			get: func(c *Config) any { return []any{c.ExistsCacheSize, c.ExistsCacheTTL} }, want: []any{1000, 5 * time.Second}},
+		{name: "exists cache size negative", env: kv{"GONE_EXISTS_CACHE_SIZE": "-1"}, wantErr: true},
+
+		// background work
+		{name: "integrity scan default", get: func(c *Config) any { return []any{c.IntegrityScan, c.IntegrityRate} }, want: []any{time.Duration(0), 50}},
+		{name: "integrity scan", env: kv{"GONE_INTEGRITY_SCAN": "6h", "GONE_INTEGRITY_RATE": "10", "GONE_INTEGRITY_REPAIR": "true"},
+			get: func(c *Config) any { return []any{c.IntegrityScan, c.IntegrityRate, c.IntegrityRepair} }, want: []any{6 * time.Hour, 10, true}},
+		{name: "integrity rate zero", env: kv{"GONE_INTEGRITY_SCAN": "6h", "GONE_INTEGRITY_RATE": "0"}, wantErr: true},
+		{name: "expiry buckets default", get: func(c *Config) any { return len(c.ExpiryBuckets) }, want: 0},
+		{name: "expiry buckets", env: kv{"GONE_EXPIRY_BUCKETS": "1m,1h,24h"},
+			get: func(c *Config) any { return c.ExpiryBuckets }, want: []time.Duration{time.Minute, time.Hour, 24 * time.Hour}},
+		{name: "expiry buckets negative", env: kv{"GONE_EXPIRY_BUCKETS": "-1m"}, wantErr: true},
+		{name: "expiry webhook", env: kv{"GONE_EXPIRY_WEBHOOK": "https://hooks.example.com/gone"},
+			get: func(c *Config) any { return c.ExpiryWebhook }, want: "https://hooks.example.com/gone"},
+		{name: "expiry webhook invalid", env: kv{"GONE_EXPIRY_WEBHOOK": "not a url"}, wantErr: true},
+		{name: "expire batch size", env: kv{"GONE_EXPIRE_BATCH_SIZE": "500"}, get: func(c *Config) any { return c.ExpireBatchSize }, want: 500},
+		{name: "expire batch size negative", env: kv{"GONE_EXPIRE_BATCH_SIZE": "-1"}, wantErr: true},
+		{name: "janitor intervals default", get: func(c *Config) any { return []any{c.JanitorInterval, c.JanitorMinInterval} }, want: []any{time.Minute, time.Duration(0)}},
+		{name: "janitor intervals", env: kv{"GONE_JANITOR_INTERVAL": "10m", "GONE_JANITOR_MIN_INTERVAL": "5s"},
+			get: func(c *Config) any { return []any{c.JanitorInterval, c.JanitorMinInterval} }, want: []any{10 * time.Minute, 5 * time.Second}},
+		{name: "janitor min above interval", env: kv{"GONE_JANITOR_INTERVAL": "10m", "GONE_JANITOR_MIN_INTERVAL": "11m"}, wantErr: true},
+		{name: "janitor interval zero", env: kv{"GONE_JANITOR_INTERVAL": "0", "GONE_JANITOR_MIN_INTERVAL": "0"}, wantErr: true},
+
+		// logging and metrics
+		{name: "logging", env: kv{"GONE_LOG_LEVEL": "debug", "GONE_LOG_FORMAT": "json"},
+			get: func(c *Config) any { return []any{c.LogLevel, c.LogFormat} }, want: []any{"debug", "json"}},
+		{name: "log level invalid", env: kv{"GONE_LOG_LEVEL": "verbose"}, wantErr: true},
+		{name: "log format invalid", env: kv{"GONE_LOG_FORMAT": "logfmt"}, wantErr: true},
+		{name: "metrics persist off", env: kv{"GONE_METRICS_PERSIST": "off"}, get: func(c *Config) any { return c.MetricsPersist }, want: "off"},
+		{name: "metrics persist invalid", env: kv{"GONE_METRICS_PERSIST": "false"}, wantErr: true},
+		{name: "metrics log interval default", get: func(c *Config) any { return c.MetricsLogInterval }, want: time.Duration(0)},
+		{name: "metrics log interval", env: kv{"GONE_METRICS_LOG_INTERVAL": "5m"}, get: func(c *Config) any { return c.MetricsLogInterval }, want: 5 * time.Minute},
+		{name: "metrics log interval negative", env: kv{"GONE_METRICS_LOG_INTERVAL": "-1s"}, wantErr: true},
+		{name: "metrics buffer default", get: func(c *Config) any { return []any{c.MetricsBuffer, c.MetricsBlockTimeout} }, want: []any{1024, time.Duration(0)}},
+		{name: "metrics buffer", env: kv{"GONE_METRICS_BUFFER": "8192", "GONE_METRICS_BLOCK_TIMEOUT": "5ms"},
+			get: func(c *Config) any { return []any{c.MetricsBuffer, c.MetricsBlockTimeout} }, want: []any{8192, 5 * time.Millisecond}},
+		{name: "metrics buffer zero", env: kv{"GONE_METRICS_BUFFER": "0"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %v", tc.env)
+				}
+				if tc.errContains != "" && !strings.Contains(err.Error(), tc.errContains) {
+					t.Fatalf("error %q does not mention %q", err, tc.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			assert.Equal(t, tc.want, tc.get(cfg))
+		})
+	}
+}
+
+func TestTTLOverridePrecedence(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	t.Setenv("GONE_TTL_OPTIONS", "10m,1h")
+	t.Setenv("GONE_MIN_TTL", "1m")
+	t.Setenv("GONE_MAX_TTL", "2h")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Equal(t, time.Minute, cfg.MinTTL, "explicit min should win over derived")
+	assert.Equal(t, 2*time.Hour, cfg.MaxTTL, "explicit max should win over derived")
+
+	// Only one bound overridden: the other is still derived.
+	t.Setenv("GONE_MIN_TTL", "")
+	t.Setenv("GONE_MAX_TTL", "3h")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Equal(t, 10*time.Minute, cfg.MinTTL)
+	assert.Equal(t, 3*time.Hour, cfg.MaxTTL)
+}
+
+func TestTTLOverrideRejectsOutOfRange(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	tests := []struct {
+		name, opts, min, max string
+	}{
+		{name: "option below floor", opts: "5m,1h", min: "10m", max: ""},
+		{name: "option above ceiling", opts: "5m,1h", min: "", max: "30m"},
+		{name: "floor above ceiling", opts: "5m", min: "2h", max: "1h"},
+		{name: "negative floor", opts: "5m", min: "-1m", max: ""},
+		{name: "unparseable", opts: "5m", min: "soon", max: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GONE_TTL_OPTIONS", tc.opts)
+			t.Setenv("GONE_MIN_TTL", tc.min)
+			t.Setenv("GONE_MAX_TTL", tc.max)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, name, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return p
+}
+
+func TestLoadConfigFileYAMLWithEnvOverride(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	p := writeConfigFile(t, "gone.yaml", `
+addr: "127.0.0.1:9000"
+data_dir: /srv/gone
+max_bytes: 2048
+ttl_options: ["10m", "2h"]
+not_found_floor: 10ms
+blob_fsync: dir
+`)
+	t.Setenv("GONE_CONFIG_FILE", p)
+	t.Setenv("GONE_ADDR", ":7000") // env beats file
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Equal(t, ":7000", cfg.Addr)
+	assert.Equal(t, "/srv/gone", cfg.DataDir)
+	assert.Equal(t, int64(2048), cfg.MaxBytes)
+	assert.Equal(t, DefaultAppConfig.InlineMaxBytes, cfg.InlineMaxBytes, "unset keys keep defaults")
+	assert.Equal(t, 10*time.Minute, cfg.MinTTL)
+	assert.Equal(t, 2*time.Hour, cfg.MaxTTL)
+	assert.Equal(t, 10*time.Millisecond, cfg.NotFoundFloor)
+	assert.Equal(t, "dir", cfg.BlobFsync)
+}
+
+func TestLoadConfigFileTOML(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	p := writeConfigFile(t, "gone.toml", `
+data_dir = "/srv/gone"
+ttl_options = ["1m", "30m"]
+max_ttl = "1h"
+tenants = ["acme:1024"]
+`)
+	t.Setenv("GONE_CONFIG_FILE", p)
+	t.Setenv("GONE_TTL_OPTIONS", "5m,30m") // env beats file
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Equal(t, "/srv/gone", cfg.DataDir)
+	assert.Len(t, cfg.TTLOptions, 2)
+	assert.Equal(t, 5*time.Minute, cfg.MinTTL)
+	assert.Equal(t, time.Hour, cfg.MaxTTL)
+	assert.Equal(t, []domain.Tenant{{Name: "acme", MaxBytes: 1024}}, cfg.Tenants)
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	tests := []struct{ name, path string }{
+		{name: "missing file", path: filepath.Join(t.TempDir(), "absent.yaml")},
+		{name: "unsupported extension", path: writeConfigFile(t, "gone.ini", "addr=:1")},
+		{name: "malformed yaml", path: writeConfigFile(t, "bad.yaml", "addr: [")},
+		{name: "invalid value", path: writeConfigFile(t, "bad.yml", "ttl_options: [\"5d\"]")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GONE_CONFIG_FILE", tc.path)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+	cert := writeConfigFile(t, "cert.pem", "cert")
+	key := writeConfigFile(t, "key.pem", "key")
+
+	t.Setenv("GONE_TLS_CERT", cert)
+	t.Setenv("GONE_TLS_KEY", key)
+	t.Setenv("GONE_TLS_MIN_VERSION", "1.3")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.True(t, cfg.TLSEnabled())
+	assert.Equal(t, "1.3", cfg.TLSMinVersion)
+
+	tests := []struct{ name, cert, key, min string }{
+		{name: "cert without key", cert: cert, key: "", min: "1.2"},
+		{name: "key without cert", cert: "", key: key, min: "1.2"},
+		{name: "unreadable cert", cert: filepath.Join(t.TempDir(), "missing.pem"), key: key, min: "1.2"},
+		{name: "bad min version", cert: cert, key: key, min: "1.1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GONE_TLS_CERT", tc.cert)
+			t.Setenv("GONE_TLS_KEY", tc.key)
+			t.Setenv("GONE_TLS_MIN_VERSION", tc.min)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadMaxTTLOptions(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+
+	opts := make([]string, 33)
+	for i := range opts {
+		opts[i] = strconv.Itoa(i+1) + "m"
+	}
+	t.Setenv("GONE_TTL_OPTIONS", strings.Join(opts, ","))
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for 33 ttl options with default max")
+	}
+	t.Setenv("GONE_MAX_TTL_OPTIONS", "33")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Len(t, cfg.TTLOptions, 33)
+	t.Setenv("GONE_MAX_TTL_OPTIONS", "0")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for GONE_MAX_TTL_OPTIONS=0")
+	}
+}
+
+func TestLoadBlobOverflow(t *testing.T) {
+	orig := cleanEnvVars(t)
+	t.Cleanup(func() { restoreEnvVars(t, orig) })
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Zero(t, cfg.BlobOverflowSize)
+	t.Setenv("GONE_BLOB_OVERFLOW_THRESHOLD", "67108864")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "blob_overflow_dir") {
+		t.Fatalf("expected missing overflow dir error, got %v", err)
+	}
+	t.Setenv("GONE_BLOB_OVERFLOW_DIR", "/mnt/slow/blobs")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	assert.Equal(t, int64(64<<20), cfg.BlobOverflowSize)
+	assert.Equal(t, "/mnt/slow/blobs", cfg.BlobOverflowDir)
+	data := t.TempDir()
+	t.Setenv("GONE_DATA_DIR", data)
+	for _, dir := range []string{filepath.Join(data, "blobs"), filepath.Join(data, "blobs", "big"), data, filepath.Join(data, "blobs", "..", "blobs")} {
+		t.Setenv("GONE_BLOB_OVERFLOW_DIR", dir)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "overlaps") {
+			t.Fatalf("overflow dir %q: expected overlap error, got %v", dir, err)
+		}
+	}
+	t.Setenv("GONE_BLOB_OVERFLOW_DIR", filepath.Join(data, "blobs-big"))
+	if _, err := Load(); err != nil {
+		t.Fatalf("sibling overflow dir: %v", err)
+	}
+	t.Setenv("GONE_BLOB_OVERFLOW_THRESHOLD", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for negative GONE_BLOB_OVERFLOW_THRESHOLD")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/haukened/gone/internal/domain"
 	"github.com/mitchellh/mapstructure"
@@ -26,3 +27,18 @@ func StringToTTLOptions() mapstructure.DecodeHookFunc {
 		return opt, nil
 	}
 }
+
+// StringToDuration is a DecodeHookFunc that converts a string to time.Duration.
+// An empty string decodes to zero so an unset-but-present variable means "not configured".
+func StringToDuration() mapstructure.DecodeHookFunc {
+	return func(f, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		s := strings.TrimSpace(data.(string))
+		if s == "" {
+			return time.Duration(0), nil
+		}
+		return time.ParseDuration(s)
+	}
+}
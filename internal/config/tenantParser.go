@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/mitchellh/mapstructure"
+)
+
+// StringToTenants is a DecodeHookFunc that converts a string to domain.Tenant.
+// An empty string for the whole list decodes to no tenants (multi-tenancy off).
+func StringToTenants() mapstructure.DecodeHookFunc {
+	return func(f, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() == reflect.String && t == reflect.TypeOf([]domain.Tenant{}) && strings.TrimSpace(data.(string)) == "" {
+			return []domain.Tenant{}, nil
+		}
+		if f.Kind() != reflect.String || t != reflect.TypeOf(domain.Tenant{}) {
+			return data, nil
+		}
+		s := strings.TrimSpace(data.(string))
+		if s == "" {
+			return nil, fmt.Errorf("empty tenant string")
+		}
+		return domain.NewTenant(s)
+	}
+}
@@ -1,10 +1,19 @@
 // Package domain errors.go contains sentinel errors
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Sentinel domain-level errors reused by higher layers.
 var (
 	ErrInvalidID  = errors.New("invalid secret id")
 	ErrTTLInvalid = errors.New("ttl invalid")
 )
+
+// Reasons ValidateID rejects an ID; both wrap ErrInvalidID.
+var (
+	ErrIDLength  = fmt.Errorf("%w: must be 32 characters", ErrInvalidID)
+	ErrIDCharset = fmt.Errorf("%w: must be lowercase hex", ErrInvalidID)
+)
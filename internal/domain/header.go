@@ -0,0 +1,47 @@
+// Package domain header.go contains the rules for secret metadata that is
+// echoed back in consume response headers.
+package domain
+
+// MaxParamsLen bounds the opaque client parameters stored with a secret; they
+// live in the index row and are only meant for small per-secret settings such
+// as AEAD parameters or salts.
+const MaxParamsLen = 1024
+
+// MaxContentTypeLen bounds the plaintext content type hint; RFC 6838 caps
+// type and subtype at 127 characters each.
+const MaxContentTypeLen = 255
+
+// ValidParams reports whether p may be stored and reflected as X-Gone-Params:
+// bounded and base64url only.
+func ValidParams(p string) bool {
+	return len(p) <= MaxParamsLen && IsBase64URL(p)
+}
+
+// ValidContentType reports whether ct may be stored and reflected as
+// X-Gone-Content-Type: bounded and free of control characters.
+func ValidContentType(ct string) bool {
+	return len(ct) <= MaxContentTypeLen && !HasControl(ct)
+}
+
+// HasControl reports whether s contains an ASCII control character (including
+// CR and LF), which must never be reflected into a response header.
+func HasControl(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBase64URL reports whether s uses only the base64url alphabet, with
+// optional '=' padding.
+func IsBase64URL(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '=') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidParams verifies the charset and length rules for echoed params.
+func TestValidParams(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{name: "empty", in: "", want: true},
+		{name: "base64url", in: "eyJrZGYiOiJhcmdvbjJpZCJ9", want: true},
+		{name: "padded", in: "YQ==", want: true},
+		{name: "at limit", in: strings.Repeat("a", MaxParamsLen), want: true},
+		{name: "too long", in: strings.Repeat("a", MaxParamsLen+1), want: false},
+		{name: "crlf", in: "a\r\nX-Evil: 1", want: false},
+		{name: "std alphabet", in: "a+b/c", want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ValidParams(tc.in); got != tc.want {
+				t.Fatalf("ValidParams(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestValidContentType verifies control characters and overlong values are
+// rejected.
+func TestValidContentType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{name: "plain", in: "text/plain; charset=utf-8", want: true},
+		{name: "too long", in: "text/" + strings.Repeat("a", MaxContentTypeLen), want: false},
+		{name: "newline", in: "text/plain\nX-Evil: 1", want: false},
+		{name: "del", in: "text/plain\x7f", want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ValidContentType(tc.in); got != tc.want {
+				t.Fatalf("ValidContentType(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
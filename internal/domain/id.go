@@ -22,18 +22,37 @@ func NewID() (SecretID, error) {
 	return SecretID(dst), nil
 }
 
-// ParseID validates s and returns it as a SecretID. It enforces:
-// - non-empty
-// - length == 32
-// - only lowercase [0-9a-f]
-// Returns ErrInvalidID on failure.
+// CryptoIDs generates IDs with NewID. It is the production IDGenerator.
+type CryptoIDs struct{}
+
+// NewID implements app.IDGenerator.
+func (CryptoIDs) NewID() (SecretID, error) { return NewID() }
+
+// idLen is the length of an encoded SecretID.
+const idLen = 32
+
+// ParseID validates s with ValidateID and returns it as a SecretID.
 func ParseID(s string) (SecretID, error) {
-	if !isValidID(s) {
-		return "", ErrInvalidID
+	if err := ValidateID(s); err != nil {
+		return "", err
 	}
 	return SecretID(s), nil
 }
 
+// ValidateID is the single definition of a well-formed secret ID: exactly 32
+// characters, all lowercase [0-9a-f]. It returns ErrIDLength or ErrIDCharset,
+// both of which match ErrInvalidID under errors.Is. Because the charset has
+// no '.', '/' or '\', a valid ID is always a safe single path element.
+func ValidateID(s string) error {
+	if len(s) != idLen {
+		return ErrIDLength
+	}
+	if !isLowerHex(s) {
+		return ErrIDCharset
+	}
+	return nil
+}
+
 // String returns the string form of the SecretID.
 func (id SecretID) String() string { return string(id) }
 
@@ -41,10 +60,10 @@ func (id SecretID) String() string { return string(id) }
 func (id SecretID) Valid() bool { return isValidID(string(id)) }
 
 // isValidID performs validation without allocating errors.
-func isValidID(s string) bool {
-	if len(s) != 32 {
-		return false
-	}
+func isValidID(s string) bool { return len(s) == idLen && isLowerHex(s) }
+
+// isLowerHex reports whether s consists only of lowercase hex digits.
+func isLowerHex(s string) bool {
 	for i := 0; i < len(s); i++ {
 		c := s[i]
 		switch {
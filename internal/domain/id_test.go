@@ -1,6 +1,9 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestParseID(t *testing.T) {
 	valid, err := ParseID("0123456789abcdef0123456789abcdef")
@@ -19,6 +22,48 @@ func TestParseID(t *testing.T) {
 	}
 }
 
+// TestValidateID covers every malformed ID the blob store rejects and checks
+// that anything containing "." or a path separator fails on charset.
+func TestValidateID(t *testing.T) {
+	if err := ValidateID("0123456789abcdef0123456789abcdef"); err != nil {
+		t.Fatalf("valid id rejected: %v", err)
+	}
+	cases := []struct {
+		id   string
+		want error
+	}{
+		{"", ErrIDLength},
+		{"../escape", ErrIDLength},
+		{"a/b", ErrIDLength},
+		{"..", ErrIDLength},
+		{"..hidden", ErrIDLength},
+		{"trick..", ErrIDLength},
+		{"slash/", ErrIDLength},
+		{`back\\slash`, ErrIDLength},
+		{"short", ErrIDLength},
+		{"1234567890abcdef1234567890abcde", ErrIDLength},
+		{"1234567890abcdef1234567890abcdef0", ErrIDLength},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz", ErrIDCharset},
+		{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", ErrIDCharset},
+		{"0123456789abcdef0123456789abcde.", ErrIDCharset},
+		{"0123456789abcdef0123456789abc/..", ErrIDCharset},
+		{`0123456789abcdef0123456789abcd\\`, ErrIDCharset},
+		{"0123456789abcdef0123456789abcd..", ErrIDCharset},
+	}
+	for _, c := range cases {
+		err := ValidateID(c.id)
+		if !errors.Is(err, c.want) {
+			t.Errorf("ValidateID(%q) = %v, want %v", c.id, err, c.want)
+		}
+		if !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ValidateID(%q) = %v, does not match ErrInvalidID", c.id, err)
+		}
+		if _, pErr := ParseID(c.id); !errors.Is(pErr, c.want) {
+			t.Errorf("ParseID(%q) = %v, want %v", c.id, pErr, c.want)
+		}
+	}
+}
+
 func TestNewID(t *testing.T) {
 	const n = 10
 	unique := make(map[string]struct{}, n)
@@ -0,0 +1,78 @@
+// Package domain tenant.go contains functions to parse and validate tenant specifications.
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTenantNameLen bounds tenant names so they remain safe as URL path
+// segments and blob subdirectory names.
+const maxTenantNameLen = 32
+
+// Tenant describes an isolated secret namespace addressed via the /t/{name}/
+// path prefix. Zero limits inherit the global configuration.
+type Tenant struct {
+	Name     string
+	MaxBytes int64         // optional per-tenant size cap (0 = global MaxBytes)
+	MaxTTL   time.Duration // optional per-tenant TTL cap (0 = global MaxTTL)
+}
+
+// NewTenant parses a tenant specification of the form "name[:max_bytes[:max_ttl]]".
+// Examples: "acme", "acme:65536", "acme:65536:1h", "acme::30m".
+// It returns an error if the name is invalid or a limit cannot be parsed.
+func NewTenant(spec string) (Tenant, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Tenant{}, errors.New("empty tenant spec")
+	}
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return Tenant{}, fmt.Errorf("too many fields in tenant spec %q", spec)
+	}
+	t := Tenant{Name: parts[0]}
+	if !ValidTenantName(t.Name) {
+		return Tenant{}, fmt.Errorf("invalid tenant name %q", t.Name)
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || n <= 0 {
+			return Tenant{}, fmt.Errorf("invalid tenant max bytes %q", parts[1])
+		}
+		t.MaxBytes = n
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		opt, err := NewTTLOption(parts[2])
+		if err != nil {
+			return Tenant{}, fmt.Errorf("invalid tenant max ttl: %w", err)
+		}
+		if opt.Duration <= 0 {
+			return Tenant{}, fmt.Errorf("invalid tenant max ttl %q", parts[2])
+		}
+		t.MaxTTL = opt.Duration
+	}
+	return t, nil
+}
+
+// ValidTenantName reports whether name is 1-32 characters of lowercase
+// [a-z0-9-] and does not start with a hyphen. These rules make the name safe
+// for use as a path segment and directory name without further escaping.
+func ValidTenantName(name string) bool {
+	if len(name) == 0 || len(name) > maxTenantNameLen || name[0] == '-' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9':
+		case c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
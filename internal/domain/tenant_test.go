@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewTenantValid verifies tenant specs with and without optional limits.
+func TestNewTenantValid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		spec string
+		want Tenant
+	}{
+		{name: "name only", spec: "acme", want: Tenant{Name: "acme"}},
+		{name: "with bytes", spec: "acme:2048", want: Tenant{Name: "acme", MaxBytes: 2048}},
+		{name: "with bytes and ttl", spec: "acme:2048:1h", want: Tenant{Name: "acme", MaxBytes: 2048, MaxTTL: time.Hour}},
+		{name: "ttl only", spec: "team-1::30m", want: Tenant{Name: "team-1", MaxTTL: 30 * time.Minute}},
+		{name: "trim whitespace", spec: " acme ", want: Tenant{Name: "acme"}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewTenant(tc.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %+v want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestNewTenantInvalid verifies malformed specs are rejected.
+func TestNewTenantInvalid(t *testing.T) {
+	t.Parallel()
+	cases := []string{"", "  ", "Acme", "-acme", "a/b", "../x", "acme:abc", "acme:0", "acme:1:1d", "acme:1:1h:x", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}
+	for _, c := range cases {
+		if _, err := NewTenant(c); err == nil {
+			t.Errorf("expected error for %q", c)
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package httpx
 
 import (
+	"bytes"
 	"html/template"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
 )
 
 // AboutRenderer abstracts template execution for the about page.
@@ -19,13 +26,42 @@ func (tr AboutTemplateRenderer) Execute(w http.ResponseWriter, data any) error {
 	return tr.T.Execute(w, data)
 }
 
+// AboutView is the data passed to the about template. When Content is set it
+// replaces the built-in explanation inside the normal page chrome.
+type AboutView struct {
+	Content template.HTML
+}
+
+// LoadAboutContent reads an operator-supplied about page from path. Files
+// ending in .md or .markdown are converted from Markdown; everything else is
+// treated as an HTML fragment. The result is always sanitized (no scripts,
+// styles, event handlers or iframes) so the page still satisfies the strict
+// CSP. An empty path returns "" and nil.
+func LoadAboutContent(path string) (template.HTML, error) {
+	if path == "" {
+		return "", nil
+	}
+	src, err := os.ReadFile(path) // #nosec G304 operator-supplied path
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		var buf bytes.Buffer
+		if err := goldmark.Convert(src, &buf); err != nil {
+			return "", err
+		}
+		src = buf.Bytes()
+	}
+	return template.HTML(bluemonday.UGCPolicy().SanitizeBytes(src)), nil // #nosec G203 sanitized by bluemonday
+}
+
 // handleAbout renders the informational /about page.
 // It returns 503 if the template is unavailable, and 404 if an unexpected path is routed here.
-// The about page is static today (no dynamic data) but a placeholder struct is retained
-// for potential future metrics or configuration exposure.
+// Operator content from GONE_ABOUT_FILE, if any, is passed through AboutView.
 func (h *Handler) handleAbout(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/about" { // exact match only
-		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		h.renderErrorPage(w, r, http.StatusNotFound, "Not Found", "The page you requested was not found.")
 		return
 	}
 	if h.AboutTmpl == nil {
@@ -33,5 +69,5 @@ func (h *Handler) handleAbout(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("about unavailable"))
 		return
 	}
-	renderTemplate(w, h.AboutTmpl, struct{}{})
+	renderTemplate(w, h.AboutTmpl, AboutView{Content: h.AboutContent})
 }
@@ -5,6 +5,8 @@ import (
 	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -64,3 +66,65 @@ func TestHandleAbout_AllBranches(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadAboutContent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return p
+	}
+
+	if got, err := LoadAboutContent(""); err != nil || got != "" {
+		t.Fatalf("empty path: %q %v", got, err)
+	}
+	if _, err := LoadAboutContent(filepath.Join(dir, "missing.md")); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+
+	md := write("about.md", "# Acme Secrets\n\nAsk [IT](https://it.example.com).\n\n<script>alert(1)</script>\n")
+	got, err := LoadAboutContent(md)
+	if err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	for _, want := range []string{"<h1>Acme Secrets</h1>", `href="https://it.example.com"`} {
+		if !strings.Contains(string(got), want) {
+			t.Fatalf("markdown missing %q: %s", want, got)
+		}
+	}
+	if strings.Contains(string(got), "<script") {
+		t.Fatalf("markdown kept script: %s", got)
+	}
+
+	html := write("about.html", `<h2 onclick="x()">Policy</h2><p style="color:red">Be nice</p><script>alert(1)</script><iframe src="https://evil"></iframe><a href="javascript:alert(1)">bad</a>`)
+	got, err = LoadAboutContent(html)
+	if err != nil {
+		t.Fatalf("html: %v", err)
+	}
+	for _, banned := range []string{"<script", "onclick", "style=", "<iframe", "javascript:"} {
+		if strings.Contains(string(got), banned) {
+			t.Fatalf("html kept %q: %s", banned, got)
+		}
+	}
+	if !strings.Contains(string(got), "Policy") || !strings.Contains(string(got), "Be nice") {
+		t.Fatalf("html lost content: %s", got)
+	}
+}
+
+func TestHandleAboutContentOverride(t *testing.T) {
+	tmpl := template.Must(template.New("about").Parse(`{{ if .Content }}{{ .Content }}{{ else }}built-in{{ end }}`))
+	h := &Handler{AboutTmpl: AboutTemplateRenderer{T: tmpl}, AboutContent: "<p>Acme</p>"}
+	rr := httptest.NewRecorder()
+	h.handleAbout(rr, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if body := rr.Body.String(); body != "<p>Acme</p>" {
+		t.Fatalf("override not rendered: %q", body)
+	}
+	h.AboutContent = ""
+	rr = httptest.NewRecorder()
+	h.handleAbout(rr, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if body := rr.Body.String(); body != "built-in" {
+		t.Fatalf("fallback not rendered: %q", body)
+	}
+}
@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// ClientIP returns the address of the client that made r. Forwarding headers
+// are honoured only when the immediate peer (RemoteAddr) falls inside one of
+// the trusted proxy prefixes; otherwise they are ignored so clients cannot
+// spoof their address. X-Forwarded-For is walked right to left and the first
+// hop outside the trusted set is the client; X-Real-IP is used only when
+// X-Forwarded-For is absent. A RemoteAddr that is not an IP (e.g. a unix
+// socket) is returned unchanged.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer, ok := parseRemoteAddr(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !inPrefixes(peer, trusted) {
+		return peer.String()
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		client := peer
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// Anything left of a malformed hop cannot be trusted.
+				break
+			}
+			client = ip.Unmap()
+			if !inPrefixes(client, trusted) {
+				break
+			}
+		}
+		return client.String()
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap().String()
+	}
+	return peer.String()
+}
+
+// RequestScheme returns the scheme ("http" or "https") the client used to
+// reach r. A TLS connection is always https. Behind a TLS-terminating proxy
+// the first X-Forwarded-Proto value is honoured, under the same rule as
+// ClientIP: only when the immediate peer falls inside trusted.
+func RequestScheme(r *http.Request, trusted []netip.Prefix) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if peer, ok := parseRemoteAddr(r.RemoteAddr); ok && inPrefixes(peer, trusted) {
+		first, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+		if p := strings.ToLower(strings.TrimSpace(first)); p == "https" || p == "http" {
+			return p
+		}
+	}
+	return "http"
+}
+
+// ClientIPMiddleware resolves the client address once per request with
+// ClientIP and stores it in the context, where request logs and audit events
+// pick it up.
+func ClientIPMiddleware(trusted []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := app.WithClientIP(r.Context(), ClientIP(r, trusted))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetClientIP returns the address stored by ClientIPMiddleware. The second
+// return reports whether a value was present.
+func GetClientIP(ctx context.Context) (string, bool) {
+	ip := app.ClientIPFromContext(ctx)
+	return ip, ip != ""
+}
+
+// secretLogger returns the logger for a secret request, tagged with its
+// correlation ID and client address.
+func secretLogger(ctx context.Context) *slog.Logger {
+	cid, _ := GetCorrelationID(ctx)
+	ip, _ := GetClientIP(ctx)
+	return slog.With("domain", "secret", "cid", cid, "client_ip", ip)
+}
+
+// ParseTrustedProxies converts CIDR strings such as "10.0.0.0/8" into
+// prefixes for ClientIP.
+func ParseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(cidrs))
+	for _, c := range cidrs {
+		p, err := netip.ParsePrefix(strings.TrimSpace(c))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+// parseRemoteAddr extracts the IP from an http.Request RemoteAddr, which is
+// normally host:port but may be a bare address in tests.
+func parseRemoteAddr(addr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	if ip, err := netip.ParseAddr(addr); err == nil {
+		return ip.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func inPrefixes(ip netip.Addr, prefixes []netip.Prefix) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		realIP string
+		want   string
+	}{
+		{"direct client", "203.0.113.7:5555", nil, "", "203.0.113.7"},
+		{"untrusted peer spoofs XFF", "203.0.113.7:5555", []string{"1.2.3.4"}, "", "203.0.113.7"},
+		{"untrusted peer spoofs X-Real-IP", "203.0.113.7:5555", nil, "1.2.3.4", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:443", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"trusted chain", "10.0.0.2:443", []string{"198.51.100.9, 10.1.1.1"}, "", "198.51.100.9"},
+		{"client prepends spoofed hop", "10.0.0.2:443", []string{"1.2.3.4, 198.51.100.9"}, "", "198.51.100.9"},
+		{"multiple XFF headers", "10.0.0.2:443", []string{"1.2.3.4", "198.51.100.9"}, "", "198.51.100.9"},
+		{"all hops trusted", "10.0.0.2:443", []string{"10.3.3.3, 10.1.1.1"}, "", "10.3.3.3"},
+		{"malformed hop", "10.0.0.2:443", []string{"1.2.3.4, junk, 10.1.1.1"}, "", "10.1.1.1"},
+		{"X-Real-IP from trusted proxy", "10.0.0.2:443", nil, "198.51.100.9", "198.51.100.9"},
+		{"XFF wins over X-Real-IP", "10.0.0.2:443", []string{"198.51.100.9"}, "1.2.3.4", "198.51.100.9"},
+		{"trusted proxy without headers", "10.0.0.2:443", nil, "", "10.0.0.2"},
+		{"ipv6 trusted proxy", "[fd00::1]:443", []string{"2001:db8::5"}, "", "2001:db8::5"},
+		{"ipv4-mapped peer", "[::ffff:10.0.0.2]:443", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"non-IP remote", "@", []string{"1.2.3.4"}, "", "@"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			for _, v := range tc.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+			if got := ClientIP(r, trusted); got != tc.want {
+				t.Fatalf("got %q want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClientIPNoTrustedProxies(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.2:443"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	if got := ClientIP(r, nil); got != "10.0.0.2" {
+		t.Fatalf("headers must be ignored without trusted proxies, got %q", got)
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	var got string
+	h := ClientIPMiddleware(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetClientIP(r.Context())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.2:443"
+	r.Header.Set("X-Forwarded-For", "198.51.100.9")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "198.51.100.9" {
+		t.Fatalf("context client ip %q", got)
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.1"}); err == nil {
+		t.Fatalf("expected error for address without prefix length")
+	}
+}
+
+func TestRequestScheme(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		proto  string
+		tls    bool
+		want   string
+	}{
+		{"plain", "203.0.113.7:5555", "", false, "http"},
+		{"direct tls", "203.0.113.7:5555", "", true, "https"},
+		{"trusted proxy https", "10.0.0.2:443", "https", false, "https"},
+		{"trusted proxy upper case", "10.0.0.2:443", "HTTPS", false, "https"},
+		{"trusted proxy list", "10.0.0.2:443", "https, http", false, "https"},
+		{"trusted proxy http", "10.0.0.2:443", "http", false, "http"},
+		{"trusted proxy junk", "10.0.0.2:443", "gopher", false, "http"},
+		{"untrusted peer spoofs https", "203.0.113.7:5555", "https", false, "http"},
+		{"tls wins over forwarded http", "10.0.0.2:443", "http", true, "https"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			if tc.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			if tc.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if got := RequestScheme(r, trusted); got != tc.want {
+				t.Fatalf("RequestScheme = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
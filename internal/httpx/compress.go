@@ -0,0 +1,159 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the smallest body worth compressing; below it the gzip
+// framing outweighs the savings.
+const gzipMinSize = 1024
+
+// GzipMiddleware compresses responses from next with gzip when the client
+// accepts it. Only text-like content types of at least gzipMinSize bytes are
+// compressed; images, fonts, range responses and bodies that already carry a
+// Content-Encoding pass through untouched. It is meant for the UI and static
+// routes only: consume responses stream opaque ciphertext and must never be
+// compressed (BREACH), so it is not mounted on the API. Responses marked with
+// MarkStreaming are never buffered or compressed.
+func GzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) || r.Header.Get("Range") != "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, flag := withStreamFlag(r.Context())
+		gw := &gzipWriter{ResponseWriter: w, stream: flag}
+		defer gw.Close()
+		next.ServeHTTP(gw, r.WithContext(ctx))
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header admits gzip with a
+// non-zero q-value, either by name or via "*".
+func acceptsGzip(header string) bool {
+	qGzip, qAny := -1.0, -1.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "x-gzip":
+			qGzip = q
+		case "*":
+			qAny = q
+		}
+	}
+	if qGzip >= 0 {
+		return qGzip > 0
+	}
+	return qAny > 0
+}
+
+// compressible reports whether responses of media type ct benefit from gzip.
+// Already-compressed formats (images, fonts, archives) are excluded.
+func compressible(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mt, "text/") {
+		return true
+	}
+	switch mt {
+	case "application/javascript", "application/json", "application/xml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// gzipWriter buffers the first gzipMinSize bytes of a response to decide
+// whether to compress it, then either streams through a gzip.Writer or
+// passes the body through unchanged. Once the handler marks the response for
+// streaming, the next write commits it uncompressed without buffering.
+type gzipWriter struct {
+	http.ResponseWriter
+	stream  *streamFlag
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if g.status == 0 && !g.decided {
+		g.status = code
+	}
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.decided {
+		g.buf = append(g.buf, b...)
+		if len(g.buf) < gzipMinSize && !g.stream.streaming() {
+			return len(b), nil
+		}
+		if err := g.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// decide commits the status line and headers, choosing compression from the
+// buffered prefix, and flushes the buffer.
+func (g *gzipWriter) decide() error {
+	g.decided = true
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	h := g.Header()
+	if h.Get("Content-Type") == "" && len(g.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+	if len(g.buf) >= gzipMinSize && !g.stream.streaming() && h.Get("Content-Encoding") == "" && g.status != http.StatusPartialContent && compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	buf := g.buf
+	g.buf = nil
+	if g.gz != nil {
+		_, err := g.gz.Write(buf)
+		return err
+	}
+	_, err := g.ResponseWriter.Write(buf)
+	return err
+}
+
+// Close flushes any buffered response and terminates the gzip stream. A
+// handler that wrote nothing leaves the response untouched.
+func (g *gzipWriter) Close() error {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			return nil
+		}
+		if err := g.decide(); err != nil {
+			return err
+		}
+	}
+	if g.gz != nil {
+		return g.gz.Close()
+	}
+	return nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (g *gzipWriter) Unwrap() http.ResponseWriter { return g.ResponseWriter }
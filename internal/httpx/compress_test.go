@@ -0,0 +1,88 @@
+package httpx_test
+
+import (
+	"compress/gzip"
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// bigConsumeService returns a large, highly compressible payload on consume.
+type bigConsumeService struct{ noopService }
+
+func (bigConsumeService) Consume(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+	body := strings.Repeat("a", 4096)
+	return app.Meta{Version: 1, NonceB64u: "n"}, io.NopCloser(strings.NewReader(body)), int64(len(body)), nil
+}
+
+func TestGzipUIRoutes(t *testing.T) {
+	dir := t.TempDir()
+	js := strings.Repeat("console.log('gone');\n", 100)
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte(js), 0o600); err != nil {
+		t.Fatalf("write asset: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), []byte(strings.Repeat("\x89PNG", 512)), 0o600); err != nil {
+		t.Fatalf("write asset: %v", err)
+	}
+	page := `<html>` + strings.Repeat("<p>gone</p>", 200) + `</html>`
+	h := httpx.New(bigConsumeService{}, 100, nil)
+	h.IndexTmpl = httpx.TemplateRenderer{T: template.Must(template.New("i").Parse(page))}
+	h.AboutTmpl = httpx.AboutTemplateRenderer{T: template.Must(template.New("a").Parse(`<html>small</html>`))}
+	h.Assets = http.FS(os.DirFS(dir))
+	router := h.Router()
+
+	tests := []struct {
+		name     string
+		path     string
+		encoding string
+		wantGzip bool
+		wantBody string
+	}{
+		{name: "html page", path: "/", encoding: "gzip, br", wantGzip: true, wantBody: page},
+		{name: "javascript asset", path: "/static/app.js", encoding: "gzip", wantGzip: true, wantBody: js},
+		{name: "client without gzip", path: "/", encoding: "br", wantBody: page},
+		{name: "gzip refused", path: "/", encoding: "gzip;q=0, *", wantBody: page},
+		{name: "small body", path: "/about", encoding: "gzip", wantBody: "<html>small</html>"},
+		{name: "already compressed type", path: "/static/logo.png", encoding: "gzip"},
+		{name: "consume stream", path: "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", encoding: "gzip", wantBody: strings.Repeat("a", 4096)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept-Encoding", tc.encoding)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d", rr.Code)
+			}
+			gotGzip := rr.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tc.wantGzip {
+				t.Fatalf("Content-Encoding=%q, want gzip=%v", rr.Header().Get("Content-Encoding"), tc.wantGzip)
+			}
+			body := rr.Body.String()
+			if gotGzip {
+				if rr.Header().Get("Content-Length") != "" {
+					t.Fatal("Content-Length must be dropped when compressing")
+				}
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("gzip reader: %v", err)
+				}
+				b, _ := io.ReadAll(zr)
+				body = string(b)
+			}
+			if tc.wantBody != "" && body != tc.wantBody {
+				t.Fatalf("body mismatch (%d bytes)", len(body))
+			}
+		})
+	}
+}
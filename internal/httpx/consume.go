@@ -1,11 +1,20 @@
 package httpx
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
 )
 
 // handleConsumeSecret implements GET /api/secret/{id}.
@@ -21,31 +30,137 @@ func (h *Handler) handleConsumeSecret(w http.ResponseWriter, r *http.Request) {
 		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
 		return
 	}
-	// create a correlation ID for logging if none exists yet
-	// and use it for this request's logging context.
-	cid, _ := GetCorrelationID(r.Context())
-	clog := slog.With("domain", "secret", "cid", cid)
+	clog := secretLogger(r.Context())
 	clog.Info("consume", "action", "start")
 	// extract ID from path
 	id := r.URL.Path[len(prefix):]
 	// attempt to consume the secret
-	meta, rc, size, err := h.Service.Consume(r.Context(), id)
+	start := time.Now()
+	ctx := r.Context()
+	if p := r.Header.Get("X-Gone-Passphrase"); p != "" {
+		ctx = app.WithPassphrase(ctx, p)
+	}
+	// The deadline bounds locating and claiming the secret only. Once Consume
+	// succeeds the secret is gone from the index, so the returned reader does
+	// not depend on opCtx and the body is streamed even if the deadline passes
+	// (bounded by the server write timeout) rather than dropped undelivered.
+	opCtx, cancel := h.opContext(ctx)
+	defer cancel()
+	meta, rc, size, err := h.Service.Consume(opCtx, id)
+	if err != nil && h.writeTimeoutIfExpired(opCtx, w) {
+		clog.Error("consume", "action", "error", "kind", "timeout")
+		return
+	}
+	if isConsumeNotFound(err) {
+		// Malformed, never-existed, expired and already-consumed IDs are
+		// indistinguishable to the caller: same status, body and latency floor.
+		waitUntil(r.Context(), start.Add(h.NotFoundFloor))
+		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		clog.Info("consume", "action", "not_found")
+		return
+	}
 	if err != nil {
 		h.mapServiceError(r.Context(), w, err)
 		clog.Error("consume", "action", "error")
 		return
 	}
 	defer rc.Close()
-	// success: write headers and copy body
+	w.Header().Add("Vary", "Accept")
+	if wantsConsumeJSON(r) {
+		// The envelope is buffered, so only bodies within MaxBody qualify.
+		// The secret is already claimed: anything larger is still delivered,
+		// raw, rather than lost.
+		if h.MaxBody <= 0 || size <= h.MaxBody {
+			h.writeConsumeJSON(r.Context(), w, meta, rc, size, clog)
+			return
+		}
+		clog.Warn("consume", "action", "json_fallback", "size", size)
+	}
+	// success: write headers and copy body; blobs can be large, so keep any
+	// wrapping middleware from buffering them
+	MarkStreaming(r.Context())
 	w.Header().Set("X-Gone-Version", fmt.Sprintf("%d", meta.Version))
 	w.Header().Set("X-Gone-Nonce", meta.NonceB64u)
+	// Rows restored from an archive skipped create validation, so recheck.
+	if meta.Params != "" && domain.ValidParams(meta.Params) {
+		w.Header().Set("X-Gone-Params", meta.Params)
+	}
+	if meta.ContentType != "" && domain.ValidContentType(meta.ContentType) {
+		w.Header().Set("X-Gone-Content-Type", meta.ContentType)
+	}
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("X-Gone-Size", strconv.FormatInt(size, 10))
+	if wantsDownload(r) {
+		// The server never sees plaintext filenames, so the name is generic.
+		w.Header().Set("Content-Disposition", `attachment; filename="secret.bin"`)
+	}
 	w.WriteHeader(http.StatusOK)
-	_, err = io.CopyN(w, rc, size)
+	if h.FlushChunk > 0 {
+		_, err = flushingCopy(w, rc, size, h.FlushChunk)
+	} else {
+		_, err = io.CopyN(w, rc, size)
+	}
 	if err != nil {
 		clog.Error("consume", "action", "error")
 		return
 	}
 	clog.Info("consume", "action", "success")
 }
+
+// consumeEnvelope is the JSON form of a consumed secret; Ciphertext is
+// unpadded base64url, like the nonce.
+type consumeEnvelope struct {
+	Version     uint8  `json:"version"`
+	Nonce       string `json:"nonce"`
+	Ciphertext  string `json:"ciphertext"`
+	Params      string `json:"params,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+}
+
+// writeConsumeJSON buffers the ciphertext from rc and writes it as a
+// consumeEnvelope.
+func (h *Handler) writeConsumeJSON(ctx context.Context, w http.ResponseWriter, meta app.Meta, rc io.Reader, size int64, clog *slog.Logger) {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(rc, buf); err != nil {
+		h.writeError(ctx, w, http.StatusInternalServerError, "internal")
+		clog.Error("consume", "action", "error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(consumeEnvelope{Version: meta.Version, Nonce: meta.NonceB64u, Ciphertext: base64.RawURLEncoding.EncodeToString(buf), Params: meta.Params, ContentType: meta.ContentType, RequestID: h.requestID(ctx)})
+	clog.Info("consume", "action", "success", "format", "json")
+}
+
+// wantsDownload reports whether the client asked for the secret as an
+// attachment via ?download=1 or the X-Gone-Download header.
+func wantsDownload(r *http.Request) bool {
+	for _, v := range []string{r.URL.Query().Get("download"), r.Header.Get("X-Gone-Download")} {
+		if on, err := strconv.ParseBool(v); err == nil && on {
+			return true
+		}
+	}
+	return false
+}
+
+// isConsumeNotFound reports whether err means the secret cannot be returned
+// for a reason the caller must not be able to tell apart.
+func isConsumeNotFound(err error) bool {
+	return errors.Is(err, domain.ErrInvalidID) || errors.Is(err, app.ErrNotFound) || errors.Is(err, os.ErrNotExist)
+}
+
+// waitUntil blocks until deadline or until ctx is done, whichever is first.
+func waitUntil(ctx context.Context, deadline time.Time) {
+	d := time.Until(deadline)
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,88 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestConsumeSelfDestruct drives wrong passphrases at an external secret
+// through the real service and store until it self-destructs, then checks the
+// right passphrase only gets 404 and the blob is gone.
+func TestConsumeSelfDestruct(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "destroy.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	blobDir := t.TempDir()
+	bs, err := filesystem.New(blobDir)
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 8), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, PassphraseAttempts: 10, MaxConsumeAttempts: 3}
+	h := httpx.New(svc, 1024, nil).Router()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	body := "external payload"
+	req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader(body))
+	req.Header.Set("Content-Length", "16")
+	req.Header.Set("X-Gone-Version", "1")
+	req.Header.Set("X-Gone-Nonce", "n1")
+	req.Header.Set("X-Gone-TTL", "30m")
+	req.Header.Set("X-Gone-Passphrase-Hash", string(hash))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status=%d body=%s", w.Code, w.Body)
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	consume := func(passphrase string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/"+resp.ID, nil)
+		if passphrase != "" {
+			req.Header.Set("X-Gone-Passphrase", passphrase)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := consume(""); code != http.StatusForbidden {
+		t.Fatalf("probe without passphrase: status=%d", code)
+	}
+	for i := 0; i < 3; i++ {
+		if code := consume("nope"); code != http.StatusForbidden {
+			t.Fatalf("wrong passphrase %d: status=%d", i, code)
+		}
+	}
+	if code := consume("hunter2"); code != http.StatusNotFound {
+		t.Fatalf("after destruction: status=%d", code)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, resp.ID+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob survived destruction: %v", err)
+	}
+}
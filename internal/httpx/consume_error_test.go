@@ -20,7 +20,7 @@ type consumeService struct { // reuse custom service for consume errors
 	internal bool
 }
 
-func (c consumeService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+func (c consumeService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
 	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Now().Add(time.Hour), nil
 }
 func (c consumeService) Consume(_ context.Context, id string) (app.Meta, io.ReadCloser, int64, error) {
@@ -47,7 +47,7 @@ func TestConsumeEndpointErrors(t *testing.T) {
 		{name: "get without id -> 405", method: http.MethodGet, path: "/api/secret", expectCode: http.StatusMethodNotAllowed, expectContains: "method not allowed"},
 		// GET /api/secret/ matches consume handler but missing id -> 404 not found
 		{name: "missing id -> 404", method: http.MethodGet, path: "/api/secret/", expectCode: http.StatusNotFound, expectContains: "not found"},
-		{name: "invalid id", method: http.MethodGet, path: "/api/secret/bad-id-!!!", service: consumeService{invalid: true}, expectCode: http.StatusBadRequest, expectContains: "invalid id"},
+		{name: "invalid id", method: http.MethodGet, path: "/api/secret/bad-id-!!!", service: consumeService{invalid: true}, expectCode: http.StatusNotFound, expectContains: "not found"},
 		{name: "internal error", method: http.MethodGet, path: "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", service: consumeService{internal: true}, expectCode: http.StatusInternalServerError, expectContains: "internal"},
 	}
 	for _, tc := range tests {
@@ -69,3 +69,41 @@ func TestConsumeEndpointErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestConsumeDownloadDisposition(t *testing.T) {
+	const id = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   string
+	}{
+		{name: "default inline", target: "/api/secret/" + id, want: ""},
+		{name: "query param", target: "/api/secret/" + id + "?download=1", want: `attachment; filename="secret.bin"`},
+		{name: "header", target: "/api/secret/" + id, header: "true", want: `attachment; filename="secret.bin"`},
+		{name: "disabled", target: "/api/secret/" + id + "?download=0", want: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := httpx.New(consumeService{}, 1024, nil)
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.header != "" {
+				req.Header.Set("X-Gone-Download", tc.header)
+			}
+			rr := httptest.NewRecorder()
+			h.Router().ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Disposition"); got != tc.want {
+				t.Fatalf("disposition got %q want %q", got, tc.want)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/octet-stream" {
+				t.Fatalf("content type got %q", got)
+			}
+			if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+				t.Fatalf("cache control got %q", got)
+			}
+		})
+	}
+}
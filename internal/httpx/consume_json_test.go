@@ -0,0 +1,119 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// onceService hands out payload on the first Consume and ErrNotFound after.
+func onceService(payload []byte, calls *int) mockService {
+	return mockService{consumeFn: func(_ context.Context, _ string) (app.Meta, io.ReadCloser, int64, error) {
+		*calls++
+		if *calls > 1 {
+			return app.Meta{}, nil, 0, app.ErrNotFound
+		}
+		return app.Meta{Version: 1, NonceB64u: "bm9uY2U"}, io.NopCloser(bytes.NewReader(payload)), int64(len(payload)), nil
+	}}
+}
+
+func consumeWithAccept(h http.Handler, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// TestConsumeAcceptJSON checks Accept: application/json yields the base64url
+// envelope and that the secret is still consumed exactly once.
+func TestConsumeAcceptJSON(t *testing.T) {
+	payload := []byte{0xfb, 0xff, 0x00, 'c', 'i', 'p', 'h', 'e', 'r'}
+	var calls int
+	h := httpx.New(onceService(payload, &calls), 1024, nil).Router()
+
+	w := consumeWithAccept(h, "application/json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content-type %q", ct)
+	}
+	if w.Header().Get("X-Gone-Nonce") != "" {
+		t.Fatalf("raw headers set on JSON response")
+	}
+	var env struct {
+		Version    int    `json:"version"`
+		Nonce      string `json:"nonce"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		t.Fatalf("ciphertext not base64url: %v", err)
+	}
+	if env.Version != 1 || env.Nonce != "bm9uY2U" || !bytes.Equal(got, payload) {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+
+	if w := consumeWithAccept(h, "application/json"); w.Code != http.StatusNotFound {
+		t.Fatalf("second consume: status=%d", w.Code)
+	}
+	if calls != 2 {
+		t.Fatalf("Consume called %d times", calls)
+	}
+}
+
+// TestConsumeAcceptRaw checks every other Accept keeps the raw body.
+func TestConsumeAcceptRaw(t *testing.T) {
+	payload := []byte("cipher")
+	for _, accept := range []string{"", "*/*", "application/octet-stream", "application/json, application/octet-stream", "application/json;q=0.5, */*", "application/json, text/plain, */*"} {
+		t.Run(accept, func(t *testing.T) {
+			var calls int
+			h := httpx.New(onceService(payload, &calls), 1024, nil).Router()
+			w := consumeWithAccept(h, accept)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status=%d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/octet-stream" {
+				t.Fatalf("content-type %q", ct)
+			}
+			if w.Header().Get("X-Gone-Version") != "1" || w.Header().Get("X-Gone-Nonce") != "bm9uY2U" {
+				t.Fatalf("missing secret headers: %v", w.Header())
+			}
+			if !bytes.Equal(w.Body.Bytes(), payload) {
+				t.Fatalf("body %q", w.Body.Bytes())
+			}
+			if w := consumeWithAccept(h, accept); w.Code != http.StatusNotFound || calls != 2 {
+				t.Fatalf("second consume: status=%d calls=%d", w.Code, calls)
+			}
+		})
+	}
+}
+
+// TestConsumeJSONOverMaxBody checks a secret larger than MaxBody is not
+// buffered for JSON but still delivered raw instead of being lost.
+func TestConsumeJSONOverMaxBody(t *testing.T) {
+	payload := []byte("0123456789")
+	var calls int
+	h := httpx.New(onceService(payload, &calls), 4, nil).Router()
+	w := consumeWithAccept(h, "application/json")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("status=%d content-type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !bytes.Equal(w.Body.Bytes(), payload) {
+		t.Fatalf("body %q", w.Body.Bytes())
+	}
+}
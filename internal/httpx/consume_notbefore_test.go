@@ -0,0 +1,91 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestConsumeNotBefore drives X-Gone-Not-Before through the real service and
+// store: reads before the embargo get 425 and leave the secret intact, the
+// first read after it succeeds, and bad or unreachable embargoes are refused.
+func TestConsumeNotBefore(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "nb.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 8), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour}
+	h := httpx.New(svc, 1024, nil).Router()
+	create := func(body, notBefore string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader(body))
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "30m")
+		req.Header.Set("X-Gone-Not-Before", notBefore)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	consume := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/"+id, nil))
+		return w
+	}
+
+	start := clk.now.Add(10 * time.Minute).Format(time.RFC3339)
+	for _, body := range []string{"tiny", "external payload"} {
+		w := create(body, start)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%q: create status=%d body=%s", body, w.Code, w.Body)
+		}
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if w := consume(resp.ID); w.Code != http.StatusTooEarly || !strings.Contains(w.Body.String(), "too early") {
+				t.Fatalf("%q: early read status=%d body=%s", body, w.Code, w.Body)
+			}
+		}
+		clk.now = clk.now.Add(10 * time.Minute)
+		if w := consume(resp.ID); w.Code != http.StatusOK || w.Body.String() != body {
+			t.Fatalf("%q: read after start status=%d body=%q", body, w.Code, w.Body)
+		}
+		if w := consume(resp.ID); w.Code != http.StatusNotFound {
+			t.Fatalf("%q: second read status=%d", body, w.Code)
+		}
+		clk.now = clk.now.Add(-10 * time.Minute)
+	}
+
+	if w := create("x", "tomorrow"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid not before") {
+		t.Fatalf("malformed: status=%d body=%s", w.Code, w.Body)
+	}
+	if w := create("x", clk.now.Add(30*time.Minute).Format(time.RFC3339)); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid not before") {
+		t.Fatalf("at expiry: status=%d body=%s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,99 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+// TestConsumeNotFoundIndistinguishable verifies that malformed, expired and
+// never-existed IDs produce byte-identical responses (ignoring the per-request
+// correlation ID) and that the not-found floor is honored.
+func TestConsumeNotFoundIndistinguishable(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "nf.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Now().UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 4), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour}
+	// Create one inline and one external secret, then move past their expiry.
+	var expiredIDs []string
+	for _, data := range []string{"abc", "external-payload"} {
+		id, _, err := svc.CreateSecret(context.Background(), strings.NewReader(data), int64(len(data)), 1, "n", time.Minute, app.CreateOptions{})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		expiredIDs = append(expiredIDs, id.String())
+	}
+	clk.now = clk.now.Add(2 * time.Minute)
+
+	h := httpx.New(svc, 1024, nil)
+	h.NotFoundFloor = 20 * time.Millisecond
+	router := h.Router()
+	do := func(id string) (*httptest.ResponseRecorder, time.Duration) {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/"+id, nil)
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		return w, time.Since(start)
+	}
+	cases := map[string]string{
+		"invalid id":     "not-a-valid-id",
+		"expired inline": expiredIDs[0],
+		"expired blob":   expiredIDs[1],
+		"never existed":  "0123456789abcdef0123456789abcdef",
+	}
+	var ref *httptest.ResponseRecorder
+	for name, id := range cases {
+		w, elapsed := do(id)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: status %d", name, w.Code)
+		}
+		if elapsed < h.NotFoundFloor {
+			t.Fatalf("%s: responded in %v, below floor", name, elapsed)
+		}
+		w.Header().Del(httpx.CorrelationIDHeader)
+		if ref == nil {
+			ref = w
+			continue
+		}
+		if !bytes.Equal(w.Body.Bytes(), ref.Body.Bytes()) {
+			t.Fatalf("%s: body %q differs from %q", name, w.Body.String(), ref.Body.String())
+		}
+		if len(w.Header()) != len(ref.Header()) {
+			t.Fatalf("%s: headers %v differ from %v", name, w.Header(), ref.Header())
+		}
+		for k := range ref.Header() {
+			if w.Header().Get(k) != ref.Header().Get(k) {
+				t.Fatalf("%s: header %s differs", name, k)
+			}
+		}
+	}
+}
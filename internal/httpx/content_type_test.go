@@ -0,0 +1,102 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestSecretContentTypeRoundTrip checks X-Gone-Content-Type is canonicalized
+// on create, echoed on raw and JSON consume, absent when never set, and that
+// values which could inject header lines are rejected.
+func TestSecretContentTypeRoundTrip(t *testing.T) {
+	var stored string
+	m := mockService{
+		optsFn: func(o app.CreateOptions) { stored = o.ContentType },
+		createFn: func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+			_, _ = io.ReadAll(ct)
+			return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+		},
+		consumeFn: func(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+			return app.Meta{Version: 1, NonceB64u: "n1", ContentType: stored}, io.NopCloser(strings.NewReader("cipher")), 6, nil
+		},
+	}
+	h := httpx.New(m, 1024, nil).Router()
+	create := func(ct string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher")))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		if ct != "" {
+			req.Header["X-Gone-Content-Type"] = []string{ct}
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	consume := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for sent, want := range map[string]string{
+		"application/pdf":              "application/pdf",
+		"Text/Plain; Charset=UTF-8":    "text/plain; charset=UTF-8",
+		`text/plain; name="a b;c.txt"`: `text/plain; name="a b;c.txt"`,
+		"":                             "",
+	} {
+		if w := create(sent); w.Code != http.StatusCreated {
+			t.Fatalf("create %q: status=%d", sent, w.Code)
+		}
+		if stored != want {
+			t.Fatalf("service got content type %q, want %q", stored, want)
+		}
+		w := consume("application/octet-stream")
+		if got, ok := w.Header()["X-Gone-Content-Type"]; want == "" && ok || want != "" && (len(got) != 1 || got[0] != want) {
+			t.Fatalf("raw consume X-Gone-Content-Type = %v, want %q", got, want)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/octet-stream" {
+			t.Fatalf("raw consume Content-Type = %q", ct)
+		}
+		var env map[string]any
+		if err := json.Unmarshal(consume("application/json").Body.Bytes(), &env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got, ok := env["content_type"]; want == "" && ok || want != "" && got != want {
+			t.Fatalf("json consume content_type = %v, want %q", got, want)
+		}
+	}
+
+	for _, bad := range []string{
+		"text/html\r\nSet-Cookie: a=b",
+		"text/plain\nX-Injected: 1",
+		"text/plain; x=\"\r\n\"",
+		"not a type",
+		"text/" + strings.Repeat("a", 256),
+	} {
+		if w := create(bad); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid content type") {
+			t.Fatalf("content type %.20q: status=%d body=%s", bad, w.Code, w.Body.String())
+		}
+	}
+
+	// A stored value that never went through create (e.g. an archive
+	// import) is still not reflected if it carries line breaks.
+	stored = "text/plain\r\nX-Injected: 1"
+	if got := consume("application/octet-stream").Header().Values("X-Gone-Content-Type"); len(got) != 0 {
+		t.Fatalf("unsafe stored content type echoed: %q", got)
+	}
+}
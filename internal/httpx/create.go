@@ -3,10 +3,16 @@ package httpx
 import (
 	"encoding/json"
 	"errors"
-	"log/slog"
+	"io"
+	"mime"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
 )
 
 // requestMeta holds parsed and validated request metadata needed to create a secret.
@@ -15,8 +21,27 @@ type requestMeta struct {
 	version       uint8
 	nonce         string
 	ttl           time.Duration
+	maxReads      int
+	passHash      string    // optional X-Gone-Passphrase-Hash (validated by the service)
+	label         string    // optional X-Gone-Label, echoed in the response and never stored
+	clientID      string    // optional X-Gone-ID (validated and gated by the service)
+	notBefore     time.Time // optional X-Gone-Not-Before embargo (zero = none)
+	params        string    // optional X-Gone-Params, stored opaquely and returned on consume
+	contentType   string    // optional X-Gone-Content-Type plaintext MIME hint, canonicalized
 }
 
+// maxLabelLen bounds X-Gone-Label; it is a small client-encrypted note, not a
+// payload channel.
+const maxLabelLen = 512
+
+// Header length bounds. maxNonceLen matches the published OpenAPI schema and
+// applies unless Handler.MaxNonceLen is set; maxTTLLen is enforced by
+// Handler.StrictHeaders.
+const (
+	maxNonceLen = 64
+	maxTTLLen   = 32
+)
+
 // parseAndValidateCreate extracts and validates headers and method/path invariants.
 // It returns a populated requestMeta or an error describing the failure. Returned
 // errors are mapped to HTTP status codes by classifyCreateError.
@@ -30,13 +55,32 @@ func checkMethodPath(r *http.Request) error {
 	return nil
 }
 
+// checkContentType enforces the optional Content-Type allowlist. Parameters
+// (e.g. charset) are ignored and media types compare case-insensitively; an
+// empty allowlist accepts anything.
+func (h *Handler) checkContentType(r *http.Request) error {
+	if len(h.AllowedContentTypes) == 0 {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return errors.New("unsupported media type")
+	}
+	for _, allowed := range h.AllowedContentTypes {
+		if strings.EqualFold(mt, allowed) {
+			return nil
+		}
+	}
+	return errors.New("unsupported media type")
+}
+
 func (h *Handler) parseContentLength(r *http.Request) (int64, error) {
 	clHeader := r.Header.Get("Content-Length")
 	if clHeader == "" {
 		return 0, errors.New("content length required")
 	}
 	cl, err := strconv.ParseInt(clHeader, 10, 64)
-	if err != nil || cl <= 0 {
+	if err != nil || cl < 0 || (cl == 0 && !h.AllowEmpty) {
 		return 0, errors.New("invalid content length")
 	}
 	if h.MaxBody > 0 && cl > h.MaxBody {
@@ -45,13 +89,26 @@ func (h *Handler) parseContentLength(r *http.Request) (int64, error) {
 	return cl, nil
 }
 
-func parseSecretHeaders(r *http.Request) (uint8, string, time.Duration, error) {
+// parseSecretHeaders reads the required X-Gone-* headers. The nonce is echoed
+// back on consume, so it must always be base64url of at most maxNonce
+// (default maxNonceLen) chars. In strict mode the TTL must also be at most
+// maxTTLLen chars.
+func parseSecretHeaders(r *http.Request, strict bool, maxNonce int) (uint8, string, time.Duration, error) {
 	versionStr := r.Header.Get("X-Gone-Version")
 	nonce := r.Header.Get("X-Gone-Nonce")
 	ttlStr := r.Header.Get("X-Gone-TTL")
 	if versionStr == "" || nonce == "" || ttlStr == "" {
 		return 0, "", 0, errors.New("missing required headers")
 	}
+	if maxNonce <= 0 {
+		maxNonce = maxNonceLen
+	}
+	if !domain.IsBase64URL(nonce) || len(nonce) > maxNonce {
+		return 0, "", 0, errors.New("invalid nonce")
+	}
+	if strict && len(ttlStr) > maxTTLLen {
+		return 0, "", 0, errors.New("invalid ttl")
+	}
 	v64, err := strconv.ParseUint(versionStr, 10, 8)
 	if err != nil {
 		return 0, "", 0, errors.New("invalid version")
@@ -63,19 +120,118 @@ func parseSecretHeaders(r *http.Request) (uint8, string, time.Duration, error) {
 	return uint8(v64), nonce, ttl, nil
 }
 
+// parseMaxReads reads the optional X-Gone-Max-Reads header. Absent means a
+// classic one-time secret; the configured upper bound is enforced by the service.
+func parseMaxReads(r *http.Request) (int, error) {
+	v := r.Header.Get("X-Gone-Max-Reads")
+	if v == "" {
+		return 1, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 16)
+	if err != nil || n == 0 {
+		return 0, errors.New("invalid max reads")
+	}
+	return int(n), nil
+}
+
+// parseNotBefore reads the optional X-Gone-Not-Before header, an RFC 3339
+// time before which the secret cannot be consumed. Ordering against the
+// expiry is checked by the service.
+func parseNotBefore(r *http.Request) (time.Time, error) {
+	v := r.Header.Get("X-Gone-Not-Before")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("invalid not before")
+	}
+	return t.UTC(), nil
+}
+
+// parseLabel reads the optional X-Gone-Label header: an opaque, client-side
+// encrypted note the creator keeps next to the link. The server only checks
+// that it is base64url (padding allowed) and bounded, then echoes it back.
+func parseLabel(r *http.Request) (string, error) {
+	v := r.Header.Get("X-Gone-Label")
+	if len(v) > maxLabelLen || !domain.IsBase64URL(v) {
+		return "", errors.New("invalid label")
+	}
+	return v, nil
+}
+
+// parseParams reads the optional X-Gone-Params header: opaque client
+// parameters (typically base64url-encoded JSON) persisted with the secret and
+// returned on consume. Like the label it must be base64url and bounded, so it
+// is always safe to reflect into a response header.
+func parseParams(r *http.Request) (string, error) {
+	v := r.Header.Get("X-Gone-Params")
+	if !domain.ValidParams(v) {
+		return "", errors.New("invalid params")
+	}
+	return v, nil
+}
+
+// parseContentTypeHint reads the optional X-Gone-Content-Type header: a MIME
+// type for the plaintext that the recipient's client uses to pick a viewer.
+// It must parse as a media type and is stored in the canonical form produced
+// by mime.FormatMediaType, which only emits tokens and quoted strings, so the
+// value echoed on consume cannot smuggle extra header lines.
+func parseContentTypeHint(r *http.Request) (string, error) {
+	v := r.Header.Get("X-Gone-Content-Type")
+	if v == "" {
+		return "", nil
+	}
+	if !domain.ValidContentType(v) {
+		return "", errors.New("invalid content type")
+	}
+	mt, params, err := mime.ParseMediaType(v)
+	if err != nil {
+		return "", errors.New("invalid content type")
+	}
+	ct := mime.FormatMediaType(mt, params)
+	if ct == "" || len(ct) > domain.MaxContentTypeLen {
+		return "", errors.New("invalid content type")
+	}
+	return ct, nil
+}
+
 func (h *Handler) parseAndValidateCreate(r *http.Request) (*requestMeta, error) {
 	if err := checkMethodPath(r); err != nil {
 		return nil, err
 	}
+	if err := h.checkContentType(r); err != nil {
+		return nil, err
+	}
 	cl, err := h.parseContentLength(r)
 	if err != nil {
 		return nil, err
 	}
-	ver, nonce, ttl, err := parseSecretHeaders(r)
+	ver, nonce, ttl, err := parseSecretHeaders(r, h.StrictHeaders, h.MaxNonceLen)
+	if err != nil {
+		return nil, err
+	}
+	reads, err := parseMaxReads(r)
+	if err != nil {
+		return nil, err
+	}
+	label, err := parseLabel(r)
+	if err != nil {
+		return nil, err
+	}
+	notBefore, err := parseNotBefore(r)
+	if err != nil {
+		return nil, err
+	}
+	params, err := parseParams(r)
 	if err != nil {
 		return nil, err
 	}
-	return &requestMeta{contentLength: cl, version: ver, nonce: nonce, ttl: ttl}, nil
+	contentType, err := parseContentTypeHint(r)
+	if err != nil {
+		return nil, err
+	}
+	return &requestMeta{contentLength: cl, version: ver, nonce: nonce, ttl: ttl, maxReads: reads, passHash: r.Header.Get("X-Gone-Passphrase-Hash"), label: label, clientID: r.Header.Get("X-Gone-ID"), notBefore: notBefore, params: params, contentType: contentType}, nil
 }
 
 // classifyCreateError maps validation error messages to HTTP status codes and
@@ -90,9 +246,16 @@ func classifyCreateError(err error) (int, string) {
 		"content length required":  http.StatusLengthRequired,
 		"invalid content length":   http.StatusBadRequest,
 		"size exceeded":            http.StatusRequestEntityTooLarge,
+		"unsupported media type":   http.StatusUnsupportedMediaType,
 		"missing required headers": http.StatusBadRequest,
 		"invalid version":          http.StatusBadRequest,
 		"invalid ttl":              http.StatusBadRequest,
+		"invalid nonce":            http.StatusBadRequest,
+		"invalid max reads":        http.StatusBadRequest,
+		"invalid label":            http.StatusBadRequest,
+		"invalid not before":       http.StatusBadRequest,
+		"invalid params":           http.StatusBadRequest,
+		"invalid content type":     http.StatusBadRequest,
 	}
 	msg := err.Error()
 	if code, ok := lookup[msg]; ok {
@@ -101,12 +264,10 @@ func classifyCreateError(err error) (int, string) {
 	return http.StatusBadRequest, "bad request"
 }
 
-// handleCreateSecret implements POST /api/secret.
 // handleCreateSecret implements POST /api/secret.
 // It delegates validation to parseAndValidateCreate to reduce complexity.
 func (h *Handler) handleCreateSecret(w http.ResponseWriter, r *http.Request) {
-	cid, _ := GetCorrelationID(r.Context())
-	clog := slog.With("domain", "secret", "cid", cid)
+	clog := secretLogger(r.Context())
 	clog.Info("create", "action", "start")
 	meta, err := h.parseAndValidateCreate(r)
 	if err != nil {
@@ -117,8 +278,25 @@ func (h *Handler) handleCreateSecret(w http.ResponseWriter, r *http.Request) {
 	}
 	body := http.MaxBytesReader(w, r.Body, meta.contentLength)
 	defer body.Close()
-	id, expires, svcErr := h.Service.CreateSecret(r.Context(), body, meta.contentLength, meta.version, meta.nonce, meta.ttl)
+	ctx := app.WithMaxReads(r.Context(), meta.maxReads)
+	if meta.passHash != "" {
+		ctx = app.WithPassphraseHash(ctx, meta.passHash)
+	}
+	if meta.clientID != "" {
+		ctx = app.WithClientID(ctx, meta.clientID)
+	}
+	if !meta.notBefore.IsZero() {
+		ctx = app.WithNotBefore(ctx, meta.notBefore)
+	}
+	ctx, cancel := h.opContext(ctx)
+	defer cancel()
+	payload := &declaredBody{r: h.idleBody(w, body), remaining: meta.contentLength}
+	id, expires, svcErr := h.Service.CreateSecret(ctx, ctxReader{ctx: ctx, r: payload}, meta.contentLength, meta.version, meta.nonce, meta.ttl, app.CreateOptions{Params: meta.params, ContentType: meta.contentType})
 	if svcErr != nil {
+		if h.writeTimeoutIfExpired(ctx, w) {
+			clog.Error("create", "action", "error", "kind", "timeout")
+			return
+		}
 		h.mapServiceError(r.Context(), w, svcErr)
 		clog.Error("create", "action", "error", "kind", "service")
 		return
@@ -127,7 +305,100 @@ func (h *Handler) handleCreateSecret(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(struct {
 		ID        string    `json:"id"`
+		URL       string    `json:"url,omitempty"`
 		ExpiresAt time.Time `json:"expires_at"`
-	}{ID: id.String(), ExpiresAt: expires})
+		Label     string    `json:"label,omitempty"`
+		RequestID string    `json:"request_id,omitempty"`
+	}{ID: id.String(), URL: h.shareURL(r, id), ExpiresAt: expires, Label: meta.label, RequestID: h.requestID(r.Context())})
 	clog.Info("create", "action", "success", "ttl_secs", int(meta.ttl.Seconds()))
 }
+
+// PublicBaseAuto as Handler.PublicBaseURL builds share URLs from the request
+// Host and RequestScheme instead of a fixed origin.
+const PublicBaseAuto = "auto"
+
+// shareURL returns the web UI link for id under PublicBaseURL, or "" when no
+// base URL is set or the secret belongs to a tenant (the UI serves only the
+// root namespace). The decryption key lives in the fragment the client adds;
+// the server never has it.
+func (h *Handler) shareURL(r *http.Request, id domain.SecretID) string {
+	base := h.PublicBaseURL
+	if base == "" || app.TenantFromContext(r.Context()) != "" {
+		return ""
+	}
+	if base == PublicBaseAuto {
+		base = RequestScheme(r, h.TrustedProxies) + "://" + r.Host
+	}
+	return strings.TrimRight(base, "/") + "/secret/" + id.String()
+}
+
+// declaredBody yields exactly the declared number of bytes from a
+// MaxBytesReader. Stores read exactly size bytes and would silently ignore
+// anything after them, so on the final read it probes for excess data and, if
+// present, fails with the reader's *http.MaxBytesError instead of returning
+// the last chunk. Returning no data with the error keeps io.ReadFull and
+// io.CopyN from swallowing it.
+type declaredBody struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (d *declaredBody) Read(p []byte) (int, error) {
+	if d.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.r.Read(p)
+	d.remaining -= int64(n)
+	if d.remaining > 0 || err != nil {
+		return n, err
+	}
+	var probe [1]byte
+	if _, perr := d.r.Read(probe[:]); perr != nil && !errors.Is(perr, io.EOF) {
+		return 0, perr
+	}
+	return n, nil
+}
+
+// errUploadStalled reports that the client stopped sending the request body
+// for longer than Handler.UploadIdle.
+var errUploadStalled = errors.New("upload stalled")
+
+// uploadWriteGrace is how long after the last body read a create may take to
+// store the secret and write its response; it matches the server's default
+// WriteTimeout.
+const uploadWriteGrace = 10 * time.Second
+
+// idleBody wraps body so every Read first pushes the connection's read
+// deadline UploadIdle into the future. A steadily progressing upload may take
+// as long as it needs (overriding the server ReadTimeout) while one that stops
+// sending fails with errUploadStalled. The write deadline, which the server
+// counts from the request headers, moves along with it so a long upload still
+// gets its response. A zero UploadIdle returns body as is.
+func (h *Handler) idleBody(w http.ResponseWriter, body io.Reader) io.Reader {
+	if h.UploadIdle <= 0 {
+		return body
+	}
+	return &idleDeadlineReader{r: body, rc: http.NewResponseController(w), idle: h.UploadIdle}
+}
+
+type idleDeadlineReader struct {
+	r    io.Reader
+	rc   *http.ResponseController
+	idle time.Duration
+}
+
+func (d *idleDeadlineReader) Read(p []byte) (int, error) {
+	// Writers that cannot set deadlines (e.g. test recorders) leave the
+	// server ReadTimeout in charge.
+	now := time.Now()
+	_ = d.rc.SetReadDeadline(now.Add(d.idle))
+	_ = d.rc.SetWriteDeadline(now.Add(d.idle + uploadWriteGrace))
+	n, err := d.r.Read(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = errUploadStalled
+	}
+	return n, err
+}
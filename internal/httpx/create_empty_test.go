@@ -0,0 +1,81 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestCreateSecretEmpty round-trips a zero-length secret through the real
+// service and store when empties are allowed, and checks the default still
+// rejects them.
+func TestCreateSecretEmpty(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 64), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour}
+	h := httpx.New(svc, 1024, nil)
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader(""))
+		req.Header.Set("Content-Length", "0")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		w := httptest.NewRecorder()
+		h.Router().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := create(); w.Code != http.StatusBadRequest {
+		t.Fatalf("default: status=%d body=%s", w.Code, w.Body)
+	}
+
+	h.AllowEmpty, svc.AllowEmpty = true, true
+	w := create()
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body)
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/"+resp.ID, nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "0" {
+		t.Fatalf("consume: status=%d len=%d cl=%q", w.Code, w.Body.Len(), w.Header().Get("Content-Length"))
+	}
+	if w.Header().Get("X-Gone-Nonce") != "n1" {
+		t.Fatalf("consume: nonce=%q", w.Header().Get("X-Gone-Nonce"))
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/"+resp.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("second consume: status=%d", w.Code)
+	}
+}
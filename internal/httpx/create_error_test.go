@@ -19,7 +19,7 @@ type failingService struct { // implements ServicePort for error injection
 	fail bool
 }
 
-func (f failingService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+func (f failingService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
 	if f.fail {
 		return "", time.Time{}, errors.New("boom")
 	}
@@ -82,3 +82,40 @@ func TestCreateEndpointErrors(t *testing.T) {
 		})
 	}
 }
+
+// TestCreateContentTypeAllowlist covers the optional Content-Type policy:
+// unset accepts anything, otherwise only listed media types pass.
+func TestCreateContentTypeAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		ct      string
+		want    int
+	}{
+		{name: "unset allows any", ct: "image/png", want: http.StatusCreated},
+		{name: "unset allows missing", want: http.StatusCreated},
+		{name: "allowed", allowed: []string{"application/octet-stream"}, ct: "application/octet-stream", want: http.StatusCreated},
+		{name: "allowed ignores params and case", allowed: []string{"application/octet-stream"}, ct: "Application/Octet-Stream; charset=binary", want: http.StatusCreated},
+		{name: "disallowed", allowed: []string{"application/octet-stream"}, ct: "text/html", want: http.StatusUnsupportedMediaType},
+		{name: "missing when restricted", allowed: []string{"application/octet-stream"}, want: http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := httpx.New(failingService{}, 1024, nil)
+			h.AllowedContentTypes = tc.allowed
+			req := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("0123456789")))
+			req.Header.Set("Content-Length", "10")
+			req.Header.Set("X-Gone-Version", "1")
+			req.Header.Set("X-Gone-Nonce", "n")
+			req.Header.Set("X-Gone-TTL", "5m")
+			if tc.ct != "" {
+				req.Header.Set("Content-Type", tc.ct)
+			}
+			rr := httptest.NewRecorder()
+			h.Router().ServeHTTP(rr, req)
+			if rr.Code != tc.want {
+				t.Fatalf("got %d want %d body=%s", rr.Code, tc.want, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// fixedID is an app.IDGenerator that always returns the same ID.
+type fixedID domain.SecretID
+
+func (f fixedID) NewID() (domain.SecretID, error) { return domain.SecretID(f), nil }
+
+// TestCreateSecretDeterministicID drives a create through the real service and
+// store with a stub generator and checks the exact ID in the response.
+func TestCreateSecretDeterministicID(t *testing.T) {
+	const want = "0123456789abcdef0123456789abcdef"
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "id.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 64), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, IDs: fixedID(want)}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("cipher"))
+	req.Header.Set("Content-Length", "6")
+	req.Header.Set("X-Gone-Version", "1")
+	req.Header.Set("X-Gone-Nonce", "n1")
+	req.Header.Set("X-Gone-TTL", "5m")
+	w := httptest.NewRecorder()
+	httpx.New(svc, 1024, nil).Router().ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body)
+	}
+	var body struct {
+		ID        string    `json:"id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.ID != want || !body.ExpiresAt.Equal(clk.now.Add(5*time.Minute)) {
+		t.Fatalf("got %+v, want id %s", body, want)
+	}
+}
+
+// TestCreateSecretClientID drives X-Gone-ID through the real service and
+// store: a valid ID is used as-is, a malformed one is rejected, a taken one
+// (inline or external) conflicts, and the header is refused unless enabled.
+func TestCreateSecretClientID(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "id.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 8), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, ClientIDs: true}
+	h := httpx.New(svc, 1024, nil).Router()
+	create := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader(body))
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		req.Header.Set("X-Gone-ID", id)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	const inline, external = "00000000000000000000000000001234", "00000000000000000000000000005678"
+	for _, tc := range []struct{ id, body string }{{inline, "short"}, {external, "longer than inline"}} {
+		w := create(tc.id, tc.body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: status=%d body=%s", tc.id, w.Code, w.Body)
+		}
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.ID != tc.id {
+			t.Fatalf("%s: got %+v err %v", tc.id, resp, err)
+		}
+		if w := create(tc.id, tc.body); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "id exists") {
+			t.Fatalf("%s collision: status=%d body=%s", tc.id, w.Code, w.Body)
+		}
+	}
+	// The original secrets survive the rejected duplicates.
+	if _, rc, _, err := svc.Consume(t.Context(), external); err != nil {
+		t.Fatalf("consume original: %v", err)
+	} else {
+		rc.Close()
+	}
+
+	for _, bad := range []string{"1234", "ZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ", "../../../etc/passwd"} {
+		if w := create(bad, "x"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid id") {
+			t.Fatalf("%q: status=%d body=%s", bad, w.Code, w.Body)
+		}
+	}
+
+	svc.ClientIDs = false
+	if w := create("00000000000000000000000000009999", "x"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "client ids disabled") {
+		t.Fatalf("disabled: status=%d body=%s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,82 @@
+package httpx_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// drainService reads the whole body like the real store before answering.
+type drainService struct{}
+
+func (drainService) CreateSecret(_ context.Context, r io.Reader, size int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
+	if _, err := io.CopyN(io.Discard, r, size); err != nil {
+		return "", time.Time{}, err
+	}
+	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Now().Add(time.Hour), nil
+}
+func (drainService) Consume(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+	return app.Meta{}, nil, 0, app.ErrNotFound
+}
+
+// sendSlowly posts a 30-byte body in 10-byte chunks separated by gap and,
+// when stall is set, stops after the first chunk. It returns the status code.
+func sendSlowly(t *testing.T, addr string, gap time.Duration, stall bool) int {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, _ = fmt.Fprintf(conn, "POST /api/secret HTTP/1.1\r\nHost: x\r\nContent-Length: 30\r\nX-Gone-Version: 1\r\nX-Gone-Nonce: n\r\nX-Gone-TTL: 5m\r\n\r\n")
+	for i := 0; i < 3; i++ {
+		if _, err := conn.Write([]byte("0123456789")); err != nil {
+			break
+		}
+		if stall {
+			break
+		}
+		time.Sleep(gap)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestCreateUploadIdleTimeout(t *testing.T) {
+	h := httpx.New(drainService{}, 1024, nil)
+	h.UploadIdle = 150 * time.Millisecond
+	srv := httptest.NewUnstartedServer(h.Router())
+	// Static Read and WriteTimeouts shorter than the whole upload: progress
+	// must keep extending both deadlines past them, or the 201 is lost.
+	srv.Config.ReadTimeout = 200 * time.Millisecond
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	if code := sendSlowly(t, addr, 100*time.Millisecond, false); code != http.StatusCreated {
+		t.Fatalf("progressing upload: got %d want 201", code)
+	}
+	start := time.Now()
+	if code := sendSlowly(t, addr, 0, true); code != http.StatusRequestTimeout {
+		t.Fatalf("stalled upload: got %d want 408", code)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("stall detected after %v", elapsed)
+	}
+}
@@ -0,0 +1,76 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestCreateSecretLabel checks X-Gone-Label is echoed verbatim, omitted from
+// the response when absent, and rejected when malformed or oversized.
+func TestCreateSecretLabel(t *testing.T) {
+	created := 0
+	m := mockService{createFn: func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+		created++
+		_, _ = io.ReadAll(ct)
+		return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+	}}
+	h := httpx.New(m, 1024, nil).Router()
+	create := func(label string, set bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher")))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		if set {
+			req.Header.Set("X-Gone-Label", label)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	const label = "c2VjcmV0LWZvci1ib2I_LQ=="
+	w := create(label, true)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status=%d", w.Code)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp["label"] != label {
+		t.Fatalf("label not echoed: %v", resp)
+	}
+
+	w = create("", false)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status=%d", w.Code)
+	}
+	resp = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := resp["label"]; ok {
+		t.Fatalf("unexpected label in response: %v", resp)
+	}
+
+	before := created
+	for _, bad := range []string{"not base64!", "a/b+c", strings.Repeat("A", 513)} {
+		if w := create(bad, true); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid label") {
+			t.Fatalf("label %.20q: status=%d body=%s", bad, w.Code, w.Body.String())
+		}
+	}
+	if created != before {
+		t.Fatalf("service called for rejected labels")
+	}
+}
@@ -0,0 +1,97 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// blockingService holds every CreateSecret until release is closed, so the
+// test controls how many creates are in flight.
+type blockingService struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b blockingService) CreateSecret(_ context.Context, r io.Reader, _ int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
+	_, _ = io.ReadAll(r)
+	b.entered <- struct{}{}
+	<-b.release
+	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Now().Add(time.Hour), nil
+}
+func (blockingService) Consume(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+	return app.Meta{}, nil, 0, app.ErrNotFound
+}
+
+func TestCreateConcurrencyLimit(t *testing.T) {
+	const limit, total = 2, 6
+	svc := blockingService{entered: make(chan struct{}, total), release: make(chan struct{})}
+	h := httpx.New(svc, 1024, nil)
+	h.MaxCreates = limit
+	router := h.Router()
+
+	codes := make(chan *httptest.ResponseRecorder, total)
+	var wg sync.WaitGroup
+	send := func() {
+		defer wg.Done()
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("abc"))
+		req.Header.Set("Content-Length", "3")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n")
+		req.Header.Set("X-Gone-TTL", "5m")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		codes <- rr
+	}
+	// Fill every slot and wait until both holders are inside the service.
+	wg.Add(limit)
+	for i := 0; i < limit; i++ {
+		go send()
+	}
+	for i := 0; i < limit; i++ {
+		<-svc.entered
+	}
+	// Everything beyond the limit is turned away while the slots are held.
+	wg.Add(total - limit)
+	for i := 0; i < total-limit; i++ {
+		go send()
+	}
+	for i := 0; i < total-limit; i++ {
+		rr := <-codes
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 while saturated, got %d", rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Fatalf("expected Retry-After on 503")
+		}
+	}
+	close(svc.release)
+	wg.Wait()
+	close(codes)
+	for rr := range codes {
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("slot holder got %d", rr.Code)
+		}
+	}
+
+	// Slots are released once the handler returns.
+	req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("abc"))
+	req.Header.Set("Content-Length", "3")
+	req.Header.Set("X-Gone-Version", "1")
+	req.Header.Set("X-Gone-Nonce", "n")
+	req.Header.Set("X-Gone-TTL", "5m")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected slot to be free after release, got %d", rr.Code)
+	}
+}
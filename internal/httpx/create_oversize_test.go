@@ -0,0 +1,84 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestCreateBodyLongerThanDeclared sends more bytes than Content-Length
+// declares and expects 413 with nothing persisted, for both inline and blob
+// payloads.
+func TestCreateBodyLongerThanDeclared(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "os.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	blobDir := t.TempDir()
+	bs, err := filesystem.New(blobDir)
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Now().UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 4), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour}
+	router := httpx.New(svc, 1024, nil).Router()
+
+	tests := []struct {
+		name     string
+		declared int
+		body     string
+		want     int
+	}{
+		{name: "inline exact", declared: 3, body: "abc", want: http.StatusCreated},
+		{name: "blob exact", declared: 10, body: "0123456789", want: http.StatusCreated},
+		{name: "inline excess", declared: 3, body: "abcdef", want: http.StatusRequestEntityTooLarge},
+		{name: "blob excess", declared: 10, body: strings.Repeat("x", 2048), want: http.StatusRequestEntityTooLarge},
+	}
+	created := 0
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader(tc.body))
+			req.Header.Set("Content-Length", strconv.Itoa(tc.declared))
+			req.Header.Set("X-Gone-Version", "1")
+			req.Header.Set("X-Gone-Nonce", "n")
+			req.Header.Set("X-Gone-TTL", "5m")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("status got %d want %d body=%s", w.Code, tc.want, w.Body.String())
+			}
+			if tc.want == http.StatusCreated {
+				created++
+				return
+			}
+			if !strings.Contains(w.Body.String(), "size exceeded") {
+				t.Fatalf("unexpected body %s", w.Body.String())
+			}
+		})
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM secrets`).Scan(&rows); err != nil || rows != created {
+		t.Fatalf("expected %d rows, got %d (err=%v)", created, rows, err)
+	}
+	entries, _ := os.ReadDir(blobDir)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the exact blob on disk, got %d files", len(entries))
+	}
+}
@@ -59,6 +59,15 @@ func Test_parseContentLength(t *testing.T) {
 	if _, err := h.parseContentLength(req4); err == nil {
 		t.Fatalf("expected zero error")
 	}
+	h.AllowEmpty = true
+	if v, err := h.parseContentLength(req4); err != nil || v != 0 {
+		t.Fatalf("AllowEmpty: expected 0 got %d err %v", v, err)
+	}
+	req4.Header.Set("Content-Length", "-1")
+	if _, err := h.parseContentLength(req4); err == nil {
+		t.Fatalf("AllowEmpty: expected negative error")
+	}
+	h.AllowEmpty = false
 	// exceeded
 	req5 := httptest.NewRequest(http.MethodPost, "/api/secret", nil)
 	req5.Header.Set("Content-Length", strconv.FormatInt(11, 10))
@@ -73,13 +82,13 @@ func Test_parseSecretHeaders(t *testing.T) {
 	req.Header.Set("X-Gone-Version", "1")
 	req.Header.Set("X-Gone-Nonce", "n")
 	req.Header.Set("X-Gone-TTL", "5m")
-	ver, nonce, ttl, err := parseSecretHeaders(req)
+	ver, nonce, ttl, err := parseSecretHeaders(req, false, 0)
 	if err != nil || ver != 1 || nonce != "n" || ttl != 5*time.Minute {
 		t.Fatalf("unexpected success parse: %v %d %s %v", err, ver, nonce, ttl)
 	}
 	// missing
 	req2 := httptest.NewRequest(http.MethodPost, "/api/secret", nil)
-	if _, _, _, err := parseSecretHeaders(req2); err == nil {
+	if _, _, _, err := parseSecretHeaders(req2, false, 0); err == nil {
 		t.Fatalf("expected missing headers error")
 	}
 	// bad version
@@ -87,7 +96,7 @@ func Test_parseSecretHeaders(t *testing.T) {
 	req3.Header.Set("X-Gone-Version", "9999")
 	req3.Header.Set("X-Gone-Nonce", "n")
 	req3.Header.Set("X-Gone-TTL", "5m")
-	if _, _, _, err := parseSecretHeaders(req3); err == nil {
+	if _, _, _, err := parseSecretHeaders(req3, false, 0); err == nil {
 		t.Fatalf("expected invalid version error")
 	}
 	// bad ttl
@@ -95,11 +104,110 @@ func Test_parseSecretHeaders(t *testing.T) {
 	req4.Header.Set("X-Gone-Version", "1")
 	req4.Header.Set("X-Gone-Nonce", "n")
 	req4.Header.Set("X-Gone-TTL", "notdur")
-	if _, _, _, err := parseSecretHeaders(req4); err == nil {
+	if _, _, _, err := parseSecretHeaders(req4, false, 0); err == nil {
 		t.Fatalf("expected invalid ttl error")
 	}
 }
 
+func Test_parseSecretHeadersStrict(t *testing.T) {
+	newReq := func(nonce, ttl string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", nil)
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", nonce)
+		req.Header.Set("X-Gone-TTL", ttl)
+		return req
+	}
+	long := strings.Repeat("A", maxNonceLen+1)
+	// Lenient mode only skips the TTL length check.
+	if _, _, _, err := parseSecretHeaders(newReq(long, "5m"), false, 0); err == nil || err.Error() != "invalid nonce" {
+		t.Fatalf("lenient overlong nonce: got %v", err)
+	}
+	if _, _, _, err := parseSecretHeaders(newReq("n", strings.Repeat("0", maxTTLLen)+"5m"), false, 0); err != nil {
+		t.Fatalf("lenient long ttl: %v", err)
+	}
+	if _, nonce, _, err := parseSecretHeaders(newReq(strings.Repeat("A", maxNonceLen), "5m"), true, 0); err != nil || len(nonce) != maxNonceLen {
+		t.Fatalf("strict nonce at bound: %v", err)
+	}
+	for _, tc := range []struct{ nonce, ttl, want string }{
+		{long, "5m", "invalid nonce"},
+		{"not base64!", "5m", "invalid nonce"},
+		{"n", strings.Repeat("1", maxTTLLen) + "s", "invalid ttl"},
+	} {
+		_, _, _, err := parseSecretHeaders(newReq(tc.nonce, tc.ttl), true, 0)
+		if err == nil || err.Error() != tc.want {
+			t.Fatalf("nonce %.16q ttl %.16q: got %v want %s", tc.nonce, tc.ttl, err, tc.want)
+		}
+		if code, _ := classifyCreateError(err); code != http.StatusBadRequest {
+			t.Fatalf("%s mapped to %d", tc.want, code)
+		}
+	}
+}
+
+func Test_parseSecretHeadersNonce(t *testing.T) {
+	newReq := func(nonce string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", nil)
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		req.Header.Set("X-Gone-Nonce", nonce)
+		return req
+	}
+	for _, tc := range []struct {
+		name, nonce string
+		strict      bool
+		max         int
+		ok          bool
+	}{
+		{"crlf injection", "abc\r\nSet-Cookie: x=1", false, 0, false},
+		{"bare lf", "abc\ndef", false, 0, false},
+		{"nul", "abc\x00", false, 0, false},
+		{"del", "abc\x7f", false, 0, false},
+		{"tab", "abc\tdef", true, 0, false},
+		{"lenient punctuation", "not base64!", false, 0, false},
+		{"strict not base64url", "not base64!", true, 0, false},
+		{"lenient default max", strings.Repeat("A", maxNonceLen+1), false, 0, false},
+		{"at configured max", "AAAA", false, 4, true},
+		{"over configured max", "AAAAA", false, 4, false},
+		{"strict configured max", strings.Repeat("A", maxNonceLen+10), true, maxNonceLen + 10, true},
+		{"strict over configured max", strings.Repeat("A", 17), true, 16, false},
+	} {
+		_, nonce, _, err := parseSecretHeaders(newReq(tc.nonce), tc.strict, tc.max)
+		if tc.ok {
+			if err != nil || nonce != tc.nonce {
+				t.Fatalf("%s: got %q, %v", tc.name, nonce, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != "invalid nonce" {
+			t.Fatalf("%s: expected invalid nonce, got %v", tc.name, err)
+		}
+	}
+}
+
+func Test_parseMaxReads(t *testing.T) {
+	cases := []struct {
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{header: "", want: 1},
+		{header: "3", want: 3},
+		{header: "0", wantErr: true},
+		{header: "-1", wantErr: true},
+		{header: "many", wantErr: true},
+		{header: "70000", wantErr: true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Gone-Max-Reads", tc.header)
+		}
+		got, err := parseMaxReads(req)
+		if (err != nil) != tc.wantErr || (!tc.wantErr && got != tc.want) {
+			t.Errorf("parseMaxReads(%q) = %d, %v", tc.header, got, err)
+		}
+	}
+}
+
 func Test_parseAndValidateCreate(t *testing.T) {
 	h := newTestHandler(50)
 	req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("abc"))
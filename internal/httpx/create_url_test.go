@@ -0,0 +1,73 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestCreateSecretShareURL checks the create response carries a share URL
+// only when PublicBaseURL is set, and never for tenant-scoped creates.
+func TestCreateSecretShareURL(t *testing.T) {
+	m := mockService{createFn: func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+		_, _ = io.ReadAll(ct)
+		return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+	}}
+	tests := []struct {
+		name, base, path, remote, proto, want string
+	}{
+		{name: "unset", path: "/api/secret"},
+		{name: "configured", base: "https://gone.example.com", path: "/api/secret", want: "https://gone.example.com/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{name: "trailing slash", base: "https://gone.example.com/", path: "/api/secret", want: "https://gone.example.com/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{name: "tenant", base: "https://gone.example.com", path: "/t/acme/api/secret"},
+		{name: "auto plain", base: httpx.PublicBaseAuto, path: "/api/secret", want: "http://example.com/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{name: "auto trusted proxy", base: httpx.PublicBaseAuto, path: "/api/secret", remote: "10.0.0.2:443", proto: "https", want: "https://example.com/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{name: "auto untrusted proxy", base: httpx.PublicBaseAuto, path: "/api/secret", proto: "https", want: "http://example.com/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := httpx.New(m, 1024, nil)
+			h.PublicBaseURL = tc.base
+			h.Tenants = []domain.Tenant{{Name: "acme"}}
+			h.TrustedProxies, _ = httpx.ParseTrustedProxies([]string{"10.0.0.0/8"})
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader([]byte("cipher")))
+			if tc.remote != "" {
+				req.RemoteAddr = tc.remote
+			}
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			req.Header.Set("Content-Length", "6")
+			req.Header.Set("X-Gone-Version", "1")
+			req.Header.Set("X-Gone-Nonce", "n1")
+			req.Header.Set("X-Gone-TTL", "5m")
+			w := httptest.NewRecorder()
+			h.Router().ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			got, present := resp["url"]
+			if tc.want == "" {
+				if present {
+					t.Fatalf("url = %v, want absent", got)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("url = %v, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package httpx_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/haukened/gone/internal/httpx"
+)
+
+var nonceRe = regexp.MustCompile(`script-src 'self' 'nonce-([A-Z2-7]+)';`)
+
+// TestCSPNonce checks each page request gets its own nonce, echoed in the
+// CSP header and rendered into inline script tags, and that the default
+// policy stays nonce-free.
+func TestCSPNonce(t *testing.T) {
+	tmpl := template.Must(template.New("p").Parse(`<script nonce="{{.CSPNonce}}">boot()</script>`))
+	h := httpx.New(mockService{}, 1024, nil)
+	h.IndexTmpl = httpx.TemplateRenderer{T: tmpl}
+	h.SecretTmpl = httpx.TemplateRenderer{T: tmpl}
+	h.CSPNonce = true
+	router := h.Router()
+
+	seen := map[string]bool{}
+	for _, path := range []string{"/", "/", "/secret/0123456789abcdef0123456789abcdef"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		m := nonceRe.FindStringSubmatch(w.Header().Get("Content-Security-Policy"))
+		if m == nil {
+			t.Fatalf("%s: no nonce in CSP %q", path, w.Header().Get("Content-Security-Policy"))
+		}
+		if seen[m[1]] {
+			t.Fatalf("%s: nonce %s reused", path, m[1])
+		}
+		seen[m[1]] = true
+		if want := `<script nonce="` + m[1] + `">`; !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("%s: body %q missing %q", path, w.Body, want)
+		}
+	}
+
+	h.CSPNonce = false
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if csp := w.Header().Get("Content-Security-Policy"); strings.Contains(csp, "nonce") || !strings.Contains(csp, "script-src 'self';") {
+		t.Fatalf("default CSP changed: %q", csp)
+	}
+	if !strings.Contains(w.Body.String(), `<script nonce="">`) {
+		t.Fatalf("default body %q", w.Body)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/haukened/gone/internal/app"
 	"github.com/haukened/gone/internal/domain"
@@ -21,28 +24,130 @@ import (
 //   - code: HTTP status code to return.
 //   - msg: User-facing error message included in the JSON payload.
 func writeJSONError(ctx context.Context, w http.ResponseWriter, code int, msg string) {
+	writeErrorBody(w, code, errorBody{Error: msg})
+}
+
+// errorBody is the JSON error payload; RequestID is only set when the handler
+// echoes correlation IDs.
+type errorBody struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func writeErrorBody(w http.ResponseWriter, code int, body errorBody) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(struct {
-		Error string `json:"error"`
-	}{Error: msg})
+	_ = json.NewEncoder(w).Encode(body)
 }
 
 // writeError writes a JSON error body with given status code.
 func (h *Handler) writeError(ctx context.Context, w http.ResponseWriter, code int, msg string) {
-	writeJSONError(ctx, w, code, msg)
+	writeErrorBody(w, code, errorBody{Error: msg, RequestID: h.requestID(ctx)})
+}
+
+// requestID returns the correlation ID to echo in JSON bodies, or "" when
+// EchoRequestID is off.
+func (h *Handler) requestID(ctx context.Context) string {
+	if !h.EchoRequestID {
+		return ""
+	}
+	cid, _ := GetCorrelationID(ctx)
+	return cid
+}
+
+// opContext derives the context for a create/consume service call, bounded by
+// OpTimeout when configured.
+func (h *Handler) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.OpTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.OpTimeout)
+}
+
+// writeTimeoutIfExpired writes 503 and returns true when opCtx hit its
+// deadline. Errors surfacing from a timed-out call are often generic I/O or
+// driver errors, so the context is checked rather than err.
+func (h *Handler) writeTimeoutIfExpired(opCtx context.Context, w http.ResponseWriter) bool {
+	if !errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	cid, _ := GetCorrelationID(opCtx)
+	slog.Warn("service error", "cid", cid, "code", "timeout")
+	h.writeError(opCtx, w, http.StatusServiceUnavailable, "timeout")
+	return true
+}
+
+// ctxReader fails reads once ctx is done so a stalled upload cannot outlive
+// the operation deadline.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
 }
 
 // mapServiceError maps domain/store/service errors to HTTP responses.
 func (h *Handler) mapServiceError(ctx context.Context, w http.ResponseWriter, err error) {
 	cid, _ := GetCorrelationID(ctx)
+	var tooLarge *http.MaxBytesError
 	switch {
+	case errors.As(err, &tooLarge):
+		slog.Warn("service error", "cid", cid, "code", "body_too_large")
+		h.writeError(ctx, w, http.StatusRequestEntityTooLarge, "size exceeded")
+	case errors.Is(err, errUploadStalled):
+		slog.Warn("service error", "cid", cid, "code", "upload_stalled")
+		h.writeError(ctx, w, http.StatusRequestTimeout, "upload stalled")
 	case errors.Is(err, domain.ErrInvalidID):
 		slog.Warn("service error", "cid", cid, "code", "invalid_id")
 		h.writeError(ctx, w, http.StatusBadRequest, "invalid id")
 	case errors.Is(err, app.ErrSizeExceeded):
 		slog.Warn("service error", "cid", cid, "code", "size_exceeded")
 		h.writeError(ctx, w, http.StatusRequestEntityTooLarge, "size exceeded")
+	case errors.Is(err, app.ErrTooSmall):
+		slog.Warn("service error", "cid", cid, "code", "too_small")
+		h.writeError(ctx, w, http.StatusBadRequest, "size too small")
+	case errors.Is(err, app.ErrMaxReadsInvalid):
+		slog.Warn("service error", "cid", cid, "code", "max_reads_invalid")
+		h.writeError(ctx, w, http.StatusBadRequest, "invalid max reads")
+	case errors.Is(err, app.ErrPassphraseHashInvalid):
+		slog.Warn("service error", "cid", cid, "code", "passphrase_hash_invalid")
+		h.writeError(ctx, w, http.StatusBadRequest, "invalid passphrase hash")
+	case errors.Is(err, app.ErrPassphraseMismatch):
+		slog.Warn("service error", "cid", cid, "code", "passphrase_mismatch")
+		h.writeError(ctx, w, http.StatusForbidden, "passphrase required")
+	case errors.Is(err, app.ErrTooManyAttempts):
+		slog.Warn("service error", "cid", cid, "code", "too_many_attempts")
+		h.writeError(ctx, w, http.StatusTooManyRequests, "too many attempts")
+	case errors.Is(err, app.ErrDuplicateID):
+		slog.Warn("service error", "cid", cid, "code", "duplicate_id")
+		h.writeError(ctx, w, http.StatusConflict, "id exists")
+	case errors.Is(err, app.ErrClientIDNotAllowed):
+		slog.Warn("service error", "cid", cid, "code", "client_id_not_allowed")
+		h.writeError(ctx, w, http.StatusBadRequest, "client ids disabled")
+	case errors.Is(err, app.ErrVersionUnsupported):
+		slog.Warn("service error", "cid", cid, "code", "version_unsupported")
+		h.writeError(ctx, w, http.StatusBadRequest, "unsupported version")
+	case errors.Is(err, app.ErrNotBeforeInvalid):
+		slog.Warn("service error", "cid", cid, "code", "not_before_invalid")
+		h.writeError(ctx, w, http.StatusBadRequest, "invalid not before")
+	case errors.Is(err, app.ErrTooEarly):
+		slog.Info("service error", "cid", cid, "code", "too_early")
+		h.writeError(ctx, w, http.StatusTooEarly, "too early")
+	case errors.Is(err, app.ErrQuotaExceeded):
+		slog.Warn("service error", "cid", cid, "code", "quota_exceeded")
+		var qe *app.QuotaExceededError
+		if errors.As(err, &qe) {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(qe.RetryAfter)))
+		}
+		h.writeError(ctx, w, http.StatusTooManyRequests, "quota exceeded")
+	case errors.Is(err, app.ErrStorageFull):
+		slog.Warn("service error", "cid", cid, "code", "storage_full")
+		h.writeError(ctx, w, http.StatusInsufficientStorage, "insufficient storage")
 	case errors.Is(err, app.ErrNotFound):
 		slog.Info("service error", "cid", cid, "code", "not_found")
 		h.writeError(ctx, w, http.StatusNotFound, "not found")
@@ -59,3 +164,9 @@ func (h *Handler) mapServiceError(ctx context.Context, w http.ResponseWriter, er
 	}
 
 }
+
+// retryAfterSeconds converts d to a Retry-After value, rounding up so a
+// client never retries before d has passed.
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
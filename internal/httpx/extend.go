@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// SecretExtender is optionally implemented by the ServicePort (as
+// *app.Service does) to give an unread secret a new lifetime.
+type SecretExtender interface {
+	Extend(ctx context.Context, id string, ttl time.Duration) (time.Time, error)
+}
+
+// extendRoute sends PATCH requests to handleExtendSecret and everything else
+// to next.
+func (h *Handler) extendRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			h.handleExtendSecret(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleExtendSecret implements PATCH /api/secret/{id}: the secret's expiry
+// becomes now plus the X-Gone-TTL header. Gated secrets also need
+// X-Gone-Passphrase. Only mounted with AllowExtend.
+func (h *Handler) handleExtendSecret(w http.ResponseWriter, r *http.Request) {
+	ext, ok := h.Service.(SecretExtender)
+	if !ok {
+		h.writeError(r.Context(), w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := strings.CutPrefix(r.URL.Path, "/api/secret/")
+	if !ok || id == "" {
+		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		return
+	}
+	clog := secretLogger(r.Context())
+	ttl, err := time.ParseDuration(r.Header.Get("X-Gone-TTL"))
+	if err != nil {
+		h.writeError(r.Context(), w, http.StatusBadRequest, "invalid ttl")
+		clog.Error("extend", "action", "error", "kind", "validation")
+		return
+	}
+	ctx := r.Context()
+	if p := r.Header.Get("X-Gone-Passphrase"); p != "" {
+		ctx = app.WithPassphrase(ctx, p)
+	}
+	ctx, cancel := h.opContext(ctx)
+	defer cancel()
+	expires, err := ext.Extend(ctx, id, ttl)
+	if err != nil && h.writeTimeoutIfExpired(ctx, w) {
+		clog.Error("extend", "action", "error", "kind", "timeout")
+		return
+	}
+	if isConsumeNotFound(err) {
+		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		clog.Info("extend", "action", "not_found")
+		return
+	}
+	if err != nil {
+		h.mapServiceError(r.Context(), w, err)
+		clog.Error("extend", "action", "error", "kind", "service")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(struct {
+		ID        string    `json:"id"`
+		ExpiresAt time.Time `json:"expires_at"`
+		RequestID string    `json:"request_id,omitempty"`
+	}{ID: id, ExpiresAt: expires, RequestID: h.requestID(r.Context())})
+	clog.Info("extend", "action", "success", "ttl_secs", int(ttl.Seconds()))
+}
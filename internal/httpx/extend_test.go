@@ -0,0 +1,141 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestExtendSecret drives PATCH /api/secret/{id} through the real service and
+// store: an unread secret gets a new expiry and stays readable past the old
+// one, an expired one is 404, a TTL above MaxTTL or one that would shorten an
+// embargoed secret's life is rejected, and the route is absent unless
+// AllowExtend is set.
+func TestExtendSecret(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "extend.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Unix(1700000000, 0).UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 1024), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour}
+	h := httpx.New(svc, 1024, nil)
+	h.AllowExtend = true
+	router := h.Router()
+
+	create := func(passHash string, notBefore ...time.Time) string {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("cipher"))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "10m")
+		if passHash != "" {
+			req.Header.Set("X-Gone-Passphrase-Hash", passHash)
+		}
+		if len(notBefore) > 0 {
+			req.Header.Set("X-Gone-Not-Before", notBefore[0].Format(time.RFC3339))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create status=%d body=%s", w.Code, w.Body)
+		}
+		var resp struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.ID
+	}
+	extend := func(router http.Handler, id, ttl, passphrase string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/secret/"+id, nil)
+		req.Header.Set("X-Gone-TTL", ttl)
+		if passphrase != "" {
+			req.Header.Set("X-Gone-Passphrase", passphrase)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	consume := func(id string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/"+id, nil))
+		return w.Code
+	}
+
+	id := create("")
+	clk.now = clk.now.Add(5 * time.Minute)
+	w := extend(router, id, "30m", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("extend status=%d body=%s", w.Code, w.Body)
+	}
+	var resp struct {
+		ID        string    `json:"id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ID != id || !resp.ExpiresAt.Equal(clk.now.Add(30*time.Minute)) {
+		t.Fatalf("extend response %+v, want expiry %v", resp, clk.now.Add(30*time.Minute))
+	}
+	if w := extend(router, id, "2h", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "ttl invalid") {
+		t.Fatalf("over max: status=%d body=%s", w.Code, w.Body)
+	}
+	if w := extend(router, id, "soon", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid ttl") {
+		t.Fatalf("bad ttl: status=%d body=%s", w.Code, w.Body)
+	}
+	clk.now = clk.now.Add(20 * time.Minute) // past the original expiry
+	if code := consume(id); code != http.StatusOK {
+		t.Fatalf("consume after extend status=%d", code)
+	}
+
+	expired := create("")
+	clk.now = clk.now.Add(11 * time.Minute)
+	if w := extend(router, expired, "30m", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("extend expired: status=%d body=%s", w.Code, w.Body)
+	}
+
+	embargoed := create("", clk.now.Add(8*time.Minute))
+	if w := extend(router, embargoed, "5m", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "ttl invalid") {
+		t.Fatalf("extend onto embargo: status=%d body=%s", w.Code, w.Body)
+	}
+	clk.now = clk.now.Add(9 * time.Minute)
+	if code := consume(embargoed); code != http.StatusOK {
+		t.Fatalf("consume embargoed after refused extend status=%d", code)
+	}
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	gated := create(string(hash))
+	if w := extend(router, gated, "30m", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("extend gated without passphrase: status=%d", w.Code)
+	}
+	if w := extend(router, gated, "30m", "hunter2"); w.Code != http.StatusOK {
+		t.Fatalf("extend gated: status=%d body=%s", w.Code, w.Body)
+	}
+
+	h.AllowExtend = false
+	if w := extend(h.Router(), gated, "30m", "hunter2"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("extend disabled: status=%d", w.Code)
+	}
+}
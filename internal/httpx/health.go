@@ -1,6 +1,11 @@
 package httpx
 
-import "net/http"
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
 
 // handleHealth returns liveness.
 func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -8,7 +13,16 @@ func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("ok"))
 }
 
+// readyStatus is the verbose /readyz body. Secrets is omitted when the count
+// is unavailable.
+type readyStatus struct {
+	Status        string `json:"status"`
+	Secrets       *int64 `json:"secrets,omitempty"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
 // handleReady returns readiness; if probe unavailable or failing => 503.
+// With ReadyVerbose a passing probe answers with a readyStatus body.
 func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
 	if h.Readiness != nil {
 		if err := h.Readiness(r.Context()); err != nil {
@@ -16,6 +30,23 @@ func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if !h.ReadyVerbose {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready"))
+		return
+	}
+	st := readyStatus{Status: "ready"}
+	if !h.Started.IsZero() {
+		st.UptimeSeconds = int64(time.Since(h.Started) / time.Second)
+	}
+	if h.SecretCount != nil {
+		if n, err := h.SecretCount(r.Context()); err == nil {
+			st.Secrets = &n
+		} else {
+			slog.Warn("readyz secret count failed", "domain", "health", "err", err)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("ready"))
+	_ = json.NewEncoder(w).Encode(st)
 }
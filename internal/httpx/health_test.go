@@ -2,11 +2,13 @@ package httpx
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestHandleReady_NoReadiness ensures 200 when no readiness probe is configured.
@@ -72,3 +74,46 @@ func TestHandleReady_NotReady(t *testing.T) {
 		t.Fatalf("expected body to contain 'not ready', got %q", body)
 	}
 }
+
+// TestHandleReady_Verbose checks the JSON body carries the count and uptime,
+// and omits the count when it cannot be read.
+func TestHandleReady_Verbose(t *testing.T) {
+	h := &Handler{
+		ReadyVerbose: true,
+		Started:      time.Now().Add(-90 * time.Second),
+		SecretCount:  func(context.Context) (int64, error) { return 7, nil },
+	}
+	rr := httptest.NewRecorder()
+	h.handleReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status %d, content type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["status"] != "ready" || body["secrets"] != float64(7) {
+		t.Fatalf("body = %v", body)
+	}
+	if up, _ := body["uptime_seconds"].(float64); up < 90 {
+		t.Fatalf("uptime_seconds = %v, want >= 90", body["uptime_seconds"])
+	}
+
+	h.SecretCount = func(context.Context) (int64, error) { return 0, errors.New("locked") }
+	rr = httptest.NewRecorder()
+	h.handleReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	body = nil
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := body["secrets"]; ok || rr.Code != http.StatusOK {
+		t.Fatalf("status %d, body %v; want 200 without secrets", rr.Code, body)
+	}
+
+	h.Readiness = func(context.Context) error { return errors.New("db unavailable") }
+	rr = httptest.NewRecorder()
+	h.handleReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("failing probe: status %d", rr.Code)
+	}
+}
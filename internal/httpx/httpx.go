@@ -6,8 +6,10 @@ package httpx
 
 import (
 	"context"
+	"html/template"
 	"io"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/haukened/gone/internal/app"
@@ -17,26 +19,77 @@ import (
 // ServicePort abstracts the subset of app.Service used by the HTTP layer.
 // It is satisfied by *app.Service in production and mocked in tests.
 type ServicePort interface {
-	CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration) (id domain.SecretID, expiresAt time.Time, err error)
+	CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration, opts app.CreateOptions) (id domain.SecretID, expiresAt time.Time, err error)
 	Consume(ctx context.Context, idStr string) (app.Meta, io.ReadCloser, int64, error)
 }
 
 // Handler wires HTTP endpoints to the application service.
 // It is safe for concurrent use. Zero-value is not valid; construct via New.
 type Handler struct {
-	Service    ServicePort
-	MaxBody    int64                       // mirror service.MaxBytes (defense-in-depth)
-	Readiness  func(context.Context) error // optional readiness probe
-	IndexTmpl  IndexRenderer               // optional renderer for index page
-	AboutTmpl  AboutRenderer               // optional renderer for about page
-	SecretTmpl SecretRenderer              // optional renderer for secret consumption page
-	ErrorTmpl  IndexRenderer               // optional renderer for generic error pages (404, 500, etc.)
-	Assets     http.FileSystem             // static assets filesystem (optional)
-	MinTTL     time.Duration               // lower TTL bound (from config)
-	MaxTTL     time.Duration               // upper TTL bound (from config)
-	TTLOptions []domain.TTLOption          // explicit configured TTL options
+	Service       ServicePort
+	MaxBody       int64                       // mirror service.MaxBytes (defense-in-depth)
+	Readiness     func(context.Context) error // optional readiness probe
+	IndexTmpl     IndexRenderer               // optional renderer for index page
+	AboutTmpl     AboutRenderer               // optional renderer for about page
+	AboutContent  template.HTML               // sanitized operator about content (empty = built-in text)
+	SecretTmpl    SecretRenderer              // optional renderer for secret consumption page
+	ErrorTmpl     IndexRenderer               // optional renderer for generic error pages (404, 500, etc.)
+	Assets        http.FileSystem             // static assets filesystem (optional)
+	MinTTL        time.Duration               // lower TTL bound (from config)
+	MaxTTL        time.Duration               // upper TTL bound (from config)
+	TTLOptions    []domain.TTLOption          // explicit configured TTL options
+	TTLRange      bool                        // UI accepts any TTL in [MinTTL,MaxTTL] rather than only TTLOptions
+	Tenants       []domain.Tenant             // optional tenants served under /t/{name}/
+	Tracer        app.Tracer                  // optional request tracer (nil disables tracing)
+	NotFoundFloor time.Duration               // minimum latency of consume not-found responses (0 = none)
+	UI            UIMode                      // web UI mode (zero value = UIEnabled)
+	UIRedirectURL string                      // target for "/" when UI is UIRedirect
+	OpTimeout     time.Duration               // per-request deadline for create/consume service calls (0 = none)
+	UploadIdle    time.Duration               // max gap between create body reads before the upload is dropped (0 = server ReadTimeout)
+	Build         BuildInfo                   // reported by GET /version
+	OpenAPI       []byte                      // JSON OpenAPI document for GET /api/openapi.json (nil = not served)
+	MaxCreates    int                         // simultaneous create requests across all tenants (0 = unlimited)
+	RevealHints   bool                        // secret page says whether a link is malformed or unavailable
+	StrictHeaders bool                        // bound the X-Gone-TTL length on create (see parseSecretHeaders)
+	MaxNonceLen   int                         // longest X-Gone-Nonce accepted on create (0 = 64)
+	AllowEmpty    bool                        // accept Content-Length: 0 on create (service must allow empties too)
+	Latency       app.Observer                // optional sink for per-endpoint latency summaries (nil = off)
+	CSPNonce      bool                        // add a per-request nonce to script-src, exposed to page templates as .CSPNonce
+	PublicBaseURL string                      // external origin for the create response "url" field (empty = omitted, PublicBaseAuto = from request)
+	EchoRequestID bool                        // copy the correlation ID into JSON create/consume/error bodies as "request_id"
+	DirectRouting bool                        // serve 404s from the "/" catch-all instead of probing every response (see Router)
+
+	AllowedContentTypes []string       // create request media types accepted (empty = any)
+	TrustedProxies      []netip.Prefix // peers whose X-Forwarded-For/X-Real-IP are believed (see ClientIP)
+	StaticMaxAge        int            // Cache-Control max-age for /static/ assets in seconds (0 = DefaultStaticMaxAge)
+	StaticImmutable     bool           // add the immutable directive to /static/ Cache-Control (fingerprinted asset names)
+
+	ReadyVerbose bool                                 // /readyz answers with a JSON status body instead of "ready"
+	SecretCount  func(context.Context) (int64, error) // optional live secret count for the verbose /readyz body
+	Started      time.Time                            // process start, for uptime in the verbose /readyz body
+	FlushChunk   int                                  // raw consume bodies are flushed every this many bytes (0 = left to the HTTP stack)
+	RequireHTTPS bool                                 // reject create/consume over plain HTTP (see requireHTTPS)
+	AllowExtend  bool                                 // serve PATCH /api/secret/{id} to reset an unread secret's TTL
+	ReadOnly     bool                                 // freeze the store: creates get 503, consumes and extends 423 (see readOnly)
 }
 
+// DefaultStaticMaxAge is the /static/ max-age, in seconds, when
+// Handler.StaticMaxAge is unset.
+const DefaultStaticMaxAge = 300
+
+// UIMode selects whether the web UI (pages and static assets) is served.
+type UIMode string
+
+// Supported UI modes. API and health endpoints are served in every mode.
+const (
+	UIEnabled  UIMode = "enabled"  // serve index, about, secret pages and assets
+	UIDisabled UIMode = "disabled" // UI routes return 404
+	UIRedirect UIMode = "redirect" // "/" redirects to UIRedirectURL; other UI routes 404
+)
+
+// uiEnabled reports whether the web UI routes should be mounted.
+func (h *Handler) uiEnabled() bool { return h.UI == "" || h.UI == UIEnabled }
+
 // New returns a configured Handler.
 // svc: application service port implementation.
 // maxBody: maximum allowed request body size (0 disables extra check).
@@ -46,37 +99,104 @@ func New(svc ServicePort, maxBody int64, readiness func(context.Context) error)
 }
 
 // Router constructs and returns an http.Handler with all routes mounted and
-// security headers middleware applied.
+// security headers middleware applied. By default every request passes
+// through a probeWriter so that a handler declining a path (writing nothing)
+// falls back to the not-found page. With DirectRouting the "/" catch-all
+// renders that page itself and the per-request wrapper is skipped; every
+// handler must then write a response.
 func (h *Handler) Router() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", h.handleIndex)
-	mux.HandleFunc("/about", h.handleAbout)
-	mux.HandleFunc("/secret/", h.handleSecret) // expect /secret/{id}
-	mux.HandleFunc("/api/secret", h.handleCreateSecret)
-	mux.HandleFunc("/api/secret/", h.handleConsumeSecret) // expect /api/secret/{id}
+	if h.uiEnabled() {
+		// Pages and assets may be gzipped; API routes never are.
+		index := GzipMiddleware(http.HandlerFunc(h.handleIndex))
+		if h.DirectRouting {
+			index = h.catchAll(index)
+		}
+		mux.Handle("/", index)
+		mux.Handle("/about", GzipMiddleware(http.HandlerFunc(h.handleAbout)))
+		mux.Handle("/secret/", GzipMiddleware(http.HandlerFunc(h.handleSecret))) // expect /secret/{id}
+		if h.Assets != nil {
+			mux.Handle("/static/", GzipMiddleware(http.StripPrefix("/static/", h.staticHandler())))
+		}
+	} else {
+		mux.HandleFunc("/", h.handleUIOff)
+	}
+	// One limiter guards creates in every namespace. Latency covers requests
+	// turned away by the limiter too.
+	create := LatencyMiddleware(h.Latency, "latency_create_us", h.limitConcurrency(h.MaxCreates, http.HandlerFunc(h.handleCreateSecret)))
+	consume := LatencyMiddleware(h.Latency, "latency_consume_us", http.HandlerFunc(h.handleConsumeSecret))
+	if h.AllowExtend {
+		consume = h.extendRoute(consume)
+	}
+	if h.ReadOnly {
+		create = h.readOnly(http.StatusServiceUnavailable)
+		consume = h.readOnly(http.StatusLocked)
+	}
+	if h.RequireHTTPS {
+		create = h.requireHTTPS(create)
+		consume = h.requireHTTPS(consume)
+	}
+	mux.Handle("/api/secret", create)
+	mux.Handle("/api/secret/", consume) // expect /api/secret/{id}
 	mux.HandleFunc("/healthz", h.handleHealth)
 	mux.HandleFunc("/readyz", h.handleReady)
-	if h.Assets != nil {
-		mux.Handle("/static/", http.StripPrefix("/static/", h.staticHandler()))
+	mux.HandleFunc("/version", h.handleVersion)
+	mux.HandleFunc("/api/config", h.handleConfig)
+	if h.OpenAPI != nil {
+		mux.HandleFunc("/api/openapi.json", h.handleOpenAPI)
+	}
+	if len(h.Tenants) > 0 {
+		mux.Handle(tenantPrefix, h.tenantHandler(create, consume))
+	}
+	var inner http.Handler = mux
+	if !h.DirectRouting {
+		inner = h.notFoundFallback(mux)
 	}
-	// We can't set a NotFoundHandler on net/http ServeMux; instead wrap the constructed mux
-	// with a fallback that checks for 404 responses after attempting routing.
-	wrapped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if h.Tracer != nil {
+		inner = TracingMiddleware(h.Tracer, inner)
+	}
+	// Order: correlation ID -> client IP -> security headers -> tracing (optional) -> fallback wrapper
+	return h.secureHeaders(correlationIDMiddleware(h.writeError, ClientIPMiddleware(h.TrustedProxies, inner)))
+}
+
+// notFoundFallback wraps next so a request no handler wrote a response for
+// gets the not-found page. We can't set a NotFoundHandler on net/http
+// ServeMux, so the check happens after routing.
+func (h *Handler) notFoundFallback(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Use a ResponseRecorder-like shim to detect if a handler wrote anything.
 		rw := &probeWriter{ResponseWriter: w}
-		mux.ServeHTTP(rw, r)
+		next.ServeHTTP(rw, r)
 		if rw.wroteHeader { // some handler handled it
 			return
 		}
-		// No handler matched: choose JSON vs HTML based on path prefix.
-		if len(r.URL.Path) >= 5 && r.URL.Path[:5] == "/api/" {
-			h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		// No handler matched: JSON vs HTML is negotiated from Accept and path.
+		h.renderErrorPage(w, r, http.StatusNotFound, "Not Found", "The page you requested was not found.")
+	})
+}
+
+// catchAll serves index for "/" and the not-found page for any other path
+// that reaches the "/" route, standing in for notFoundFallback under
+// DirectRouting. The page is rendered outside index so it is never gzipped,
+// matching the fallback.
+func (h *Handler) catchAll(index http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			h.renderErrorPage(w, r, http.StatusNotFound, "Not Found", "The page you requested was not found.")
 			return
 		}
-		h.renderErrorPage(w, r, http.StatusNotFound, "Not Found", "The page you requested was not found.")
+		index.ServeHTTP(w, r)
 	})
-	// Order: correlation ID -> security headers -> fallback wrapper
-	return h.secureHeaders(CorrelationIDMiddleware(wrapped))
+}
+
+// handleUIOff answers every non-API route when the UI is not served: "/" is
+// redirected in UIRedirect mode, everything else is a JSON 404.
+func (h *Handler) handleUIOff(w http.ResponseWriter, r *http.Request) {
+	if h.UI == UIRedirect && r.URL.Path == "/" && h.UIRedirectURL != "" {
+		http.Redirect(w, r, h.UIRedirectURL, http.StatusFound)
+		return
+	}
+	h.writeError(r.Context(), w, http.StatusNotFound, "not found")
 }
 
 // probeWriter records whether a downstream handler wrote headers/body.
@@ -95,11 +215,15 @@ func (p *probeWriter) Write(b []byte) (int, error) {
 	return p.ResponseWriter.Write(b)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (p *probeWriter) Unwrap() http.ResponseWriter { return p.ResponseWriter }
+
 // secureHeaders middleware adds standard security & cache control headers.
 func (h *Handler) secureHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Default: deny everything, then allow only self scripts/styles/images.
-		// Avoid inline scripts/styles to keep a strong CSP.
+		// Avoid inline scripts/styles to keep a strong CSP; with CSPNonce a
+		// fresh nonce per request admits nonce-tagged inline scripts only.
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("Referrer-Policy", "no-referrer")
 		// Cache defaults per route: index handler will override to no-store; static handler sets long-lived.
@@ -107,7 +231,13 @@ func (h *Handler) secureHeaders(next http.Handler) http.Handler {
 			w.Header().Set("Cache-Control", "no-store")
 			w.Header().Set("Pragma", "no-cache")
 		}
-		w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'self'; style-src 'self'; img-src 'self' data:; connect-src 'self'; font-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'self'")
+		var nonce string
+		if h.CSPNonce {
+			var ctx context.Context
+			ctx, nonce = withCSPNonce(r.Context())
+			r = r.WithContext(ctx)
+		}
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy(nonce))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"net/http"
@@ -19,9 +20,13 @@ import (
 type mockService struct {
 	createFn  func(ctx context.Context, ct io.Reader, size int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error)
 	consumeFn func(ctx context.Context, id string) (app.Meta, io.ReadCloser, int64, error)
+	optsFn    func(app.CreateOptions) // optional: observes the create options
 }
 
-func (m mockService) CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration) (domain.SecretID, time.Time, error) {
+func (m mockService) CreateSecret(ctx context.Context, ct io.Reader, size int64, version uint8, nonce string, ttl time.Duration, opts app.CreateOptions) (domain.SecretID, time.Time, error) {
+	if m.optsFn != nil {
+		m.optsFn(opts)
+	}
 	return m.createFn(ctx, ct, size, version, nonce, ttl)
 }
 func (m mockService) Consume(ctx context.Context, idStr string) (app.Meta, io.ReadCloser, int64, error) {
@@ -91,6 +96,9 @@ func TestHandleConsumeSuccess(t *testing.T) {
 	if n := w.Header().Get("X-Gone-Nonce"); n != "n1" {
 		t.Fatalf("nonce header %s", n)
 	}
+	if s := w.Header().Get("X-Gone-Size"); s != "6" {
+		t.Fatalf("size header %s", s)
+	}
 	if !bytes.Equal(w.Body.Bytes(), []byte("cipher")) {
 		t.Fatalf("body mismatch")
 	}
@@ -145,3 +153,22 @@ func TestHandleSecretPage(t *testing.T) {
 		t.Fatalf("content-type %s", ct)
 	}
 }
+
+// BenchmarkRouter compares the probeWriter 404 fallback with DirectRouting on
+// a matched route and an unmatched one.
+func BenchmarkRouter(b *testing.B) {
+	for _, direct := range []bool{false, true} {
+		h := httpx.New(noopService{}, 1024, nil)
+		h.DirectRouting = direct
+		router := h.Router()
+		for _, path := range []string{"/healthz", "/missing"} {
+			b.Run(fmt.Sprintf("direct=%v%s", direct, path), func(b *testing.B) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				b.ReportAllocs()
+				for b.Loop() {
+					router.ServeHTTP(httptest.NewRecorder(), req)
+				}
+			})
+		}
+	}
+}
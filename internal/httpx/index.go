@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/haukened/gone/internal/domain"
@@ -33,13 +34,15 @@ type IndexView struct {
 	TTLOptions    []TTLOptionView
 	MinTTLHuman   string
 	MaxTTLHuman   string
+	TTLRange      bool   // render a free-form TTL input instead of the presets
+	CSPNonce      string // script nonce for inline <script nonce> tags (empty unless Handler.CSPNonce)
 }
 
 // TTLOptionView is the subset of a domain TTLOption needed by the template.
 // DurationSeconds is provided for potential client-side scripting.
 type TTLOptionView struct {
-	Label           string
-	DurationSeconds int
+	Label           string `json:"label"`
+	DurationSeconds int    `json:"seconds"`
 }
 
 func humanBytes(n int64) string {
@@ -88,33 +91,57 @@ func (h *Handler) handleIndex(w http.ResponseWriter, r *http.Request) {
 		MaxBytesHuman: humanBytes(h.MaxBody),
 		MinTTLSeconds: int(h.MinTTL.Seconds()),
 		MaxTTLSeconds: int(h.MaxTTL.Seconds()),
+		TTLRange:      h.TTLRange,
+		CSPNonce:      GetCSPNonce(r.Context()),
 	}
 	view.MinTTLHuman = humanTTL(view.MinTTLSeconds)
 	view.MaxTTLHuman = humanTTL(view.MaxTTLSeconds)
-	if len(h.TTLOptions) > 0 {
-		// copy then sort descending by duration so longest appears first (default selected)
-		tmp := make([]domain.TTLOption, len(h.TTLOptions))
-		copy(tmp, h.TTLOptions)
-		sort.Slice(tmp, func(i, j int) bool { return tmp[i].Duration > tmp[j].Duration })
-		view.TTLOptions = make([]TTLOptionView, 0, len(tmp))
-		for _, opt := range tmp {
-			view.TTLOptions = append(view.TTLOptions, TTLOptionView{Label: opt.Label, DurationSeconds: int(opt.Duration.Seconds())})
-		}
-	}
+	view.TTLOptions = h.ttlOptionViews()
 	renderTemplate(w, h.IndexTmpl, view)
 }
 
+// ttlOptionViews returns the configured TTL options sorted longest first (the
+// default selection), or nil when none are configured.
+func (h *Handler) ttlOptionViews() []TTLOptionView {
+	if len(h.TTLOptions) == 0 {
+		return nil
+	}
+	tmp := make([]domain.TTLOption, len(h.TTLOptions))
+	copy(tmp, h.TTLOptions)
+	sort.Slice(tmp, func(i, j int) bool { return tmp[i].Duration > tmp[j].Duration })
+	views := make([]TTLOptionView, 0, len(tmp))
+	for _, opt := range tmp {
+		views = append(views, TTLOptionView{Label: opt.Label, DurationSeconds: int(opt.Duration.Seconds())})
+	}
+	return views
+}
+
 // staticHandler serves embedded/static assets under /static/.
 func (h *Handler) staticHandler() http.Handler {
 	fs := h.Assets
+	cacheControl := h.staticCacheControl()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Prevent directory listings; require a file with extension
 		if strings.HasSuffix(r.URL.Path, "/") || path.Ext(r.URL.Path) == "" {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-		// Long-lived caching; caller can fingerprint filenames later.
-		w.Header().Set("Cache-Control", "public, max-age=300")
+		// Long-lived caching; operators with fingerprinted filenames can
+		// raise the max-age and mark assets immutable.
+		w.Header().Set("Cache-Control", cacheControl)
 		http.FileServer(fs).ServeHTTP(w, r)
 	})
 }
+
+// staticCacheControl returns the Cache-Control value for static assets.
+func (h *Handler) staticCacheControl() string {
+	maxAge := h.StaticMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultStaticMaxAge
+	}
+	cc := "public, max-age=" + strconv.Itoa(maxAge)
+	if h.StaticImmutable {
+		cc += ", immutable"
+	}
+	return cc
+}
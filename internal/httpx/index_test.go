@@ -20,7 +20,7 @@ import (
 
 type noopService struct{}
 
-func (noopService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+func (noopService) CreateSecret(_ context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
 	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Now().Add(time.Hour), nil
 }
 func (noopService) Consume(_ context.Context, _ string) (app.Meta, io.ReadCloser, int64, error) {
@@ -80,6 +80,41 @@ func TestStaticHandler(t *testing.T) {
 	}
 }
 
+// TestStaticCacheControl checks the configured max-age and immutable
+// directive reach static asset responses.
+func TestStaticCacheControl(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.3f9a.js"), []byte("ok"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		maxAge    int
+		immutable bool
+		want      string
+	}{
+		{name: "default", want: "public, max-age=300"},
+		{name: "configured", maxAge: 31536000, want: "public, max-age=31536000"},
+		{name: "immutable", maxAge: 31536000, immutable: true, want: "public, max-age=31536000, immutable"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := httpx.New(noopService{}, 100, nil)
+			h.Assets = http.FS(os.DirFS(dir))
+			h.StaticMaxAge = tc.maxAge
+			h.StaticImmutable = tc.immutable
+			w := httptest.NewRecorder()
+			h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/app.3f9a.js", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d", w.Code)
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != tc.want {
+				t.Fatalf("Cache-Control = %q, want %q", cc, tc.want)
+			}
+		})
+	}
+}
+
 // TestNotFoundHTML ensures non-API unknown routes return an HTML 404 page (not JSON).
 func TestNotFoundHTML(t *testing.T) {
 	indexTmpl := template.Must(template.New("index").Parse(`<html><body>Index</body></html>`))
@@ -0,0 +1,78 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// summaryRecorder is an app.Observer keeping count and sum per name.
+type summaryRecorder struct {
+	mu    sync.Mutex
+	count map[string]int64
+	sum   map[string]int64
+}
+
+func (s *summaryRecorder) Observe(name string, v int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count[name]++
+	s.sum[name] += v
+}
+
+func TestLatencySummaries(t *testing.T) {
+	svc := mockService{
+		createFn: func(context.Context, io.Reader, int64, uint8, string, time.Duration) (domain.SecretID, time.Time, error) {
+			time.Sleep(time.Millisecond)
+			return domain.SecretID("0123456789abcdef0123456789abcdef"), time.Now().Add(time.Hour), nil
+		},
+		consumeFn: func(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+			time.Sleep(time.Millisecond)
+			return app.Meta{}, nil, 0, app.ErrNotFound
+		},
+	}
+	rec := &summaryRecorder{count: map[string]int64{}, sum: map[string]int64{}}
+	h := httpx.New(svc, 1024, nil)
+	h.Latency = rec
+	h.Tenants = []domain.Tenant{{Name: "acme"}}
+	router := h.Router()
+
+	for _, prefix := range []string{"", "/t/acme"} {
+		req := httptest.NewRequest(http.MethodPost, prefix+"/api/secret", strings.NewReader("cipher"))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %q: status=%d", prefix, w.Code)
+		}
+		// Error paths are timed too.
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, prefix+"/api/secret/0123456789abcdef0123456789abcdef", nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("consume %q: status=%d", prefix, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	for _, name := range []string{"latency_create_us", "latency_consume_us"} {
+		if rec.count[name] != 2 || rec.sum[name] <= 0 {
+			t.Fatalf("%s: count=%d sum=%d", name, rec.count[name], rec.sum[name])
+		}
+	}
+	if len(rec.count) != 2 {
+		t.Fatalf("unexpected summaries: %v", rec.count)
+	}
+}
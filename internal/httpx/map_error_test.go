@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/haukened/gone/internal/app"
 	"github.com/haukened/gone/internal/domain"
@@ -23,6 +25,14 @@ func TestMapServiceError(t *testing.T) {
 		{"invalid id", domain.ErrInvalidID, http.StatusBadRequest, "invalid id"},
 		{"size exceeded", app.ErrSizeExceeded, http.StatusRequestEntityTooLarge, "size exceeded"},
 		{"not found", app.ErrNotFound, http.StatusNotFound, "not found"},
+		{"storage full", app.ErrStorageFull, http.StatusInsufficientStorage, "insufficient storage"},
+		{"quota exceeded", app.ErrQuotaExceeded, http.StatusTooManyRequests, "quota exceeded"},
+		{"duplicate id", app.ErrDuplicateID, http.StatusConflict, "id exists"},
+		{"client ids disabled", app.ErrClientIDNotAllowed, http.StatusBadRequest, "client ids disabled"},
+		{"too small", app.ErrTooSmall, http.StatusBadRequest, "size too small"},
+		{"version unsupported", app.ErrVersionUnsupported, http.StatusBadRequest, "unsupported version"},
+		{"not before invalid", app.ErrNotBeforeInvalid, http.StatusBadRequest, "invalid not before"},
+		{"too early", app.ErrTooEarly, http.StatusTooEarly, "too early"},
 		{"ttl invalid", domain.ErrTTLInvalid, http.StatusBadRequest, "ttl invalid"},
 		{"os not exist", os.ErrNotExist, http.StatusNotFound, "not found"},
 		{"internal default", errors.New("boom"), http.StatusInternalServerError, "internal"},
@@ -60,3 +70,15 @@ func indexOf(haystack, needle string) int {
 	}
 	return -1
 }
+
+func TestQuotaRetryAfter(t *testing.T) {
+	h := &Handler{}
+	w := httptest.NewRecorder()
+	h.mapServiceError(context.Background(), w, &app.QuotaExceededError{RetryAfter: 61*time.Minute + 500*time.Millisecond})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != strconv.Itoa(61*60+1) {
+		t.Fatalf("Retry-After = %q", got)
+	}
+}
@@ -3,16 +3,12 @@ package httpx
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
-)
-
-// correlationIDCtxKey is the unexported context key type to avoid collisions.
-// We intentionally use a private struct{} key rather than a string to prevent
-// accidental overwrites from other packages.
-type correlationIDCtxKey struct{}
 
-var cidKey = correlationIDCtxKey{}
+	"github.com/haukened/gone/internal/app"
+)
 
 // CorrelationIDHeader is the HTTP header used for inbound/outbound correlation IDs.
 const CorrelationIDHeader = "X-Correlation-ID"
@@ -23,29 +19,38 @@ const CorrelationIDHeader = "X-Correlation-ID"
 // rejected with HTTP 400 and the chain is not continued. Downstream handlers can
 // retrieve the value via GetCorrelationID.
 func CorrelationIDMiddleware(next http.Handler) http.Handler {
+	return correlationIDMiddleware(writeJSONError, next)
+}
+
+// correlationIDMiddleware is CorrelationIDMiddleware with the invalid-ID
+// response written by writeErr, so the Handler can echo the generated ID in
+// the body.
+func correlationIDMiddleware(writeErr func(context.Context, http.ResponseWriter, int, string), next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cid := r.Header.Get(CorrelationIDHeader)
 		cid, ok := sanitizeCorrelationID(cid)
 		if !ok {
 			// Generate a fresh correlation ID for this error response so logs remain traceable.
 			generated := uuid.New().String()
-			ctx := context.WithValue(r.Context(), cidKey, generated)
+			ctx := app.WithCorrelationID(r.Context(), generated)
 			w.Header().Set(CorrelationIDHeader, generated)
-			writeJSONError(ctx, w, http.StatusBadRequest, "invalid correlation id")
+			writeErr(ctx, w, http.StatusBadRequest, "invalid correlation id")
 			return
 		}
-		// Store the CID in the request context for downstream handlers.
-		ctx := context.WithValue(r.Context(), cidKey, cid)
+		// Store the CID in the request context for downstream handlers and
+		// the core (audit events).
+		ctx := app.WithCorrelationID(r.Context(), cid)
 		w.Header().Set(CorrelationIDHeader, cid)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// GetCorrelationID extracts the correlation ID from the context. The second
-// boolean return reports whether a value was present.
+// GetCorrelationID extracts the correlation ID stored by
+// CorrelationIDMiddleware (see app.WithCorrelationID). The second boolean
+// return reports whether a value was present.
 func GetCorrelationID(ctx context.Context) (string, bool) {
-	id, ok := ctx.Value(cidKey).(string)
-	return id, ok
+	id := app.CorrelationIDFromContext(ctx)
+	return id, id != ""
 }
 
 // sanitizeCorrelationID validates and canonicalizes an inbound correlation ID.
@@ -66,3 +71,64 @@ func sanitizeCorrelationID(cid string) (string, bool) {
 	}
 	return uid.String(), true
 }
+
+// LatencyMiddleware records how long next takes to serve each request, in
+// microseconds, as a summary observation under name. Every outcome counts,
+// including error responses. A nil obs disables the measurement.
+func LatencyMiddleware(obs app.Observer, name string, next http.Handler) http.Handler {
+	if obs == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		defer func() { obs.Observe(name, time.Since(start).Microseconds()) }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireHTTPS rejects requests that reached the service over plain HTTP with
+// 403, so a listener exposed without TLS by mistake never carries ciphertext.
+// The scheme comes from RequestScheme: X-Forwarded-Proto counts only from one
+// of h.TrustedProxies, and an untrusted peer is treated as plain HTTP.
+func (h *Handler) requireHTTPS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RequestScheme(r, h.TrustedProxies) != "https" {
+			h.writeError(r.Context(), w, http.StatusForbidden, "https required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readOnly answers every request with status and a "read only" error. Router
+// mounts it in place of the create and consume routes while the service is
+// frozen, so nothing is created, consumed or extended; secret page probes do
+// not go through those routes and keep working.
+func (h *Handler) readOnly(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.writeError(r.Context(), w, status, "read only")
+	})
+}
+
+// limitConcurrency admits at most limit requests to next at once.
+// Requests arriving while every slot is taken are rejected immediately with
+// 503 and Retry-After rather than queued, so a burst of large uploads cannot
+// exhaust memory or file handles. A slot is held until next returns. A limit
+// of zero or less disables the check.
+func (h *Handler) limitConcurrency(limit int, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+	slots := make(chan struct{}, limit)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			w.Header().Set("Retry-After", "1")
+			h.writeError(r.Context(), w, http.StatusServiceUnavailable, "busy")
+			return
+		}
+		defer func() { <-slots }()
+		next.ServeHTTP(w, r)
+	})
+}
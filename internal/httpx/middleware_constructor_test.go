@@ -13,7 +13,7 @@ import (
 
 type ctorService struct{}
 
-func (ctorService) CreateSecret(context.Context, io.Reader, int64, uint8, string, time.Duration) (domain.SecretID, time.Time, error) {
+func (ctorService) CreateSecret(context.Context, io.Reader, int64, uint8, string, time.Duration, app.CreateOptions) (domain.SecretID, time.Time, error) {
 	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Now(), nil
 }
 func (ctorService) Consume(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
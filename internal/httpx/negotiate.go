@@ -0,0 +1,94 @@
+package httpx
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// wantsJSON decides whether an error response for r should be JSON rather
+// than an HTML page. An Accept header that prefers application/json over
+// text/html (or vice versa) wins; when it expresses no preference between
+// the two (absent, */*, or equal weights) the path decides: /api/ routes get
+// JSON, everything else HTML.
+func wantsJSON(r *http.Request) bool {
+	qJSON, qHTML := acceptQuality(r.Header.Get("Accept"))
+	switch {
+	case qJSON > qHTML:
+		return true
+	case qHTML > qJSON:
+		return false
+	default:
+		return strings.HasPrefix(r.URL.Path, "/api/")
+	}
+}
+
+// acceptQuality returns the q-values the Accept header assigns to JSON and
+// HTML. Exact media types take precedence over type wildcards (application/*,
+// text/*); */* is ignored since it expresses no preference.
+func acceptQuality(accept string) (qJSON, qHTML float64) {
+	var wildJSON, wildHTML float64
+	var exactJSON, exactHTML bool
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := qValue(params)
+		switch mt {
+		case "application/json":
+			qJSON, exactJSON = q, true
+		case "text/html":
+			qHTML, exactHTML = q, true
+		case "application/*":
+			wildJSON = q
+		case "text/*":
+			wildHTML = q
+		}
+	}
+	if !exactJSON {
+		qJSON = wildJSON
+	}
+	if !exactHTML {
+		qHTML = wildHTML
+	}
+	return qJSON, qHTML
+}
+
+// wantsConsumeJSON reports whether a consume request asked for the JSON
+// envelope: Accept must name application/json with a higher q-value than
+// application/octet-stream gets, directly or through application/* or */*.
+// Ties keep the raw body, so existing clients are unaffected.
+func wantsConsumeJSON(r *http.Request) bool {
+	var qJSON, qRaw, qWild float64
+	exactRaw := false
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mt {
+		case "application/json":
+			qJSON = qValue(params)
+		case "application/octet-stream":
+			qRaw, exactRaw = qValue(params), true
+		case "application/*", "*/*":
+			qWild = max(qWild, qValue(params))
+		}
+	}
+	if !exactRaw {
+		qRaw = qWild
+	}
+	return qJSON > qRaw
+}
+
+// qValue returns the q parameter of an Accept entry, defaulting to 1.
+func qValue(params map[string]string) float64 {
+	if v, ok := params["q"]; ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return 1.0
+}
@@ -0,0 +1,87 @@
+package httpx
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWantsJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   bool
+	}{
+		{name: "api no accept", path: "/api/nope", want: true},
+		{name: "page no accept", path: "/nope", want: false},
+		{name: "api any", path: "/api/nope", accept: "*/*", want: true},
+		{name: "page any", path: "/nope", accept: "*/*", want: false},
+		{name: "page json", path: "/nope", accept: "application/json", want: true},
+		{name: "api html", path: "/api/nope", accept: "text/html", want: false},
+		{name: "browser on page", path: "/nope", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: false},
+		{name: "json weighted higher", path: "/nope", accept: "text/html;q=0.5, application/json", want: true},
+		{name: "html weighted higher", path: "/api/nope", accept: "application/json;q=0.2, text/html;q=0.9", want: false},
+		{name: "equal weights fall back to path", path: "/nope", accept: "application/json, text/html", want: false},
+		{name: "type wildcard", path: "/nope", accept: "application/*", want: true},
+		{name: "exact beats wildcard", path: "/nope", accept: "application/*, application/json;q=0", want: false},
+		{name: "malformed ignored", path: "/api/nope", accept: ";;;", want: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			if got := wantsJSON(r); got != tc.want {
+				t.Fatalf("wantsJSON=%v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestRouterNotFoundNegotiation checks the 404 fallback honours Accept on
+// both API and page paths, with and without DirectRouting.
+func TestRouterNotFoundNegotiation(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		t.Run(fmt.Sprintf("direct=%v", direct), func(t *testing.T) {
+			testRouterNotFoundNegotiation(t, direct)
+		})
+	}
+}
+
+func testRouterNotFoundNegotiation(t *testing.T, direct bool) {
+	h := &Handler{ErrorTmpl: TemplateRenderer{T: template.Must(template.New("e").Parse("<html>{{.Title}}</html>"))}, DirectRouting: direct}
+	router := h.Router()
+	tests := []struct {
+		path, accept, wantCT string
+	}{
+		{path: "/api/nope", wantCT: "application/json"},
+		{path: "/api/nope", accept: "text/html", wantCT: "text/html"},
+		{path: "/missing", wantCT: "text/html"},
+		{path: "/missing", accept: "application/json", wantCT: "application/json"},
+		{path: "/about/extra", accept: "application/json", wantCT: "application/json"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path+" "+tc.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusNotFound {
+				t.Fatalf("status %d want 404", rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, tc.wantCT) {
+				t.Fatalf("content-type %q want %s", ct, tc.wantCT)
+			}
+			if tc.wantCT == "application/json" && !strings.Contains(rr.Body.String(), `"not found"`) {
+				t.Fatalf("unexpected JSON body %s", rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleOpenAPI implements GET /api/openapi.json, serving the pre-rendered
+// OpenAPI document in Handler.OpenAPI. Like build info it is immutable for
+// the life of the process, so it may be cached briefly.
+func (h *Handler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.writeError(r.Context(), w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(h.OpenAPI)))
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Del("Pragma")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(h.OpenAPI)
+	}
+}
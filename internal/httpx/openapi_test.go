@@ -0,0 +1,56 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haukened/gone/docs"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	spec, err := docs.OpenAPIJSON()
+	if err != nil {
+		t.Fatalf("OpenAPIJSON: %v", err)
+	}
+	h := httpx.New(noopService{}, 100, nil)
+	h.OpenAPI = spec
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content-type %q", ct)
+	}
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.OpenAPI == "" {
+		t.Fatalf("missing openapi version")
+	}
+	for path, method := range map[string]string{"/api/secret": "post", "/api/secret/{id}": "get", "/api/openapi.json": "get"} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Fatalf("spec missing %s %s", method, path)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/openapi.json", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status %d", w.Code)
+	}
+
+	// Without a document the route is not mounted.
+	w = httptest.NewRecorder()
+	httpx.New(noopService{}, 100, nil).Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unset spec status %d", w.Code)
+	}
+}
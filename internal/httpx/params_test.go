@@ -0,0 +1,87 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestSecretParamsPassthrough checks X-Gone-Params reaches the service on
+// create, is returned on raw and JSON consume, is absent when never set, and
+// is rejected when malformed or oversized, including on raw consume of a
+// stored value that never went through create.
+func TestSecretParamsPassthrough(t *testing.T) {
+	var stored string
+	m := mockService{
+		optsFn: func(o app.CreateOptions) { stored = o.Params },
+		createFn: func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+			_, _ = io.ReadAll(ct)
+			return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+		},
+		consumeFn: func(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+			return app.Meta{Version: 1, NonceB64u: "n1", Params: stored}, io.NopCloser(strings.NewReader("cipher")), 6, nil
+		},
+	}
+	h := httpx.New(m, 1024, nil).Router()
+	create := func(params string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher")))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		if params != "" {
+			req.Header.Set("X-Gone-Params", params)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	consume := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, params := range []string{"eyJrZGYiOiJhcmdvbjJpZCJ9", ""} {
+		if w := create(params); w.Code != http.StatusCreated {
+			t.Fatalf("create %q: status=%d", params, w.Code)
+		}
+		if stored != params {
+			t.Fatalf("service got params %q, want %q", stored, params)
+		}
+		w := consume("application/octet-stream")
+		if got, ok := w.Header()["X-Gone-Params"]; params == "" && ok || params != "" && (len(got) != 1 || got[0] != params) {
+			t.Fatalf("raw consume X-Gone-Params = %v, want %q", got, params)
+		}
+		var env map[string]any
+		if err := json.Unmarshal(consume("application/json").Body.Bytes(), &env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got, ok := env["params"]; params == "" && ok || params != "" && got != params {
+			t.Fatalf("json consume params = %v, want %q", got, params)
+		}
+	}
+
+	for _, bad := range []string{"not base64!", "a/b+c", strings.Repeat("A", 1025)} {
+		if w := create(bad); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid params") {
+			t.Fatalf("params %.20q: status=%d body=%s", bad, w.Code, w.Body.String())
+		}
+	}
+
+	stored = "a\r\nSet-Cookie: x=1"
+	if w := consume("application/octet-stream"); w.Code != http.StatusOK || w.Header().Get("X-Gone-Params") != "" {
+		t.Fatalf("unchecked stored params: status=%d X-Gone-Params=%q", w.Code, w.Header().Get("X-Gone-Params"))
+	}
+}
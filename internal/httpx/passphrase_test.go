@@ -0,0 +1,94 @@
+package httpx_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// TestPassphraseGatedConsume creates a gated secret and checks that wrong or
+// missing passphrases get 403 without consuming it, repeated failures lock
+// it with 429, and the right passphrase (on a fresh secret) returns it once.
+func TestPassphraseGatedConsume(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "pp.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("sqlite: %v", err)
+	}
+	bs, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("blobs: %v", err)
+	}
+	clk := &stepClock{now: time.Now().UTC()}
+	svc := &app.Service{Store: store.New(ix, bs, clk, 64), Clock: clk, MaxBytes: 1024, MinTTL: time.Minute, MaxTTL: time.Hour, PassphraseAttempts: 2}
+	router := httpx.New(svc, 1024, nil).Router()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+
+	create := func(passHash string) string {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", strings.NewReader("abc"))
+		req.Header.Set("Content-Length", "3")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n")
+		req.Header.Set("X-Gone-TTL", "5m")
+		req.Header.Set("X-Gone-Passphrase-Hash", passHash)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			return w.Body.String()
+		}
+		var out struct{ ID string }
+		_ = json.NewDecoder(w.Body).Decode(&out)
+		return out.ID
+	}
+	consume := func(id, passphrase string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/"+id, nil)
+		if passphrase != "" {
+			req.Header.Set("X-Gone-Passphrase", passphrase)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if body := create("plaintext"); !strings.Contains(body, "invalid passphrase hash") {
+		t.Fatalf("expected invalid hash rejection, got %s", body)
+	}
+
+	id := create(string(hash))
+	if code := consume(id, ""); code != http.StatusForbidden {
+		t.Fatalf("missing passphrase: got %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := consume(id, "wrong"); code != http.StatusForbidden {
+			t.Fatalf("wrong passphrase %d: got %d", i, code)
+		}
+	}
+	if code := consume(id, "hunter2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected lockout, got %d", code)
+	}
+
+	id = create(string(hash))
+	if code := consume(id, "hunter2"); code != http.StatusOK {
+		t.Fatalf("correct passphrase: got %d", code)
+	}
+	if code := consume(id, "hunter2"); code != http.StatusNotFound {
+		t.Fatalf("second read should be gone, got %d", code)
+	}
+}
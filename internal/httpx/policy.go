@@ -0,0 +1,46 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// PolicyView is the JSON body of GET /api/config: the create limits a client
+// needs to configure itself, mirroring what the index page renders.
+type PolicyView struct {
+	MaxBytes      int64           `json:"max_bytes"`
+	MinTTLSeconds int             `json:"min_ttl_seconds"`
+	MaxTTLSeconds int             `json:"max_ttl_seconds"`
+	MinTTLLabel   string          `json:"min_ttl_label"`
+	MaxTTLLabel   string          `json:"max_ttl_label"`
+	TTLRange      bool            `json:"ttl_range"`   // any TTL in [min,max] is offered, not only ttl_options
+	TTLOptions    []TTLOptionView `json:"ttl_options"` // longest first; empty when none are configured
+}
+
+// handleConfig implements GET /api/config. The policy is public and fixed for
+// the life of the process, so it may be cached briefly.
+func (h *Handler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.writeError(r.Context(), w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	view := PolicyView{
+		MaxBytes:      h.MaxBody,
+		MinTTLSeconds: int(h.MinTTL.Seconds()),
+		MaxTTLSeconds: int(h.MaxTTL.Seconds()),
+		TTLRange:      h.TTLRange,
+		TTLOptions:    h.ttlOptionViews(),
+	}
+	view.MinTTLLabel = humanTTL(view.MinTTLSeconds)
+	view.MaxTTLLabel = humanTTL(view.MaxTTLSeconds)
+	if view.TTLOptions == nil {
+		view.TTLOptions = []TTLOptionView{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Del("Pragma")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_ = json.NewEncoder(w).Encode(view)
+	}
+}
@@ -0,0 +1,59 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+func TestConfigEndpoint(t *testing.T) {
+	h := httpx.New(noopService{}, 2048, nil)
+	h.MinTTL = 5 * time.Minute
+	h.MaxTTL = 24 * time.Hour
+	h.TTLRange = true
+	for _, l := range []string{"1h", "24h", "5m"} {
+		opt, err := domain.NewTTLOption(l)
+		if err != nil {
+			t.Fatalf("ttl option: %v", err)
+		}
+		h.TTLOptions = append(h.TTLOptions, opt)
+	}
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatalf("cache-control %q", cc)
+	}
+	var got httpx.PolicyView
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.MaxBytes != 2048 || got.MinTTLSeconds != 300 || got.MaxTTLSeconds != 86400 || !got.TTLRange {
+		t.Fatalf("limits mismatch: %+v", got)
+	}
+	if got.MinTTLLabel != "5m" || got.MaxTTLLabel != "24h" {
+		t.Fatalf("labels %q %q", got.MinTTLLabel, got.MaxTTLLabel)
+	}
+	want := []httpx.TTLOptionView{{Label: "24h", DurationSeconds: 86400}, {Label: "1h", DurationSeconds: 3600}, {Label: "5m", DurationSeconds: 300}}
+	if len(got.TTLOptions) != len(want) {
+		t.Fatalf("ttl options %+v", got.TTLOptions)
+	}
+	for i := range want {
+		if got.TTLOptions[i] != want[i] {
+			t.Fatalf("option %d got %+v want %+v", i, got.TTLOptions[i], want[i])
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/config", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status %d", w.Code)
+	}
+}
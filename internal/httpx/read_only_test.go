@@ -0,0 +1,88 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// probeService adds httpx.SecretProber to mockService.
+type probeService struct {
+	mockService
+	live map[string]bool
+}
+
+func (p probeService) Exists(_ context.Context, id string) (bool, error) {
+	return p.live[id], nil
+}
+
+// viewRecorder records the data handed to the secret page template.
+type viewRecorder struct{ got *httpx.SecretView }
+
+func (v viewRecorder) Execute(_ http.ResponseWriter, data any) error {
+	*v.got = data.(httpx.SecretView)
+	return nil
+}
+
+// TestReadOnly checks create, consume and extend are refused without reaching
+// the service while the secret page probe still reports existence.
+func TestReadOnly(t *testing.T) {
+	const live = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	called := false
+	svc := probeService{live: map[string]bool{live: true}}
+	svc.createFn = func(context.Context, io.Reader, int64, uint8, string, time.Duration) (domain.SecretID, time.Time, error) {
+		called = true
+		return live, time.Now(), nil
+	}
+	svc.consumeFn = func(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+		called = true
+		return app.Meta{}, io.NopCloser(strings.NewReader("")), 0, nil
+	}
+	var view httpx.SecretView
+	h := httpx.New(svc, 1024, nil)
+	h.ReadOnly, h.AllowExtend, h.RevealHints = true, true, true
+	h.SecretTmpl = viewRecorder{got: &view}
+	router := h.Router()
+
+	create := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher")))
+	create.Header.Set("Content-Length", "6")
+	create.Header.Set("X-Gone-Version", "1")
+	create.Header.Set("X-Gone-Nonce", "n1")
+	create.Header.Set("X-Gone-TTL", "5m")
+	extend := httptest.NewRequest(http.MethodPatch, "/api/secret/"+live, nil)
+	extend.Header.Set("X-Gone-TTL", "5m")
+	for name, tc := range map[string]struct {
+		req  *http.Request
+		want int
+	}{
+		"create":  {create, http.StatusServiceUnavailable},
+		"consume": {httptest.NewRequest(http.MethodGet, "/api/secret/"+live, nil), http.StatusLocked},
+		"extend":  {extend, http.StatusLocked},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, tc.req)
+		if w.Code != tc.want || !strings.Contains(w.Body.String(), "read only") {
+			t.Fatalf("%s: status %d body %q, want %d", name, w.Code, w.Body.String(), tc.want)
+		}
+	}
+	if called {
+		t.Fatal("service called in read-only mode")
+	}
+
+	for id, hint := range map[string]string{live: "", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": httpx.HintUnavailable} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secret/"+id, nil))
+		if w.Code != http.StatusOK || view.Hint != hint {
+			t.Fatalf("secret page %s: status %d hint %q, want %q", id, w.Code, view.Hint, hint)
+		}
+	}
+}
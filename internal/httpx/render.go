@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
 // errorPageData supplies fields for the generic error template.
@@ -42,9 +43,14 @@ func renderTemplate(w http.ResponseWriter, tmpl interface {
 	execAndWriteTemplate(w, tmpl, data, http.StatusOK)
 }
 
-// renderErrorPage renders an HTML error page if an error template is configured; otherwise
-// falls back to plain text. It intentionally does not include correlation IDs in the body.
-func (h *Handler) renderErrorPage(w http.ResponseWriter, _ *http.Request, status int, title, message string) {
+// renderErrorPage writes an error response negotiated by wantsJSON: a JSON
+// error body for API clients, otherwise the HTML error page if an error
+// template is configured, falling back to a plain text status.
+func (h *Handler) renderErrorPage(w http.ResponseWriter, r *http.Request, status int, title, message string) {
+	if wantsJSON(r) {
+		h.writeError(r.Context(), w, status, strings.ToLower(http.StatusText(status)))
+		return
+	}
 	if h.ErrorTmpl == nil {
 		writePlainStatus(w, status)
 		return
@@ -0,0 +1,92 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestEchoRequestID checks create, JSON consume and error bodies, including
+// those written by the read-only and HTTPS guards and the invalid correlation
+// ID rejection, carry request_id equal to the X-Correlation-ID header only
+// when EchoRequestID is set.
+func TestEchoRequestID(t *testing.T) {
+	create := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher")))
+		req.Header.Set("Content-Length", "6")
+		req.Header.Set("X-Gone-Version", "1")
+		req.Header.Set("X-Gone-Nonce", "n1")
+		req.Header.Set("X-Gone-TTL", "5m")
+		return req
+	}
+	consume := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+		req.Header.Set("Accept", "application/json")
+		return req
+	}
+	badTTL := func() *http.Request {
+		req := create()
+		req.Header.Set("X-Gone-TTL", "bogus")
+		return req
+	}
+	badCID := func() *http.Request {
+		req := consume()
+		req.Header.Set(httpx.CorrelationIDHeader, "abc123")
+		return req
+	}
+	for _, echo := range []bool{false, true} {
+		svc := onceService([]byte("cipher"), new(int))
+		svc.createFn = func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+			_, _ = io.ReadAll(ct)
+			return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+		}
+		h := httpx.New(svc, 1024, nil)
+		h.EchoRequestID = echo
+		router := h.Router()
+		h.ReadOnly = true
+		readOnly := h.Router()
+		h.ReadOnly, h.RequireHTTPS = false, true
+		httpsOnly := h.Router()
+		for name, tc := range map[string]struct {
+			router http.Handler
+			req    func() *http.Request
+			code   int
+		}{
+			"create":    {router, create, http.StatusCreated},
+			"consume":   {router, consume, http.StatusOK},
+			"error":     {router, badTTL, http.StatusBadRequest},
+			"read only": {readOnly, consume, http.StatusLocked},
+			"https":     {httpsOnly, create, http.StatusForbidden},
+			"bad cid":   {router, badCID, http.StatusBadRequest},
+		} {
+			w := httptest.NewRecorder()
+			tc.router.ServeHTTP(w, tc.req())
+			if w.Code != tc.code {
+				t.Fatalf("%s: status %d: %s", name, w.Code, w.Body.String())
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%s: decode: %v", name, err)
+			}
+			got, present := body["request_id"]
+			if !echo {
+				if present {
+					t.Fatalf("%s: request_id = %v with echo off", name, got)
+				}
+				continue
+			}
+			cid := w.Header().Get(httpx.CorrelationIDHeader)
+			if cid == "" || got != cid {
+				t.Fatalf("%s: request_id = %v, header %q", name, got, cid)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestRequireHTTPS checks create and consume are refused over plain HTTP and
+// that X-Forwarded-Proto is only believed from a trusted proxy.
+func TestRequireHTTPS(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	for _, tc := range []struct {
+		name    string
+		require bool
+		peer    string
+		proto   string
+		tls     bool
+		want    int
+	}{
+		{"trusted proxy https", true, "10.0.0.1:1234", "https", false, http.StatusOK},
+		{"trusted proxy http", true, "10.0.0.1:1234", "http", false, http.StatusForbidden},
+		{"direct plain", true, "203.0.113.5:1234", "", false, http.StatusForbidden},
+		{"untrusted forwarded https", true, "203.0.113.5:1234", "https", false, http.StatusForbidden},
+		{"direct tls", true, "203.0.113.5:1234", "", true, http.StatusOK},
+		{"off", false, "203.0.113.5:1234", "", false, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			created := false
+			svc := onceService([]byte("cipher"), new(int))
+			svc.createFn = func(_ context.Context, ct io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+				created = true
+				_, _ = io.ReadAll(ct)
+				return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(1000, 0).UTC(), nil
+			}
+			h := httpx.New(svc, 1024, nil)
+			h.RequireHTTPS = tc.require
+			h.TrustedProxies = trusted
+			router := h.Router()
+			prepare := func(req *http.Request) *http.Request {
+				req.RemoteAddr = tc.peer
+				if tc.proto != "" {
+					req.Header.Set("X-Forwarded-Proto", tc.proto)
+				}
+				if tc.tls {
+					req.TLS = &tls.ConnectionState{}
+				}
+				return req
+			}
+
+			req := prepare(httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader([]byte("cipher"))))
+			req.Header.Set("Content-Length", "6")
+			req.Header.Set("X-Gone-Version", "1")
+			req.Header.Set("X-Gone-Nonce", "n1")
+			req.Header.Set("X-Gone-TTL", "5m")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			wantCreate := http.StatusCreated
+			if tc.want == http.StatusForbidden {
+				wantCreate = http.StatusForbidden
+			}
+			if w.Code != wantCreate || created != (wantCreate == http.StatusCreated) {
+				t.Fatalf("create: status %d, service called %v", w.Code, created)
+			}
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, prepare(httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)))
+			if w.Code != tc.want {
+				t.Fatalf("consume: status %d, want %d", w.Code, tc.want)
+			}
+			if tc.want == http.StatusForbidden && !strings.Contains(w.Body.String(), "https required") {
+				t.Fatalf("consume body %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package httpx
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+
+	"github.com/haukened/gone/internal/domain"
 )
 
 // SecretRenderer abstracts template execution for the secret consumption page.
@@ -11,13 +15,33 @@ type SecretRenderer interface {
 	Execute(w http.ResponseWriter, data any) error
 }
 
+// SecretProber is optionally implemented by the ServicePort (as *app.Service
+// does) to check for a secret without consuming it.
+type SecretProber interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// Secret page hints surfaced when Handler.RevealHints is set.
+const (
+	HintMalformed   = "malformed"   // the ID in the link is not a valid secret ID
+	HintUnavailable = "unavailable" // well-formed, but expired, consumed or never created
+)
+
+// SecretView is the data passed to the secret page template. Hint is empty
+// unless hints are enabled and the secret cannot be fetched; CSPNonce is empty
+// unless Handler.CSPNonce is set.
+type SecretView struct {
+	Hint     string
+	CSPNonce string
+}
+
 // handleSecret serves the HTML page used to fetch and decrypt a one-time secret.
 // It expects paths of the form /secret/{id}. A bare /secret/ (no ID) returns 404.
 // The page itself performs client-side fetch & decrypt using the key fragment.
 func (h *Handler) handleSecret(w http.ResponseWriter, r *http.Request) {
 	const prefix = "/secret/"
 	if !strings.HasPrefix(r.URL.Path, prefix) || len(r.URL.Path) == len(prefix) { // no id present
-		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+		h.renderErrorPage(w, r, http.StatusNotFound, "Not Found", "The page you requested was not found.")
 		return
 	}
 	if h.SecretTmpl == nil {
@@ -25,6 +49,27 @@ func (h *Handler) handleSecret(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("secret template unavailable"))
 		return
 	}
-	// Minimal data today; future fields could include feature flags.
-	renderTemplate(w, h.SecretTmpl, struct{}{})
+	renderTemplate(w, h.SecretTmpl, SecretView{Hint: h.secretHint(r.Context(), r.URL.Path[len(prefix):]), CSPNonce: GetCSPNonce(r.Context())})
+}
+
+// secretHint classifies id for the page when RevealHints is on. Probe
+// failures (including services without probe support) yield no hint so the
+// page falls back to its normal fetch.
+func (h *Handler) secretHint(ctx context.Context, id string) string {
+	if !h.RevealHints {
+		return ""
+	}
+	p, ok := h.Service.(SecretProber)
+	if !ok {
+		return ""
+	}
+	live, err := p.Exists(ctx, id)
+	switch {
+	case errors.Is(err, domain.ErrInvalidID):
+		return HintMalformed
+	case err != nil || live:
+		return ""
+	default:
+		return HintUnavailable
+	}
 }
@@ -1,11 +1,14 @@
 package httpx
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/haukened/gone/internal/domain"
 )
 
 // stubTemplate is a successful template that writes a fixed body.
@@ -112,3 +115,58 @@ func TestHandleSecret(t *testing.T) {
 		})
 	}
 }
+
+// probingService adds SecretProber to tenantRecorder.
+type probingService struct {
+	*tenantRecorder
+	live map[string]bool
+	err  error
+}
+
+func (p probingService) Exists(_ context.Context, id string) (bool, error) {
+	if _, err := domain.ParseID(id); err != nil {
+		return false, domain.ErrInvalidID
+	}
+	return p.live[id], p.err
+}
+
+// viewTemplate records the data handed to the template.
+type viewTemplate struct{ got *SecretView }
+
+func (v viewTemplate) Execute(w http.ResponseWriter, data any) error {
+	*v.got = data.(SecretView)
+	return nil
+}
+
+func TestHandleSecretHints(t *testing.T) {
+	const live = "0123456789abcdef0123456789abcdef"
+	const gone = "ffffffffffffffffffffffffffffffff"
+	tests := []struct {
+		name  string
+		svc   ServicePort
+		hints bool
+		id    string
+		want  string
+	}{
+		{"hints off", probingService{}, false, "bad", ""},
+		{"malformed", probingService{}, true, "bad", HintMalformed},
+		{"unavailable", probingService{}, true, gone, HintUnavailable},
+		{"live", probingService{live: map[string]bool{live: true}}, true, live, ""},
+		{"probe error", probingService{err: errors.New("db down")}, true, gone, ""},
+		{"no prober", &tenantRecorder{}, true, "bad", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var view SecretView
+			h := &Handler{Service: tc.svc, SecretTmpl: viewTemplate{got: &view}, RevealHints: tc.hints}
+			rr := httptest.NewRecorder()
+			h.handleSecret(rr, httptest.NewRequest(http.MethodGet, "/secret/"+tc.id, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d", rr.Code)
+			}
+			if view.Hint != tc.want {
+				t.Fatalf("hint %q want %q", view.Hint, tc.want)
+			}
+		})
+	}
+}
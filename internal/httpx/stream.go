@@ -0,0 +1,66 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// streamCtxKey is the unexported context key for the per-response stream flag.
+type streamCtxKey struct{}
+
+// streamFlag records that a handler asked for its response to be streamed
+// through unbuffered. Response-wrapping middleware installs one in the
+// request context before calling next and checks it before buffering.
+type streamFlag struct{ set atomic.Bool }
+
+// withStreamFlag returns a child context carrying a fresh, unset stream flag.
+func withStreamFlag(ctx context.Context) (context.Context, *streamFlag) {
+	f := &streamFlag{}
+	return context.WithValue(ctx, streamCtxKey{}, f), f
+}
+
+// MarkStreaming asks any response-wrapping middleware above the handler to
+// pass the response through untouched: no buffering, no compression. Handlers
+// call it before writing bodies of unbounded size, such as consumed blobs. It
+// is a no-op when no such middleware is installed.
+func MarkStreaming(ctx context.Context) {
+	if f, ok := ctx.Value(streamCtxKey{}).(*streamFlag); ok {
+		f.set.Store(true)
+	}
+}
+
+// streaming reports whether the handler marked the response for streaming.
+func (f *streamFlag) streaming() bool { return f != nil && f.set.Load() }
+
+// flushingCopy copies n bytes from src to w in writes of at most chunk bytes,
+// flushing after each so clients see steady download progress instead of
+// whatever the HTTP stack buffers. Writers that cannot flush still get every
+// byte. Like io.CopyN it reports io.EOF when src ends early.
+func flushingCopy(w http.ResponseWriter, src io.Reader, n int64, chunk int) (int64, error) {
+	rc := http.NewResponseController(w)
+	buf := make([]byte, min(int64(chunk), n))
+	var written int64
+	for written < n {
+		m, err := io.ReadFull(src, buf[:min(int64(len(buf)), n-written)])
+		if m > 0 {
+			wn, werr := w.Write(buf[:m])
+			written += int64(wn)
+			if werr != nil {
+				return written, werr
+			}
+			if ferr := rc.Flush(); ferr != nil && !errors.Is(ferr, http.ErrNotSupported) {
+				return written, ferr
+			}
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return written, io.EOF
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
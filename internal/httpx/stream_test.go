@@ -0,0 +1,115 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+func TestGzipMarkStreaming(t *testing.T) {
+	body := strings.Repeat("gone ", 1000)
+	rr := httptest.NewRecorder()
+	h := httpx.GzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpx.MarkStreaming(r.Context())
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "gone")
+		if rr.Body.String() != "gone" {
+			t.Errorf("first write buffered: recorder has %q", rr.Body.String())
+		}
+		_, _ = io.WriteString(w, body[4:])
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(rr, req)
+	if ce := rr.Header().Get("Content-Encoding"); ce != "" {
+		t.Fatalf("Content-Encoding = %q, want none", ce)
+	}
+	if rr.Body.String() != body {
+		t.Fatal("body mismatch")
+	}
+}
+
+// zeroes is an endless, allocation-free source of ciphertext-sized bodies.
+type zeroes struct{}
+
+func (zeroes) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+// discardWriter is a ResponseWriter that drops the body, so the test measures
+// only what the handler and middleware allocate.
+type discardWriter struct {
+	h http.Header
+	n int64
+}
+
+func (d *discardWriter) Header() http.Header { return d.h }
+func (d *discardWriter) WriteHeader(int)     {}
+func (d *discardWriter) Write(b []byte) (int, error) {
+	d.n += int64(len(b))
+	return len(b), nil
+}
+
+func TestConsumeLargeBlobConstantMemory(t *testing.T) {
+	const size = 64 << 20
+	m := mockService{consumeFn: func(context.Context, string) (app.Meta, io.ReadCloser, int64, error) {
+		return app.Meta{Version: 1, NonceB64u: "n"}, io.NopCloser(io.LimitReader(zeroes{}, size)), size, nil
+	}}
+	// Mount consume behind the gzip wrapper as a buffering middleware would be.
+	h := httpx.GzipMiddleware(httpx.New(m, 1024, nil).Router())
+	req := httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := &discardWriter{h: http.Header{}}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	h.ServeHTTP(w, req)
+	runtime.ReadMemStats(&after)
+
+	if w.n != size {
+		t.Fatalf("wrote %d bytes, want %d", w.n, size)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 4<<20 {
+		t.Fatalf("allocated %d bytes streaming a %d byte blob", alloc, size)
+	}
+}
+
+// flushCounter records how many bytes had been written at each Flush.
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushedAt []int
+}
+
+func (f *flushCounter) Flush() { f.flushedAt = append(f.flushedAt, f.Body.Len()) }
+
+func TestConsumeFlushesEachChunk(t *testing.T) {
+	payload := []byte(strings.Repeat("x", 10*1024))
+	for _, tc := range []struct {
+		chunk int
+		want  []int
+	}{
+		{0, nil},
+		{4096, []int{4096, 8192, 10240}},
+	} {
+		h := httpx.New(onceService(payload, new(int)), 0, nil)
+		h.FlushChunk = tc.chunk
+		w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+		h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil))
+		if w.Code != http.StatusOK || w.Body.String() != string(payload) {
+			t.Fatalf("chunk %d: status %d, body %d bytes", tc.chunk, w.Code, w.Body.Len())
+		}
+		if !slices.Equal(w.flushedAt, tc.want) {
+			t.Fatalf("chunk %d: flushed at %v, want %v", tc.chunk, w.flushedAt, tc.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// tenantPrefix is the path prefix under which tenant-scoped API routes live.
+const tenantPrefix = "/t/"
+
+// tenantHandler serves /t/{tenant}/api/secret[/{id}]. It validates the tenant
+// against the configured set, scopes the request context to it, strips the
+// prefix, and dispatches to the regular create/consume handlers. Only API
+// routes are exposed per tenant; the UI remains at the root namespace.
+// create and consume are the (possibly wrapped) handlers shared with the root
+// namespace.
+func (h *Handler) tenantHandler(create, consume http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/api/secret", create)
+	mux.Handle("/api/secret/", consume)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, rest, ok := splitTenantPath(r.URL.Path)
+		if !ok || !h.hasTenant(name) {
+			h.writeError(r.Context(), w, http.StatusNotFound, "not found")
+			return
+		}
+		r2 := r.WithContext(app.WithTenant(r.Context(), name))
+		u := *r.URL
+		u.Path = rest
+		u.RawPath = ""
+		r2.URL = &u
+		mux.ServeHTTP(w, r2)
+	})
+}
+
+// splitTenantPath splits "/t/{tenant}/rest" into the tenant name and "/rest".
+// It reports false when the path lacks a tenant segment or a remainder.
+func splitTenantPath(p string) (tenant, rest string, ok bool) {
+	if !strings.HasPrefix(p, tenantPrefix) {
+		return "", "", false
+	}
+	tail := p[len(tenantPrefix):]
+	i := strings.IndexByte(tail, '/')
+	if i <= 0 {
+		return "", "", false
+	}
+	return tail[:i], tail[i:], true
+}
+
+// hasTenant reports whether name is one of the configured tenants.
+func (h *Handler) hasTenant(name string) bool {
+	for _, t := range h.Tenants {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+)
+
+// tenantRecorder captures the tenant seen by the service for each call.
+type tenantRecorder struct {
+	createTenant  string
+	consumeTenant string
+	consumeID     string
+}
+
+func (s *tenantRecorder) CreateSecret(ctx context.Context, ct io.Reader, size int64, _ uint8, _ string, _ time.Duration, _ app.CreateOptions) (domain.SecretID, time.Time, error) {
+	s.createTenant = app.TenantFromContext(ctx)
+	_, _ = io.CopyN(io.Discard, ct, size)
+	return domain.SecretID("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), time.Unix(0, 0).UTC(), nil
+}
+
+func (s *tenantRecorder) Consume(ctx context.Context, id string) (app.Meta, io.ReadCloser, int64, error) {
+	s.consumeTenant = app.TenantFromContext(ctx)
+	s.consumeID = id
+	return app.Meta{}, nil, 0, app.ErrNotFound
+}
+
+func TestSplitTenantPath(t *testing.T) {
+	tests := []struct {
+		path, tenant, rest string
+		ok                 bool
+	}{
+		{"/t/acme/api/secret", "acme", "/api/secret", true},
+		{"/t/acme/", "acme", "/", true},
+		{"/t/acme", "", "", false},
+		{"/t//api/secret", "", "", false},
+		{"/api/secret", "", "", false},
+	}
+	for _, tc := range tests {
+		tenant, rest, ok := splitTenantPath(tc.path)
+		if tenant != tc.tenant || rest != tc.rest || ok != tc.ok {
+			t.Fatalf("%s: got (%q,%q,%v)", tc.path, tenant, rest, ok)
+		}
+	}
+}
+
+func TestTenantRoutesScopeContext(t *testing.T) {
+	svc := &tenantRecorder{}
+	h := New(svc, 1024, nil)
+	h.Tenants = []domain.Tenant{{Name: "acme"}, {Name: "globex"}}
+	router := h.Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/t/acme/api/secret", bytes.NewReader([]byte("cipher")))
+	req.Header.Set("Content-Length", "6")
+	req.Header.Set("X-Gone-Version", "1")
+	req.Header.Set("X-Gone-Nonce", "n")
+	req.Header.Set("X-Gone-TTL", "5m")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status %d", w.Code)
+	}
+	if svc.createTenant != "acme" {
+		t.Fatalf("expected tenant acme, got %q", svc.createTenant)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t/globex/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("consume status %d", w.Code)
+	}
+	if svc.consumeTenant != "globex" || svc.consumeID != "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" {
+		t.Fatalf("unexpected consume scope tenant=%q id=%q", svc.consumeTenant, svc.consumeID)
+	}
+}
+
+func TestTenantRoutesRejectUnknown(t *testing.T) {
+	svc := &tenantRecorder{}
+	h := New(svc, 1024, nil)
+	h.Tenants = []domain.Tenant{{Name: "acme"}}
+	router := h.Router()
+	for _, p := range []string{"/t/other/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "/t/acme/about", "/t/acme"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404 got %d", p, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("%s: expected json 404 got %q", p, ct)
+		}
+	}
+	if svc.consumeTenant != "" || svc.consumeID != "" {
+		t.Fatalf("service should not be reached for unknown tenant")
+	}
+}
+
+func TestTenantRoutesDisabledByDefault(t *testing.T) {
+	svc := &tenantRecorder{}
+	h := New(svc, 1024, nil)
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/t/acme/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+	if svc.consumeID != "" {
+		t.Fatalf("service should not be reached when tenants are disabled")
+	}
+}
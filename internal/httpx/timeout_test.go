@@ -0,0 +1,80 @@
+package httpx_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// slowService blocks until ctx is done, like a store stuck on a lock.
+func slowService() mockService {
+	return mockService{
+		createFn: func(ctx context.Context, _ io.Reader, _ int64, _ uint8, _ string, _ time.Duration) (domain.SecretID, time.Time, error) {
+			<-ctx.Done()
+			return "", time.Time{}, ctx.Err()
+		},
+		consumeFn: func(ctx context.Context, _ string) (app.Meta, io.ReadCloser, int64, error) {
+			<-ctx.Done()
+			return app.Meta{}, nil, 0, ctx.Err()
+		},
+	}
+}
+
+func TestOpTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		req  func() *http.Request
+	}{
+		{name: "create", req: func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewBufferString("cipher"))
+			r.Header.Set("Content-Length", "6")
+			r.Header.Set("X-Gone-Version", "1")
+			r.Header.Set("X-Gone-Nonce", "nonce")
+			r.Header.Set("X-Gone-TTL", "5m")
+			return r
+		}},
+		{name: "consume", req: func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil)
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := httpx.New(slowService(), 1024, nil)
+			h.MinTTL, h.MaxTTL = time.Minute, time.Hour
+			h.OpTimeout = 20 * time.Millisecond
+			w := httptest.NewRecorder()
+			start := time.Now()
+			h.Router().ServeHTTP(w, tc.req())
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("handler did not honor timeout: %v", elapsed)
+			}
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status got %d body=%s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestOpTimeoutStreamsClaimedSecret ensures a secret already claimed by the
+// service is delivered even if the deadline passes afterwards.
+func TestOpTimeoutStreamsClaimedSecret(t *testing.T) {
+	svc := mockService{consumeFn: func(ctx context.Context, _ string) (app.Meta, io.ReadCloser, int64, error) {
+		<-ctx.Done() // deadline passes right as the claim completes
+		return app.Meta{Version: 1, NonceB64u: "n"}, io.NopCloser(bytes.NewReader([]byte("ok"))), 2, nil
+	}}
+	h := httpx.New(svc, 1024, nil)
+	h.OpTimeout = 10 * time.Millisecond
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("expected delivered secret, got %d %q", w.Code, w.Body.String())
+	}
+}
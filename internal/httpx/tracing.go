@@ -0,0 +1,70 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// statusRecorder captures the response status for span attributes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// TracingMiddleware starts one span per request using tracer. It must run
+// inside CorrelationIDMiddleware so the correlation ID can be attached. The
+// raw path is never recorded because it may contain a secret ID; a route
+// template is used instead.
+func TracingMiddleware(tracer app.Tracer, next http.Handler) http.Handler {
+	tracer = app.TracerOrNoop(tracer)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cid, _ := GetCorrelationID(r.Context())
+		ctx, span := tracer.Start(r.Context(), "http.request",
+			app.Attr{Key: "http.method", Value: r.Method},
+			app.Attr{Key: "http.route", Value: routeTemplate(r.URL.Path)},
+			app.Attr{Key: "correlation_id", Value: cid},
+		)
+		defer span.End()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttrs(app.Attr{Key: "http.status_code", Value: rec.status})
+	})
+}
+
+// routeTemplate maps a request path to a low-cardinality route name with any
+// secret ID or tenant segment replaced by a placeholder.
+func routeTemplate(p string) string {
+	if tenant, rest, ok := splitTenantPath(p); ok && tenant != "" {
+		return "/t/{tenant}" + routeTemplate(rest)
+	}
+	switch {
+	case strings.HasPrefix(p, "/api/secret/"):
+		return "/api/secret/{id}"
+	case strings.HasPrefix(p, "/secret/"):
+		return "/secret/{id}"
+	case strings.HasPrefix(p, "/static/"):
+		return "/static/*"
+	case p == "/", p == "/about", p == "/api/secret", p == "/healthz", p == "/readyz":
+		return p
+	}
+	return "other"
+}
@@ -0,0 +1,72 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+type captureTracer struct {
+	name  string
+	attrs map[string]any
+}
+
+func (c *captureTracer) Start(ctx context.Context, name string, attrs ...app.Attr) (context.Context, app.Span) {
+	c.name = name
+	c.attrs = map[string]any{}
+	for _, a := range attrs {
+		c.attrs[a.Key] = a.Value
+	}
+	return ctx, captureSpan{c}
+}
+
+type captureSpan struct{ c *captureTracer }
+
+func (s captureSpan) SetAttrs(attrs ...app.Attr) {
+	for _, a := range attrs {
+		s.c.attrs[a.Key] = a.Value
+	}
+}
+func (captureSpan) RecordError(error) {}
+func (captureSpan) End()              {}
+
+func TestRouteTemplate(t *testing.T) {
+	cases := map[string]string{
+		"/":           "/",
+		"/api/secret": "/api/secret",
+		"/api/secret/0123456789abcdef0123456789abcdef": "/api/secret/{id}",
+		"/secret/abc":            "/secret/{id}",
+		"/static/app.js":         "/static/*",
+		"/t/acme/api/secret/abc": "/t/{tenant}/api/secret/{id}",
+		"/nope":                  "other",
+	}
+	for in, want := range cases {
+		if got := routeTemplate(in); got != want {
+			t.Errorf("routeTemplate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTracingMiddlewareRecordsRequest(t *testing.T) {
+	tr := &captureTracer{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	h := CorrelationIDMiddleware(TracingMiddleware(tr, next))
+	req := httptest.NewRequest(http.MethodGet, "/api/secret/0123456789abcdef0123456789abcdef", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if tr.name != "http.request" {
+		t.Fatalf("span name = %q", tr.name)
+	}
+	if tr.attrs["http.route"] != "/api/secret/{id}" {
+		t.Fatalf("route attr = %v", tr.attrs["http.route"])
+	}
+	if tr.attrs["correlation_id"] != rr.Header().Get(CorrelationIDHeader) {
+		t.Fatalf("correlation id attr mismatch")
+	}
+	if tr.attrs["http.status_code"] != http.StatusTeapot {
+		t.Fatalf("status attr = %v", tr.attrs["http.status_code"])
+	}
+}
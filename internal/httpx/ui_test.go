@@ -0,0 +1,62 @@
+package httpx_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haukened/gone/internal/httpx"
+)
+
+// TestUIModes checks which routes each UI mode serves. API and health
+// endpoints must be reachable in every mode.
+func TestUIModes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("ok"), 0o600); err != nil {
+		t.Fatalf("write asset: %v", err)
+	}
+	page := template.Must(template.New("page").Parse(`<html>page</html>`))
+	newHandler := func(mode httpx.UIMode) http.Handler {
+		h := httpx.New(noopService{}, 100, nil)
+		h.IndexTmpl = httpx.TemplateRenderer{T: page}
+		h.AboutTmpl = httpx.AboutTemplateRenderer{T: page}
+		h.SecretTmpl = httpx.TemplateRenderer{T: page}
+		h.Assets = http.FS(os.DirFS(dir))
+		h.UI = mode
+		h.UIRedirectURL = "https://example.com/ui"
+		return h.Router()
+	}
+	const secretPath = "/secret/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	tests := []struct {
+		mode httpx.UIMode
+		want map[string]int
+	}{
+		{mode: "", want: map[string]int{"/": 200, "/about": 200, secretPath: 200, "/static/app.js": 200, "/healthz": 200}},
+		{mode: httpx.UIEnabled, want: map[string]int{"/": 200, "/about": 200, secretPath: 200, "/static/app.js": 200, "/healthz": 200}},
+		{mode: httpx.UIDisabled, want: map[string]int{"/": 404, "/about": 404, secretPath: 404, "/static/app.js": 404, "/healthz": 200, "/api/secret": 405}},
+		{mode: httpx.UIRedirect, want: map[string]int{"/": 302, "/about": 404, secretPath: 404, "/static/app.js": 404, "/healthz": 200, "/api/secret": 405}},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			router := newHandler(tc.mode)
+			for path, code := range tc.want {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				if w.Code != code {
+					t.Fatalf("%s: status got %d want %d", path, w.Code, code)
+				}
+				if code == http.StatusFound {
+					if loc := w.Header().Get("Location"); loc != "https://example.com/ui" {
+						t.Fatalf("redirect location %q", loc)
+					}
+				}
+				if code == http.StatusNotFound && w.Header().Get("Content-Type") != "application/json" {
+					t.Fatalf("%s: expected JSON 404, got %q", path, w.Header().Get("Content-Type"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// BuildInfo identifies the running binary. Values are injected at build time
+// via -ldflags in main.
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Built   string `json:"built"`
+}
+
+// handleVersion implements GET /version. Build info is public and immutable
+// for the life of the process, so it may be cached briefly.
+func (h *Handler) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.writeError(r.Context(), w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Del("Pragma")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(h.Build)
+}
@@ -0,0 +1,45 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haukened/gone/internal/httpx"
+)
+
+func TestVersionEndpoint(t *testing.T) {
+	h := httpx.New(noopService{}, 100, nil)
+	h.Build = httpx.BuildInfo{Version: "v1.2.3", Commit: "abc123", Built: "2026-01-02T03:04:05Z"}
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content-type %q", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatalf("cache-control %q", cc)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]string{"version": "v1.2.3", "commit": "abc123", "built": "2026-01-02T03:04:05Z"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected keys %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s got %q want %q", k, got[k], v)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status %d", w.Code)
+	}
+}
@@ -8,13 +8,14 @@ import (
 	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Store abstracts the minimal store operations the Janitor requires after simplification.
-// We deliberately avoid exposing batch semantics; the underlying store internally handles
-// expired secret deletion (including best-effort blob cleanup) and whole-store reconciliation
-// (orphan blob removal).
+// Batching is not exposed here; the underlying store handles expired secret deletion
+// (including best-effort blob cleanup and optional bounded batches, see
+// store.WithExpireBatch) and whole-store reconciliation (orphan blob removal).
 type Store interface {
 	// DeleteExpired deletes secrets whose expiry is <= t and returns the number removed.
 	DeleteExpired(ctx context.Context, t time.Time) (int, error)
@@ -23,12 +24,41 @@ type Store interface {
 	Reconcile(ctx context.Context) error
 }
 
+// IntegrityScanner is optionally implemented by a Store that can verify that
+// externally stored blobs exist and match their indexed size.
+type IntegrityScanner interface {
+	// VerifyBlobs checks at most one blob per pace and reports how many were
+	// checked and found broken; with repair, broken entries are removed.
+	VerifyBlobs(ctx context.Context, pace time.Duration, repair bool) (checked, broken int, err error)
+}
+
+// ExpiryScheduler is optionally implemented by a Store that can report when
+// its next secret becomes eligible for deletion. With Config.MinInterval set
+// it turns the fixed schedule into an expiry-driven one.
+type ExpiryScheduler interface {
+	// NextExpiry returns the earliest time DeleteExpired would remove
+	// something, or the zero time when nothing is stored.
+	NextExpiry(ctx context.Context) (time.Time, error)
+}
+
+// expirySlack delays an expiry-driven cycle past the reported expiry, which
+// the index stores with one-second precision and deletes strictly after.
+const expirySlack = time.Second
+
 // Config holds tunables for the Janitor.
 type Config struct {
-	Interval time.Duration // how often a cycle begins
+	Interval time.Duration // how often a cycle begins; the longest wait under expiry-driven scheduling
+	// MinInterval enables expiry-driven scheduling when the store implements
+	// ExpiryScheduler: each cycle is planned just after the next expiry, but
+	// no sooner than MinInterval and no later than Interval (0 = fixed Interval).
+	MinInterval time.Duration
 	// BatchSize kept for backward compatibility/no-op to avoid breaking existing callers.
 	BatchSize int          // (deprecated) ignored; retained to prevent widespread refactors
 	Logger    *slog.Logger // optional logger (defaults to slog.Default())
+
+	IntegrityInterval time.Duration // how often an integrity scan begins (0 = disabled)
+	IntegrityPace     time.Duration // minimum delay between blob checks within a scan
+	IntegrityRepair   bool          // tombstone entries whose blobs are missing or truncated
 }
 
 // Metrics accumulates counters (in-memory) for operational insight.
@@ -39,6 +69,7 @@ type Metrics struct {
 	Deleted             uint64
 	Processed           uint64
 	CycleLastDurationMS int64
+	SkippedCycles       uint64 // cycles not run because one was already in progress
 }
 
 // MetricsView is a read-only snapshot safe to copy.
@@ -47,6 +78,7 @@ type MetricsView struct {
 	Deleted             uint64
 	Processed           uint64
 	CycleLastDurationMS int64
+	SkippedCycles       uint64
 }
 
 func (m *Metrics) addProcessed(n int) {
@@ -65,6 +97,11 @@ func (m *Metrics) addDeleted(n int) {
 	m.Deleted += uint64(n)
 	m.mu.Unlock()
 }
+func (m *Metrics) addSkipped() {
+	m.mu.Lock()
+	m.SkippedCycles++
+	m.mu.Unlock()
+}
 func (m *Metrics) recordCycle(d time.Duration) {
 	m.mu.Lock()
 	m.Cycles++
@@ -79,10 +116,13 @@ type Janitor struct {
 	metrics *Metrics
 	ext     ExternalMetrics // optional external metrics collector
 
-	ticker *time.Ticker
-	stopCh chan struct{}
-	doneCh chan struct{}
-	once   sync.Once
+	running atomic.Bool // a cycle is in progress; guards runCycle against overlap
+
+	ticker   *time.Ticker
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	scanDone chan struct{} // closed when the integrity loop exits (nil if not running)
+	once     sync.Once
 }
 
 // New constructs but does not start a Janitor.
@@ -117,12 +157,19 @@ func (j *Janitor) Start(ctx context.Context) {
 	} // already started
 	j.ticker = time.NewTicker(j.cfg.Interval)
 	go j.loop(ctx)
+	if sc, ok := j.store.(IntegrityScanner); ok && j.cfg.IntegrityInterval > 0 {
+		j.scanDone = make(chan struct{})
+		go j.scanLoop(ctx, sc)
+	}
 }
 
 // Stop signals the loop to exit and waits for completion.
 func (j *Janitor) Stop() {
 	j.once.Do(func() { close(j.stopCh) })
 	<-j.doneCh
+	if j.scanDone != nil {
+		<-j.scanDone
+	}
 }
 
 // MetricsSnapshot returns a copy of current metrics.
@@ -134,6 +181,7 @@ func (j *Janitor) MetricsSnapshot() MetricsView {
 		Deleted:             j.metrics.Deleted,
 		Processed:           j.metrics.Processed,
 		CycleLastDurationMS: j.metrics.CycleLastDurationMS,
+		SkippedCycles:       j.metrics.SkippedCycles,
 	}
 }
 
@@ -145,6 +193,13 @@ func (j *Janitor) loop(ctx context.Context) {
 		}
 		close(j.doneCh)
 	}()
+	sched, _ := j.store.(ExpiryScheduler)
+	if j.cfg.MinInterval <= 0 {
+		sched = nil
+	}
+	if sched != nil {
+		j.ticker.Reset(j.nextDelay(ctx, sched))
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -155,20 +210,61 @@ func (j *Janitor) loop(ctx context.Context) {
 			return
 		case <-j.ticker.C:
 			j.runCycle(ctx)
+			if sched != nil {
+				j.ticker.Reset(j.nextDelay(ctx, sched))
+			}
 		}
 	}
 }
 
-// runCycle performs one full expiry + orphan cleanup cycle.
-func (j *Janitor) runCycle(ctx context.Context) {
-	start := time.Now()
+// nextDelay returns the wait before the next expiry-driven cycle: until just
+// after the store's next expiry, clamped to [MinInterval, Interval]. An empty
+// store or a failed lookup waits the full Interval.
+func (j *Janitor) nextDelay(ctx context.Context, sched ExpiryScheduler) time.Duration {
+	next, err := sched.NextExpiry(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			j.cfg.Logger.Warn("next expiry", "domain", "janitor", "error", err)
+		}
+		return j.cfg.Interval
+	}
+	if next.IsZero() {
+		return j.cfg.Interval
+	}
+	return min(max(time.Until(next)+expirySlack, j.cfg.MinInterval), j.cfg.Interval)
+}
+
+// RunOnce performs a single cleanup cycle synchronously without starting the
+// loop, for one-shot invocations such as `gone cleanup`. It returns the number
+// of expired secrets deleted and any expiry or reconcile error.
+func (j *Janitor) RunOnce(ctx context.Context) (int, error) {
+	return j.runCycle(ctx)
+}
+
+// runCycle performs one full expiry + orphan cleanup cycle. It is
+// single-flight: a call made while another cycle is still running (e.g. a slow
+// Reconcile over a large blob directory) is skipped rather than racing it on
+// the same orphans, and counted in SkippedCycles. Errors are logged and also
+// returned for RunOnce; the loop ignores them.
+func (j *Janitor) runCycle(ctx context.Context) (int, error) {
 	log := j.cfg.Logger.With("domain", "janitor", "action", "cycle")
+	if !j.running.CompareAndSwap(false, true) {
+		j.metrics.addSkipped()
+		if j.ext != nil {
+			j.ext.Inc("janitor_skipped_cycles_total", 1)
+		}
+		log.Warn("cycle skipped", "reason", "previous_running")
+		return 0, nil
+	}
+	defer j.running.Store(false)
+	start := time.Now()
 	now := time.Now().UTC()
 	count, err := j.store.DeleteExpired(ctx, now)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Error("expire", "error", err)
 	}
-	if rerr := j.store.Reconcile(ctx); rerr != nil && !errors.Is(rerr, context.Canceled) {
+	rerr := j.store.Reconcile(ctx)
+	if rerr != nil && !errors.Is(rerr, context.Canceled) {
 		log.Error("reconcile", "error", rerr)
 	}
 	j.metrics.addProcessed(count)
@@ -180,7 +276,48 @@ func (j *Janitor) runCycle(ctx context.Context) {
 	// Orphan count unknown with simplified Reconcile; skip addOrphans.
 	j.metrics.recordCycle(time.Since(start))
 	log.Info("cycle complete", "processed", count, "deleted", count, "ms", time.Since(start).Milliseconds())
+	return count, errors.Join(err, rerr)
+}
+
+// scanLoop runs integrity scans on their own schedule so a slow, throttled
+// scan never delays expiry. Stop cancels a scan in progress.
+func (j *Janitor) scanLoop(ctx context.Context, sc IntegrityScanner) {
+	defer close(j.scanDone)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-j.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	t := time.NewTicker(j.cfg.IntegrityInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			j.runScan(ctx, sc)
+		}
+	}
 }
 
-// NOTE: Simplified implementation: batch semantics removed. Revisit only if future
-// scale requires incremental draining to reduce lock contention.
+// runScan performs one integrity pass and reports broken blobs.
+func (j *Janitor) runScan(ctx context.Context, sc IntegrityScanner) {
+	start := time.Now()
+	log := j.cfg.Logger.With("domain", "janitor", "action", "integrity")
+	checked, broken, err := sc.VerifyBlobs(ctx, j.cfg.IntegrityPace, j.cfg.IntegrityRepair)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Error("scan", "error", err)
+	}
+	if j.ext != nil && broken > 0 {
+		j.ext.Inc("blob_integrity_failures_total", int64(broken))
+	}
+	if broken > 0 {
+		log.Warn("broken blobs", "checked", checked, "broken", broken, "repaired", j.cfg.IntegrityRepair)
+		return
+	}
+	log.Info("scan complete", "checked", checked, "ms", time.Since(start).Milliseconds())
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -76,6 +77,21 @@ func TestJanitorCycleReconcileError(t *testing.T) {
 	}
 }
 
+func TestJanitorRunOnce(t *testing.T) {
+	fs := &fakeStore{expireCount: 4}
+	j := New(fs, nil, Config{Interval: time.Hour})
+	if n, err := j.RunOnce(context.Background()); n != 4 || err != nil {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	fs.expireErr, fs.reconErr = errors.New("expire"), errors.New("recon")
+	if _, err := j.RunOnce(context.Background()); !errors.Is(err, fs.expireErr) || !errors.Is(err, fs.reconErr) {
+		t.Fatalf("expected both errors, got %v", err)
+	}
+	if mv := j.MetricsSnapshot(); mv.Cycles != 2 {
+		t.Fatalf("cycles = %d", mv.Cycles)
+	}
+}
+
 func TestJanitorContextCancelEarly(t *testing.T) {
 	fs := &fakeStore{expireCount: 5}
 	j := New(fs, nil, Config{Interval: time.Hour})
@@ -162,3 +178,192 @@ func TestJanitorExternalMetrics(t *testing.T) {
 		t.Fatalf("unexpected observations %+v", obs)
 	}
 }
+
+// scanStore adds IntegrityScanner to fakeStore.
+type scanStore struct {
+	fakeStore
+	broken int
+	pace   time.Duration
+	repair bool
+	scans  chan struct{}
+}
+
+func (s *scanStore) VerifyBlobs(ctx context.Context, pace time.Duration, repair bool) (int, int, error) {
+	s.mu.Lock()
+	s.pace, s.repair = pace, repair
+	s.mu.Unlock()
+	select {
+	case s.scans <- struct{}{}:
+	default:
+	}
+	return 3, s.broken, nil
+}
+
+func TestJanitorIntegrityScan(t *testing.T) {
+	ss := &scanStore{broken: 2, scans: make(chan struct{}, 1)}
+	ec := newExternalCollector()
+	j := New(ss, ec, Config{Interval: time.Hour, IntegrityInterval: 5 * time.Millisecond, IntegrityPace: 20 * time.Millisecond, IntegrityRepair: true})
+	j.Start(context.Background())
+	select {
+	case <-ss.scans:
+	case <-time.After(time.Second):
+		t.Fatalf("integrity scan did not run")
+	}
+	j.Stop()
+	ss.mu.Lock()
+	if ss.pace != 20*time.Millisecond || !ss.repair {
+		t.Fatalf("scan options not passed through: pace=%v repair=%v", ss.pace, ss.repair)
+	}
+	ss.mu.Unlock()
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if ec.counters["blob_integrity_failures_total"] < 2 {
+		t.Fatalf("expected integrity failures counted, got %v", ec.counters)
+	}
+}
+
+func TestJanitorIntegrityScanDisabled(t *testing.T) {
+	ss := &scanStore{scans: make(chan struct{}, 1)}
+	j := New(ss, nil, Config{Interval: time.Hour})
+	j.Start(context.Background())
+	j.Stop()
+	if j.scanDone != nil {
+		t.Fatalf("scan loop should not start without an interval")
+	}
+}
+
+// blockingStore holds Reconcile until release is closed and records whether
+// two Reconcile calls were ever in flight at once.
+type blockingStore struct {
+	entered  chan struct{}
+	release  chan struct{}
+	inflight atomic.Int32
+	overlap  atomic.Bool
+}
+
+func (b *blockingStore) DeleteExpired(context.Context, time.Time) (int, error) { return 0, nil }
+
+func (b *blockingStore) Reconcile(context.Context) error {
+	if b.inflight.Add(1) > 1 {
+		b.overlap.Store(true)
+	}
+	defer b.inflight.Add(-1)
+	b.entered <- struct{}{}
+	<-b.release
+	return nil
+}
+
+func TestJanitorCycleSingleFlight(t *testing.T) {
+	bs := &blockingStore{entered: make(chan struct{}, 4), release: make(chan struct{})}
+	ec := newExternalCollector()
+	j := New(bs, ec, Config{Interval: time.Hour, Logger: slog.Default()})
+	done := make(chan struct{})
+	go func() {
+		j.runCycle(context.Background())
+		close(done)
+	}()
+	<-bs.entered // first cycle is now stuck in Reconcile
+	// Overlapping ticks arrive while it is still running.
+	for i := 0; i < 3; i++ {
+		j.runCycle(context.Background())
+	}
+	close(bs.release)
+	<-done
+	if bs.overlap.Load() {
+		t.Fatal("Reconcile ran concurrently")
+	}
+	mv := j.MetricsSnapshot()
+	if mv.Cycles != 1 || mv.SkippedCycles != 3 {
+		t.Fatalf("metrics %+v", mv)
+	}
+	ec.mu.Lock()
+	skipped := ec.counters["janitor_skipped_cycles_total"]
+	ec.mu.Unlock()
+	if skipped != 3 {
+		t.Fatalf("external skipped counter %d", skipped)
+	}
+	// The guard is released once the cycle finishes.
+	bs.entered = make(chan struct{}, 1)
+	j.runCycle(context.Background())
+	if mv := j.MetricsSnapshot(); mv.Cycles != 2 {
+		t.Fatalf("expected a new cycle after the first finished, %+v", mv)
+	}
+}
+
+// expiryStore is a fakeStore that reports a fixed next expiry and records
+// when each cycle ran.
+type expiryStore struct {
+	fakeStore
+	next   time.Time
+	err    error
+	cycles chan time.Time
+}
+
+func (e *expiryStore) NextExpiry(context.Context) (time.Time, error) { return e.next, e.err }
+
+func (e *expiryStore) DeleteExpired(ctx context.Context, t time.Time) (int, error) {
+	select {
+	case e.cycles <- time.Now():
+	default:
+	}
+	return e.fakeStore.DeleteExpired(ctx, t)
+}
+
+func TestNextDelayBounds(t *testing.T) {
+	j := New(&fakeStore{}, nil, Config{Interval: time.Hour, MinInterval: 5 * time.Second})
+	tests := []struct {
+		name string
+		next time.Time
+		err  error
+		want time.Duration
+	}{
+		{name: "empty store", want: time.Hour},
+		{name: "lookup error", next: time.Now(), err: errors.New("boom"), want: time.Hour},
+		{name: "backlog", next: time.Now().Add(-time.Minute), want: 5 * time.Second},
+		{name: "beyond interval", next: time.Now().Add(2 * time.Hour), want: time.Hour},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := j.nextDelay(context.Background(), &expiryStore{next: tc.next, err: tc.err}); got != tc.want {
+				t.Fatalf("nextDelay = %v, want %v", got, tc.want)
+			}
+		})
+	}
+	got := j.nextDelay(context.Background(), &expiryStore{next: time.Now().Add(30 * time.Second)})
+	if want := 30*time.Second + expirySlack; got > want || got < want-time.Second {
+		t.Fatalf("nextDelay = %v, want about %v", got, want)
+	}
+}
+
+// TestExpiryDrivenCycle checks a near-future expiry pulls the first cycle in
+// from the hour-long Interval to just after that expiry.
+func TestExpiryDrivenCycle(t *testing.T) {
+	next := time.Now().Add(50 * time.Millisecond)
+	es := &expiryStore{next: next, cycles: make(chan time.Time, 1)}
+	j := New(es, nil, Config{Interval: time.Hour, MinInterval: 10 * time.Millisecond})
+	j.Start(context.Background())
+	defer j.Stop()
+	select {
+	case ran := <-es.cycles:
+		due := next.Add(expirySlack)
+		if ran.Before(due) || ran.After(due.Add(time.Second)) {
+			t.Fatalf("cycle ran at %v, want just after %v", ran, due)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no cycle before the expiry-driven deadline")
+	}
+}
+
+// TestFixedScheduleWithoutMinInterval checks a store reporting expiries does
+// not change the schedule unless MinInterval is set.
+func TestFixedScheduleWithoutMinInterval(t *testing.T) {
+	es := &expiryStore{next: time.Now().Add(-time.Minute), cycles: make(chan time.Time, 1)}
+	j := New(es, nil, Config{Interval: time.Hour})
+	j.Start(context.Background())
+	defer j.Stop()
+	select {
+	case <-es.cycles:
+		t.Fatal("cycle ran before Interval")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -3,46 +3,208 @@ package metrics
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// maxTokenBytes bounds the body accepted by RotateTokenHandler.
+const maxTokenBytes = 1024
+
+// Token is a bearer token that can be replaced at runtime. The zero value
+// (and a nil *Token) holds the empty token, which disables authentication.
+type Token struct {
+	p atomic.Pointer[string]
+}
+
+// NewToken returns a Token holding s.
+func NewToken(s string) *Token {
+	t := &Token{}
+	t.Set(s)
+	return t
+}
+
+// Get returns the current token.
+func (t *Token) Get() string {
+	if t == nil {
+		return ""
+	}
+	if p := t.p.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// Set replaces the token; requests authorized afterwards must carry s.
+func (t *Token) Set(s string) { t.p.Store(&s) }
+
 // SnapshotProvider abstracts Manager for testing.
 type SnapshotProvider interface {
 	Snapshot(ctx context.Context) (map[string]int64, map[string]summaryAgg, error)
 }
 
+// Report is the JSON-friendly form of a metrics snapshot shared by the HTTP
+// handler and the `gone metrics` CLI.
+type Report struct {
+	Counters  map[string]int64            `json:"counters"`
+	Summaries map[string]map[string]int64 `json:"summaries"`
+}
+
+// BuildReport takes a snapshot from provider and converts summaries
+// (unexported fields) to plain count/sum/min/max maps.
+func BuildReport(ctx context.Context, provider SnapshotProvider) (Report, error) {
+	counters, summaries, err := provider.Snapshot(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	outSummaries := make(map[string]map[string]int64, len(summaries))
+	for k, v := range summaries {
+		outSummaries[k] = map[string]int64{
+			"count": v.count,
+			"sum":   v.sum,
+			"min":   v.min,
+			"max":   v.max,
+		}
+	}
+	return Report{Counters: counters, Summaries: outSummaries}, nil
+}
+
 // Handler returns an http.HandlerFunc that writes JSON metrics snapshot.
 // If token is non-empty, requests must include Authorization: Bearer <token>.
-func Handler(provider SnapshotProvider, token string) http.HandlerFunc {
+func Handler(provider SnapshotProvider, token *Token) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if token != "" {
-			hdr := r.Header.Get("Authorization")
-			const prefix = "Bearer "
-			if len(hdr) <= len(prefix) || hdr[:len(prefix)] != prefix || hdr[len(prefix):] != token {
-				w.WriteHeader(http.StatusUnauthorized)
-				return
-			}
-		}
-		counters, summaries, err := provider.Snapshot(r.Context())
+		if !authorized(r, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, err := BuildReport(r.Context(), provider)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		// Convert summaries (unexported fields) to JSON-friendly structure.
-		outSummaries := make(map[string]map[string]int64, len(summaries))
-		for k, v := range summaries {
-			outSummaries[k] = map[string]int64{
-				"count": v.count,
-				"sum":   v.sum,
-				"min":   v.min,
-				"max":   v.max,
-			}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ExpirySource reports live secrets bucketed by remaining TTL; *store.Store
+// implements it.
+type ExpirySource interface {
+	ExpiryHistogram(ctx context.Context, buckets []time.Duration) (map[string]int64, error)
+}
+
+// ExpiryReport is the JSON body served by ExpiryHandler. Bucket keys are the
+// upper bounds as Go durations plus "+Inf"; counts are not cumulative.
+type ExpiryReport struct {
+	Buckets map[string]int64 `json:"buckets"`
+	Total   int64            `json:"total"`
+}
+
+// ExpiryHandler returns an http.HandlerFunc that writes the time-to-expiry
+// distribution of live secrets as JSON, guarded like Handler. A nil src
+// responds 404.
+func ExpiryHandler(src ExpirySource, buckets []time.Duration, token *Token) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if src == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		hist, err := src.ExpiryHistogram(r.Context(), buckets)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
-		resp := map[string]any{
-			"counters":  counters,
-			"summaries": outSummaries,
+		resp := ExpiryReport{Buckets: hist}
+		for _, n := range hist {
+			resp.Total += n
 		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
 	}
 }
+
+// RequireToken wraps next so that requests must carry
+// Authorization: Bearer <token>. An empty token allows every request.
+func RequireToken(token *Token, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RotateTokenHandler returns an http.HandlerFunc that replaces token with the
+// request body (surrounding whitespace trimmed). Only POST is accepted and the
+// request must carry the current token; the old token stops working as soon
+// as the response is written. An empty new token is rejected so rotation
+// can never switch authentication off.
+func RotateTokenHandler(token *Token) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxTokenBytes+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		next := strings.TrimSpace(string(body))
+		if next == "" || len(body) > maxTokenBytes {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token.Set(next)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Resetter zeroes all metrics; *Manager implements it.
+type Resetter interface {
+	Reset(ctx context.Context) error
+}
+
+// ResetHandler returns an http.HandlerFunc that zeroes all metrics via r.
+// Only POST is accepted and the request must carry the current token.
+func ResetHandler(r Resetter, token *Token) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(req, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.Reset(req.Context()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized reports whether r carries the expected bearer token.
+func authorized(r *http.Request, t *Token) bool {
+	token := t.Get()
+	if token == "" {
+		return true
+	}
+	hdr := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	return len(hdr) > len(prefix) && hdr[:len(prefix)] == prefix && hdr[len(prefix):] == token
+}
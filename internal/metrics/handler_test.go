@@ -3,9 +3,12 @@ package metrics
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type fakeSnapshot struct {
@@ -20,7 +23,7 @@ func (f *fakeSnapshot) Snapshot(ctx context.Context) (map[string]int64, map[stri
 
 func TestHandlerAuth(t *testing.T) {
 	f := &fakeSnapshot{c: map[string]int64{"a": 1}, s: map[string]summaryAgg{"x": {count: 2, sum: 5, min: 2, max: 3}}}
-	h := Handler(f, "tok")
+	h := Handler(f, NewToken("tok"))
 
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rw := httptest.NewRecorder()
@@ -53,10 +56,155 @@ func TestHandlerAuth(t *testing.T) {
 
 func TestHandlerNoToken(t *testing.T) {
 	f := &fakeSnapshot{c: map[string]int64{"c": 10}, s: map[string]summaryAgg{}}
-	h := Handler(f, "")
+	h := Handler(f, nil)
 	rw := httptest.NewRecorder()
 	h(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
 	if rw.Code != http.StatusOK {
 		t.Fatalf("expected 200 got %d", rw.Code)
 	}
 }
+
+type fakeExpiry struct {
+	hist map[string]int64
+	err  error
+	got  []time.Duration
+}
+
+func (f *fakeExpiry) ExpiryHistogram(_ context.Context, buckets []time.Duration) (map[string]int64, error) {
+	f.got = buckets
+	return f.hist, f.err
+}
+
+func TestExpiryHandler(t *testing.T) {
+	f := &fakeExpiry{hist: map[string]int64{"5m0s": 2, "1h0m0s": 1, "+Inf": 0}}
+	buckets := []time.Duration{5 * time.Minute, time.Hour}
+	h := ExpiryHandler(f, buckets, NewToken("tok"))
+
+	rw := httptest.NewRecorder()
+	h(rw, httptest.NewRequest(http.MethodGet, "/admin/expiry", nil))
+	if rw.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 got %d", rw.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/expiry", nil)
+	req.Header.Set("Authorization", "Bearer tok")
+	rw = httptest.NewRecorder()
+	h(rw, req)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rw.Code)
+	}
+	var rep ExpiryReport
+	if err := json.Unmarshal(rw.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rep.Total != 3 || rep.Buckets["5m0s"] != 2 || len(rep.Buckets) != 3 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if len(f.got) != 2 || f.got[1] != time.Hour {
+		t.Fatalf("buckets not passed through: %v", f.got)
+	}
+
+	f.err = errors.New("boom")
+	rw = httptest.NewRecorder()
+	ExpiryHandler(f, buckets, nil)(rw, httptest.NewRequest(http.MethodGet, "/admin/expiry", nil))
+	if rw.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 got %d", rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	ExpiryHandler(nil, buckets, nil)(rw, httptest.NewRequest(http.MethodGet, "/admin/expiry", nil))
+	if rw.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without source got %d", rw.Code)
+	}
+}
+
+func TestRotateTokenHandler(t *testing.T) {
+	f := &fakeSnapshot{c: map[string]int64{}, s: map[string]summaryAgg{}}
+	token := NewToken("old")
+	snap := Handler(f, token)
+	rotate := RotateTokenHandler(token)
+
+	get := func(bearer string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rw := httptest.NewRecorder()
+		snap(rw, req)
+		return rw.Code
+	}
+	post := func(bearer, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/metrics/rotate-token", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rw := httptest.NewRecorder()
+		rotate(rw, req)
+		return rw.Code
+	}
+
+	if code := post("wrong", "new"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token got %d", code)
+	}
+	if code := post("old", "  \n"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty token got %d", code)
+	}
+	if code := post("old", strings.Repeat("x", maxTokenBytes+1)); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for oversized token got %d", code)
+	}
+	rw := httptest.NewRecorder()
+	rotate(rw, httptest.NewRequest(http.MethodGet, "/metrics/rotate-token", nil))
+	if rw.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 got %d", rw.Code)
+	}
+	if token.Get() != "old" {
+		t.Fatalf("token changed by rejected request: %q", token.Get())
+	}
+
+	if code := post("old", "new\n"); code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", code)
+	}
+	if code := get("old"); code != http.StatusUnauthorized {
+		t.Fatalf("old token still accepted: %d", code)
+	}
+	if code := get("new"); code != http.StatusOK {
+		t.Fatalf("new token rejected: %d", code)
+	}
+	if code := post("old", "newer"); code != http.StatusUnauthorized {
+		t.Fatalf("old token rotated again: %d", code)
+	}
+}
+
+type fakeResetter struct {
+	calls int
+	err   error
+}
+
+func (f *fakeResetter) Reset(context.Context) error {
+	f.calls++
+	return f.err
+}
+
+func TestResetHandler(t *testing.T) {
+	f := &fakeResetter{}
+	h := ResetHandler(f, NewToken("tok"))
+	do := func(method, bearer string) int {
+		req := httptest.NewRequest(method, "/metrics/reset", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rw := httptest.NewRecorder()
+		h(rw, req)
+		return rw.Code
+	}
+	if code := do(http.MethodGet, "tok"); code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: got %d", code)
+	}
+	if code := do(http.MethodPost, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: got %d", code)
+	}
+	if f.calls != 0 {
+		t.Fatalf("rejected requests reset metrics %d times", f.calls)
+	}
+	if code := do(http.MethodPost, "tok"); code != http.StatusNoContent || f.calls != 1 {
+		t.Fatalf("reset: got %d after %d calls", code, f.calls)
+	}
+	f.err = errors.New("boom")
+	if code := do(http.MethodPost, "tok"); code != http.StatusInternalServerError {
+		t.Fatalf("failing reset: got %d", code)
+	}
+}
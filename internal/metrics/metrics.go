@@ -3,14 +3,19 @@
 // flushes them to the shared SQLite database used for secrets. The design
 // intentionally avoids dependencies and complex histogram logic; only
 // monotonic counters and simple (count,sum,min,max) summaries are supported.
+// Config.InMemory disables persistence entirely.
 package metrics
 
 import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,20 +24,54 @@ const (
 	CounterSecretsCreated       = "secrets_created_total"
 	CounterSecretsConsumed      = "secrets_consumed_total"
 	CounterSecretsExpiredDelete = "secrets_expired_deleted_total"
+	// CounterEventsDropped counts Inc/Observe events discarded because the
+	// event buffer was full. It is tracked outside the channel.
+	CounterEventsDropped = "metrics_events_dropped_total"
+	// CounterBlobIntegrityFailures counts index entries whose blob was
+	// missing or wrongly sized during an integrity scan.
+	CounterBlobIntegrityFailures = "blob_integrity_failures_total"
+	// CounterJanitorSkippedCycles counts janitor cycles dropped because the
+	// previous one was still running.
+	CounterJanitorSkippedCycles = "janitor_skipped_cycles_total"
+	// CounterSecretsDestroyed counts secrets removed after too many wrong
+	// passphrases (GONE_MAX_CONSUME_ATTEMPTS).
+	CounterSecretsDestroyed = "secrets_destroyed_total"
 	// Future: CounterOrphanBlobsDeleted = "secrets_orphan_blobs_deleted_total"
 )
 
 // Summary names.
 const (
 	SummaryJanitorDeletedPerCycle = "janitor_deleted_per_cycle"
+	SummarySecretSizeBytes        = "secret_size_bytes"
+	SummaryLatencyCreateUS        = "latency_create_us"
+	SummaryLatencyConsumeUS       = "latency_consume_us"
 )
 
 // Config controls flush cadence and logging.
 type Config struct {
 	FlushInterval time.Duration
 	Logger        *slog.Logger
+	// InMemory keeps counters and summaries in process memory only: nothing
+	// is written to (or read from) the database and values reset on restart.
+	InMemory bool
+	// ReadDB, if set, serves the persisted reads behind Snapshot so they do
+	// not contend with flushes on the primary handle.
+	ReadDB *sql.DB
+	// LogInterval, if positive, logs a Snapshot summary at roughly this
+	// cadence, rounded to whole flush cycles (at least one). Zero disables it.
+	LogInterval time.Duration
+	// BufferSize is the event channel capacity (0 = DefaultBufferSize).
+	BufferSize int
+	// BlockTimeout, if positive, makes Inc and Observe wait up to this long
+	// for room in a full event channel before dropping, trading caller
+	// latency for accuracy. Zero drops immediately.
+	BlockTimeout time.Duration
 }
 
+// DefaultBufferSize is the event channel capacity when Config.BufferSize is
+// unset.
+const DefaultBufferSize = 1024
+
 // Manager aggregates metric events and flushes them.
 type Manager struct {
 	cfg     Config
@@ -42,10 +81,18 @@ type Manager struct {
 	done    chan struct{}
 	started bool
 
+	// flushMu serializes flush and Reset, so a flush that has already taken
+	// the deltas cannot commit them after a reset truncated the tables.
+	flushMu sync.Mutex
+
 	// in-memory deltas (protected by mu)
 	mu        sync.Mutex
 	counters  map[string]int64
 	summaries map[string]*summaryAgg
+
+	// dropped counts events lost to a full buffer. It is atomic so the drop
+	// path never blocks or re-enters the (full) channel.
+	dropped atomic.Int64
 }
 
 type eventKind int
@@ -76,10 +123,13 @@ func New(db *sql.DB, cfg Config) *Manager {
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
 	m := &Manager{
 		cfg:       cfg,
 		db:        db,
-		events:    make(chan event, 1024),
+		events:    make(chan event, cfg.BufferSize),
 		stop:      make(chan struct{}),
 		done:      make(chan struct{}),
 		counters:  make(map[string]int64),
@@ -88,8 +138,11 @@ func New(db *sql.DB, cfg Config) *Manager {
 	return m
 }
 
-// InitSchema ensures metrics tables exist.
+// InitSchema ensures metrics tables exist. It is a no-op in InMemory mode.
 func (m *Manager) InitSchema(ctx context.Context) error {
+	if m.cfg.InMemory {
+		return nil
+	}
 	ddlCounters := `CREATE TABLE IF NOT EXISTS metrics_counters (
 		name TEXT PRIMARY KEY,
 		value INTEGER NOT NULL
@@ -110,6 +163,25 @@ func (m *Manager) InitSchema(ctx context.Context) error {
 	return nil
 }
 
+// Healthy reports whether the tables flush writes to are usable, so a broken
+// metrics schema fails readiness. It is a no-op in InMemory mode.
+func (m *Manager) Healthy(ctx context.Context) error {
+	if m.cfg.InMemory {
+		return nil
+	}
+	for _, q := range []string{
+		`SELECT name, value FROM metrics_counters LIMIT 1`,
+		`SELECT name, count, sum, min, max FROM metrics_summaries LIMIT 1`,
+	} {
+		rows, err := m.db.QueryContext(ctx, q)
+		if err != nil {
+			return fmt.Errorf("metrics schema: %w", err)
+		}
+		_ = rows.Close()
+	}
+	return nil
+}
+
 // Start launches the background flush loop.
 func (m *Manager) Start(ctx context.Context) {
 	if m.started {
@@ -136,24 +208,41 @@ func (m *Manager) Inc(name string, delta int64) {
 	if delta <= 0 {
 		return
 	}
-	select {
-	case m.events <- event{kind: eventInc, name: name, v: delta}:
-	default:
-		// channel full; best-effort drop (could add a dropped counter later)
-	}
+	m.send(event{kind: eventInc, name: name, v: delta})
 }
 
 // Observe records a summary observation.
 func (m *Manager) Observe(name string, value int64) {
+	m.send(event{kind: eventObserve, name: name, v: value})
+}
+
+// send queues ev, waiting up to BlockTimeout for room when the channel is
+// full. Events that still do not fit are counted as dropped.
+func (m *Manager) send(ev event) {
 	select {
-	case m.events <- event{kind: eventObserve, name: name, v: value}:
+	case m.events <- ev:
+		return
 	default:
 	}
+	if m.cfg.BlockTimeout > 0 {
+		t := time.NewTimer(m.cfg.BlockTimeout)
+		defer t.Stop()
+		select {
+		case m.events <- ev:
+			return
+		case <-t.C:
+		}
+	}
+	m.dropped.Add(1)
 }
 
 func (m *Manager) loop(ctx context.Context) {
 	log := m.cfg.Logger.With("domain", "metrics")
 	Ticker := time.NewTicker(m.cfg.FlushInterval)
+	var every, cycles int64
+	if m.cfg.LogInterval > 0 {
+		every = max(1, int64(m.cfg.LogInterval/m.cfg.FlushInterval))
+	}
 	defer func() {
 		Ticker.Stop()
 		close(m.done)
@@ -172,10 +261,34 @@ func (m *Manager) loop(ctx context.Context) {
 			if err := m.flush(ctx); err != nil && !errors.Is(err, context.Canceled) {
 				log.Error("flush", "error", err)
 			}
+			if cycles++; every > 0 && cycles%every == 0 {
+				m.logSummary(ctx, log)
+			}
 		}
 	}
 }
 
+// logSummary writes the current Snapshot as a single structured log line,
+// with counters and summaries grouped and sorted by name.
+func (m *Manager) logSummary(ctx context.Context, log *slog.Logger) {
+	counters, summaries, err := m.Snapshot(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Error("summary", "error", err)
+		}
+		return
+	}
+	var cs, ss []any
+	for _, name := range slices.Sorted(maps.Keys(counters)) {
+		cs = append(cs, slog.Int64(name, counters[name]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(summaries)) {
+		agg := summaries[name]
+		ss = append(ss, slog.Group(name, "count", agg.count, "sum", agg.sum, "min", agg.min, "max", agg.max))
+	}
+	log.Info("metrics summary", slog.Group("counters", cs...), slog.Group("summaries", ss...))
+}
+
 func (m *Manager) apply(ev event) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -201,8 +314,14 @@ func (m *Manager) apply(ev event) {
 }
 
 // Snapshot returns current (persisted + in-memory deltas) by reading persisted
-// state and layering deltas. This is optional and may be refined later.
+// state and layering deltas. In InMemory mode only the in-memory state is
+// returned.
 func (m *Manager) Snapshot(ctx context.Context) (counters map[string]int64, summaries map[string]summaryAgg, err error) {
+	if m.cfg.InMemory {
+		counters, summaries = make(map[string]int64), make(map[string]summaryAgg)
+		m.layerDeltas(counters, summaries)
+		return counters, summaries, nil
+	}
 	counters, err = m.loadPersistedCounters(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -215,10 +334,18 @@ func (m *Manager) Snapshot(ctx context.Context) (counters map[string]int64, summ
 	return counters, summaries, nil
 }
 
+// reader returns the handle used for persisted reads.
+func (m *Manager) reader() *sql.DB {
+	if m.cfg.ReadDB != nil {
+		return m.cfg.ReadDB
+	}
+	return m.db
+}
+
 // loadPersistedCounters reads counters from storage.
 func (m *Manager) loadPersistedCounters(ctx context.Context) (map[string]int64, error) {
 	counters := make(map[string]int64)
-	rows, err := m.db.QueryContext(ctx, `SELECT name, value FROM metrics_counters`)
+	rows, err := m.reader().QueryContext(ctx, `SELECT name, value FROM metrics_counters`)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +364,7 @@ func (m *Manager) loadPersistedCounters(ctx context.Context) (map[string]int64,
 // loadPersistedSummaries reads summaries from storage.
 func (m *Manager) loadPersistedSummaries(ctx context.Context) (map[string]summaryAgg, error) {
 	summaries := make(map[string]summaryAgg)
-	rows, err := m.db.QueryContext(ctx, `SELECT name, count, sum, min, max FROM metrics_summaries`)
+	rows, err := m.reader().QueryContext(ctx, `SELECT name, count, sum, min, max FROM metrics_summaries`)
 	if err != nil {
 		return nil, err
 	}
@@ -260,6 +387,9 @@ func (m *Manager) layerDeltas(counters map[string]int64, summaries map[string]su
 	for n, v := range m.counters {
 		counters[n] += v
 	}
+	if d := m.dropped.Load(); d > 0 {
+		counters[CounterEventsDropped] += d
+	}
 	for n, agg := range m.summaries {
 		cur := summaries[n]
 		if cur.count == 0 { // no persisted value yet
@@ -279,7 +409,17 @@ func (m *Manager) layerDeltas(counters map[string]int64, summaries map[string]su
 }
 
 // flush writes in-memory deltas to SQLite in a single transaction and resets them.
+// In InMemory mode the deltas are the only state, so they are kept and only
+// dropped events are folded in.
 func (m *Manager) flush(ctx context.Context) error {
+	if m.cfg.InMemory {
+		m.mu.Lock()
+		m.foldDropped()
+		m.mu.Unlock()
+		return nil
+	}
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
 	cCopy, sCopy, ok := m.swapAndCopyDeltas()
 	if !ok { // nothing to flush
 		return nil
@@ -297,11 +437,41 @@ func (m *Manager) flush(ctx context.Context) error {
 	return tx.Commit()
 }
 
+// Reset zeroes every counter and summary: the persisted tables are emptied
+// and the in-memory deltas and dropped-event tally cleared, all while flushes
+// and event application are held off. Events recorded after Reset returns are
+// counted from zero; events still queued when it runs may land on either side.
+func (m *Manager) Reset(ctx context.Context) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.cfg.InMemory {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, q := range []string{`DELETE FROM metrics_counters`, `DELETE FROM metrics_summaries`} {
+			if _, err := tx.ExecContext(ctx, q); err != nil {
+				return errors.Join(err, tx.Rollback())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	m.counters = make(map[string]int64)
+	m.summaries = make(map[string]*summaryAgg)
+	m.dropped.Store(0)
+	return nil
+}
+
 // swapAndCopyDeltas copies in-memory deltas and resets maps under lock.
 // Returns false if there is nothing to flush.
 func (m *Manager) swapAndCopyDeltas() (map[string]int64, map[string]*summaryAgg, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.foldDropped()
 	if len(m.counters) == 0 && len(m.summaries) == 0 {
 		return nil, nil, false
 	}
@@ -319,6 +489,16 @@ func (m *Manager) swapAndCopyDeltas() (map[string]int64, map[string]*summaryAgg,
 	return cCopy, sCopy, true
 }
 
+// foldDropped moves the dropped-event tally into the counter deltas. Callers
+// hold mu.
+func (m *Manager) foldDropped() {
+	if d := m.dropped.Swap(0); d > 0 {
+		m.counters[CounterEventsDropped] += d
+		// At most one warning per flush keeps the log rate bounded.
+		m.cfg.Logger.Warn("metrics events dropped", "domain", "metrics", "count", d)
+	}
+}
+
 // upsertCounters persists counter deltas.
 func (m *Manager) upsertCounters(ctx context.Context, tx *sql.Tx, counters map[string]int64) error {
 	for name, delta := range counters {
@@ -3,7 +3,10 @@ package metrics
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -381,3 +384,330 @@ func TestManagerObserveChannelFullDrop(t *testing.T) {
 		t.Fatalf("expected only first observe kept %+v", agg)
 	}
 }
+
+func TestManagerDropCounter(t *testing.T) {
+	db := openTempDB(t)
+	m := New(db, Config{})
+	ctx := context.Background()
+	if err := m.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	m.events = make(chan event, 1)
+	m.Inc(CounterSecretsCreated, 1)             // fills buffer
+	m.Inc(CounterSecretsCreated, 1)             // dropped
+	m.Observe(SummaryJanitorDeletedPerCycle, 5) // dropped
+	counters, _, err := m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if counters[CounterEventsDropped] != 2 {
+		t.Fatalf("expected 2 drops before flush got %d", counters[CounterEventsDropped])
+	}
+	if err := m.flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	m.Inc(CounterSecretsCreated, 1) // buffer still full; dropped again
+	counters, _, err = m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if counters[CounterEventsDropped] != 3 {
+		t.Fatalf("expected persisted+pending drops 3 got %d", counters[CounterEventsDropped])
+	}
+}
+
+func TestManagerInMemory(t *testing.T) {
+	db := openTempDB(t)
+	m := New(db, Config{FlushInterval: time.Hour, InMemory: true})
+	ctx := context.Background()
+	if err := m.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	m.Inc(CounterSecretsCreated, 2)
+	m.Observe(SummarySecretSizeBytes, 10)
+	m.Observe(SummarySecretSizeBytes, 30)
+	for len(m.events) > 0 {
+		m.apply(<-m.events)
+	}
+	// Flushes must not reset the in-memory state, which is all there is.
+	if err := m.flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	m.Inc(CounterSecretsCreated, 1)
+	m.apply(<-m.events)
+	m.Stop(ctx)
+
+	counters, summaries, err := m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if counters[CounterSecretsCreated] != 3 {
+		t.Fatalf("expected 3 got %d", counters[CounterSecretsCreated])
+	}
+	if agg := summaries[SummarySecretSizeBytes]; agg.count != 2 || agg.sum != 40 || agg.min != 10 || agg.max != 30 {
+		t.Fatalf("bad summary %+v", agg)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'metrics_%'`).Scan(&n); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("expected no metrics tables, found %d", n)
+	}
+}
+
+func TestManagerInMemoryNoDB(t *testing.T) {
+	// A nil db would panic on any access, proving none happens.
+	m := New(nil, Config{FlushInterval: 5 * time.Millisecond, InMemory: true})
+	ctx := context.Background()
+	if err := m.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	m.Start(ctx)
+	m.Inc(CounterSecretsConsumed, 4)
+	time.Sleep(20 * time.Millisecond) // several flush ticks
+	m.Stop(ctx)
+	counters, _, err := m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if counters[CounterSecretsConsumed] != 4 {
+		t.Fatalf("expected 4 got %d", counters[CounterSecretsConsumed])
+	}
+}
+
+func TestManagerSnapshotReadDB(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "m.db")
+	db, err := sql.Open("sqlite3", p+"?_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ro, err := sql.Open("sqlite3", "file:"+p+"?mode=ro")
+	if err != nil {
+		t.Fatalf("open ro: %v", err)
+	}
+	m := New(db, Config{ReadDB: ro})
+	ctx := context.Background()
+	if err := m.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	m.Inc(CounterSecretsCreated, 2)
+	m.apply(<-m.events)
+	if err := m.flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	counters, _, err := m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if counters[CounterSecretsCreated] != 2 {
+		t.Fatalf("expected 2 got %d", counters[CounterSecretsCreated])
+	}
+	ro.Close()
+	if _, _, err := m.Snapshot(ctx); err == nil {
+		t.Fatalf("snapshot did not read through ReadDB")
+	}
+}
+
+func TestManagerHealthy(t *testing.T) {
+	db := openTempDB(t)
+	m := New(db, Config{})
+	ctx := context.Background()
+	if err := m.Healthy(ctx); err == nil {
+		t.Fatal("expected error before schema init")
+	}
+	if err := m.InitSchema(ctx); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	if err := m.Healthy(ctx); err != nil {
+		t.Fatalf("healthy: %v", err)
+	}
+	if _, err := db.Exec(`DROP TABLE metrics_summaries`); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if err := m.Healthy(ctx); err == nil {
+		t.Fatal("expected error after dropping metrics_summaries")
+	}
+	if err := New(db, Config{InMemory: true}).Healthy(ctx); err != nil {
+		t.Fatalf("in-memory healthy: %v", err)
+	}
+}
+
+// drainEvents applies queued events when the loop is not running.
+func drainEvents(m *Manager) {
+	for {
+		select {
+		case ev := <-m.events:
+			m.apply(ev)
+		default:
+			return
+		}
+	}
+}
+
+func TestManagerReset(t *testing.T) {
+	for _, inMemory := range []bool{false, true} {
+		t.Run(map[bool]string{false: "persisted", true: "in-memory"}[inMemory], func(t *testing.T) {
+			ctx := context.Background()
+			m := New(openTempDB(t), Config{FlushInterval: time.Millisecond, InMemory: inMemory})
+			if err := m.InitSchema(ctx); err != nil {
+				t.Fatalf("schema: %v", err)
+			}
+			m.Inc(CounterSecretsCreated, 5)
+			m.Observe(SummarySecretSizeBytes, 100)
+			drainEvents(m)
+			if err := m.flush(ctx); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+			m.Inc(CounterSecretsConsumed, 2) // unflushed delta
+			drainEvents(m)
+			m.dropped.Add(3)
+
+			if err := m.Reset(ctx); err != nil {
+				t.Fatalf("Reset: %v", err)
+			}
+			counters, summaries, err := m.Snapshot(ctx)
+			if err != nil {
+				t.Fatalf("snapshot: %v", err)
+			}
+			if len(counters) != 0 || len(summaries) != 0 {
+				t.Fatalf("after reset: counters=%v summaries=%v", counters, summaries)
+			}
+
+			// Increments racing the flush loop after the reset all count.
+			m.Start(ctx)
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 100 {
+						m.Inc(CounterSecretsCreated, 1)
+						time.Sleep(10 * time.Microsecond)
+					}
+				}()
+			}
+			wg.Wait()
+			time.Sleep(20 * time.Millisecond) // let the loop apply queued events
+			m.Stop(ctx)
+			counters, _, err = m.Snapshot(ctx)
+			if err != nil {
+				t.Fatalf("snapshot: %v", err)
+			}
+			if got := counters[CounterSecretsCreated] + counters[CounterEventsDropped]; got != 800 {
+				t.Fatalf("post-reset increments = %v, want 800 counted or dropped", counters)
+			}
+		})
+	}
+}
+
+// summaryCounter is a slog.Handler counting "metrics summary" records.
+type summaryCounter struct {
+	slog.Handler
+	n *atomic.Int64
+}
+
+func (c summaryCounter) Enabled(context.Context, slog.Level) bool { return true }
+
+func (c summaryCounter) Handle(_ context.Context, r slog.Record) error {
+	if r.Message == "metrics summary" {
+		c.n.Add(1)
+	}
+	return nil
+}
+
+func (c summaryCounter) WithAttrs([]slog.Attr) slog.Handler { return c }
+
+func TestManagerLogInterval(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		interval time.Duration
+		want     bool
+	}{
+		{"disabled", 0, false},
+		{"every third flush", 30 * time.Millisecond, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := new(atomic.Int64)
+			m := New(nil, Config{
+				FlushInterval: 10 * time.Millisecond,
+				LogInterval:   tc.interval,
+				Logger:        slog.New(summaryCounter{n: n}),
+				InMemory:      true,
+			})
+			m.Inc(CounterSecretsCreated, 1)
+			start := time.Now()
+			m.Start(context.Background())
+			if tc.want {
+				deadline := time.Now().Add(2 * time.Second)
+				for n.Load() < 2 && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+			} else {
+				time.Sleep(60 * time.Millisecond)
+			}
+			m.Stop(context.Background())
+			got := n.Load()
+			if !tc.want {
+				if got != 0 {
+					t.Fatalf("summaries logged with interval 0: %d", got)
+				}
+				return
+			}
+			if got < 2 {
+				t.Fatalf("summaries = %d, want at least 2", got)
+			}
+			// Two summaries need at least six flush cycles.
+			if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+				t.Fatalf("two summaries after %v, faster than every third flush", elapsed)
+			}
+		})
+	}
+}
+
+func TestManagerBufferFullModes(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		m := New(nil, Config{BufferSize: 1, InMemory: true})
+		if cap(m.events) != 1 {
+			t.Fatalf("buffer cap %d", cap(m.events))
+		}
+		m.Inc(CounterSecretsCreated, 1)
+		start := time.Now()
+		m.Inc(CounterSecretsCreated, 1)
+		if time.Since(start) > 50*time.Millisecond {
+			t.Fatal("drop mode blocked")
+		}
+		if got := m.dropped.Load(); got != 1 {
+			t.Fatalf("dropped %d, want 1", got)
+		}
+	})
+	t.Run("block times out", func(t *testing.T) {
+		m := New(nil, Config{BufferSize: 1, BlockTimeout: 20 * time.Millisecond, InMemory: true})
+		m.Inc(CounterSecretsCreated, 1)
+		start := time.Now()
+		m.Observe(SummarySecretSizeBytes, 5)
+		if waited := time.Since(start); waited < 20*time.Millisecond {
+			t.Fatalf("returned after %v, before the block timeout", waited)
+		}
+		if got := m.dropped.Load(); got != 1 {
+			t.Fatalf("dropped %d, want 1", got)
+		}
+	})
+	t.Run("block gets room", func(t *testing.T) {
+		m := New(nil, Config{BufferSize: 1, BlockTimeout: 2 * time.Second, InMemory: true})
+		m.Inc(CounterSecretsCreated, 1)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			m.apply(<-m.events)
+		}()
+		m.Inc(CounterSecretsCreated, 2)
+		if got := m.dropped.Load(); got != 0 {
+			t.Fatalf("dropped %d, want 0", got)
+		}
+		if ev := <-m.events; ev.v != 2 {
+			t.Fatalf("queued event %+v", ev)
+		}
+	})
+}
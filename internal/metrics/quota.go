@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Quota caps creates per namespace per UTC day. The counter and the day it
+// belongs to live in the metrics database, so restarts do not reset the cap.
+// It implements app.CreateQuota.
+type Quota struct {
+	db    *sql.DB
+	limit int64
+}
+
+// NewQuota returns a Quota admitting limit creates per namespace per UTC day
+// and ensures its table exists.
+func NewQuota(ctx context.Context, db *sql.DB, limit int64) (*Quota, error) {
+	const ddl = `CREATE TABLE IF NOT EXISTS create_quota (
+		namespace TEXT PRIMARY KEY,
+		day INTEGER NOT NULL,
+		count INTEGER NOT NULL
+	);`
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		return nil, err
+	}
+	return &Quota{db: db, limit: limit}, nil
+}
+
+// Take claims one create for namespace on now's UTC day. The check and the
+// increment are a single upsert: a new day restarts the count at one, and a
+// full day leaves the row untouched and returns no row.
+func (q *Quota) Take(ctx context.Context, namespace string, now time.Time) (bool, error) {
+	const upsert = `INSERT INTO create_quota(namespace, day, count) VALUES(?, ?, 1)
+		ON CONFLICT(namespace) DO UPDATE SET
+			count = CASE WHEN day = excluded.day THEN count + 1 ELSE 1 END,
+			day = excluded.day
+		WHERE day != excluded.day OR count < ?
+		RETURNING count`
+	day := now.UTC().Unix() / int64(24*time.Hour/time.Second)
+	var n int64
+	err := q.db.QueryRowContext(ctx, upsert, namespace, day, q.limit).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuotaTake(t *testing.T) {
+	ctx := context.Background()
+	db := openTempDB(t)
+	q, err := NewQuota(ctx, db, 3)
+	if err != nil {
+		t.Fatalf("new quota: %v", err)
+	}
+	day := time.Date(2025, 3, 1, 23, 0, 0, 0, time.UTC)
+	take := func(ns string, now time.Time) bool {
+		t.Helper()
+		ok, err := q.Take(ctx, ns, now)
+		if err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		return ok
+	}
+	for i := 0; i < 3; i++ {
+		if !take("", day) {
+			t.Fatalf("create %d rejected within quota", i+1)
+		}
+	}
+	if take("", day.Add(59*time.Minute)) {
+		t.Fatalf("4th create in the same UTC day admitted")
+	}
+	// Namespaces are counted separately.
+	if !take("acme", day) {
+		t.Fatalf("tenant charged for root creates")
+	}
+	// The window resets at UTC midnight.
+	next := day.Add(time.Hour)
+	if !take("", next) {
+		t.Fatalf("create rejected after UTC midnight")
+	}
+	if !take("", next) || !take("", next) || take("", next) {
+		t.Fatalf("new day did not get exactly the full quota")
+	}
+
+	// The count survives a new Quota on the same database (restart).
+	q2, err := NewQuota(ctx, db, 3)
+	if err != nil {
+		t.Fatalf("reopen quota: %v", err)
+	}
+	if ok, err := q2.Take(ctx, "", next); err != nil || ok {
+		t.Fatalf("quota reset by restart: ok=%v err=%v", ok, err)
+	}
+}
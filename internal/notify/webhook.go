@@ -0,0 +1,86 @@
+// Package notify provides store.ExpiryNotifier adapters that tell an external
+// system about secrets that expired without being read. Notifications never
+// contain secret contents or full secret IDs.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/store"
+)
+
+var _ store.ExpiryNotifier = (*Webhook)(nil)
+
+// EventExpiredUnread names the webhook event for secrets that expired unread.
+const EventExpiredUnread = "expired_unread"
+
+// defaultTimeout bounds each webhook delivery.
+const defaultTimeout = 10 * time.Second
+
+// Payload is the JSON body POSTed for one expiry sweep.
+type Payload struct {
+	Event   string          `json:"event"`
+	Time    time.Time       `json:"time"`
+	Secrets []ExpiredSecret `json:"secrets"`
+}
+
+// ExpiredSecret describes one secret in a Payload. IDHash is the app.HashID
+// fingerprint, the same one used in audit records and traces.
+type ExpiredSecret struct {
+	IDHash   string `json:"id_hash"`
+	Tenant   string `json:"tenant,omitempty"`
+	External bool   `json:"external"`
+}
+
+// Webhook POSTs a Payload to a fixed URL. Delivery is attempted once; a
+// failure or non-2xx answer is logged and dropped.
+type Webhook struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+	log    *slog.Logger
+}
+
+// NewWebhook returns a Webhook delivering to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: defaultTimeout}, now: time.Now, log: slog.Default().With("domain", "notify")}
+}
+
+// ExpiredUnread sends one expired_unread event covering recs.
+func (w *Webhook) ExpiredUnread(ctx context.Context, recs []store.ExpiredRecord) {
+	p := Payload{Event: EventExpiredUnread, Time: w.now().UTC(), Secrets: make([]ExpiredSecret, 0, len(recs))}
+	for _, r := range recs {
+		p.Secrets = append(p.Secrets, ExpiredSecret{IDHash: app.HashID(r.ID), Tenant: r.Tenant, External: r.External})
+	}
+	if err := w.post(ctx, p); err != nil {
+		w.log.Error("expiry webhook", "count", len(recs), "error", err)
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/store"
+)
+
+func TestWebhookExpiredUnread(t *testing.T) {
+	const id = "0123456789abcdef0123456789abcdef"
+	got := make(chan Payload, 1)
+	var raw string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("method=%s ct=%s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		raw = string(body)
+		var p Payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		got <- p
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL)
+	now := time.Unix(1700000000, 0).UTC()
+	wh.now = func() time.Time { return now }
+	wh.ExpiredUnread(context.Background(), []store.ExpiredRecord{{ID: id, External: true, Tenant: "acme"}, {ID: strings.Repeat("f", 32)}})
+
+	p := <-got
+	if p.Event != EventExpiredUnread || !p.Time.Equal(now) || len(p.Secrets) != 2 {
+		t.Fatalf("payload %+v", p)
+	}
+	if s := p.Secrets[0]; s.IDHash != app.HashID(id) || s.Tenant != "acme" || !s.External {
+		t.Fatalf("secret %+v", s)
+	}
+	if strings.Contains(raw, id) {
+		t.Fatalf("full id leaked: %s", raw)
+	}
+}
+
+func TestWebhookFailureIsLogged(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	wh := NewWebhook(srv.URL)
+	if err := wh.post(context.Background(), Payload{Event: EventExpiredUnread}); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	// Delivery failures never panic or block the sweep.
+	wh.ExpiredUnread(context.Background(), []store.ExpiredRecord{{ID: strings.Repeat("a", 32)}})
+	if calls != 2 {
+		t.Fatalf("expected one attempt per call, got %d", calls)
+	}
+	NewWebhook("http://127.0.0.1:0/unreachable").ExpiredUnread(context.Background(), nil)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// WithExistsCache keeps the last size Exists results for up to ttl so pages
+// polling the same link do not hit the index every time. Only the liveness
+// boolean is cached, never secret data. Save, Consume, FailAttempt, expiry
+// sweeps and integrity repairs drop the affected entry, and Consume always
+// goes to the index, so a stale entry can never let a secret be read twice;
+// at worst Exists lags a change made elsewhere (another node, a race with an
+// in-flight probe) by ttl. A size or ttl of zero or less disables the cache.
+func WithExistsCache(size int, ttl time.Duration) Option {
+	return func(s *Store) {
+		if size > 0 && ttl > 0 {
+			s.exists = newExistsCache(size, ttl)
+		}
+	}
+}
+
+// existsCache is a mutex-guarded LRU of Exists results keyed by tenant and
+// ID. A nil *existsCache is valid and caches nothing.
+type existsCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List // front = most recently used; values are *existsEntry
+	items map[existsKey]*list.Element
+}
+
+type existsKey struct{ tenant, id string }
+
+type existsEntry struct {
+	key  existsKey
+	live bool
+	at   time.Time
+}
+
+func newExistsCache(size int, ttl time.Duration) *existsCache {
+	return &existsCache{size: size, ttl: ttl, order: list.New(), items: make(map[existsKey]*list.Element, size)}
+}
+
+// get returns the cached result for k if it is younger than ttl at now.
+func (c *existsCache) get(k existsKey, now time.Time) (live, ok bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[k]
+	if !ok {
+		return false, false
+	}
+	e := el.Value.(*existsEntry)
+	if now.Sub(e.at) >= c.ttl {
+		c.order.Remove(el)
+		delete(c.items, k)
+		return false, false
+	}
+	c.order.MoveToFront(el)
+	return e.live, true
+}
+
+// put records live for k at now, evicting the least recently used entry when
+// the cache is full.
+func (c *existsCache) put(k existsKey, live bool, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		e := el.Value.(*existsEntry)
+		e.live, e.at = live, now
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*existsEntry).key)
+	}
+	c.items[k] = c.order.PushFront(&existsEntry{key: k, live: live, at: now})
+}
+
+// forget drops any cached result for k.
+func (c *existsCache) forget(k existsKey) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		c.order.Remove(el)
+		delete(c.items, k)
+	}
+}
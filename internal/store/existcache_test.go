@@ -0,0 +1,72 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+)
+
+// probeCountingIndex counts Exists calls that reach a real index.
+type probeCountingIndex struct {
+	*sqlite.Index
+	probes int
+}
+
+func (p *probeCountingIndex) Exists(ctx context.Context, id string, now time.Time) (bool, error) {
+	p.probes++
+	return p.Index.Exists(ctx, id, now)
+}
+
+func TestStoreExistsCache(t *testing.T) {
+	ctx := context.Background()
+	clk := &fixedClock{now: time.Now().UTC()}
+	ix, _ := sqlite.New(openTestDB(t))
+	idx := &probeCountingIndex{Index: ix}
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(idx, bs, clk, 64, store.WithExistsCache(2, time.Second))
+
+	const a, b, c = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "cccccccccccccccccccccccccccccccc"
+	exists := func(id string, want bool, wantProbes int) {
+		t.Helper()
+		live, err := st.Exists(ctx, id)
+		if err != nil {
+			t.Fatalf("Exists(%s): %v", id, err)
+		}
+		if live != want || idx.probes != wantProbes {
+			t.Fatalf("Exists(%s) = %v after %d probes, want %v after %d", id, live, idx.probes, want, wantProbes)
+		}
+	}
+
+	exists(a, false, 1) // miss
+	exists(a, false, 1) // hit
+	if err := st.Save(ctx, a, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte("x")), 1, clk.now.Add(time.Hour)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	exists(a, true, 2) // Save dropped the cached negative
+	exists(a, true, 2) // hit
+
+	// Consume always reaches the index and drops the cached positive.
+	if _, rc, _, err := st.Consume(ctx, a); err != nil {
+		t.Fatalf("consume: %v", err)
+	} else {
+		rc.Close()
+	}
+	exists(a, false, 3)
+	if _, _, _, err := st.Consume(ctx, a); err == nil {
+		t.Fatal("second consume succeeded")
+	}
+
+	// Entries lapse after the TTL.
+	clk.now = clk.now.Add(time.Second)
+	exists(a, false, 4)
+
+	// The least recently used entry is evicted at capacity.
+	exists(b, false, 5)
+	exists(c, false, 6)
+	exists(a, false, 7)
+}
@@ -0,0 +1,220 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Encrypted blob layout: a header of blobMagic followed by a random salt,
+// then the payload as a sequence of AES-256-GCM chunks of at most chunkSize
+// plaintext bytes. Each blob gets its own key, derived with HKDF from the
+// server key and the salt, so chunk nonces can simply count up: bytes 3..10
+// hold the chunk index and byte 11 is 1 for the final chunk, which makes
+// truncation and reordering detectable. The blob ID is the associated data,
+// binding each file to its name.
+const (
+	blobMagic = "GONEENC1"
+	saltSize  = 32
+	chunkSize = 64 << 10
+	tagSize   = 16
+	headerLen = len(blobMagic) + saltSize
+
+	// KeySize is the length of the server key accepted by WithEncryptionKey.
+	KeySize = 32
+)
+
+// errBlobCorrupt reports an encrypted blob that fails authentication or is
+// truncated.
+var errBlobCorrupt = errors.New("encrypted blob corrupt or truncated")
+
+// WithEncryptionKey encrypts blob files at rest with key (KeySize bytes).
+// Blobs written before a key was configured stay readable as they are;
+// removing the key later makes encrypted blobs unreadable.
+func WithEncryptionKey(key []byte) Option {
+	return func(b *BlobStore) { b.key = key }
+}
+
+// blobAEAD derives the per-blob AEAD from the server key and salt.
+func (b *BlobStore) blobAEAD(salt []byte) (cipher.AEAD, error) {
+	k, err := hkdf.Key(sha256.New, b.key, salt, "gone blob v1", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce builds the nonce for chunk n.
+func chunkNonce(n uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], n)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptTo streams exactly size bytes from r to w as an encrypted blob for
+// id. Memory use is bounded by one chunk regardless of size.
+func (b *BlobStore) encryptTo(w io.Writer, id string, r io.Reader, size int64) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := b.blobAEAD(salt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, blobMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write(salt); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize, chunkSize+tagSize)
+	for n := uint64(0); ; n++ {
+		m := int64(chunkSize)
+		if size < m {
+			m = size
+		}
+		if _, err := io.ReadFull(r, buf[:m]); err != nil {
+			return err
+		}
+		size -= m
+		final := size == 0
+		if _, err := w.Write(aead.Seal(buf[:0], chunkNonce(n, final), buf[:m], []byte(id))); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// hasBlobMagic reports whether the file at path starts with the encrypted
+// blob header.
+func hasBlobMagic(path string) bool {
+	f, err := os.Open(path) // #nosec G304 path constructed internally
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	hdr := make([]byte, len(blobMagic))
+	_, err = io.ReadFull(f, hdr)
+	return err == nil && string(hdr) == blobMagic
+}
+
+// openBlobFile opens path and, if it carries the encrypted header, wraps the
+// file in a decrypting reader. Otherwise the file itself is returned as the
+// reader. Either way the caller owns closing the returned file.
+func (b *BlobStore) openBlobFile(path, id string) (io.Reader, *os.File, error) {
+	f, err := os.Open(path) // #nosec G304 path constructed internally
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.key == nil {
+		return f, f, nil
+	}
+	br := bufio.NewReaderSize(f, chunkSize+tagSize)
+	hdr, err := br.Peek(headerLen)
+	if err != nil || !bytes.Equal(hdr[:len(blobMagic)], []byte(blobMagic)) {
+		return br, f, nil // plaintext blob from before encryption was enabled
+	}
+	aead, err := b.blobAEAD(hdr[len(blobMagic):])
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := br.Discard(headerLen); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return &decryptReader{src: br, aead: aead, ad: []byte(id), buf: make([]byte, 0, chunkSize+tagSize)}, f, nil
+}
+
+// decryptReader yields the plaintext of an encrypted blob one authenticated
+// chunk at a time.
+type decryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	ad      []byte
+	n       uint64
+	buf     []byte
+	pending []byte
+	done    bool
+	err     error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// next reads and opens the following chunk. A chunk is final when nothing
+// follows it; it must then carry the final nonce flag.
+func (d *decryptReader) next() error {
+	ct := d.buf[:chunkSize+tagSize]
+	m, err := io.ReadFull(d.src, ct)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return errBlobCorrupt // stream ended before the final chunk
+		}
+		return err
+	}
+	final := false
+	if _, pErr := d.src.Peek(1); errors.Is(pErr, io.EOF) {
+		final = true
+	}
+	pt, oErr := d.aead.Open(ct[:0], chunkNonce(d.n, final), ct[:m], d.ad)
+	if oErr != nil {
+		return errBlobCorrupt
+	}
+	d.n++
+	d.pending = pt
+	d.done = final
+	return nil
+}
+
+// plainSize returns the plaintext length of an encrypted blob file of
+// fileSize bytes.
+func plainSize(fileSize int64) (int64, error) {
+	n := fileSize - int64(headerLen)
+	full := n / (chunkSize + tagSize)
+	rem := n % (chunkSize + tagSize)
+	if n < tagSize || (rem != 0 && rem < tagSize) {
+		return 0, fmt.Errorf("%w: size %d", errBlobCorrupt, fileSize)
+	}
+	if rem == 0 {
+		return full * chunkSize, nil
+	}
+	return full*chunkSize + rem - tagSize, nil
+}
+
+// readCloser pairs a (decrypting) reader with the closer of its file.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,238 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey() []byte { return bytes.Repeat([]byte{7}, KeySize) }
+
+// countingReader yields n deterministic pseudo-random bytes without holding
+// them in memory.
+type countingReader struct {
+	n    int64
+	seed byte
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.n == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.n {
+		p = p[:c.n]
+	}
+	for i := range p {
+		c.seed = c.seed*31 + 17
+		p[i] = c.seed
+	}
+	c.n -= int64(len(p))
+	return len(p), nil
+}
+
+func TestEncryptedStreamingRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := New(dir, WithEncryptionKey(testKey()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	const id = "0123456789abcdef0123456789abcdef"
+	const size = 5<<20 + 123 // several chunks plus a partial one
+
+	src := &countingReader{n: size}
+	want := sha256.New()
+	if err := bs.Write(id, io.TeeReader(src, want), size); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, id+".blob"))
+	if err != nil {
+		t.Fatalf("read raw: %v", err)
+	}
+	if !bytes.HasPrefix(raw, []byte(blobMagic)) {
+		t.Fatalf("missing header")
+	}
+	probe := make([]byte, 64)
+	_, _ = (&countingReader{n: 64}).Read(probe)
+	if bytes.Contains(raw, probe) {
+		t.Fatalf("plaintext visible on disk")
+	}
+	if n, err := bs.Stat(id); err != nil || n != size {
+		t.Fatalf("Stat = %d, %v; want %d", n, err, size)
+	}
+
+	rc, err := bs.Open(id)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got := sha256.New()
+	if n, err := io.Copy(got, rc); err != nil || n != size {
+		t.Fatalf("Open read %d, %v", n, err)
+	}
+	rc.Close()
+	if !bytes.Equal(got.Sum(nil), want.Sum(nil)) {
+		t.Fatalf("Open plaintext mismatch")
+	}
+
+	rc, err = bs.Consume(id)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	got.Reset()
+	// Small reads exercise the chunk buffering in the decrypting reader.
+	if n, err := io.CopyBuffer(got, struct{ io.Reader }{rc}, make([]byte, 1000)); err != nil || n != size {
+		t.Fatalf("Consume read %d, %v", n, err)
+	}
+	if !bytes.Equal(got.Sum(nil), want.Sum(nil)) {
+		t.Fatalf("Consume plaintext mismatch")
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob not deleted on close: %v", err)
+	}
+}
+
+func TestEncryptedSizes(t *testing.T) {
+	bs, err := New(t.TempDir(), WithEncryptionKey(testKey()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 2 * chunkSize} {
+		id := "00000000000000000000000000000" + string(rune('a'+i)) + "00"
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		if err := bs.Write(id, bytes.NewReader(data), int64(size)); err != nil {
+			t.Fatalf("size %d: Write: %v", size, err)
+		}
+		if n, err := bs.Stat(id); err != nil || n != int64(size) {
+			t.Fatalf("size %d: Stat = %d, %v", size, n, err)
+		}
+		rc, err := bs.Consume(id)
+		if err != nil {
+			t.Fatalf("size %d: Consume: %v", size, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("size %d: read %d bytes, %v", size, len(got), err)
+		}
+	}
+}
+
+func TestEncryptedTamperDetected(t *testing.T) {
+	const id = "0123456789abcdef0123456789abcdef"
+	data := make([]byte, 3*chunkSize)
+	_, _ = rand.Read(data)
+	for name, mutate := range map[string]func([]byte) []byte{
+		"flipped bit":       func(b []byte) []byte { b[len(b)/2] ^= 1; return b },
+		"truncated at edge": func(b []byte) []byte { return b[:headerLen+2*(chunkSize+tagSize)] },
+		"truncated mid":     func(b []byte) []byte { return b[:len(b)-100] },
+		"header only":       func(b []byte) []byte { return b[:headerLen] },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			bs, err := New(dir, WithEncryptionKey(testKey()))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := bs.Write(id, bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			p := filepath.Join(dir, id+".blob")
+			raw, _ := os.ReadFile(p)
+			if err := os.WriteFile(p, mutate(raw), 0o600); err != nil {
+				t.Fatalf("rewrite: %v", err)
+			}
+			rc, err := bs.Open(id)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer rc.Close()
+			if _, err := io.Copy(io.Discard, rc); !errors.Is(err, errBlobCorrupt) {
+				t.Fatalf("err = %v, want errBlobCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedBoundToIDAndKey(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := New(dir, WithEncryptionKey(testKey()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	const id, other = "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+	if err := bs.Write(id, bytes.NewReader([]byte("payload")), 7); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := os.Rename(filepath.Join(dir, id+".blob"), filepath.Join(dir, other+".blob")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	rc, _ := bs.Open(other)
+	if _, err := io.ReadAll(rc); !errors.Is(err, errBlobCorrupt) {
+		t.Fatalf("swapped id: err = %v", err)
+	}
+	rc.Close()
+
+	wrong, _ := New(dir, WithEncryptionKey(bytes.Repeat([]byte{8}, KeySize)))
+	if err := os.Rename(filepath.Join(dir, other+".blob"), filepath.Join(dir, id+".blob")); err != nil {
+		t.Fatalf("rename back: %v", err)
+	}
+	rc, _ = wrong.Open(id)
+	if _, err := io.ReadAll(rc); !errors.Is(err, errBlobCorrupt) {
+		t.Fatalf("wrong key: err = %v", err)
+	}
+	rc.Close()
+}
+
+func TestEncryptedReadsPlaintextBlobs(t *testing.T) {
+	dir := t.TempDir()
+	plain, _ := New(dir)
+	const id = "0123456789abcdef0123456789abcdef"
+	data := []byte("written before the key was set")
+	if err := plain.Write(id, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	bs, err := New(dir, WithEncryptionKey(testKey()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if n, err := bs.Stat(id); err != nil || n != int64(len(data)) {
+		t.Fatalf("Stat = %d, %v", n, err)
+	}
+	rc, err := bs.Consume(id)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob not deleted on close: %v", err)
+	}
+}
+
+func TestEncryptionKeyPropagation(t *testing.T) {
+	if _, err := New(t.TempDir(), WithEncryptionKey([]byte("short"))); err == nil {
+		t.Fatalf("expected error for short key")
+	}
+	bs, _ := New(t.TempDir(), WithEncryptionKey(testKey()))
+	ts, err := bs.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant: %v", err)
+	}
+	if !bytes.Equal(ts.(*BlobStore).key, testKey()) {
+		t.Fatalf("tenant store lost the key")
+	}
+}
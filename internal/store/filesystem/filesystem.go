@@ -3,7 +3,9 @@
 package filesystem
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -14,18 +16,70 @@ import (
 	"github.com/haukened/gone/internal/store"
 )
 
-// Ensure BlobStore implements store.BlobStorage
-var _ store.BlobStorage = (*BlobStore)(nil)
+// Ensure BlobStore implements store.BlobStorage and its optional extensions.
+var (
+	_ store.BlobStorage   = (*BlobStore)(nil)
+	_ store.TenantScoper  = (*BlobStore)(nil)
+	_ store.BlobOpener    = (*BlobStore)(nil)
+	_ store.BlobStater    = (*BlobStore)(nil)
+	_ store.SpaceReporter = (*BlobStore)(nil)
+)
+
+// FsyncPolicy controls when blob data is flushed to stable storage.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways fsyncs every blob file before Write returns (default).
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncNone skips fsync entirely. Writes are faster but a crash shortly
+	// after a create can lose blobs whose index rows were already committed,
+	// weakening the crash-safe guarantee of app.SecretStore.Save.
+	FsyncNone FsyncPolicy = "none"
+	// FsyncDir fsyncs the blob file and its containing directory so that the
+	// directory entry (creation or removal) is also durable.
+	FsyncDir FsyncPolicy = "dir"
+)
+
+// MaxShardDepth is the deepest directory sharding supported by WithShardDepth.
+const MaxShardDepth = 3
+
+// tempSuffix marks in-progress writes. List ignores these files and removes
+// those older than staleTempAge, which only a crash mid-Write leaves behind.
+const (
+	tempSuffix   = ".tmp"
+	staleTempAge = 24 * time.Hour
+)
 
 // BlobStore implements store.BlobStorage using the local filesystem.
 // Files are named by the secret ID (with a fixed suffix) to simplify lookup.
+// With a shard depth of n, blobs live n directories deep, each level named
+// by the next two hex characters of the ID (e.g. ab/cd/<id>.blob for n=2).
 type BlobStore struct {
-	root string
+	root  string
+	fsync FsyncPolicy
+	depth int
+	key   []byte
+}
+
+// Option customizes optional BlobStore behavior.
+type Option func(*BlobStore)
+
+// WithFsync sets the fsync policy used by Write and Consume.
+func WithFsync(p FsyncPolicy) Option {
+	return func(b *BlobStore) { b.fsync = p }
+}
+
+// WithShardDepth spreads blobs over n levels of two-hex-character
+// subdirectories. Zero (the default) keeps a flat directory. Blobs written
+// flat before sharding was enabled remain readable and listable.
+func WithShardDepth(n int) Option {
+	return func(b *BlobStore) { b.depth = n }
 }
 
 // New returns a filesystem-backed blob store rooted at dir. The directory
-// must already exist with secure permissions (0700 recommended).
-func New(root string) (*BlobStore, error) {
+// must already exist with secure permissions (0700 recommended). The fsync
+// policy defaults to FsyncAlways; an unknown policy is an error.
+func New(root string, opts ...Option) (*BlobStore, error) {
 	fi, err := os.Stat(root)
 	if err != nil {
 		return nil, err
@@ -33,54 +87,204 @@ func New(root string) (*BlobStore, error) {
 	if !fi.IsDir() {
 		return nil, errors.New("blob root is not a directory")
 	}
-	return &BlobStore{root: root}, nil
+	b := &BlobStore{root: root, fsync: FsyncAlways}
+	for _, opt := range opts {
+		opt(b)
+	}
+	switch b.fsync {
+	case FsyncAlways, FsyncNone, FsyncDir:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", b.fsync)
+	}
+	if b.depth < 0 || b.depth > MaxShardDepth {
+		return nil, fmt.Errorf("shard depth %d outside [0,%d]", b.depth, MaxShardDepth)
+	}
+	if b.key != nil && len(b.key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(b.key))
+	}
+	return b, nil
 }
 
-// path constructs the full path to the blob file for a given secret ID.
-func (b *BlobStore) path(id string) string { return filepath.Join(b.root, id+".blob") }
+// ForTenant returns a BlobStore rooted at a per-tenant subdirectory of the
+// current root, creating it (0700) if absent. Tenant names are validated so
+// the subdirectory cannot escape the root. List on the parent skips these
+// subdirectories, keeping each namespace's reconciliation independent. With
+// sharding on, a two-hex-character tenant name is refused: its directory
+// would be one of the parent's shards, and each namespace would reconcile
+// away the other's blobs.
+func (b *BlobStore) ForTenant(tenant string) (store.BlobStorage, error) {
+	if !domain.ValidTenantName(tenant) {
+		return nil, errors.New("invalid tenant name")
+	}
+	if b.depth > 0 && IsShardName(tenant) {
+		return nil, fmt.Errorf("tenant name %q collides with a blob shard directory", tenant)
+	}
+	dir := filepath.Join(b.root, tenant)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &BlobStore{root: dir, fsync: b.fsync, depth: b.depth, key: b.key}, nil
+}
+
+// path constructs the full path to the blob file for a given secret ID under
+// the configured sharding.
+func (b *BlobStore) path(id string) string {
+	parts := make([]string, 0, b.depth+2)
+	parts = append(parts, b.root)
+	for i := 0; i < b.depth; i++ {
+		parts = append(parts, id[2*i:2*i+2])
+	}
+	return filepath.Join(append(parts, id+".blob")...)
+}
+
+// existing returns the path holding the blob for id: the sharded path, or the
+// flat path for blobs written before sharding was enabled.
+func (b *BlobStore) existing(id string) string {
+	p := b.path(id)
+	if b.depth == 0 {
+		return p
+	}
+	if _, err := os.Lstat(p); errors.Is(err, os.ErrNotExist) {
+		flat := filepath.Join(b.root, id+".blob")
+		if _, err := os.Lstat(flat); err == nil {
+			return flat
+		}
+	}
+	return p
+}
 
 // Write stores exactly size bytes from r into a file associated with id.
+// The data goes to a "<id>.blob.tmp" sibling that is fsynced (per policy)
+// and then hard-linked into place, so a crash mid-write never leaves a
+// partial .blob behind. An existing blob for id fails with an error wrapping
+// os.ErrExist, usually before r is read; the temp file is created O_EXCL and
+// the link fails if the blob appeared meanwhile, so of several concurrent
+// writers of one id exactly one succeeds and none replaces another's blob.
 func (b *BlobStore) Write(id string, r io.Reader, size int64) error {
 	if err := validateID(id); err != nil {
 		return err
 	}
 	p := b.path(id)
+	if _, err := os.Lstat(b.existing(id)); err == nil {
+		return &os.PathError{Op: "write", Path: p, Err: os.ErrExist}
+	}
+	if b.depth > 0 {
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := p + tempSuffix
 	// #nosec G304: path is constructed from a fixed root plus a validated ID with a fixed suffix; no traversal possible.
-	f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = io.CopyN(f, r, size)
-	if err != nil {
-		// delete partial file on error
-		_ = os.Remove(p)
+	if err = b.writeTemp(f, id, r, size); err != nil {
+		_ = os.Remove(tmp) // delete partial file on error
 		return err
 	}
-	if err = f.Sync(); err != nil {
+	err = os.Link(tmp, p)
+	_ = os.Remove(tmp)
+	if err != nil {
 		return err
 	}
+	if b.fsync == FsyncDir {
+		return syncDir(filepath.Dir(p))
+	}
 	return nil
 }
 
+// writeTemp copies size bytes from r into f (encrypting them when a key is
+// set), fsyncs it per policy, and closes it.
+func (b *BlobStore) writeTemp(f *os.File, id string, r io.Reader, size int64) error {
+	var err error
+	if b.key != nil {
+		bw := bufio.NewWriterSize(f, chunkSize+tagSize)
+		if err = b.encryptTo(bw, id, r, size); err == nil {
+			err = bw.Flush()
+		}
+	} else {
+		_, err = io.CopyN(f, r, size)
+	}
+	if err == nil && b.fsync != FsyncNone {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// syncDir fsyncs a directory so that entries created or removed within it
+// survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 path is the blob root, not user input
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 // Consume opens a blob file for reading by ID and returns a ReadCloser whose
-// Close deletes the underlying file (delete-on-close semantics).
+// Close deletes the underlying file (delete-on-close semantics). Encrypted
+// blobs are decrypted as they are read.
 func (b *BlobStore) Consume(id string) (io.ReadCloser, error) {
 	if err := validateID(id); err != nil {
 		return nil, err
 	}
-	p := b.path(id)
-	f, err := os.Open(p) // #nosec G304 path constructed internally
+	p := b.existing(id)
+	r, f, err := b.openBlobFile(p, id)
+	if err != nil {
+		return nil, err
+	}
+	d := &deletingReadCloser{File: f, path: p, syncDir: b.fsync == FsyncDir}
+	if r == io.Reader(f) {
+		return d, nil
+	}
+	return readCloser{Reader: r, Closer: d}, nil
+}
+
+// Open opens a blob file for reading by ID without deleting it. It serves
+// the non-final reads of multi-read secrets.
+func (b *BlobStore) Open(id string) (io.ReadCloser, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	r, f, err := b.openBlobFile(b.existing(id), id)
 	if err != nil {
 		return nil, err
 	}
-	return &deletingReadCloser{File: f, path: p}, nil
+	if r == io.Reader(f) {
+		return f, nil
+	}
+	return readCloser{Reader: r, Closer: f}, nil
+}
+
+// Stat returns the size in bytes of the blob for id. For encrypted blobs
+// this is the plaintext size, so it still matches the index record.
+func (b *BlobStore) Stat(id string) (int64, error) {
+	if err := validateID(id); err != nil {
+		return 0, err
+	}
+	p := b.existing(id)
+	fi, err := os.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	if b.key == nil || !hasBlobMagic(p) {
+		return fi.Size(), nil
+	}
+	return plainSize(fi.Size())
 }
 
 // deletingReadCloser wraps an *os.File and deletes its path on Close.
+// When syncDir is set the parent directory is fsynced after removal so the
+// deletion itself is durable.
 type deletingReadCloser struct {
 	*os.File
-	path string
+	path    string
+	syncDir bool
 }
 
 func (d *deletingReadCloser) Close() error {
@@ -88,6 +292,9 @@ func (d *deletingReadCloser) Close() error {
 	fErr := d.File.Close()
 	// Attempt deletion regardless of close error (best-effort cleanup).
 	rmErr := os.Remove(d.path)
+	if rmErr == nil && d.syncDir {
+		rmErr = syncDir(filepath.Dir(d.path))
+	}
 	if fErr != nil {
 		return fErr
 	}
@@ -102,43 +309,84 @@ func (b *BlobStore) Delete(id string) error {
 	if err := validateID(id); err != nil {
 		return err
 	}
-	return os.Remove(b.path(id))
+	return os.Remove(b.existing(id))
 }
 
-// List returns all blob IDs currently present. Higher layers derive orphans
-// by diffing against index-reported external IDs.
+// List returns all blob IDs currently present, walking shard directories
+// and including flat blobs left from before sharding. Higher layers derive
+// orphans by diffing against index-reported external IDs. Writes in progress
+// live under their .tmp name until linked into place, so they are never listed however
+// long they take; stale temp files from interrupted writes are removed along
+// the way.
 func (b *BlobStore) List() ([]string, error) {
-	entries, err := os.ReadDir(b.root)
-	if err != nil {
+	var ids []string
+	if err := b.listDir(b.root, "", 0, &ids); err != nil {
 		return nil, err
 	}
-	var ids []string
+	return ids, nil
+}
+
+// listDir collects blob IDs from dir, which sits level shard directories
+// below the root along prefix. Files are taken from the root (flat layout)
+// and from the leaf shard level; only two-hex-character directories are
+// descended into, so tenant subdirectories stay independent.
+func (b *BlobStore) listDir(dir, prefix string, level int, ids *[]string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
 	for _, e := range entries {
+		name := e.Name()
 		if e.IsDir() {
+			if level < b.depth && IsShardName(name) {
+				if err := b.listDir(filepath.Join(dir, name), prefix+name, level+1, ids); err != nil {
+					return err
+				}
+			}
 			continue
 		}
-		name := e.Name()
-		if filepath.Ext(name) != ".blob" {
+		if level != 0 && level != b.depth {
+			continue
+		}
+		if strings.HasSuffix(name, ".blob"+tempSuffix) {
+			if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > staleTempAge {
+				_ = os.Remove(filepath.Join(dir, name)) // best-effort
+			}
+			continue
+		}
+		if filepath.Ext(name) != ".blob" || !strings.HasPrefix(name, prefix) {
 			continue
 		}
 		// Basic freshness guard: skip very recent files (<1s) to avoid races.
 		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) < time.Second {
 			continue
 		}
-		ids = append(ids, name[:len(name)-5])
+		*ids = append(*ids, name[:len(name)-5])
 	}
-	return ids, nil
+	return nil
 }
 
-// validateID enforces that the blob ID is a canonical 32-character lowercase
-// hexadecimal secret ID (domain.SecretID). This both prevents path traversal
-// (no separators, fixed length) and guarantees uniform filenames.
-func validateID(id string) error {
-	if _, err := domain.ParseID(id); err != nil { // ParseID enforces length==32 and [0-9a-f]
-		return errors.New("invalid blob id: must be 32 lowercase hex chars")
+// IsShardName reports whether name is a two-character lowercase hex shard
+// directory name. A tenant with such a name would collide with the shard
+// directories once blobs are sharded.
+func IsShardName(name string) bool {
+	if len(name) != 2 {
+		return false
 	}
-	if strings.Contains(id, "..") { // defense-in-depth (ParseID already forbids '.')
-		return errors.New("invalid blob id: contains '..'")
+	for _, c := range []byte(name) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateID enforces that the blob ID is a canonical secret ID (see
+// domain.ValidateID). This both prevents path traversal (the charset has no
+// '.' or separators) and guarantees uniform filenames.
+func validateID(id string) error {
+	if err := domain.ValidateID(id); err != nil {
+		return fmt.Errorf("invalid blob id: %w", err)
 	}
 	return nil
 }
@@ -1,12 +1,17 @@
 package filesystem
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/haukened/gone/internal/domain"
 )
 
 func TestDeletingReadCloser(t *testing.T) {
@@ -202,8 +207,8 @@ func TestBlobStoreInvalidIDs(t *testing.T) {
 		"1234567890abcdef1234567890abcdef0", // length 33
 	}
 	for _, id := range cases {
-		if err := bs.Write(id, bytesReader(payload), int64(len(payload))); err == nil {
-			t.Fatalf("expected write error for id=%q", id)
+		if err := bs.Write(id, bytesReader(payload), int64(len(payload))); !errors.Is(err, domain.ErrInvalidID) {
+			t.Fatalf("expected invalid id write error for id=%q, got %v", id, err)
 		}
 		if _, err := bs.Consume(id); err == nil {
 			t.Fatalf("expected consume error for id=%q", id)
@@ -263,3 +268,462 @@ func TestListWithNoBlobs(t *testing.T) {
 		t.Fatalf("expected 0 ids when only directories present, got: %v", ids)
 	}
 }
+
+func TestForTenant(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := New(dir)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	for _, bad := range []string{"", "..", "a/b", "UPPER"} {
+		if _, err := bs.ForTenant(bad); err == nil {
+			t.Fatalf("expected error for tenant %q", bad)
+		}
+	}
+	scoped, err := bs.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant: %v", err)
+	}
+	id := "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	if err := scoped.Write(id, bytesReader([]byte("x")), 1); err != nil {
+		t.Fatalf("scoped write: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme", id+".blob")); err != nil {
+		t.Fatalf("expected blob in tenant dir: %v", err)
+	}
+	// The parent namespace does not list tenant blobs.
+	time.Sleep(1100 * time.Millisecond)
+	ids, err := bs.List()
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("parent List should be empty, got %v err=%v", ids, err)
+	}
+}
+
+func TestWriteFsyncPolicies(t *testing.T) {
+	for _, p := range []FsyncPolicy{FsyncAlways, FsyncNone, FsyncDir} {
+		t.Run(string(p), func(t *testing.T) {
+			dir := t.TempDir()
+			bs, err := New(dir, WithFsync(p))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			id := "abcdefabcdefabcdefabcdefabcdefab"
+			data := []byte("payload-" + string(p))
+			if err := bs.Write(id, bytesReader(data), int64(len(data))); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, id+".blob"))
+			if err != nil {
+				t.Fatalf("blob missing after write: %v", err)
+			}
+			if string(got) != string(data) {
+				t.Fatalf("content mismatch: %q", got)
+			}
+			rc, err := bs.Consume(id)
+			if err != nil {
+				t.Fatalf("Consume: %v", err)
+			}
+			if err := rc.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, id+".blob")); !os.IsNotExist(err) {
+				t.Fatalf("expected blob removed, err=%v", err)
+			}
+		})
+	}
+}
+
+func TestNewUnknownFsyncPolicy(t *testing.T) {
+	if _, err := New(t.TempDir(), WithFsync("sometimes")); err == nil {
+		t.Fatalf("expected error for unknown fsync policy")
+	}
+}
+
+// BenchmarkWriteFsync compares blob write throughput across fsync policies.
+func BenchmarkWriteFsync(b *testing.B) {
+	data := make([]byte, 16*1024)
+	for _, p := range []FsyncPolicy{FsyncAlways, FsyncNone, FsyncDir} {
+		b.Run(string(p), func(b *testing.B) {
+			bs, err := New(b.TempDir(), WithFsync(p))
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				id := fmt.Sprintf("%032x", i)
+				if err := bs.Write(id, bytesReader(data), int64(len(data))); err != nil {
+					b.Fatalf("Write: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBlobStoreStat(t *testing.T) {
+	bs, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	id := "dddddddddddddddddddddddddddddddd"
+	if _, err := bs.Stat(id); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist for missing blob, got %v", err)
+	}
+	if err := bs.Write(id, bytesReader([]byte("12345")), 5); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if n, err := bs.Stat(id); err != nil || n != 5 {
+		t.Fatalf("Stat got %d, %v", n, err)
+	}
+	if _, err := bs.Stat("../etc"); err == nil {
+		t.Fatalf("expected invalid id error")
+	}
+}
+
+func TestShardedWriteListDelete(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := New(dir, WithShardDepth(2))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ids := []string{"abcd0000000000000000000000000000", "abce0000000000000000000000000000", "0f000000000000000000000000000000"}
+	for _, id := range ids {
+		if err := bs.Write(id, bytesReader([]byte("x")), 1); err != nil {
+			t.Fatalf("Write %s: %v", id, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ab", "cd", ids[0]+".blob")); err != nil {
+		t.Fatalf("expected sharded file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ids[0]+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob written flat despite sharding")
+	}
+	backdate(t, dir)
+	got, err := bs.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("List = %v, want %v", got, ids)
+	}
+	if n, err := bs.Stat(ids[1]); err != nil || n != 1 {
+		t.Fatalf("Stat = %d, %v", n, err)
+	}
+	if err := bs.Delete(ids[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	rc, err := bs.Consume(ids[2])
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	_ = rc.Close()
+	if got, _ := bs.List(); len(got) != 1 || got[0] != ids[0] {
+		t.Fatalf("List after delete = %v", got)
+	}
+}
+
+func TestShardedReadsFlatBlobs(t *testing.T) {
+	dir := t.TempDir()
+	flat, _ := New(dir)
+	id := "1234567890abcdef1234567890abcdef"
+	if err := flat.Write(id, bytesReader([]byte("legacy")), 6); err != nil {
+		t.Fatalf("flat Write: %v", err)
+	}
+	bs, err := New(dir, WithShardDepth(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	backdate(t, dir)
+	if got, _ := bs.List(); len(got) != 1 || got[0] != id {
+		t.Fatalf("flat blob not listed: %v", got)
+	}
+	rc, err := bs.Open(id)
+	if err != nil {
+		t.Fatalf("Open flat blob: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(b) != "legacy" {
+		t.Fatalf("read %q", b)
+	}
+	rc, err = bs.Consume(id)
+	if err != nil {
+		t.Fatalf("Consume flat blob: %v", err)
+	}
+	_ = rc.Close()
+	if _, err := os.Stat(filepath.Join(dir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("flat blob not deleted on consume")
+	}
+}
+
+func TestShardedListSkipsTenantDirs(t *testing.T) {
+	dir := t.TempDir()
+	bs, _ := New(dir, WithShardDepth(1))
+	ts, err := bs.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant: %v", err)
+	}
+	id := "abcdef0123456789abcdef0123456789"
+	if err := ts.Write(id, bytesReader([]byte("t")), 1); err != nil {
+		t.Fatalf("tenant Write: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme", "ab", id+".blob")); err != nil {
+		t.Fatalf("tenant blob not sharded: %v", err)
+	}
+	backdate(t, dir)
+	if got, _ := bs.List(); len(got) != 0 {
+		t.Fatalf("root List saw tenant blobs: %v", got)
+	}
+	if got, _ := ts.List(); len(got) != 1 {
+		t.Fatalf("tenant List = %v", got)
+	}
+}
+
+func TestShardedForTenantRejectsShardNames(t *testing.T) {
+	flat, _ := New(t.TempDir())
+	if _, err := flat.ForTenant("ab"); err != nil {
+		t.Fatalf("flat ForTenant(ab): %v", err)
+	}
+	sharded, _ := New(t.TempDir(), WithShardDepth(1))
+	for _, name := range []string{"ab", "09", "f0"} {
+		if _, err := sharded.ForTenant(name); err == nil {
+			t.Fatalf("sharded ForTenant(%q) accepted", name)
+		}
+	}
+	if _, err := sharded.ForTenant("ag"); err != nil {
+		t.Fatalf("sharded ForTenant(ag): %v", err)
+	}
+}
+
+func TestWriteConcurrentSameID(t *testing.T) {
+	for _, depth := range []int{0, 1} {
+		bs, _ := New(t.TempDir(), WithShardDepth(depth))
+		for round := range 50 {
+			id := fmt.Sprintf("%032x", round)
+			const writers = 8
+			errs := make(chan error, writers)
+			var wg sync.WaitGroup
+			for w := range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- bs.Write(id, bytesReader([]byte{byte('a' + w)}), 1)
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			won := 0
+			for err := range errs {
+				switch {
+				case err == nil:
+					won++
+				case !errors.Is(err, os.ErrExist):
+					t.Fatalf("depth %d: Write error %v, want os.ErrExist", depth, err)
+				}
+			}
+			if won != 1 {
+				t.Fatalf("depth %d round %d: %d writers succeeded, want 1", depth, round, won)
+			}
+		}
+	}
+}
+
+// publishingReader plants a blob at path on its first Read, like a
+// concurrent writer of the same id finishing between the existence check and
+// the publish.
+type publishingReader struct {
+	path string
+	done bool
+}
+
+func (p *publishingReader) Read(b []byte) (int, error) {
+	if !p.done {
+		p.done = true
+		if err := os.WriteFile(p.path, []byte("winner"), 0o600); err != nil {
+			return 0, err
+		}
+	}
+	b[0] = 'x'
+	return 1, nil
+}
+
+func TestWriteDoesNotReplaceConcurrentBlob(t *testing.T) {
+	dir := t.TempDir()
+	bs, _ := New(dir)
+	id := "abcdef0123456789abcdef0123456789"
+	p := filepath.Join(dir, id+".blob")
+	if err := bs.Write(id, &publishingReader{path: p}, 1); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("Write = %v, want os.ErrExist", err)
+	}
+	if got, _ := os.ReadFile(p); string(got) != "winner" {
+		t.Fatalf("blob replaced: %q", got)
+	}
+	if _, err := os.Stat(p + tempSuffix); !os.IsNotExist(err) {
+		t.Fatalf("temp file left behind: %v", err)
+	}
+}
+
+func TestNewShardDepthRange(t *testing.T) {
+	for _, n := range []int{-1, MaxShardDepth + 1} {
+		if _, err := New(t.TempDir(), WithShardDepth(n)); err == nil {
+			t.Fatalf("expected error for depth %d", n)
+		}
+	}
+}
+
+// backdate pushes every file under dir past List's freshness guard.
+func backdate(t *testing.T, dir string) {
+	t.Helper()
+	old := time.Now().Add(-time.Minute)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return os.Chtimes(p, old, old)
+	})
+	if err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+}
+
+// failingReader yields n bytes and then fails, like a client dropping mid-upload.
+type failingReader struct{ n int }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.n == 0 {
+		return 0, errors.New("connection reset")
+	}
+	k := min(len(p), f.n)
+	for i := range p[:k] {
+		p[i] = 'x'
+	}
+	f.n -= k
+	return k, nil
+}
+
+func TestWriteFailureLeavesNoBlob(t *testing.T) {
+	for _, depth := range []int{0, 2} {
+		dir := t.TempDir()
+		bs, err := New(dir, WithShardDepth(depth))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		id := "abababababababababababababababab"
+		if err := bs.Write(id, &failingReader{n: 10}, 100); err == nil {
+			t.Fatal("expected write error")
+		}
+		// Neither the final blob nor the temp file may remain.
+		_ = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				t.Fatalf("depth %d: leftover file %s", depth, p)
+			}
+			return nil
+		})
+		if _, err := bs.Consume(id); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("depth %d: expected ErrNotExist, got %v", depth, err)
+		}
+	}
+}
+
+func TestWriteExistingBlob(t *testing.T) {
+	dir := t.TempDir()
+	bs, _ := New(dir)
+	id := "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd"
+	if err := bs.Write(id, bytesReader([]byte("first")), 5); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	r := bytes.NewReader([]byte("second"))
+	if err := bs.Write(id, r, 6); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+	if r.Len() != 6 {
+		t.Fatal("reader must not be consumed when the blob exists")
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, id+".blob"))
+	if string(got) != "first" {
+		t.Fatalf("existing blob clobbered: %q", got)
+	}
+}
+
+func TestListSkipsAndSweepsTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	bs, _ := New(dir)
+	// Temp files as a crash mid-Write would leave them: one stale, one recent.
+	stale := filepath.Join(dir, "efefefefefefefefefefefefefefefef.blob.tmp")
+	fresh := filepath.Join(dir, "fefefefefefefefefefefefefefefefe.blob.tmp")
+	for _, p := range []string{stale, fresh} {
+		if err := os.WriteFile(p, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("write temp: %v", err)
+		}
+	}
+	old := time.Now().Add(-25 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	ids, err := bs.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("temp files must not be listed as blobs: %v", ids)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale temp file should be removed, stat err=%v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("in-progress temp file must be kept: %v", err)
+	}
+}
+
+func TestFreeBytes(t *testing.T) {
+	bs, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	n, err := bs.FreeBytes()
+	if err != nil || n <= 0 {
+		t.Fatalf("FreeBytes = %d, %v", n, err)
+	}
+	bs.root = filepath.Join(bs.root, "missing")
+	if _, err := bs.FreeBytes(); err == nil {
+		t.Fatal("expected error for missing root")
+	}
+}
+
+// TestListSkipsInProgressWrite holds a Write open far past the freshness
+// guard and checks its temp file is neither listed nor swept until renamed.
+func TestListSkipsInProgressWrite(t *testing.T) {
+	bs, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	const id = "0123456789abcdef0123456789abcdef"
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- bs.Write(id, pr, 8) }()
+	if _, err := pw.Write([]byte("slow")); err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	tmp := bs.path(id) + tempSuffix
+	if err := os.Chtimes(tmp, old, old); err != nil {
+		t.Fatalf("chtimes temp: %v", err)
+	}
+	if ids, err := bs.List(); err != nil || len(ids) != 0 {
+		t.Fatalf("in-progress write listed: %v %v", ids, err)
+	}
+	if _, err := os.Stat(tmp); err != nil {
+		t.Fatalf("in-progress temp file removed: %v", err)
+	}
+
+	_, _ = pw.Write([]byte("done"))
+	_ = pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := os.Chtimes(bs.path(id), old, old); err != nil {
+		t.Fatalf("chtimes blob: %v", err)
+	}
+	if ids, err := bs.List(); err != nil || len(ids) != 1 || ids[0] != id {
+		t.Fatalf("finished blob not listed: %v %v", ids, err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package filesystem
+
+import "errors"
+
+// FreeBytes is not available on this platform.
+func (b *BlobStore) FreeBytes() (int64, error) {
+	return 0, errors.ErrUnsupported
+}
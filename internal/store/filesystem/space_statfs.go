@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package filesystem
+
+import "syscall"
+
+// FreeBytes reports the space available to unprivileged writers on the
+// volume holding the blob root.
+func (b *BlobStore) FreeBytes() (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(b.root, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// ErrVerifyUnsupported is returned by VerifyBlobs when the index cannot
+// enumerate records, the blob backend cannot stat blobs, or repair is
+// requested and the index cannot delete single records.
+var ErrVerifyUnsupported = errors.New("store: backend does not support integrity scans")
+
+// VerifyBlobs checks that every externally stored secret has a blob whose size
+// matches the index. It is the inverse of Reconcile: index rows pointing at a
+// missing or wrongly sized blob are broken, since the secret can never be
+// served. At most one blob is checked per pace (0 = no limit) to avoid I/O
+// storms. With repair set, broken rows are deleted along with any leftover
+// blob; a row already gone (consumed concurrently) is not counted as broken.
+// Without repair such a race may briefly over-report.
+func (s *Store) VerifyBlobs(ctx context.Context, pace time.Duration, repair bool) (checked, broken int, err error) {
+	walker, ok := s.index.(IndexWalker)
+	if !ok {
+		return 0, 0, ErrVerifyUnsupported
+	}
+	deleter, canDelete := s.index.(IndexDeleter)
+	if repair && !canDelete {
+		return 0, 0, ErrVerifyUnsupported
+	}
+	// Snapshot external rows first so the index cursor is not held open
+	// while the scan is throttled.
+	var recs []Record
+	err = walker.Walk(ctx, func(rec Record) error {
+		if rec.External {
+			recs = append(recs, Record{ID: rec.ID, Tenant: rec.Tenant, Size: rec.Size})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	var tick <-chan time.Time
+	if pace > 0 {
+		t := time.NewTicker(pace)
+		defer t.Stop()
+		tick = t.C
+	}
+	for i, rec := range recs {
+		if i > 0 && tick != nil {
+			select {
+			case <-ctx.Done():
+				return checked, broken, ctx.Err()
+			case <-tick:
+			}
+		}
+		if err = ctx.Err(); err != nil {
+			return checked, broken, err
+		}
+		blobs, bErr := s.blobsFor(rec.Tenant)
+		if bErr != nil {
+			return checked, broken, bErr
+		}
+		stater, ok := blobs.(BlobStater)
+		if !ok {
+			return checked, broken, ErrVerifyUnsupported
+		}
+		checked++
+		if size, sErr := stater.Stat(rec.ID); sErr == nil && size == rec.Size {
+			continue
+		}
+		if !repair {
+			broken++
+			continue
+		}
+		dErr := deleter.Delete(app.WithTenant(ctx, rec.Tenant), rec.ID)
+		if errors.Is(dErr, app.ErrNotFound) {
+			continue
+		}
+		if dErr != nil {
+			return checked, broken, dErr
+		}
+		broken++
+		s.exists.forget(existsKey{rec.Tenant, rec.ID})
+		s.forgetBlob(rec.Size)
+		_ = blobs.Delete(rec.ID) // best-effort; a missing blob is expected here
+	}
+	return checked, broken, nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"io"
+	"sync"
+)
+
+// openGate tracks Consume calls that have claimed a read from the index but
+// not yet opened its blob. A final read of a multi-read secret deletes the
+// blob on Close, and a non-final read committed just before it opens the blob
+// only after its own index transaction, so the delete waits for every
+// in-flight claim on the same ID to finish opening first. The zero value is
+// ready to use.
+type openGate struct {
+	mu      sync.Mutex
+	pending map[existsKey]*pendingOpens
+}
+
+type pendingOpens struct {
+	n    int
+	done chan struct{} // closed once n drops back to zero
+}
+
+// enter registers an in-flight claim on k.
+func (g *openGate) enter(k existsKey) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pending == nil {
+		g.pending = make(map[existsKey]*pendingOpens)
+	}
+	p := g.pending[k]
+	if p == nil {
+		p = &pendingOpens{done: make(chan struct{})}
+		g.pending[k] = p
+	}
+	p.n++
+}
+
+// leave releases a claim registered by enter, waking any waiters once the
+// last claim on k is gone.
+func (g *openGate) leave(k existsKey) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p := g.pending[k]
+	if p == nil {
+		return
+	}
+	if p.n--; p.n == 0 {
+		close(p.done)
+		delete(g.pending, k)
+	}
+}
+
+// wait blocks until no claims on k are in flight.
+func (g *openGate) wait(k existsKey) {
+	g.mu.Lock()
+	p := g.pending[k]
+	g.mu.Unlock()
+	if p != nil {
+		<-p.done
+	}
+}
+
+// gatedCloser delays the delete-on-close of a final blob read until
+// concurrent readers of the same secret have opened their own handles.
+type gatedCloser struct {
+	io.ReadCloser
+	gate *openGate
+	key  existsKey
+}
+
+func (c gatedCloser) Close() error {
+	c.gate.wait(c.key)
+	return c.ReadCloser.Close()
+}
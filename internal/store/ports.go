@@ -15,23 +15,48 @@ import (
 
 // Index abstracts the metadata/index operations (typically backed by SQLite).
 // It stores secret metadata, inlined small ciphertext, and references to blob
-// files for larger payloads.
+// files for larger payloads. Insert, Consume and ListExternalIDs are scoped to
+// the tenant carried by ctx (see app.TenantFromContext); DeleteExpired spans
+// all tenants and reports each record's tenant for blob cleanup.
 type Index interface {
+	// Insert adds a record, failing with an error wrapping app.ErrDuplicateID
+	// if id is taken. inline is only valid for the duration of the call; the
+	// Store reuses its backing array afterwards.
 	Insert(ctx context.Context, id string, meta app.Meta, inline []byte, external bool, size int64, createdAt, expiresAt time.Time) error
-	// Consume returns secret data and hard-deletes the row in the same transaction.
+	// Consume returns secret data and, on the final permitted read, hard-deletes
+	// the row in the same transaction unless it reports IndexResult.Retained.
 	Consume(ctx context.Context, id string, now time.Time) (*IndexResult, error)
 	DeleteExpired(ctx context.Context, t time.Time) (expired []ExpiredRecord, err error)
 	// ListExternalIDs returns IDs of secrets whose payloads are stored externally.
 	ListExternalIDs(ctx context.Context) ([]string, error)
+	// ExternalBytes returns the summed size of all externally stored payloads
+	// across every tenant.
+	ExternalBytes(ctx context.Context) (int64, error)
+	// ExpiryHistogram counts live records across all tenants by time left
+	// until expiry at now. Each bucket bound d counts records whose remaining
+	// time is at most d and above the next smaller bound; records beyond the
+	// largest bound are counted under BucketOverflow. Keys come from
+	// BucketLabel and every bucket is present, zero or not.
+	ExpiryHistogram(ctx context.Context, now time.Time, buckets []time.Duration) (map[string]int64, error)
 }
 
+// BucketOverflow labels the histogram bucket above the largest bound.
+const BucketOverflow = "+Inf"
+
+// BucketLabel returns the histogram key for bucket bound d, e.g. "30m0s".
+func BucketLabel(d time.Duration) string { return d.String() }
+
 // IndexResult bundles the data returned by Index.Consume
 type IndexResult struct {
-	Meta      app.Meta
-	Inline    []byte
-	External  bool
-	Size      int64
-	ExpiresAt time.Time
+	Meta           app.Meta
+	Inline         []byte
+	External       bool
+	Size           int64
+	ExpiresAt      time.Time
+	ReadsRemaining int // reads left after this one; 0 means the record was deleted
+	// Retained marks a final read whose record is kept for a consume grace
+	// window (ReadsRemaining is 0). The blob must be left for the janitor.
+	Retained bool
 }
 
 // BlobStorage abstracts large payload persistence (e.g. filesystem). Implementations
@@ -42,6 +67,8 @@ type IndexResult struct {
 // use Delete/List to clean orphans left by crashes occurring after index
 // removal but before successful blob deletion.
 type BlobStorage interface {
+	// Write stores exactly size bytes from r. If a blob named id already
+	// exists it MUST fail with an error wrapping fs.ErrExist before reading r.
 	Write(id string, r io.Reader, size int64) error
 	// Consume returns a reader for the blob. Close MUST attempt to delete the
 	// blob file. If deletion fails, Close should return that error (unless a
@@ -54,8 +81,141 @@ type BlobStorage interface {
 	List() ([]string, error)
 }
 
+// TenantScoper is optionally implemented by BlobStorage backends that can
+// isolate blobs per tenant (e.g. one subdirectory per tenant). Backends that
+// do not implement it share a single namespace for all tenants.
+type TenantScoper interface {
+	// ForTenant returns a BlobStorage confined to the named tenant.
+	ForTenant(tenant string) (BlobStorage, error)
+}
+
+// BlobOpener is optionally implemented by BlobStorage backends to read a blob
+// without deleting it. It is required to serve multi-read secrets; the final
+// read still goes through BlobStorage.Consume.
+type BlobOpener interface {
+	Open(id string) (io.ReadCloser, error)
+}
+
+// BlobStater is optionally implemented by BlobStorage backends that can
+// report a blob's stored size without reading it. It is required for
+// integrity scans.
+type BlobStater interface {
+	// Stat returns the size of blob id, or an error wrapping os.ErrNotExist
+	// when the blob is missing.
+	Stat(id string) (int64, error)
+}
+
+// SpaceReporter is optionally implemented by BlobStorage backends that can
+// report free space on the volume holding their blobs. It is required by
+// WithMinFreeBytes.
+type SpaceReporter interface {
+	// FreeBytes returns the bytes available to unprivileged writers.
+	FreeBytes() (int64, error)
+}
+
+// IndexDeleter is optionally implemented by Index backends that can remove a
+// single record by ID within the tenant carried by ctx. It is used to
+// tombstone entries whose blobs are unreadable.
+type IndexDeleter interface {
+	// Delete removes the record, returning app.ErrNotFound if none matched.
+	Delete(ctx context.Context, id string) error
+}
+
+// PassphraseIndex is optionally implemented by Index backends that persist
+// app.Meta.PassphraseHash. It reads the hash without consuming the record.
+type PassphraseIndex interface {
+	// PassphraseHash returns the hash for id within the tenant carried by ctx,
+	// or app.ErrNotFound if no record is live at now.
+	PassphraseHash(ctx context.Context, id string, now time.Time) (string, error)
+}
+
+// BatchExpirer is optionally implemented by Index backends that can delete
+// expired records a bounded number at a time (see WithExpireBatch).
+type BatchExpirer interface {
+	// DeleteExpiredBatch deletes at most limit records across all tenants
+	// whose expiry is before t, in its own transaction, and returns them.
+	DeleteExpiredBatch(ctx context.Context, t time.Time, limit int) ([]ExpiredRecord, error)
+}
+
+// NextExpirer is optionally implemented by Index backends that can report
+// the earliest expiry among stored records, so the janitor can schedule its
+// next cycle around it.
+type NextExpirer interface {
+	// NextExpiry returns the earliest expiry across all tenants, or the zero
+	// time when there are no records.
+	NextExpiry(ctx context.Context) (time.Time, error)
+}
+
+// Toucher is optionally implemented by Index backends that can move the
+// expiry of a stored record.
+type Toucher interface {
+	// Touch sets expires_at of the record id, within the tenant carried by
+	// ctx, to expiresAt if it is unexpired at now and not already consumed,
+	// or returns app.ErrNotFound. An expiresAt earlier than the current
+	// expiry, or not after the record's not-before time, is refused with
+	// domain.ErrTTLInvalid so the record always stays readable.
+	Touch(ctx context.Context, id string, now, expiresAt time.Time) error
+}
+
+// LiveCounter is optionally implemented by Index backends that can count
+// readable records cheaply, for operator status output.
+type LiveCounter interface {
+	// CountLive returns how many records across all tenants are unexpired at
+	// now and not already consumed.
+	CountLive(ctx context.Context, now time.Time) (int64, error)
+}
+
+// AttemptIndex is optionally implemented by Index backends that persist a
+// failed consume count per record. It backs app.AttemptRecorder.
+type AttemptIndex interface {
+	// FailAttempt atomically increments the count for the record id live at
+	// now within the tenant carried by ctx, deleting the record once the count
+	// reaches limit. It reports whether the record was deleted and whether its
+	// payload was external, or returns app.ErrNotFound.
+	FailAttempt(ctx context.Context, id string, now time.Time, limit int) (deleted, external bool, err error)
+}
+
+// IndexProber is optionally implemented by Index backends that can check for
+// a record without consuming it.
+type IndexProber interface {
+	// Exists reports whether id is live at now within the tenant carried by ctx.
+	Exists(ctx context.Context, id string, now time.Time) (bool, error)
+}
+
+// IndexWalker is optionally implemented by Index backends that can enumerate
+// every record across all tenants. It is required for export.
+type IndexWalker interface {
+	// Walk calls fn for each record; a non-nil error from fn stops the walk
+	// and is returned.
+	Walk(ctx context.Context, fn func(Record) error) error
+}
+
+// Record is a complete index entry as enumerated by IndexWalker.
+type Record struct {
+	ID             string
+	Tenant         string
+	Meta           app.Meta
+	Inline         []byte // nil when External
+	External       bool
+	Size           int64
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	ReadsRemaining int
+	NotBefore      time.Time // embargo; zero when readable immediately
+}
+
 // ExpiredRecord represents an expired secret needing blob cleanup (if blobPath non-empty).
 type ExpiredRecord struct {
 	ID       string
-	External bool // true if payload stored in blob storage
+	External bool   // true if payload stored in blob storage
+	Tenant   string // owning tenant ("" for the default namespace)
+	Read     bool   // read at least once before expiring (multi-read or consume grace)
+}
+
+// ExpiryNotifier is told about secrets that expired without ever being read.
+// It is called once per DeleteExpired sweep, after the rows are gone, with
+// only the unread records. Implementations handle (log) their own failures;
+// a notification problem never fails the sweep.
+type ExpiryNotifier interface {
+	ExpiredUnread(ctx context.Context, recs []ExpiredRecord)
 }
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+// WithMaxBlobBytes caps the total bytes of externally stored payloads across
+// all tenants. Saves that would exceed the budget fail with
+// app.ErrStorageFull; nothing is evicted to make room. Inline secrets are
+// exempt. Zero disables the quota.
+func WithMaxBlobBytes(n int64) Option {
+	return func(s *Store) { s.maxBlobBytes = n }
+}
+
+// reserveBlob claims size bytes of the blob budget for an in-progress Save.
+// The committed usage is loaded from the index on first use and cached.
+func (s *Store) reserveBlob(ctx context.Context, size int64) error {
+	if s.maxBlobBytes <= 0 {
+		return nil
+	}
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	if !s.quotaLoaded {
+		n, err := s.index.ExternalBytes(ctx)
+		if err != nil {
+			return err
+		}
+		s.blobIndexed, s.quotaLoaded = n, true
+	}
+	if s.blobIndexed+s.blobInflight+size > s.maxBlobBytes {
+		return app.ErrStorageFull
+	}
+	s.blobInflight += size
+	return nil
+}
+
+// settleBlob resolves a reservation made by reserveBlob. When committed the
+// bytes move to the indexed total; otherwise they are released.
+func (s *Store) settleBlob(size int64, committed bool) {
+	if s.maxBlobBytes <= 0 {
+		return
+	}
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	s.blobInflight -= size
+	if committed {
+		s.blobIndexed += size
+	}
+}
+
+// forgetBlob subtracts a removed external payload from the cached usage.
+func (s *Store) forgetBlob(size int64) {
+	if s.maxBlobBytes <= 0 {
+		return
+	}
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	s.blobIndexed -= size
+	if s.blobIndexed < 0 {
+		s.blobIndexed = 0
+	}
+}
+
+// refreshBlobBytes re-reads committed usage from the index so the cache
+// cannot drift on long-running instances. It is called after expiry sweeps.
+func (s *Store) refreshBlobBytes(ctx context.Context) error {
+	if s.maxBlobBytes <= 0 {
+		return nil
+	}
+	n, err := s.index.ExternalBytes(ctx)
+	if err != nil {
+		return err
+	}
+	s.quotaMu.Lock()
+	s.blobIndexed, s.quotaLoaded = n, true
+	s.quotaMu.Unlock()
+	return nil
+}
+
+// freeSpaceTTL bounds how long a free-space reading is reused, so busy
+// instances do not statfs on every create.
+const freeSpaceTTL = 2 * time.Second
+
+// WithMinFreeBytes rejects Saves with app.ErrStorageFull when writing them
+// would leave fewer than n bytes free on the blob volume. It needs a blob
+// backend implementing SpaceReporter and is ignored otherwise. Zero disables
+// the check.
+func WithMinFreeBytes(n int64) Option {
+	return func(s *Store) { s.minFreeBytes = n }
+}
+
+// checkFreeSpace applies WithMinFreeBytes to a Save of size bytes, refreshing
+// the cached reading when it is older than freeSpaceTTL.
+func (s *Store) checkFreeSpace(size int64, now time.Time) error {
+	if s.minFreeBytes <= 0 {
+		return nil
+	}
+	sr, ok := s.blobs.(SpaceReporter)
+	if !ok {
+		return nil
+	}
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	if s.freeCheckedAt.IsZero() || now.Sub(s.freeCheckedAt) >= freeSpaceTTL || now.Before(s.freeCheckedAt) {
+		n, err := sr.FreeBytes()
+		if err != nil {
+			return err
+		}
+		s.freeBytes, s.freeCheckedAt = n, now
+	}
+	if s.freeBytes-size < s.minFreeBytes {
+		return app.ErrStorageFull
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DefaultBusyRetries is the number of extra attempts made when SQLite reports
+// the database busy or locked.
+const DefaultBusyRetries = 3
+
+// Backoff bounds for busy retries. The delay doubles per attempt with full
+// jitter and is capped at retryMaxDelay.
+const (
+	retryBaseDelay = 5 * time.Millisecond
+	retryMaxDelay  = 100 * time.Millisecond
+)
+
+// Option customizes optional Index behavior.
+type Option func(*Index)
+
+// WithBusyRetries sets how many times an operation is retried after a
+// SQLITE_BUSY/SQLITE_LOCKED error. Zero disables retries.
+func WithBusyRetries(n int) Option {
+	return func(i *Index) {
+		if n >= 0 {
+			i.retries = n
+		}
+	}
+}
+
+// isBusy reports whether err is a transient lock error worth retrying.
+// Constraint violations and all other errors are returned as-is.
+func isBusy(err error) bool {
+	var se sqlite3.Error
+	if !errors.As(err, &se) {
+		return false
+	}
+	return se.Code == sqlite3.ErrBusy || se.Code == sqlite3.ErrLocked
+}
+
+// isDuplicate reports whether err is a primary key violation.
+func isDuplicate(err error) bool {
+	var se sqlite3.Error
+	return errors.As(err, &se) && se.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
+// withRetry runs fn, retrying up to retries more times with jittered
+// exponential backoff while it fails with a busy/locked error. It stops early
+// when ctx is done and returns the last error from fn.
+func withRetry(ctx context.Context, retries int, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isBusy(err) {
+			return err
+		}
+		t := time.NewTimer(rand.N(delay) + 1)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
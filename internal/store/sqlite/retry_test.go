@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/haukened/gone/internal/app"
+)
+
+func TestWithRetry(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	locked := sqlite3.Error{Code: sqlite3.ErrLocked}
+	constraint := sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error // returned by successive calls; nil once exhausted
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success first try", retries: 3, wantCalls: 1},
+		{name: "busy then success", retries: 3, errs: []error{busy, locked}, wantCalls: 3},
+		{name: "gives up after retries", retries: 2, errs: []error{busy, busy, busy, busy}, wantCalls: 3, wantErr: busy},
+		{name: "retries disabled", retries: 0, errs: []error{busy}, wantCalls: 1, wantErr: busy},
+		{name: "constraint not retried", retries: 3, errs: []error{constraint}, wantCalls: 1, wantErr: constraint},
+		{name: "other error not retried", retries: 3, errs: []error{errors.New("boom")}, wantCalls: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(context.Background(), tc.retries, func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tc.wantCalls {
+				t.Fatalf("calls got %d want %d", calls, tc.wantCalls)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err got %v want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && len(tc.errs) < tc.wantCalls && err != nil {
+				t.Fatalf("unexpected err %v", err)
+			}
+		})
+	}
+}
+
+func TestWithRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := withRetry(ctx, 5, func() error {
+		calls++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	})
+	if calls != 1 || err == nil {
+		t.Fatalf("expected single attempt on canceled ctx, calls=%d err=%v", calls, err)
+	}
+}
+
+func TestIndexInsertDuplicateNotRetried(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db, WithBusyRetries(5))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if ix.retries != 5 {
+		t.Fatalf("retries option not applied")
+	}
+	now := time.Now().UTC()
+	meta := app.Meta{Version: 1, NonceB64u: "dup"}
+	if err = ix.Insert(context.Background(), "dup", meta, []byte("a"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	err = ix.Insert(context.Background(), "dup", meta, []byte("b"), false, 1, now, now.Add(time.Minute))
+	var se sqlite3.Error
+	if !errors.As(err, &se) || se.Code != sqlite3.ErrConstraint {
+		t.Fatalf("expected constraint error, got %v", err)
+	}
+}
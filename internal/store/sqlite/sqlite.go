@@ -6,30 +6,81 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
 	"github.com/haukened/gone/internal/store"
 
 	// database/sql SQLite driver
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var _ store.Index = (*Index)(nil)
+var (
+	_ store.Index           = (*Index)(nil)
+	_ store.IndexWalker     = (*Index)(nil)
+	_ store.IndexDeleter    = (*Index)(nil)
+	_ store.PassphraseIndex = (*Index)(nil)
+	_ store.AttemptIndex    = (*Index)(nil)
+	_ store.NextExpirer     = (*Index)(nil)
+	_ store.LiveCounter     = (*Index)(nil)
+	_ store.Toucher         = (*Index)(nil)
+)
 
 // Index implements store.Index using SQLite (via database/sql). It is safe for
 // concurrent use; database/sql manages connection pooling and serialization.
-type Index struct{ db *sql.DB }
+// Writes that hit SQLITE_BUSY/SQLITE_LOCKED are retried (see WithBusyRetries).
+type Index struct {
+	db      *sql.DB
+	retries int
+	grace   time.Duration // keep consumed external rows this long (0 = delete at once)
+	ro      *sql.DB       // optional read-only handle for reporting queries (nil = db)
+}
 
 // New constructs an Index, initializing the required schema if absent.
-func New(db *sql.DB) (*Index, error) {
-	ix := &Index{db: db}
+func New(db *sql.DB, opts ...Option) (*Index, error) {
+	ix := &Index{db: db, retries: DefaultBusyRetries}
+	for _, opt := range opts {
+		opt(ix)
+	}
 	if err := ix.init(); err != nil {
 		return nil, err
 	}
 	return ix, nil
 }
 
+// WithConsumeGrace keeps the row of an external secret for d after its final
+// read instead of deleting it, so the reader may fetch it again within the
+// window; the janitor sweeps it afterwards. This deliberately weakens the
+// read-once guarantee and is off by default. Inline secrets are unaffected.
+func WithConsumeGrace(d time.Duration) Option {
+	return func(i *Index) {
+		if d > 0 {
+			i.grace = d
+		}
+	}
+}
+
+// WithReadDB routes the reporting queries (ExpiryHistogram and Walk) through
+// ro, a read-only handle on the same database, so they do not queue behind
+// the write path on the primary handle. Everything else keeps using the
+// primary. The database should be in WAL mode for readers and the writer not
+// to block each other.
+func WithReadDB(ro *sql.DB) Option {
+	return func(i *Index) { i.ro = ro }
+}
+
+// reader returns the handle for reporting queries.
+func (i *Index) reader() *sql.DB {
+	if i.ro != nil {
+		return i.ro
+	}
+	return i.db
+}
+
 func (i *Index) init() error {
 	schema := `CREATE TABLE IF NOT EXISTS secrets (
 id TEXT PRIMARY KEY,
@@ -39,34 +90,172 @@ inline BLOB,
 external INTEGER NOT NULL DEFAULT 0,
 size INTEGER NOT NULL,
 created_at INTEGER NOT NULL,
-expires_at INTEGER NOT NULL
+expires_at INTEGER NOT NULL,
+tenant TEXT NOT NULL DEFAULT '',
+reads_remaining INTEGER NOT NULL DEFAULT 1,
+passphrase_hash TEXT NOT NULL DEFAULT '',
+consumed_at INTEGER NOT NULL DEFAULT 0,
+reads_taken INTEGER NOT NULL DEFAULT 0,
+not_before INTEGER NOT NULL DEFAULT 0,
+failed_attempts INTEGER NOT NULL DEFAULT 0,
+params TEXT NOT NULL DEFAULT '',
+content_type TEXT NOT NULL DEFAULT ''
 );`
-	_, err := i.db.Exec(schema)
-	return err
+	if _, err := i.db.Exec(schema); err != nil {
+		return err
+	}
+	return i.migrate()
 }
 
-// Insert stores a new secret row.
+// columnMigrations lists columns added after the initial schema. Databases
+// created by older releases are upgraded in place by migrate.
+var columnMigrations = []struct{ name, ddl string }{
+	{"tenant", `ALTER TABLE secrets ADD COLUMN tenant TEXT NOT NULL DEFAULT ''`},
+	{"reads_remaining", `ALTER TABLE secrets ADD COLUMN reads_remaining INTEGER NOT NULL DEFAULT 1`},
+	{"passphrase_hash", `ALTER TABLE secrets ADD COLUMN passphrase_hash TEXT NOT NULL DEFAULT ''`},
+	{"consumed_at", `ALTER TABLE secrets ADD COLUMN consumed_at INTEGER NOT NULL DEFAULT 0`},
+	{"reads_taken", `ALTER TABLE secrets ADD COLUMN reads_taken INTEGER NOT NULL DEFAULT 0`},
+	{"not_before", `ALTER TABLE secrets ADD COLUMN not_before INTEGER NOT NULL DEFAULT 0`},
+	{"failed_attempts", `ALTER TABLE secrets ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0`},
+	{"params", `ALTER TABLE secrets ADD COLUMN params TEXT NOT NULL DEFAULT ''`},
+	{"content_type", `ALTER TABLE secrets ADD COLUMN content_type TEXT NOT NULL DEFAULT ''`},
+}
+
+// migrate adds any columns from columnMigrations missing on the secrets table.
+func (i *Index) migrate() error {
+	have, err := i.columns()
+	if err != nil {
+		return err
+	}
+	for _, m := range columnMigrations {
+		if _, ok := have[m.name]; ok {
+			continue
+		}
+		if _, err := i.db.Exec(m.ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columns returns the set of column names currently on the secrets table.
+func (i *Index) columns() (map[string]struct{}, error) {
+	rows, err := i.db.Query(`SELECT name FROM pragma_table_info('secrets')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols[name] = struct{}{}
+	}
+	return cols, rows.Err()
+}
+
+// Insert stores a new secret row within the tenant carried by ctx. The row's
+// read allowance comes from app.MaxReadsFromContext. IDs are unique across all
+// tenants; a taken ID yields an error wrapping app.ErrDuplicateID.
 func (i *Index) Insert(ctx context.Context, id string, meta app.Meta, inline []byte, external bool, size int64, createdAt, expiresAt time.Time) error {
-	const q = `INSERT INTO secrets (id, version, nonce_b64u, inline, external, size, created_at, expires_at) VALUES (?,?,?,?,?,?,?,?)`
+	const q = `INSERT INTO secrets (id, version, nonce_b64u, inline, external, size, created_at, expires_at, tenant, reads_remaining, passphrase_hash, not_before, params, content_type) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
 	ext := 0
 	if external {
 		ext = 1
 	}
-	_, err := i.db.ExecContext(ctx, q, id, meta.Version, meta.NonceB64u, inline, ext, size, createdAt.Unix(), expiresAt.Unix())
+	var notBefore int64
+	if nb := app.NotBeforeFromContext(ctx); !nb.IsZero() {
+		notBefore = nb.Unix()
+	}
+	err := withRetry(ctx, i.retries, func() error {
+		_, err := i.db.ExecContext(ctx, q, id, meta.Version, meta.NonceB64u, inline, ext, size, createdAt.Unix(), expiresAt.Unix(), app.TenantFromContext(ctx), app.MaxReadsFromContext(ctx), meta.PassphraseHash, notBefore, meta.Params, meta.ContentType)
+		return err
+	})
+	if isDuplicate(err) {
+		return fmt.Errorf("%w: %w", app.ErrDuplicateID, err)
+	}
 	return err
 }
 
-// Consume hard-deletes the row and returns its data (including expiry) if it existed.
-// Expiration is not interpreted here; callers decide if an expired row constitutes not found.
-func (i *Index) Consume(ctx context.Context, id string, _ time.Time) (*store.IndexResult, error) {
-	const del = `DELETE FROM secrets WHERE id=? RETURNING version, nonce_b64u, inline, external, size, expires_at`
+// Consume performs one read of the row and returns its data (including expiry)
+// if it existed. Only rows belonging to the tenant carried by ctx are eligible.
+// A row with reads left and not yet expired at now is decremented; otherwise
+// it is hard-deleted. Both happen in one transaction so the counter can never
+// go below zero. With WithConsumeGrace the final read of an external row
+// marks it consumed and caps its expiry at now+grace rather than deleting it
+// (IndexResult.Retained). Callers still decide if an expired row constitutes
+// not found. A row whose not-before time is still ahead of now is left
+// untouched and reported as app.ErrTooEarly.
+func (i *Index) Consume(ctx context.Context, id string, now time.Time) (res *store.IndexResult, err error) {
+	err = withRetry(ctx, i.retries, func() error {
+		res, err = consumeTxn(ctx, i.db, id, now, i.grace)
+		return err
+	})
+	return res, err
+}
+
+// consumeTxn runs consumeRow in its own transaction.
+func consumeTxn(ctx context.Context, db *sql.DB, id string, now time.Time, grace time.Duration) (*store.IndexResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+	res, err := consumeRow(ctx, tx, id, app.TenantFromContext(ctx), now, grace)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+	return res, nil
+}
+
+// consumeRow decrements a multi-read row or deletes the row on its final read.
+// With a grace period, an unexpired external row is retained instead; only the
+// first final read starts the window, so re-reads cannot extend it. Embargoed
+// rows are checked first and never modified.
+func consumeRow(ctx context.Context, tx *sql.Tx, id, tenant string, now time.Time, grace time.Duration) (*store.IndexResult, error) {
+	const early = `SELECT 1 FROM secrets WHERE id=? AND tenant=? AND not_before > ?`
+	const dec = `UPDATE secrets SET reads_remaining = reads_remaining - 1, reads_taken = reads_taken + 1 WHERE id=? AND tenant=? AND reads_remaining > 1 AND expires_at > ? RETURNING version, nonce_b64u, params, content_type, inline, external, size, expires_at, reads_remaining`
+	const keep = `UPDATE secrets SET consumed_at = CASE consumed_at WHEN 0 THEN ? ELSE consumed_at END, expires_at = CASE consumed_at WHEN 0 THEN MIN(expires_at, ?) ELSE expires_at END WHERE id=? AND tenant=? AND external=1 AND expires_at > ? RETURNING version, nonce_b64u, params, content_type, inline, external, size, expires_at, 0`
+	const del = `DELETE FROM secrets WHERE id=? AND tenant=? RETURNING version, nonce_b64u, params, content_type, inline, external, size, expires_at, 0`
+	var one int
+	switch err := tx.QueryRowContext(ctx, early, id, tenant, now.Unix()).Scan(&one); {
+	case err == nil:
+		return nil, app.ErrTooEarly
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+	res, err := scanConsumed(tx.QueryRowContext(ctx, dec, id, tenant, now.Unix()))
+	if errors.Is(err, app.ErrNotFound) && grace > 0 {
+		res, err = scanConsumed(tx.QueryRowContext(ctx, keep, now.Unix(), now.Add(grace).Unix(), id, tenant, now.Unix()))
+		if err == nil {
+			res.Retained = true
+		}
+	}
+	if errors.Is(err, app.ErrNotFound) {
+		res, err = scanConsumed(tx.QueryRowContext(ctx, del, id, tenant))
+	}
+	return res, err
+}
+
+// scanConsumed reads a consumed row, mapping no rows to app.ErrNotFound.
+func scanConsumed(row *sql.Row) (*store.IndexResult, error) {
 	var (
 		res         store.IndexResult
 		extInt      int
 		expiresUnix int64
 	)
-	row := i.db.QueryRowContext(ctx, del, id)
-	if err := row.Scan(&res.Meta.Version, &res.Meta.NonceB64u, &res.Inline, &extInt, &res.Size, &expiresUnix); err != nil {
+	if err := row.Scan(&res.Meta.Version, &res.Meta.NonceB64u, &res.Meta.Params, &res.Meta.ContentType, &res.Inline, &extInt, &res.Size, &expiresUnix, &res.ReadsRemaining); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, app.ErrNotFound
 		}
@@ -78,8 +267,12 @@ func (i *Index) Consume(ctx context.Context, id string, _ time.Time) (*store.Ind
 }
 
 // DeleteExpired selects secrets expiring before t and deletes them, returning records for blob cleanup.
-func (i *Index) DeleteExpired(ctx context.Context, t time.Time) ([]store.ExpiredRecord, error) {
-	return deleteExpiredTxn(ctx, i.db, t)
+func (i *Index) DeleteExpired(ctx context.Context, t time.Time) (recs []store.ExpiredRecord, err error) {
+	err = withRetry(ctx, i.retries, func() error {
+		recs, err = deleteExpiredTxn(ctx, i.db, t)
+		return err
+	})
+	return recs, err
 }
 
 // deleteExpiredTxn performs the DeleteExpired logic; isolated to reduce cyclomatic complexity on the method receiver.
@@ -110,10 +303,50 @@ func deleteExpiredTxn(ctx context.Context, db *sql.DB, t time.Time) ([]store.Exp
 	return recs, nil
 }
 
+// DeleteExpiredBatch deletes at most limit records expired before t in one
+// short statement, so a large backlog can be drained without holding the
+// write lock for the whole sweep. Callers repeat it until fewer than limit
+// records come back.
+func (i *Index) DeleteExpiredBatch(ctx context.Context, t time.Time, limit int) (recs []store.ExpiredRecord, err error) {
+	const q = `DELETE FROM secrets WHERE id IN (SELECT id FROM secrets WHERE expires_at < ? LIMIT ?)
+RETURNING id, external, tenant, consumed_at != 0 OR reads_taken > 0`
+	err = withRetry(ctx, i.retries, func() error {
+		rows, qErr := i.db.QueryContext(ctx, q, t.Unix(), limit)
+		if qErr != nil {
+			return qErr
+		}
+		recs, qErr = scanExpiredRows(rows)
+		return qErr
+	})
+	return recs, err
+}
+
+// NextExpiry returns the earliest expires_at across all tenants, or the zero
+// time when the table is empty. Records already expired but not yet swept
+// count too, so a backlog yields a time in the past.
+func (i *Index) NextExpiry(ctx context.Context) (time.Time, error) {
+	var next sql.NullInt64
+	if err := i.reader().QueryRowContext(ctx, `SELECT MIN(expires_at) FROM secrets`).Scan(&next); err != nil {
+		return time.Time{}, err
+	}
+	if !next.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(next.Int64, 0).UTC(), nil
+}
+
+// CountLive counts unexpired rows across all tenants, leaving out rows only
+// retained for a consume grace.
+func (i *Index) CountLive(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	err := i.reader().QueryRowContext(ctx, `SELECT COUNT(*) FROM secrets WHERE expires_at > ? AND consumed_at = 0`, now.Unix()).Scan(&n)
+	return n, err
+}
+
 func selectExpired(ctx context.Context, q interface {
 	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
 }, t time.Time) ([]store.ExpiredRecord, error) {
-	const sel = `SELECT id, external FROM secrets WHERE expires_at < ?`
+	const sel = `SELECT id, external, tenant, consumed_at != 0 OR reads_taken > 0 FROM secrets WHERE expires_at < ?`
 	rows, err := q.QueryContext(ctx, sel, t.Unix())
 	if err != nil {
 		return nil, err
@@ -129,7 +362,7 @@ func deleteExpired(ctx context.Context, e interface {
 	return err
 }
 
-// scanExpiredRows reads all rows (id, external) from the provided *sql.Rows into a
+// scanExpiredRows reads all rows (id, external, tenant, read) from the provided *sql.Rows into a
 // slice of ExpiredRecord. It always closes the rows. The returned slice may be
 // empty if no rows were present. An error is returned if scanning or rows.Err()
 // produces an error.
@@ -138,11 +371,12 @@ func scanExpiredRows(rows *sql.Rows) ([]store.ExpiredRecord, error) {
 	var recs []store.ExpiredRecord
 	for rows.Next() {
 		var r store.ExpiredRecord
-		var extInt int
-		if err := rows.Scan(&r.ID, &extInt); err != nil {
+		var extInt, readInt int
+		if err := rows.Scan(&r.ID, &extInt, &r.Tenant, &readInt); err != nil {
 			return nil, err
 		}
 		r.External = extInt == 1
+		r.Read = readInt == 1
 		recs = append(recs, r)
 	}
 	if err := rows.Err(); err != nil {
@@ -151,10 +385,11 @@ func scanExpiredRows(rows *sql.Rows) ([]store.ExpiredRecord, error) {
 	return recs, nil
 }
 
-// ListExternalIDs returns IDs of secrets with external (blob) storage.
+// ListExternalIDs returns IDs of secrets with external (blob) storage
+// belonging to the tenant carried by ctx.
 func (i *Index) ListExternalIDs(ctx context.Context) ([]string, error) {
-	const q = `SELECT id FROM secrets WHERE external=1`
-	rows, err := i.db.QueryContext(ctx, q)
+	const q = `SELECT id FROM secrets WHERE external=1 AND tenant=?`
+	rows, err := i.db.QueryContext(ctx, q, app.TenantFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -172,3 +407,206 @@ func (i *Index) ListExternalIDs(ctx context.Context) ([]string, error) {
 	}
 	return ids, nil
 }
+
+// PassphraseHash returns the passphrase hash of the row id belonging to the
+// tenant carried by ctx without consuming it. Rows expired at now are
+// reported as app.ErrNotFound.
+func (i *Index) PassphraseHash(ctx context.Context, id string, now time.Time) (string, error) {
+	const q = `SELECT passphrase_hash FROM secrets WHERE id=? AND tenant=? AND expires_at > ?`
+	var h string
+	err := i.db.QueryRowContext(ctx, q, id, app.TenantFromContext(ctx), now.Unix()).Scan(&h)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", app.ErrNotFound
+	}
+	return h, err
+}
+
+// Exists reports whether the row id belonging to the tenant carried by ctx is
+// unexpired at now. The row is left untouched.
+func (i *Index) Exists(ctx context.Context, id string, now time.Time) (bool, error) {
+	const q = `SELECT 1 FROM secrets WHERE id=? AND tenant=? AND expires_at > ?`
+	var one int
+	err := i.db.QueryRowContext(ctx, q, id, app.TenantFromContext(ctx), now.Unix()).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FailAttempt counts one failed consume against the live row id belonging to
+// the tenant carried by ctx and deletes the row once the count reaches limit.
+// The increment and the delete share a transaction, so concurrent failures
+// cannot overshoot the limit. It returns app.ErrNotFound if no row is live.
+func (i *Index) FailAttempt(ctx context.Context, id string, now time.Time, limit int) (deleted, external bool, err error) {
+	err = withRetry(ctx, i.retries, func() error {
+		deleted, external, err = failAttemptTxn(ctx, i.db, id, app.TenantFromContext(ctx), now, limit)
+		return err
+	})
+	return deleted, external, err
+}
+
+func failAttemptTxn(ctx context.Context, db *sql.DB, id, tenant string, now time.Time, limit int) (deleted, external bool, err error) {
+	const inc = `UPDATE secrets SET failed_attempts = failed_attempts + 1 WHERE id=? AND tenant=? AND expires_at > ? RETURNING failed_attempts, external`
+	const del = `DELETE FROM secrets WHERE id=? AND tenant=?`
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	var n, ext int
+	if err = tx.QueryRowContext(ctx, inc, id, tenant, now.Unix()).Scan(&n, &ext); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = app.ErrNotFound
+		}
+		return false, false, err
+	}
+	if n >= limit {
+		if _, err = tx.ExecContext(ctx, del, id, tenant); err != nil {
+			return false, false, err
+		}
+		deleted = true
+	}
+	if err = tx.Commit(); err != nil {
+		return false, false, err
+	}
+	return deleted, ext == 1, nil
+}
+
+// Delete removes the secret row id belonging to the tenant carried by ctx.
+// It returns app.ErrNotFound if no such row exists.
+func (i *Index) Delete(ctx context.Context, id string) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM secrets WHERE id=? AND tenant=?`, id, app.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return app.ErrNotFound
+	}
+	return nil
+}
+
+// Touch moves the expiry of the live, unconsumed row id in the tenant carried
+// by ctx later, to expiresAt. It returns app.ErrNotFound when no such row
+// exists, and domain.ErrTTLInvalid when expiresAt would shorten the row's
+// life or fall at or before its not-before time.
+func (i *Index) Touch(ctx context.Context, id string, now, expiresAt time.Time) error {
+	const q = `UPDATE secrets SET expires_at=? WHERE id=? AND tenant=? AND expires_at > ? AND consumed_at = 0 AND expires_at <= ? AND not_before < ?`
+	const live = `SELECT 1 FROM secrets WHERE id=? AND tenant=? AND expires_at > ? AND consumed_at = 0`
+	tenant := app.TenantFromContext(ctx)
+	var n int64
+	err := withRetry(ctx, i.retries, func() error {
+		res, err := i.db.ExecContext(ctx, q, expiresAt.Unix(), id, tenant, now.Unix(), expiresAt.Unix(), expiresAt.Unix())
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var one int
+		err := i.db.QueryRowContext(ctx, live, id, tenant, now.Unix()).Scan(&one)
+		if errors.Is(err, sql.ErrNoRows) {
+			return app.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return domain.ErrTTLInvalid
+	}
+	return nil
+}
+
+// ExternalBytes returns the total size of externally stored payloads across
+// all tenants.
+func (i *Index) ExternalBytes(ctx context.Context) (int64, error) {
+	var n int64
+	err := i.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM secrets WHERE external=1`).Scan(&n)
+	return n, err
+}
+
+// ExpiryHistogram counts unexpired rows by remaining lifetime in a single
+// grouped query. Bounds are sorted and de-duplicated; remaining time is
+// compared at one-second resolution, matching stored expiries.
+func (i *Index) ExpiryHistogram(ctx context.Context, now time.Time, buckets []time.Duration) (map[string]int64, error) {
+	bounds := append([]time.Duration(nil), buckets...)
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+	out := make(map[string]int64, len(bounds)+1)
+	var q strings.Builder
+	args := make([]any, 0, 2*len(bounds)+2)
+	q.WriteString(`SELECT CASE`)
+	for n, b := range bounds {
+		out[store.BucketLabel(b)] = 0
+		q.WriteString(` WHEN expires_at - ? <= ? THEN ?`)
+		args = append(args, now.Unix(), int64(b/time.Second), n)
+	}
+	out[store.BucketOverflow] = 0
+	q.WriteString(` ELSE ? END AS bucket, COUNT(*) FROM secrets WHERE expires_at > ? GROUP BY bucket`)
+	args = append(args, len(bounds), now.Unix())
+	if len(bounds) == 0 {
+		// CASE needs at least one WHEN; everything overflows anyway.
+		q.Reset()
+		q.WriteString(`SELECT ? AS bucket, COUNT(*) FROM secrets WHERE expires_at > ? GROUP BY bucket`)
+	}
+	rows, err := i.reader().QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket int
+		var n int64
+		if err := rows.Scan(&bucket, &n); err != nil {
+			return nil, err
+		}
+		if bucket < len(bounds) {
+			out[store.BucketLabel(bounds[bucket])] = n
+		} else {
+			out[store.BucketOverflow] = n
+		}
+	}
+	return out, rows.Err()
+}
+
+// Walk calls fn for every secret row across all tenants, oldest first. Rows
+// already consumed and only held for a consume grace are skipped so an export
+// cannot revive them.
+func (i *Index) Walk(ctx context.Context, fn func(store.Record) error) error {
+	const q = `SELECT id, tenant, version, nonce_b64u, passphrase_hash, inline, external, size, created_at, expires_at, reads_remaining, not_before, params, content_type FROM secrets WHERE consumed_at = 0 ORDER BY created_at, id`
+	rows, err := i.reader().QueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			r                              store.Record
+			extInt                         int
+			createdAt, expireAt, notBefore int64
+		)
+		if err := rows.Scan(&r.ID, &r.Tenant, &r.Meta.Version, &r.Meta.NonceB64u, &r.Meta.PassphraseHash, &r.Inline, &extInt, &r.Size, &createdAt, &expireAt, &r.ReadsRemaining, &notBefore, &r.Meta.Params, &r.Meta.ContentType); err != nil {
+			return err
+		}
+		r.External = extInt == 1
+		r.CreatedAt = time.Unix(createdAt, 0).UTC()
+		r.ExpiresAt = time.Unix(expireAt, 0).UTC()
+		if notBefore != 0 {
+			r.NotBefore = time.Unix(notBefore, 0).UTC()
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
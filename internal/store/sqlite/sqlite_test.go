@@ -4,13 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/domain"
+	"github.com/haukened/gone/internal/store"
 )
 
 // openTestDB opens a transient SQLite database file in a temp dir with WAL enabled.
@@ -183,6 +188,85 @@ func TestIndexDeleteExpired(t *testing.T) {
 	}
 }
 
+func TestIndexDeleteExpiredBatch(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, id := range []string{"e1", "e2", "e3", "e4", "e5"} {
+		if err := ix.Insert(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, []byte("x"), false, 1, now.Add(-time.Hour), now.Add(-time.Minute)); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+	if err := ix.Insert(ctx, "future", app.Meta{Version: 1, NonceB64u: "n"}, []byte("f"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert future: %v", err)
+	}
+	var sizes []int
+	seen := map[string]bool{}
+	for {
+		recs, err := ix.DeleteExpiredBatch(ctx, now, 2)
+		if err != nil {
+			t.Fatalf("DeleteExpiredBatch: %v", err)
+		}
+		sizes = append(sizes, len(recs))
+		for _, r := range recs {
+			if seen[r.ID] {
+				t.Fatalf("record %s returned twice", r.ID)
+			}
+			seen[r.ID] = true
+		}
+		if len(recs) < 2 {
+			break
+		}
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" || len(seen) != 5 {
+		t.Fatalf("batch sizes %v, ids %v", sizes, seen)
+	}
+	if _, err := ix.Consume(ctx, "future", now); err != nil {
+		t.Fatalf("future consume: %v", err)
+	}
+}
+
+// TestIndexDeleteExpiredRead flags rows that were read before expiring: a
+// partially read multi-read secret and one kept by the consume grace.
+func TestIndexDeleteExpiredRead(t *testing.T) {
+	ix, err := New(openTestDB(t), WithConsumeGrace(time.Minute))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	exp := now.Add(10 * time.Minute)
+	for _, r := range []struct {
+		id    string
+		reads int
+		ext   bool
+	}{{"unread", 1, false}, {"multi", 3, false}, {"grace", 1, true}} {
+		if err := ix.Insert(app.WithMaxReads(ctx, r.reads), r.id, app.Meta{Version: 1, NonceB64u: "n"}, nil, r.ext, 1, now, exp); err != nil {
+			t.Fatalf("insert %s: %v", r.id, err)
+		}
+	}
+	for _, id := range []string{"multi", "grace"} {
+		if _, err := ix.Consume(ctx, id, now); err != nil {
+			t.Fatalf("consume %s: %v", id, err)
+		}
+	}
+	recs, err := ix.DeleteExpired(ctx, exp.Add(time.Second))
+	if err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	read := map[string]bool{}
+	for _, r := range recs {
+		read[r.ID] = r.Read
+	}
+	if len(read) != 3 || read["unread"] || !read["multi"] || !read["grace"] {
+		t.Fatalf("read flags %+v", recs)
+	}
+}
+
 func TestIndexListExternalIDs(t *testing.T) {
 	db := openTestDB(t)
 	ix, err := New(db)
@@ -225,8 +309,12 @@ func TestIndexInsertDuplicate(t *testing.T) {
 	if err := ix.Insert(ctx, "dup1", meta, []byte("a"), false, 1, now, now.Add(time.Minute)); err != nil {
 		t.Fatalf("first insert: %v", err)
 	}
-	if err := ix.Insert(ctx, "dup1", meta, []byte("b"), false, 1, now, now.Add(time.Minute)); err == nil {
-		t.Fatalf("expected duplicate insert error")
+	if err := ix.Insert(ctx, "dup1", meta, []byte("b"), false, 1, now, now.Add(time.Minute)); !errors.Is(err, app.ErrDuplicateID) {
+		t.Fatalf("expected ErrDuplicateID, got %v", err)
+	}
+	// IDs are unique across tenants too.
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "dup1", meta, []byte("c"), false, 1, now, now.Add(time.Minute)); !errors.Is(err, app.ErrDuplicateID) {
+		t.Fatalf("expected ErrDuplicateID across tenants, got %v", err)
 	}
 }
 
@@ -284,3 +372,658 @@ func TestIndexListExternalIDsClosedDB(t *testing.T) {
 		t.Fatalf("expected error querying closed DB")
 	}
 }
+
+func TestIndexTenantScoping(t *testing.T) {
+	db := openTestDB(t)
+	ix, _ := New(db)
+	now := time.Now().UTC()
+	ctxA := app.WithTenant(context.Background(), "acme")
+	ctxB := app.WithTenant(context.Background(), "globex")
+	if err := ix.Insert(ctxA, "tenantA", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 10, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if ids, err := ix.ListExternalIDs(ctxB); err != nil || len(ids) != 0 {
+		t.Fatalf("tenant B should see no ids, got %v err=%v", ids, err)
+	}
+	if ids, err := ix.ListExternalIDs(ctxA); err != nil || len(ids) != 1 {
+		t.Fatalf("tenant A should see its id, got %v err=%v", ids, err)
+	}
+	if _, err := ix.Consume(ctxB, "tenantA", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for cross-tenant consume, got %v", err)
+	}
+	recs, err := ix.DeleteExpired(context.Background(), now.Add(time.Hour))
+	if err != nil || len(recs) != 1 || recs[0].Tenant != "acme" {
+		t.Fatalf("expected expired record tagged with tenant, got %+v err=%v", recs, err)
+	}
+}
+
+func TestIndexMigratesLegacySchema(t *testing.T) {
+	db := openTestDB(t)
+	legacy := `CREATE TABLE secrets (
+id TEXT PRIMARY KEY,
+version INTEGER NOT NULL,
+nonce_b64u TEXT NOT NULL,
+inline BLOB,
+external INTEGER NOT NULL DEFAULT 0,
+size INTEGER NOT NULL,
+created_at INTEGER NOT NULL,
+expires_at INTEGER NOT NULL
+);`
+	if _, err := db.Exec(legacy); err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO secrets (id, version, nonce_b64u, inline, external, size, created_at, expires_at) VALUES ('old',1,'n',x'61',0,1,0,?)`, time.Now().Add(time.Hour).Unix()); err != nil {
+		t.Fatalf("legacy insert: %v", err)
+	}
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New on legacy schema: %v", err)
+	}
+	// Pre-existing rows land in the default namespace, ungated.
+	if h, err := ix.PassphraseHash(context.Background(), "old", time.Now()); err != nil || h != "" {
+		t.Fatalf("migrated row passphrase hash got %q, %v", h, err)
+	}
+	if _, err := ix.Consume(context.Background(), "old", time.Now()); err != nil {
+		t.Fatalf("consume migrated row: %v", err)
+	}
+}
+
+func TestIndexExternalBytes(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if n, err := ix.ExternalBytes(ctx); err != nil || n != 0 {
+		t.Fatalf("empty index: n=%d err=%v", n, err)
+	}
+	now := time.Now().UTC()
+	if err := ix.Insert(ctx, "inl", app.Meta{Version: 1, NonceB64u: "ni"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert inline: %v", err)
+	}
+	if err := ix.Insert(ctx, "extA", app.Meta{Version: 1, NonceB64u: "na"}, nil, true, 11, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert extA: %v", err)
+	}
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "extB", app.Meta{Version: 1, NonceB64u: "nb"}, nil, true, 12, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert extB: %v", err)
+	}
+	n, err := ix.ExternalBytes(ctx)
+	if err != nil {
+		t.Fatalf("ExternalBytes: %v", err)
+	}
+	if n != 23 {
+		t.Fatalf("expected 23 external bytes across tenants, got %d", n)
+	}
+}
+
+func TestIndexConsumeMultiRead(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := ix.Insert(app.WithMaxReads(ctx, 3), "multi", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	for _, want := range []int{2, 1, 0} {
+		res, err := ix.Consume(ctx, "multi", now)
+		if err != nil {
+			t.Fatalf("consume (want %d left): %v", want, err)
+		}
+		if res.ReadsRemaining != want || string(res.Inline) != "d" {
+			t.Fatalf("reads remaining = %d inline=%q, want %d", res.ReadsRemaining, res.Inline, want)
+		}
+	}
+	if _, err := ix.Consume(ctx, "multi", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after final read, got %v", err)
+	}
+}
+
+func TestIndexConsumeNotBefore(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Unix(1700000000, 0).UTC()
+	ctx := app.WithNotBefore(app.WithMaxReads(context.Background(), 2), now.Add(time.Minute))
+	if err := ix.Insert(ctx, "nb", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	var got store.Record
+	if err := ix.Walk(context.Background(), func(r store.Record) error { got = r; return nil }); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if !got.NotBefore.Equal(now.Add(time.Minute)) {
+		t.Fatalf("walk not before = %v", got.NotBefore)
+	}
+	if _, err := ix.Consume(context.Background(), "nb", now.Add(59*time.Second)); !errors.Is(err, app.ErrTooEarly) {
+		t.Fatalf("expected ErrTooEarly, got %v", err)
+	}
+	res, err := ix.Consume(context.Background(), "nb", now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("consume at start: %v", err)
+	}
+	if res.ReadsRemaining != 1 {
+		t.Fatalf("early read was counted: reads remaining = %d", res.ReadsRemaining)
+	}
+}
+
+func TestIndexFailAttempt(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	if err := ix.Insert(ctx, "ext", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 10, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if deleted, _, err := ix.FailAttempt(ctx, "ext", now, 3); err != nil || deleted {
+			t.Fatalf("failure %d: deleted=%v err=%v", i, deleted, err)
+		}
+	}
+	if ok, _ := ix.Exists(ctx, "ext", now); !ok {
+		t.Fatalf("row gone before the limit")
+	}
+	deleted, external, err := ix.FailAttempt(ctx, "ext", now, 3)
+	if err != nil || !deleted || !external {
+		t.Fatalf("at limit: deleted=%v external=%v err=%v", deleted, external, err)
+	}
+	if _, _, err := ix.FailAttempt(ctx, "ext", now, 3); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after deletion, got %v", err)
+	}
+
+	// Expired rows and other tenants' rows are not charged.
+	if err := ix.Insert(ctx, "old", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, _, err := ix.FailAttempt(ctx, "old", now, 1); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expired row: %v", err)
+	}
+	if _, _, err := ix.FailAttempt(app.WithTenant(ctx, "acme"), "old", now.Add(-time.Second), 1); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("cross-tenant row: %v", err)
+	}
+}
+
+func TestIndexConsumeMultiReadExpiredDeletes(t *testing.T) {
+	db := openTestDB(t)
+	ix, _ := New(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := ix.Insert(app.WithMaxReads(ctx, 5), "exp", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	res, err := ix.Consume(ctx, "exp", now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if res.ReadsRemaining != 0 {
+		t.Fatalf("expired multi-read row should be deleted, %d reads left", res.ReadsRemaining)
+	}
+	if _, err := ix.Consume(ctx, "exp", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected row gone, got %v", err)
+	}
+}
+
+// TestIndexConsumeMultiReadConcurrent proves the read counter cannot go
+// negative: exactly maxReads concurrent consumers succeed.
+func TestIndexConsumeMultiReadConcurrent(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "conc.db?_busy_timeout=5000&_txlock=immediate&_journal_mode=WAL")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	const maxReads, workers = 3, 24
+	if err := ix.Insert(app.WithMaxReads(ctx, maxReads), "conc", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		remaining []int
+		notFound  int
+	)
+	start := make(chan struct{})
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			res, err := ix.Consume(ctx, "conc", now)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, app.ErrNotFound):
+				notFound++
+			case err != nil:
+				t.Errorf("consume: %v", err)
+			default:
+				remaining = append(remaining, res.ReadsRemaining)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if len(remaining) != maxReads || notFound != workers-maxReads {
+		t.Fatalf("successes=%d notFound=%d, want %d/%d", len(remaining), notFound, maxReads, workers-maxReads)
+	}
+	seen := map[int]bool{}
+	for _, r := range remaining {
+		if r < 0 || r >= maxReads || seen[r] {
+			t.Fatalf("unexpected reads remaining sequence %v", remaining)
+		}
+		seen[r] = true
+	}
+}
+
+func TestIndexDelete(t *testing.T) {
+	ix, err := New(openTestDB(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "ext", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 5, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Delete(ctx, "ext"); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("delete in wrong tenant: %v", err)
+	}
+	if err := ix.Delete(app.WithTenant(ctx, "acme"), "ext"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := ix.Delete(app.WithTenant(ctx, "acme"), "ext"); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("second delete: %v", err)
+	}
+}
+
+func TestIndexPassphraseHash(t *testing.T) {
+	ix, err := New(openTestDB(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := ix.Insert(ctx, "gated", app.Meta{Version: 1, NonceB64u: "n", PassphraseHash: "$2a$04$hash"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert gated: %v", err)
+	}
+	if err := ix.Insert(ctx, "open", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert open: %v", err)
+	}
+	if h, err := ix.PassphraseHash(ctx, "gated", now); err != nil || h != "$2a$04$hash" {
+		t.Fatalf("gated hash got %q, %v", h, err)
+	}
+	if h, err := ix.PassphraseHash(ctx, "open", now); err != nil || h != "" {
+		t.Fatalf("open hash got %q, %v", h, err)
+	}
+	if _, err := ix.PassphraseHash(ctx, "gated", now.Add(time.Hour)); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expired row should be not found, got %v", err)
+	}
+	if _, err := ix.PassphraseHash(app.WithTenant(ctx, "acme"), "gated", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("other tenant should be not found, got %v", err)
+	}
+	// Reading the hash must not consume the secret.
+	if _, err := ix.Consume(ctx, "gated", now); err != nil {
+		t.Fatalf("consume after hash lookup: %v", err)
+	}
+}
+
+func TestIndexExpiryHistogram(t *testing.T) {
+	db := openTestDB(t)
+	ix, _ := New(db)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	seed := map[string]time.Duration{
+		"expired": -time.Minute,
+		"m1":      2 * time.Minute,
+		"m2":      5 * time.Minute, // bounds are inclusive
+		"h1":      30 * time.Minute,
+		"d1":      12 * time.Hour,
+		"d2":      48 * time.Hour,
+	}
+	for id, left := range seed {
+		if err := ix.Insert(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now.Add(-time.Hour), now.Add(left)); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+	// Another tenant's rows count too: the histogram is an operator view.
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "t1", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 1, now, now.Add(3*time.Minute)); err != nil {
+		t.Fatalf("insert tenant: %v", err)
+	}
+	// Unsorted, duplicated bounds are normalised.
+	got, err := ix.ExpiryHistogram(ctx, now, []time.Duration{time.Hour, 5 * time.Minute, 24 * time.Hour, time.Hour, 10 * time.Minute})
+	if err != nil {
+		t.Fatalf("ExpiryHistogram: %v", err)
+	}
+	want := map[string]int64{
+		store.BucketLabel(5 * time.Minute):  3,
+		store.BucketLabel(10 * time.Minute): 0,
+		store.BucketLabel(time.Hour):        1,
+		store.BucketLabel(24 * time.Hour):   1,
+		store.BucketOverflow:                1,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("buckets: got %v want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("bucket %s: got %d want %d (all %v)", k, got[k], v, got)
+		}
+	}
+
+	got, err = ix.ExpiryHistogram(ctx, now, nil)
+	if err != nil {
+		t.Fatalf("ExpiryHistogram no buckets: %v", err)
+	}
+	if len(got) != 1 || got[store.BucketOverflow] != 6 {
+		t.Fatalf("expected all live rows in overflow, got %v", got)
+	}
+}
+
+func TestIndexExists(t *testing.T) {
+	db := openTestDB(t)
+	ix, _ := New(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := ix.Insert(ctx, "live", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if ok, err := ix.Exists(ctx, "live", now); err != nil || !ok {
+		t.Fatalf("expected live row, got %v %v", ok, err)
+	}
+	if ok, _ := ix.Exists(ctx, "live", now.Add(2*time.Minute)); ok {
+		t.Fatalf("expired row reported live")
+	}
+	if ok, _ := ix.Exists(app.WithTenant(ctx, "acme"), "live", now); ok {
+		t.Fatalf("row visible to another tenant")
+	}
+	if ok, _ := ix.Exists(ctx, "missing", now); ok {
+		t.Fatalf("missing row reported live")
+	}
+	// Probing leaves the row consumable.
+	if _, err := ix.Consume(ctx, "live", now); err != nil {
+		t.Fatalf("consume after probe: %v", err)
+	}
+}
+
+func TestIndexConsumeGrace(t *testing.T) {
+	ix, err := New(openTestDB(t), WithConsumeGrace(30*time.Second))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	if err := ix.Insert(ctx, "ext", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 10, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert ext: %v", err)
+	}
+	if err := ix.Insert(ctx, "inl", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert inline: %v", err)
+	}
+	// Re-reads inside the window succeed but never push it out.
+	for _, at := range []time.Duration{0, 10 * time.Second, 29 * time.Second} {
+		res, err := ix.Consume(ctx, "ext", now.Add(at))
+		if err != nil {
+			t.Fatalf("read at +%s: %v", at, err)
+		}
+		if !res.Retained || res.ReadsRemaining != 0 || !res.ExpiresAt.Equal(now.Add(30*time.Second)) {
+			t.Fatalf("read at +%s: retained=%v left=%d expires=%s", at, res.Retained, res.ReadsRemaining, res.ExpiresAt)
+		}
+	}
+	var walked int
+	if err := ix.Walk(ctx, func(store.Record) error { walked++; return nil }); err != nil || walked != 1 {
+		t.Fatalf("walk should skip the retained row: walked=%d err=%v", walked, err)
+	}
+	// Past the window the row is expired and deleted like any other.
+	res, err := ix.Consume(ctx, "ext", now.Add(30*time.Second))
+	if err != nil || res.Retained || !res.ExpiresAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("read after grace: res=%+v err=%v", res, err)
+	}
+	if _, err := ix.Consume(ctx, "ext", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected row gone, got %v", err)
+	}
+	// Inline secrets keep strict read-once semantics.
+	if res, err := ix.Consume(ctx, "inl", now); err != nil || res.Retained {
+		t.Fatalf("inline read: res=%+v err=%v", res, err)
+	}
+	if _, err := ix.Consume(ctx, "inl", now); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected inline row gone, got %v", err)
+	}
+}
+
+func TestIndexConsumeGraceCappedByExpiry(t *testing.T) {
+	ix, _ := New(openTestDB(t), WithConsumeGrace(time.Minute))
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	if err := ix.Insert(ctx, "ext", app.Meta{Version: 1, NonceB64u: "n"}, nil, true, 10, now, now.Add(5*time.Second)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	res, err := ix.Consume(ctx, "ext", now)
+	if err != nil || !res.ExpiresAt.Equal(now.Add(5*time.Second)) {
+		t.Fatalf("grace must not outlive the TTL: res=%+v err=%v", res, err)
+	}
+}
+
+// TestIndexReadDB checks reporting queries go through the read-only handle
+// while writes stay on the primary.
+func TestIndexReadDB(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ro, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("open ro: %v", err)
+	}
+	ix, err := New(db, WithReadDB(ro))
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	if err := ix.Insert(ctx, "abcdabcdabcdabcdabcdabcdabcdabcd", app.Meta{Version: 1, NonceB64u: "n"}, []byte("x"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	hist, err := ix.ExpiryHistogram(ctx, now, []time.Duration{time.Hour})
+	if err != nil {
+		t.Fatalf("histogram: %v", err)
+	}
+	if hist["1h0m0s"] != 1 {
+		t.Fatalf("histogram did not see the write: %v", hist)
+	}
+	if _, err := ro.Exec(`DELETE FROM secrets`); err == nil {
+		t.Fatalf("read handle accepted a write")
+	}
+
+	// With the read handle gone, reports fail but the write path does not.
+	ro.Close()
+	if _, err := ix.ExpiryHistogram(ctx, now, []time.Duration{time.Hour}); err == nil {
+		t.Fatalf("histogram did not use the read handle")
+	}
+	if err := ix.Walk(ctx, func(store.Record) error { return nil }); err == nil {
+		t.Fatalf("walk did not use the read handle")
+	}
+	if err := ix.Insert(ctx, "bcdebcdebcdebcdebcdebcdebcdebcde", app.Meta{Version: 1, NonceB64u: "n"}, []byte("y"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert after closing read handle: %v", err)
+	}
+	if _, err := ix.Consume(ctx, "bcdebcdebcdebcdebcdebcdebcdebcde", now); err != nil {
+		t.Fatalf("consume after closing read handle: %v", err)
+	}
+}
+
+func TestIndexNextExpiry(t *testing.T) {
+	db := openTestDB(t)
+	ix, err := New(db)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if next, err := ix.NextExpiry(ctx); err != nil || !next.IsZero() {
+		t.Fatalf("empty NextExpiry = %v, %v", next, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := ix.Insert(ctx, "later", app.Meta{Version: 1, NonceB64u: "n"}, []byte("x"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "soon", app.Meta{Version: 1, NonceB64u: "n"}, []byte("x"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	next, err := ix.NextExpiry(ctx)
+	if err != nil {
+		t.Fatalf("NextExpiry: %v", err)
+	}
+	if !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("NextExpiry = %v, want %v (across tenants)", next, now.Add(time.Minute))
+	}
+}
+
+func TestIndexParams(t *testing.T) {
+	ix, err := New(openTestDB(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	const params, contentType = "eyJrZGYiOiJhcmdvbjJpZCJ9", "image/png"
+	if err := ix.Insert(ctx, "with", app.Meta{Version: 1, NonceB64u: "n", Params: params, ContentType: contentType}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert with params: %v", err)
+	}
+	if err := ix.Insert(ctx, "without", app.Meta{Version: 1, NonceB64u: "n"}, []byte("d"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert without params: %v", err)
+	}
+	want := map[string]string{"with": params, "without": ""}
+	walked := make(map[string]string)
+	if err := ix.Walk(ctx, func(r store.Record) error {
+		walked[r.ID] = r.Meta.Params
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if !maps.Equal(walked, want) {
+		t.Fatalf("walked params %v, want %v", walked, want)
+	}
+	for id, want := range want {
+		res, err := ix.Consume(ctx, id, now)
+		if err != nil {
+			t.Fatalf("consume %s: %v", id, err)
+		}
+		if res.Meta.Params != want {
+			t.Fatalf("%s params = %q, want %q", id, res.Meta.Params, want)
+		}
+		wantCT := ""
+		if id == "with" {
+			wantCT = contentType
+		}
+		if res.Meta.ContentType != wantCT {
+			t.Fatalf("%s content type = %q, want %q", id, res.Meta.ContentType, wantCT)
+		}
+	}
+}
+
+func TestIndexCountLive(t *testing.T) {
+	ix, err := New(openTestDB(t), WithConsumeGrace(time.Minute))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	if err := ix.Insert(ctx, "live", meta, []byte("x"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Insert(app.WithTenant(ctx, "acme"), "tenant", meta, []byte("x"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Insert(ctx, "expired", meta, []byte("x"), false, 1, now.Add(-time.Hour), now.Add(-time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Insert(ctx, "retained", meta, nil, true, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if res, err := ix.Consume(ctx, "retained", now); err != nil || !res.Retained {
+		t.Fatalf("consume retained = %+v, %v", res, err)
+	}
+	if n, err := ix.CountLive(ctx, now); err != nil || n != 2 {
+		t.Fatalf("CountLive = %d, %v; want 2", n, err)
+	}
+}
+
+func TestIndexTouch(t *testing.T) {
+	ix, err := New(openTestDB(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	if err := ix.Insert(ctx, "live", meta, []byte("x"), false, 1, now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Insert(ctx, "expired", meta, []byte("x"), false, 1, now.Add(-time.Hour), now.Add(-time.Minute)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := ix.Touch(ctx, "live", now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Touch live: %v", err)
+	}
+	if next, err := ix.NextExpiry(ctx); err != nil || !next.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("NextExpiry = %v, %v", next, err)
+	}
+	for name, c := range map[string]context.Context{"expired": ctx, "missing": ctx, "live": app.WithTenant(ctx, "acme")} {
+		if err := ix.Touch(c, name, now, now.Add(time.Hour)); !errors.Is(err, app.ErrNotFound) {
+			t.Fatalf("Touch %s (tenant %q) = %v, want ErrNotFound", name, app.TenantFromContext(c), err)
+		}
+	}
+	res, err := ix.Consume(ctx, "live", now.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("consume past old expiry: %v", err)
+	}
+	if !res.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("ExpiresAt = %v, want %v", res.ExpiresAt, now.Add(time.Hour))
+	}
+}
+
+func TestIndexTouchNeverShortens(t *testing.T) {
+	ix, err := New(openTestDB(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	embargoed := app.WithNotBefore(ctx, now.Add(30*time.Minute))
+	if err := ix.Insert(embargoed, "embargoed", meta, []byte("x"), false, 1, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	// A shorter expiry, including one landing on or before the embargo, must
+	// not make the secret unreadable.
+	for _, exp := range []time.Time{now.Add(10 * time.Minute), now.Add(30 * time.Minute), now.Add(45 * time.Minute)} {
+		if err := ix.Touch(ctx, "embargoed", now, exp); !errors.Is(err, domain.ErrTTLInvalid) {
+			t.Fatalf("Touch to %v = %v, want ErrTTLInvalid", exp.Sub(now), err)
+		}
+	}
+	if err := ix.Touch(ctx, "embargoed", now, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Touch longer: %v", err)
+	}
+	res, err := ix.Consume(ctx, "embargoed", now.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("consume after embargo: %v", err)
+	}
+	if !res.ExpiresAt.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("ExpiresAt = %v, want %v", res.ExpiresAt, now.Add(2*time.Hour))
+	}
+}
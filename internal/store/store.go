@@ -8,6 +8,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/haukened/gone/internal/app"
@@ -21,40 +24,236 @@ type Store struct {
 	blobs     BlobStorage
 	clock     app.Clock
 	inlineMax int64
+	tenants   []string
+	tracer    app.Tracer
+	skew      time.Duration // grace added to expiry checks for inter-node clock drift
+	notifier  ExpiryNotifier
+	batch     int          // expired records deleted per transaction (0 = all at once)
+	exists    *existsCache // recent Exists results (nil = uncached)
+	opening   openGate     // index claims whose blob is not open yet
+
+	mu     sync.Mutex             // guards scoped
+	scoped map[string]BlobStorage // lazily resolved per-tenant blob storage
+
+	inlineBufs     sync.Pool    // *[]byte scratch buffers (cap inlineMax) for inline Saves
+	inlineBudget   int64        // in-flight inline bytes above which Saves go external (0 = unlimited)
+	inlineInflight atomic.Int64 // inline bytes read by Saves whose insert has not finished
+
+	maxBlobBytes int64      // external byte budget (0 = unlimited)
+	quotaMu      sync.Mutex // guards the quota fields below
+	quotaLoaded  bool       // blobIndexed has been read from the index
+	blobIndexed  int64      // cached external bytes committed to the index
+	blobInflight int64      // external bytes reserved by in-progress Saves
+
+	minFreeBytes  int64     // free space floor for Saves (0 = unchecked)
+	freeBytes     int64     // cached SpaceReporter reading, guarded by quotaMu
+	freeCheckedAt time.Time // when freeBytes was read
+}
+
+// Option customizes optional Store behavior at construction time.
+type Option func(*Store)
+
+// WithTenants registers the tenant namespaces that Reconcile scans in
+// addition to the default namespace.
+func WithTenants(names ...string) Option {
+	return func(s *Store) { s.tenants = append(s.tenants, names...) }
+}
+
+// WithTracer instruments Save and Consume, including the index and blob
+// I/O beneath them, with spans from t.
+func WithTracer(t app.Tracer) Option {
+	return func(s *Store) { s.tracer = app.TracerOrNoop(t) }
+}
+
+// WithClockSkew tolerates clock drift between nodes: a secret stays
+// consumable (and is not swept) until d after its recorded expiry.
+func WithClockSkew(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.skew = d
+		}
+	}
+}
+
+// WithExpireBatch makes DeleteExpired drain expired records in transactions
+// of at most n rows, deleting their blobs after each one, when the Index
+// implements BatchExpirer. Zero or less keeps a single transaction.
+func WithExpireBatch(n int) Option {
+	return func(s *Store) { s.batch = n }
+}
+
+// WithExpiryNotifier reports secrets that expire unread to n after each
+// DeleteExpired sweep.
+func WithExpiryNotifier(n ExpiryNotifier) Option {
+	return func(s *Store) { s.notifier = n }
+}
+
+// WithInlineMemBudget caps the inline payload bytes held in memory by
+// concurrent Saves. A Save that would push the total past n writes its
+// payload to blob storage instead, however small, so a burst of creates
+// cannot balloon memory. Zero disables the cap.
+func WithInlineMemBudget(n int64) Option {
+	return func(s *Store) { s.inlineBudget = n }
 }
 
 // New returns a Store implementation of app.SecretStore.
-func New(index Index, blobs BlobStorage, clock app.Clock, inlineMax int64) *Store {
-	return &Store{index: index, blobs: blobs, clock: clock, inlineMax: inlineMax}
+func New(index Index, blobs BlobStorage, clock app.Clock, inlineMax int64, opts ...Option) *Store {
+	s := &Store{index: index, blobs: blobs, clock: clock, inlineMax: inlineMax, tracer: app.NoopTracer{}, scoped: make(map[string]BlobStorage)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-var _ app.SecretStore = (*Store)(nil)
+var (
+	_ app.SecretStore     = (*Store)(nil)
+	_ app.PassphraseGate  = (*Store)(nil)
+	_ app.AttemptRecorder = (*Store)(nil)
+	_ app.Extender        = (*Store)(nil)
+	_ app.FreshIDSaver    = (*Store)(nil)
+)
+
+// InlineMax returns the largest size Save keeps inline in the index.
+func (s *Store) InlineMax() int64 { return s.inlineMax }
 
 // Save persists a secret. Data <= inlineMax is stored inline; larger data
 // is written to blob storage and only the reference is kept in the index.
+// The blob is written first so a row never points at a missing file; if the
+// index insert then fails, the fresh blob is deleted and its byte budget
+// released, so a failed Save leaves nothing behind in either place.
 func (s *Store) Save(ctx context.Context, id string, meta app.Meta, r io.Reader, size int64, expiresAt time.Time) error {
+	_, err := s.save(ctx, func() (string, error) { return id, nil }, 0, meta, r, size, expiresAt)
+	return err
+}
+
+// SaveFresh implements app.FreshIDSaver. An inline payload is read once into
+// the pooled buffer and inserted again under each new ID; an external one is
+// streamed straight to blob storage and cannot be replayed, so its collisions
+// are returned without retry.
+func (s *Store) SaveFresh(ctx context.Context, next func() (string, error), retries int, meta app.Meta, r io.Reader, size int64, expiresAt time.Time) (string, error) {
+	return s.save(ctx, next, retries, meta, r, size, expiresAt)
+}
+
+// save backs Save and SaveFresh, returning the ID the secret was stored under.
+func (s *Store) save(ctx context.Context, next func() (string, error), retries int, meta app.Meta, r io.Reader, size int64, expiresAt time.Time) (id string, err error) {
 	if s == nil || s.index == nil || s.clock == nil {
-		return errors.New("store not properly initialized")
+		return "", errors.New("store not properly initialized")
 	}
 	if size < 0 {
-		return errors.New("size must be non-negative")
+		return "", errors.New("size must be non-negative")
 	}
+	ctx, span := s.tracer.Start(ctx, "store.Save", app.Attr{Key: "secret.size", Value: size})
+	defer func() { endSpan(span, err) }()
 	createdAt := s.clock.Now()
+	if err := s.checkFreeSpace(size, createdAt); err != nil {
+		return "", err
+	}
+	if id, err = next(); err != nil {
+		return "", err
+	}
 	var inline []byte
 	external := false
-	if size <= s.inlineMax {
-		// Read fully into memory for inline storage.
-		inline = make([]byte, size)
+	if size <= s.inlineMax && s.claimInline(size) {
+		defer s.inlineInflight.Add(-size)
+		// Read fully into a pooled buffer for inline storage; it is wiped and
+		// returned to the pool once the index has copied it.
+		buf := s.inlineBuf()
+		defer s.releaseInlineBuf(buf)
+		inline = (*buf)[:size]
 		if _, err := io.ReadFull(r, inline); err != nil {
-			return err
+			return "", err
 		}
 	} else {
-		if err := s.blobs.Write(id, r, size); err != nil {
-			return err
+		if err := s.reserveBlob(ctx, size); err != nil {
+			return "", err
+		}
+		if err := s.writeBlob(ctx, id, r, size); err != nil {
+			s.settleBlob(size, false)
+			return id, err
 		}
 		external = true
 	}
-	return s.index.Insert(ctx, id, meta, inline, external, size, createdAt, expiresAt)
+	span.SetAttrs(app.Attr{Key: "secret.external", Value: external})
+	for attempt := 0; ; attempt++ {
+		_, ispan := s.tracer.Start(ctx, "index.Insert")
+		err = s.index.Insert(ctx, id, meta, inline, external, size, createdAt, expiresAt)
+		endSpan(ispan, err)
+		if external || attempt >= retries || !errors.Is(err, app.ErrDuplicateID) {
+			break
+		}
+		if id, err = next(); err != nil {
+			return "", err
+		}
+	}
+	if err == nil {
+		s.exists.forget(existsKey{app.TenantFromContext(ctx), id})
+	}
+	if external {
+		s.settleBlob(size, err == nil)
+		if err != nil {
+			// The blob is unreferenced; drop it now rather than leave it for
+			// Reconcile. A duplicate-ID failure depends on this too: the
+			// caller's next attempt uses a fresh ID, so nothing would ever
+			// claim the blob written under the colliding one.
+			if blobs, bErr := s.blobsFor(app.TenantFromContext(ctx)); bErr == nil {
+				_ = blobs.Delete(id)
+			}
+		}
+	}
+	return id, err
+}
+
+// claimInline accounts size bytes against the inline memory budget and
+// reports whether they fit. A refused claim leaves the count unchanged.
+func (s *Store) claimInline(size int64) bool {
+	if s.inlineBudget <= 0 {
+		return true
+	}
+	if s.inlineInflight.Add(size) > s.inlineBudget {
+		s.inlineInflight.Add(-size)
+		return false
+	}
+	return true
+}
+
+// inlineBuf returns a scratch buffer with capacity for any inline payload.
+func (s *Store) inlineBuf() *[]byte {
+	if p, ok := s.inlineBufs.Get().(*[]byte); ok && int64(cap(*p)) >= s.inlineMax {
+		return p
+	}
+	b := make([]byte, s.inlineMax)
+	return &b
+}
+
+// releaseInlineBuf zeroes buf so no plaintext-adjacent bytes linger in the
+// pool, then returns it for reuse.
+func (s *Store) releaseInlineBuf(buf *[]byte) {
+	clear((*buf)[:cap(*buf)])
+	s.inlineBufs.Put(buf)
+}
+
+// writeBlob streams an external payload into the tenant's blob storage
+// under its own span. A blob that already exists is reported as
+// app.ErrDuplicateID.
+func (s *Store) writeBlob(ctx context.Context, id string, r io.Reader, size int64) (err error) {
+	_, span := s.tracer.Start(ctx, "blob.Write")
+	defer func() { endSpan(span, err) }()
+	blobs, err := s.blobsFor(app.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if err = blobs.Write(id, r, size); errors.Is(err, fs.ErrExist) {
+		return app.ErrDuplicateID
+	}
+	return err
+}
+
+// endSpan records err (if any) on span and ends it.
+func endSpan(span app.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
 }
 
 // Consume retrieves a secret exactly once and triggers permanent deletion.
@@ -68,15 +267,102 @@ func (s *Store) Consume(ctx context.Context, id string) (meta app.Meta, rc io.Re
 		err = errors.New("store not properly initialized")
 		return
 	}
-	now := s.clock.Now()
+	ctx, span := s.tracer.Start(ctx, "store.Consume")
+	defer func() { endSpan(span, err) }()
+	now := s.effectiveNow()
+	// Drop the cached probe result on both sides of the claim so a probe
+	// racing the consume cannot leave a positive behind.
+	key := existsKey{app.TenantFromContext(ctx), id}
+	s.exists.forget(key)
+	defer s.exists.forget(key)
+	// Hold the claim until the blob is open so a concurrent final read
+	// cannot delete it between this index transaction and the open below.
+	s.opening.enter(key)
+	defer s.opening.leave(key)
+	_, ispan := s.tracer.Start(ctx, "index.Consume")
 	res, cerr := s.index.Consume(ctx, id, now)
+	endSpan(ispan, cerr)
 	if cerr != nil {
 		return meta, nil, 0, cerr
 	}
+	if res.External && res.ReadsRemaining == 0 && !res.Retained {
+		s.forgetBlob(res.Size)
+	}
 	if expired(now, res.ExpiresAt) {
+		// The row is already gone; drop its blob too so an expired secret
+		// leaves no residue and costs the same cleanup as a consumed one.
+		if res.External && res.ReadsRemaining == 0 {
+			if blobs, bErr := s.blobsFor(app.TenantFromContext(ctx)); bErr == nil {
+				_ = blobs.Delete(id) // best-effort; Reconcile retries
+			}
+		}
 		return meta, nil, 0, app.ErrNotFound
 	}
-	return s.buildConsumeResult(id, res)
+	span.SetAttrs(app.Attr{Key: "secret.external", Value: res.External})
+	if res.External {
+		_, bspan := s.tracer.Start(ctx, "blob.Consume")
+		defer func() { endSpan(bspan, err) }()
+	}
+	return s.buildConsumeResult(app.TenantFromContext(ctx), id, res)
+}
+
+// PassphraseHash implements app.PassphraseGate. Backends that cannot store
+// hashes report every secret as ungated.
+func (s *Store) PassphraseHash(ctx context.Context, id string) (string, error) {
+	pi, ok := s.index.(PassphraseIndex)
+	if !ok {
+		return "", nil
+	}
+	return pi.PassphraseHash(ctx, id, s.effectiveNow())
+}
+
+// FailAttempt implements app.AttemptRecorder. Once limit failures are
+// reached the record is deleted and an external payload removed with it.
+// Indexes without failure counting never destroy anything.
+func (s *Store) FailAttempt(ctx context.Context, id string, limit int) (bool, error) {
+	ai, ok := s.index.(AttemptIndex)
+	if !ok {
+		return false, nil
+	}
+	deleted, external, err := ai.FailAttempt(ctx, id, s.effectiveNow(), limit)
+	if deleted {
+		s.exists.forget(existsKey{app.TenantFromContext(ctx), id})
+	}
+	if err != nil || !deleted || !external {
+		return deleted, err
+	}
+	if blobs, err := s.blobsFor(app.TenantFromContext(ctx)); err == nil {
+		_ = blobs.Delete(id) // a leftover blob is an orphan for Reconcile
+	}
+	_ = s.refreshBlobBytes(ctx) // best-effort; the next sweep refreshes it too
+	return true, nil
+}
+
+// Exists implements app.Prober. It reports app.ErrProbeUnsupported when the
+// index cannot check for a record without consuming it. Results may come from
+// the WithExistsCache cache.
+func (s *Store) Exists(ctx context.Context, id string) (bool, error) {
+	pi, ok := s.index.(IndexProber)
+	if !ok {
+		return false, app.ErrProbeUnsupported
+	}
+	key, now := existsKey{app.TenantFromContext(ctx), id}, s.clock.Now()
+	if live, hit := s.exists.get(key, now); hit {
+		return live, nil
+	}
+	live, err := pi.Exists(ctx, id, s.effectiveNow())
+	if err != nil {
+		return false, err
+	}
+	s.exists.put(key, live, now)
+	return live, nil
+}
+
+// effectiveNow is the time expiry is judged against: the clock shifted back
+// by the configured skew so a secret within the grace window still counts as
+// live. The same value is handed to the index so multi-read decrements agree.
+func (s *Store) effectiveNow() time.Time {
+	return s.clock.Now().Add(-s.skew)
 }
 
 // expired reports whether the resource is expired at now.
@@ -88,47 +374,170 @@ func expired(now time.Time, expiresAt time.Time) bool {
 }
 
 // buildConsumeResult constructs return values for a consumed secret depending on storage mode.
-func (s *Store) buildConsumeResult(id string, res *IndexResult) (meta app.Meta, rc io.ReadCloser, size int64, err error) {
+func (s *Store) buildConsumeResult(tenant, id string, res *IndexResult) (meta app.Meta, rc io.ReadCloser, size int64, err error) {
 	meta = res.Meta
 	size = res.Size
 	if res.External {
-		f, oErr := s.blobs.Consume(id)
+		blobs, bErr := s.blobsFor(tenant)
+		if bErr != nil {
+			return meta, nil, 0, bErr
+		}
+		keep := res.ReadsRemaining > 0 || res.Retained
+		f, oErr := openBlob(blobs, id, keep)
 		if oErr != nil {
 			return meta, nil, 0, oErr
 		}
+		if !keep {
+			f = gatedCloser{ReadCloser: f, gate: &s.opening, key: existsKey{tenant, id}}
+		}
 		return meta, f, size, nil
 	}
 	rc = io.NopCloser(newInlineReader(res.Inline))
 	return meta, rc, int64(len(res.Inline)), nil
 }
 
-// DeleteExpired removes expired secrets whose expiry is <= t and returns the count.
-// Blob files for expired records are removed best-effort.
+// openBlob returns a reader for blob id. Non-final reads of a multi-read
+// secret, and final reads retained for a consume grace, must leave the blob in
+// place, which requires BlobOpener; otherwise the delete-on-close Consume is
+// used.
+func openBlob(blobs BlobStorage, id string, keep bool) (io.ReadCloser, error) {
+	if !keep {
+		return blobs.Consume(id)
+	}
+	opener, ok := blobs.(BlobOpener)
+	if !ok {
+		return nil, errors.New("blob storage cannot serve multi-read secrets")
+	}
+	return opener.Open(id)
+}
+
+// DeleteExpired removes expired secrets whose expiry is <= t (less any clock
+// skew tolerance) and returns the count. Blob files for expired records are
+// removed best-effort, and any never-read secrets are passed to the expiry
+// notifier. With WithExpireBatch the work is split into bounded transactions
+// and the count covers every batch completed before an error. Failing to
+// refresh the cached blob usage afterwards is reported alongside the count
+// without skipping cleanup.
 func (s *Store) DeleteExpired(ctx context.Context, t time.Time) (int, error) {
-	expired, err := s.index.DeleteExpired(ctx, t)
-	if err != nil {
-		return 0, err
+	cutoff := t.Add(-s.skew)
+	batcher, ok := s.index.(BatchExpirer)
+	if !ok || s.batch <= 0 {
+		expired, err := s.index.DeleteExpired(ctx, cutoff)
+		if err != nil {
+			return 0, err
+		}
+		s.cleanupExpired(ctx, expired)
+		return len(expired), s.refreshBlobBytes(ctx)
 	}
-	count := len(expired)
+	count := 0
+	for {
+		expired, err := batcher.DeleteExpiredBatch(ctx, cutoff, s.batch)
+		if err != nil {
+			if count > 0 {
+				err = errors.Join(err, s.refreshBlobBytes(ctx))
+			}
+			return count, err
+		}
+		count += len(expired)
+		s.cleanupExpired(ctx, expired)
+		if len(expired) < s.batch {
+			return count, s.refreshBlobBytes(ctx)
+		}
+	}
+}
+
+// cleanupExpired deletes the blobs of external expired records best-effort
+// and passes never-read ones to the expiry notifier.
+func (s *Store) cleanupExpired(ctx context.Context, expired []ExpiredRecord) {
+	var unread []ExpiredRecord
 	for _, rec := range expired {
-		if rec.External {
-			_ = s.blobs.Delete(rec.ID) // best-effort
+		s.exists.forget(existsKey{rec.Tenant, rec.ID})
+		if !rec.Read {
+			unread = append(unread, rec)
+		}
+		if !rec.External {
+			continue
 		}
+		if blobs, bErr := s.blobsFor(rec.Tenant); bErr == nil {
+			_ = blobs.Delete(rec.ID) // best-effort
+		}
+	}
+	if s.notifier != nil && len(unread) > 0 {
+		s.notifier.ExpiredUnread(ctx, unread)
+	}
+}
+
+// ErrNextExpiryUnsupported is returned by NextExpiry when the index does not
+// implement NextExpirer.
+var ErrNextExpiryUnsupported = errors.New("store: index cannot report next expiry")
+
+// NextExpiry returns when DeleteExpired will first find something to remove:
+// the earliest indexed expiry plus any clock skew tolerance. It returns the
+// zero time when no secrets are stored.
+func (s *Store) NextExpiry(ctx context.Context) (time.Time, error) {
+	ne, ok := s.index.(NextExpirer)
+	if !ok {
+		return time.Time{}, ErrNextExpiryUnsupported
+	}
+	next, err := ne.NextExpiry(ctx)
+	if err != nil || next.IsZero() {
+		return next, err
+	}
+	return next.Add(s.skew), nil
+}
+
+// Touch implements app.Extender. Indexes that cannot update expiries yield
+// app.ErrExtendUnsupported.
+func (s *Store) Touch(ctx context.Context, id string, expiresAt time.Time) error {
+	t, ok := s.index.(Toucher)
+	if !ok {
+		return app.ErrExtendUnsupported
 	}
-	return count, nil
+	return t.Touch(ctx, id, s.effectiveNow(), expiresAt)
+}
+
+// ErrCountUnsupported is returned by CountLive when the index does not
+// implement LiveCounter.
+var ErrCountUnsupported = errors.New("store: index cannot count secrets")
+
+// CountLive returns how many secrets, across all tenants, can still be read.
+func (s *Store) CountLive(ctx context.Context) (int64, error) {
+	lc, ok := s.index.(LiveCounter)
+	if !ok {
+		return 0, ErrCountUnsupported
+	}
+	return lc.CountLive(ctx, s.effectiveNow())
 }
 
 // Reconcile scans for blob orphans and removes them. It can also be extended
-// later to verify referential integrity or rebuild indexes.
+// later to verify referential integrity or rebuild indexes. The default
+// namespace and every registered tenant are reconciled independently.
 func (s *Store) Reconcile(ctx context.Context) error {
 	if s.index == nil || s.blobs == nil {
 		return errors.New("store not properly initialized")
 	}
-	blobIDs, err := s.blobs.List()
+	if err := s.reconcileTenant(ctx, ""); err != nil {
+		return err
+	}
+	for _, t := range s.tenants {
+		if err := s.reconcileTenant(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileTenant removes orphan blobs within a single tenant namespace.
+func (s *Store) reconcileTenant(ctx context.Context, tenant string) error {
+	blobs, err := s.blobsFor(tenant)
+	if err != nil {
+		return err
+	}
+	blobIDs, err := blobs.List()
 	if err != nil {
 		return err
 	}
-	extIDs, err := s.index.ListExternalIDs(ctx)
+	extIDs, err := s.index.ListExternalIDs(app.WithTenant(ctx, tenant))
 	if err != nil {
 		return err
 	}
@@ -140,12 +549,75 @@ func (s *Store) Reconcile(ctx context.Context) error {
 	// Any blob without index entry is orphan.
 	for _, bid := range blobIDs {
 		if _, ok := indexSet[bid]; !ok {
-			_ = s.blobs.Delete(bid)
+			_ = blobs.Delete(bid)
 		}
 	}
 	return nil
 }
 
+// blobsFor resolves the blob storage for tenant. The default namespace and
+// backends that do not implement TenantScoper use the root storage.
+func (s *Store) blobsFor(tenant string) (BlobStorage, error) {
+	if tenant == "" {
+		return s.blobs, nil
+	}
+	scoper, ok := s.blobs.(TenantScoper)
+	if !ok {
+		return s.blobs, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.scoped[tenant]; ok {
+		return b, nil
+	}
+	b, err := scoper.ForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	s.scoped[tenant] = b
+	return b, nil
+}
+
+// ExpiryHistogram reports live secrets bucketed by remaining TTL, judged at
+// the same skew-adjusted time as Consume. It is read-only.
+func (s *Store) ExpiryHistogram(ctx context.Context, buckets []time.Duration) (map[string]int64, error) {
+	return s.index.ExpiryHistogram(ctx, s.effectiveNow(), buckets)
+}
+
+// ErrExportUnsupported is returned by Export when the index cannot enumerate
+// records or the blob backend cannot read blobs without deleting them.
+var ErrExportUnsupported = errors.New("store: backend does not support export")
+
+// Export calls fn for every stored secret across all tenants, including
+// expired ones not yet swept. For external payloads blob streams the
+// ciphertext without consuming it; for inline payloads blob is nil and the
+// data is in rec.Inline. Nothing is modified.
+func (s *Store) Export(ctx context.Context, fn func(rec Record, blob io.Reader) error) error {
+	walker, ok := s.index.(IndexWalker)
+	if !ok {
+		return ErrExportUnsupported
+	}
+	return walker.Walk(ctx, func(rec Record) error {
+		if !rec.External {
+			return fn(rec, nil)
+		}
+		blobs, err := s.blobsFor(rec.Tenant)
+		if err != nil {
+			return err
+		}
+		opener, ok := blobs.(BlobOpener)
+		if !ok {
+			return ErrExportUnsupported
+		}
+		rc, err := opener.Open(rec.ID)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return fn(rec, rc)
+	})
+}
+
 // inlineReader provides a zero-allocation Read over a byte slice.
 type inlineReader struct {
 	b []byte
@@ -1,9 +1,11 @@
 package store_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -199,6 +201,48 @@ func TestStoreDeleteExpired(t *testing.T) {
 	}
 }
 
+// expiryRecorder is a store.ExpiryNotifier capturing each sweep.
+type expiryRecorder struct{ sweeps [][]store.ExpiredRecord }
+
+func (e *expiryRecorder) ExpiredUnread(_ context.Context, recs []store.ExpiredRecord) {
+	e.sweeps = append(e.sweeps, recs)
+}
+
+func TestStoreDeleteExpiredNotifiesUnread(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	bs, _ := filesystem.New(t.TempDir())
+	rec := &expiryRecorder{}
+	st := store.New(ix, bs, fixedClock{now: now}, 4, store.WithExpiryNotifier(rec))
+
+	const unread, multi = "88888888888888888888888888888888", "99999999999999999999999999999999"
+	if err := st.Save(ctx, unread, app.Meta{Version: 1, NonceB64u: "a"}, bytesReader([]byte("external-data")), 13, now.Add(time.Minute)); err != nil {
+		t.Fatalf("save unread: %v", err)
+	}
+	if err := st.Save(app.WithMaxReads(ctx, 2), multi, app.Meta{Version: 1, NonceB64u: "b"}, bytesReader([]byte("inl")), 3, now.Add(time.Minute)); err != nil {
+		t.Fatalf("save multi: %v", err)
+	}
+	if _, rc, _, err := st.Consume(ctx, multi); err != nil {
+		t.Fatalf("consume multi: %v", err)
+	} else {
+		rc.Close()
+	}
+	if n, err := st.DeleteExpired(ctx, now.Add(2*time.Minute)); err != nil || n != 2 {
+		t.Fatalf("DeleteExpired = %d, %v", n, err)
+	}
+	if len(rec.sweeps) != 1 || len(rec.sweeps[0]) != 1 || rec.sweeps[0][0].ID != unread || !rec.sweeps[0][0].External {
+		t.Fatalf("notified %+v", rec.sweeps)
+	}
+	// Sweeps with nothing unread stay quiet.
+	if _, err := st.DeleteExpired(ctx, now.Add(3*time.Minute)); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if len(rec.sweeps) != 1 {
+		t.Fatalf("empty sweep notified: %+v", rec.sweeps)
+	}
+}
+
 func TestStoreReconcileDeletesOrphan(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now().UTC()
@@ -236,6 +280,48 @@ func (r *sliceReader) Read(p []byte) (int, error) {
 	return n, nil
 }
 
+// insertFailIndex wraps a real index and fails Insert with err while set.
+type insertFailIndex struct {
+	store.Index
+	err error
+}
+
+func (f *insertFailIndex) Insert(ctx context.Context, id string, meta app.Meta, inline []byte, external bool, size int64, createdAt, expiresAt time.Time) error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.Index.Insert(ctx, id, meta, inline, external, size, createdAt, expiresAt)
+}
+
+// TestStoreSaveRollsBackBlobOnInsertFailure injects an index failure after
+// the blob write succeeded and checks the blob and its budget are released.
+func TestStoreSaveRollsBackBlobOnInsertFailure(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	boom := errors.New("insert failed")
+	data := []byte("external-data")
+	const id = "abababababababababababababababab"
+	fail := &insertFailIndex{Index: ix, err: boom}
+	st := store.New(fail, bs, fixedClock{now: now}, 4, store.WithMaxBlobBytes(int64(len(data))))
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); !errors.Is(err, boom) {
+		t.Fatalf("expected insert error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob left behind after failed insert: %v", err)
+	}
+	if ids, _ := bs.List(); len(ids) != 0 {
+		t.Fatalf("unexpected blobs %v", ids)
+	}
+	// The reserved bytes were returned, so a full-budget save still fits.
+	fail.err = nil
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+		t.Fatalf("retry save: %v", err)
+	}
+}
+
 // --- Construction / nil guard tests ---
 
 // mockBlobStore minimal implementation for negative tests.
@@ -261,6 +347,62 @@ func (m mockIndex) DeleteExpired(_ context.Context, _ time.Time) ([]store.Expire
 	return nil, nil
 }
 func (m mockIndex) ListExternalIDs(_ context.Context) ([]string, error) { return nil, nil }
+func (m mockIndex) ExternalBytes(_ context.Context) (int64, error)      { return 0, nil }
+func (m mockIndex) ExpiryHistogram(_ context.Context, _ time.Time, _ []time.Duration) (map[string]int64, error) {
+	return nil, nil
+}
+
+// expiringIndex reports one expired external record per call (up to left)
+// and fails every usage refresh.
+type expiringIndex struct {
+	mockIndex
+	left *int
+}
+
+func (e expiringIndex) DeleteExpired(context.Context, time.Time) ([]store.ExpiredRecord, error) {
+	return e.DeleteExpiredBatch(context.Background(), time.Time{}, 1)
+}
+func (e expiringIndex) DeleteExpiredBatch(context.Context, time.Time, int) ([]store.ExpiredRecord, error) {
+	if *e.left == 0 {
+		return nil, nil
+	}
+	*e.left--
+	return []store.ExpiredRecord{{ID: fmt.Sprintf("%032x", *e.left), External: true}}, nil
+}
+func (expiringIndex) ExternalBytes(context.Context) (int64, error) {
+	return 0, errors.New("usage unavailable")
+}
+
+// deleteRecorder records the blob IDs passed to Delete.
+type deleteRecorder struct {
+	mockBlobStore
+	deleted *[]string
+}
+
+func (d deleteRecorder) Delete(id string) error {
+	*d.deleted = append(*d.deleted, id)
+	return nil
+}
+
+func TestStoreDeleteExpiredRefreshErrorKeepsCleanup(t *testing.T) {
+	for _, batch := range []int{0, 1} {
+		left := 2
+		if batch == 0 {
+			left = 1
+		}
+		want := left
+		var deleted []string
+		st := store.New(expiringIndex{left: &left}, deleteRecorder{deleted: &deleted}, fixedClock{now: time.Now()}, 1,
+			store.WithMaxBlobBytes(100), store.WithExpireBatch(batch))
+		n, err := st.DeleteExpired(context.Background(), time.Now())
+		if err == nil {
+			t.Fatalf("batch %d: refresh error not reported", batch)
+		}
+		if n != want || len(deleted) != want {
+			t.Fatalf("batch %d: count %d, blobs deleted %v, want %d", batch, n, deleted, want)
+		}
+	}
+}
 
 // nil store pointer tests.
 func TestStoreNilReceiverConsume(t *testing.T) {
@@ -389,3 +531,827 @@ func TestStoreReconcileDeleteErrorIgnored(t *testing.T) {
 		t.Fatalf("unexpected error despite delete failure: %v", err)
 	}
 }
+
+// --- Tenant isolation tests ---
+
+func TestStoreTenantIsolation(t *testing.T) {
+	now := time.Now().UTC()
+	clk := fixedClock{now: now}
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, clk, 4, store.WithTenants("acme", "globex"))
+	ctxA := app.WithTenant(context.Background(), "acme")
+	ctxB := app.WithTenant(context.Background(), "globex")
+
+	inlineID := "88888888888888888888888888888888"
+	extID := "99999999999999999999999999999999"
+	if err := st.Save(ctxA, inlineID, app.Meta{Version: 1, NonceB64u: "a"}, bytesReader([]byte("abc")), 3, now.Add(time.Minute)); err != nil {
+		t.Fatalf("save inline: %v", err)
+	}
+	ext := []byte("external-payload")
+	if err := st.Save(ctxA, extID, app.Meta{Version: 1, NonceB64u: "b"}, bytesReader(ext), int64(len(ext)), now.Add(time.Minute)); err != nil {
+		t.Fatalf("save external: %v", err)
+	}
+	// External blob lives in the tenant subdirectory, not the root.
+	if _, err := os.Stat(filepath.Join(blobDir, "acme", extID+".blob")); err != nil {
+		t.Fatalf("expected tenant-scoped blob: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, extID+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("blob should not be in root namespace, err=%v", err)
+	}
+	// Neither tenant B nor the default namespace may consume tenant A's secrets.
+	for _, ctx := range []context.Context{ctxB, context.Background()} {
+		for _, id := range []string{inlineID, extID} {
+			if _, _, _, err := st.Consume(ctx, id); !errors.Is(err, app.ErrNotFound) {
+				t.Fatalf("cross-tenant consume of %s: expected ErrNotFound, got %v", id, err)
+			}
+		}
+	}
+	// Tenant A still can, exactly once.
+	for _, id := range []string{inlineID, extID} {
+		_, rc, _, err := st.Consume(ctxA, id)
+		if err != nil {
+			t.Fatalf("owner consume %s: %v", id, err)
+		}
+		_, _ = io.ReadAll(rc)
+		_ = rc.Close()
+	}
+}
+
+func TestStoreTenantDeleteExpiredAndReconcile(t *testing.T) {
+	now := time.Now().UTC()
+	clk := fixedClock{now: now}
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, clk, 4, store.WithTenants("acme"))
+	ctxA := app.WithTenant(context.Background(), "acme")
+
+	expiredID := "abababababababababababababababab"
+	data := []byte("expired-external")
+	if err := st.Save(ctxA, expiredID, app.Meta{Version: 1, NonceB64u: "e"}, bytesReader(data), int64(len(data)), now.Add(-time.Minute)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	n, err := st.DeleteExpired(context.Background(), now)
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteExpired n=%d err=%v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, "acme", expiredID+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("expected tenant blob removed on expiry, err=%v", err)
+	}
+
+	// An orphan inside the tenant namespace is reconciled.
+	orphan := "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd"
+	writeTempBlob(t, filepath.Join(blobDir, "acme"), orphan, []byte("zzz"))
+	time.Sleep(1100 * time.Millisecond)
+	if err := st.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, "acme", orphan+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("expected tenant orphan removed, err=%v", err)
+	}
+}
+
+// TestStoreReconcileShardNamedTenant checks a tenant whose name matches a
+// shard directory is refused instead of reconciling away the default
+// namespace's sharded blobs.
+func TestStoreReconcileShardNamedTenant(t *testing.T) {
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir, filesystem.WithShardDepth(1))
+	st := store.New(ix, bs, fixedClock{now: now}, 4, store.WithTenants("ab"))
+
+	id := "abababababababababababababababab"
+	data := []byte("default-external")
+	if err := st.Save(context.Background(), id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	p := filepath.Join(blobDir, "ab", id+".blob")
+	old := time.Now().Add(-time.Minute)
+	_ = os.Chtimes(p, old, old)
+	if err := st.Reconcile(context.Background()); err == nil {
+		t.Fatal("Reconcile accepted tenant named like a shard")
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("default blob removed: %v", err)
+	}
+	if err := st.Save(app.WithTenant(context.Background(), "ab"), "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd", app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err == nil {
+		t.Fatal("Save in shard-named tenant succeeded")
+	}
+}
+
+func TestStoreConsumeExpiredExternalRemovesBlob(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, fixedClock{now: now}, 1)
+
+	id := "44444444444444444444444444444444"
+	data := []byte("external")
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(-time.Minute)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, _, _, err := st.Consume(ctx, id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("expected expired blob removed, stat err=%v", err)
+	}
+}
+
+func TestStoreBlobQuotaBoundaries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	clk := fixedClock{now: now}
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, clk, 4, store.WithMaxBlobBytes(20))
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	save := func(id string, size int, expires time.Time) error {
+		return st.Save(ctx, id, meta, bytesReader(make([]byte, size)), int64(size), expires)
+	}
+
+	if err := save("a0000000000000000000000000000000", 12, now.Add(time.Hour)); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	// 12 + 9 > 20: rejected without writing a blob.
+	if err := save("b0000000000000000000000000000000", 9, now.Add(time.Hour)); !errors.Is(err, app.ErrStorageFull) {
+		t.Fatalf("expected ErrStorageFull, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, "b0000000000000000000000000000000.blob")); !os.IsNotExist(err) {
+		t.Fatalf("rejected save must not leave a blob")
+	}
+	// Inline secrets are exempt from the budget.
+	if err := save("c0000000000000000000000000000000", 4, now.Add(time.Hour)); err != nil {
+		t.Fatalf("inline save: %v", err)
+	}
+	// Exactly at the budget is allowed.
+	if err := save("d0000000000000000000000000000000", 8, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("save at budget: %v", err)
+	}
+	if err := save("e0000000000000000000000000000000", 5, now.Add(time.Hour)); !errors.Is(err, app.ErrStorageFull) {
+		t.Fatalf("expected ErrStorageFull when full, got %v", err)
+	}
+	// Expiry frees the expired blob's bytes.
+	if _, err := st.DeleteExpired(ctx, now); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if err := save("e0000000000000000000000000000000", 8, now.Add(time.Hour)); err != nil {
+		t.Fatalf("save after expiry: %v", err)
+	}
+	// Consume frees bytes too.
+	_, rc, _, err := st.Consume(ctx, "a0000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	_ = rc.Close()
+	if err := save("f0000000000000000000000000000000", 12, now.Add(time.Hour)); err != nil {
+		t.Fatalf("save after consume: %v", err)
+	}
+}
+
+// spaceBlobs is a mockBlobStore reporting a settable amount of free space.
+type spaceBlobs struct {
+	mockBlobStore
+	free  int64
+	err   error
+	calls int
+}
+
+func (b *spaceBlobs) FreeBytes() (int64, error) {
+	b.calls++
+	return b.free, b.err
+}
+
+func TestStoreMinFreeBytes(t *testing.T) {
+	ctx := context.Background()
+	clk := &fixedClock{now: time.Now().UTC()}
+	bs := &spaceBlobs{free: 1000}
+	st := store.New(mockIndex{}, bs, clk, 4, store.WithMinFreeBytes(500))
+	save := func(size int) error {
+		return st.Save(ctx, "a0000000000000000000000000000000", app.Meta{Version: 1}, bytesReader(make([]byte, size)), int64(size), clk.now.Add(time.Hour))
+	}
+
+	if err := save(500); err != nil {
+		t.Fatalf("save leaving exactly the floor: %v", err)
+	}
+	if err := save(501); !errors.Is(err, app.ErrStorageFull) {
+		t.Fatalf("expected ErrStorageFull below the floor, got %v", err)
+	}
+	// The reading is cached, so a drop is only seen once it goes stale.
+	bs.free = 100
+	if err := save(2); err != nil || bs.calls != 1 {
+		t.Fatalf("cached save: err=%v calls=%d", err, bs.calls)
+	}
+	clk.now = clk.now.Add(2 * time.Second)
+	if err := save(2); !errors.Is(err, app.ErrStorageFull) || bs.calls != 2 {
+		t.Fatalf("expected inline save rejected after refresh: err=%v calls=%d", err, bs.calls)
+	}
+	clk.now = clk.now.Add(2 * time.Second)
+	bs.err = errors.New("statfs failed")
+	if err := save(2); !errors.Is(err, bs.err) {
+		t.Fatalf("expected statfs error, got %v", err)
+	}
+
+	// Backends that cannot report free space are not checked.
+	st = store.New(mockIndex{}, mockBlobStore{}, clk, 4, store.WithMinFreeBytes(500))
+	if err := st.Save(ctx, "b0000000000000000000000000000000", app.Meta{Version: 1}, bytesReader(make([]byte, 10)), 10, clk.now.Add(time.Hour)); err != nil {
+		t.Fatalf("save without SpaceReporter: %v", err)
+	}
+}
+
+func TestStoreBlobQuotaReleasedOnWriteError(t *testing.T) {
+	ctx := context.Background()
+	clk := fixedClock{now: time.Now().UTC()}
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, clk, 1, store.WithMaxBlobBytes(10))
+	// Short reader: the blob write fails and the reservation must be released.
+	if err := st.Save(ctx, "a0000000000000000000000000000000", app.Meta{}, bytesReader([]byte("abc")), 10, clk.now.Add(time.Hour)); err == nil {
+		t.Fatalf("expected short write error")
+	}
+	if err := st.Save(ctx, "b0000000000000000000000000000000", app.Meta{}, bytesReader(make([]byte, 10)), 10, clk.now.Add(time.Hour)); err != nil {
+		t.Fatalf("expected reservation released, got %v", err)
+	}
+}
+
+func TestStoreMultiReadExternalKeepsBlobUntilFinal(t *testing.T) {
+	ctx := app.WithMaxReads(context.Background(), 2)
+	now := time.Now().UTC()
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, fixedClock{now: now}, 1)
+	id := "55555555555555555555555555555555"
+	data := []byte("external")
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	blobPath := filepath.Join(blobDir, id+".blob")
+	for read := 1; read <= 2; read++ {
+		_, rc, _, err := st.Consume(context.Background(), id)
+		if err != nil {
+			t.Fatalf("read %d: %v", read, err)
+		}
+		got, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(got) != string(data) {
+			t.Fatalf("read %d: got %q", read, got)
+		}
+		_, statErr := os.Stat(blobPath)
+		if read == 1 && statErr != nil {
+			t.Fatalf("blob removed before final read: %v", statErr)
+		}
+		if read == 2 && !os.IsNotExist(statErr) {
+			t.Fatalf("blob should be removed after final read, stat err=%v", statErr)
+		}
+	}
+	if _, _, _, err := st.Consume(context.Background(), id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after final read, got %v", err)
+	}
+}
+
+// stallingOpener holds Open until release is closed, after signalling that a
+// non-final read has committed its index claim.
+type stallingOpener struct {
+	*filesystem.BlobStore
+	opening chan struct{}
+	release chan struct{}
+}
+
+func (s stallingOpener) Open(id string) (io.ReadCloser, error) {
+	close(s.opening)
+	<-s.release
+	return s.BlobStore.Open(id)
+}
+
+func TestStoreMultiReadConcurrentFinalRead(t *testing.T) {
+	ctx := app.WithMaxReads(context.Background(), 2)
+	now := time.Now().UTC()
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	fs, _ := filesystem.New(t.TempDir())
+	bs := stallingOpener{BlobStore: fs, opening: make(chan struct{}), release: make(chan struct{})}
+	st := store.New(ix, bs, fixedClock{now: now}, 1)
+	id := "56565656565656565656565656565656"
+	data := []byte("external")
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	type result struct {
+		data []byte
+		err  error
+	}
+	first := make(chan result, 1)
+	go func() {
+		_, rc, _, err := st.Consume(context.Background(), id)
+		if err != nil {
+			first <- result{err: err}
+			return
+		}
+		got, err := io.ReadAll(rc)
+		_ = rc.Close()
+		first <- result{got, err}
+	}()
+	<-bs.opening
+	// The final read lands while the first is between its index claim and
+	// its blob open; its delete must not run until that open has happened.
+	_, rc, _, err := st.Consume(context.Background(), id)
+	if err != nil {
+		t.Fatalf("final read: %v", err)
+	}
+	if got, _ := io.ReadAll(rc); string(got) != string(data) {
+		t.Fatalf("final read: got %q", got)
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- rc.Close() }()
+	select {
+	case <-closed:
+		t.Fatalf("final read deleted the blob before the concurrent read opened it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(bs.release)
+	res := <-first
+	if res.err != nil || string(res.data) != string(data) {
+		t.Fatalf("concurrent read: got %q, %v", res.data, res.err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("final Close: %v", err)
+	}
+	if _, _, _, err := st.Consume(context.Background(), id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after final read, got %v", err)
+	}
+}
+
+func TestStoreClockSkewBoundary(t *testing.T) {
+	// Expiry is stored with second precision; keep the clock on a whole second.
+	now := time.Unix(1700000000, 0).UTC()
+	tests := []struct {
+		name     string
+		skew     time.Duration
+		expires  time.Time
+		wantLive bool
+	}{
+		{name: "no skew at expiry", expires: now, wantLive: false},
+		{name: "no skew before expiry", expires: now.Add(time.Second), wantLive: true},
+		{name: "within skew", skew: 5 * time.Second, expires: now.Add(-4 * time.Second), wantLive: true},
+		{name: "at skew edge", skew: 5 * time.Second, expires: now.Add(-5 * time.Second), wantLive: false},
+		{name: "beyond skew", skew: 5 * time.Second, expires: now.Add(-6 * time.Second), wantLive: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			ix, _ := sqlite.New(openTestDB(t))
+			bs, _ := filesystem.New(t.TempDir())
+			st := store.New(ix, bs, fixedClock{now: now}, 64, store.WithClockSkew(tc.skew))
+			save := func(id string) {
+				if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte("x")), 1, tc.expires); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+			}
+			id := "44444444444444444444444444444444"
+			save(id)
+			_, rc, _, err := st.Consume(ctx, id)
+			if tc.wantLive {
+				if err != nil {
+					t.Fatalf("expected live secret, got %v", err)
+				}
+				rc.Close()
+			} else if !errors.Is(err, app.ErrNotFound) {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+			// The janitor sweep honours the same tolerance.
+			save("55555555555555555555555555555555")
+			n, err := st.DeleteExpired(ctx, now)
+			if err != nil {
+				t.Fatalf("DeleteExpired: %v", err)
+			}
+			// DeleteExpired removes expires_at < t, so only rows strictly past the window go.
+			wantSwept := tc.expires.Before(now.Add(-tc.skew))
+			if (n == 1) != wantSwept {
+				t.Fatalf("swept=%d want swept=%v", n, wantSwept)
+			}
+		})
+	}
+}
+
+func TestStoreVerifyBlobsTruncated(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, fixedClock{now: now}, 4)
+
+	data := []byte("external-payload")
+	ids := []string{
+		"66666666666666666666666666666666", // healthy
+		"77777777777777777777777777777777", // truncated
+		"88888888888888888888888888888888", // missing
+	}
+	for _, id := range ids {
+		if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := os.Truncate(filepath.Join(blobDir, ids[1]+".blob"), 3); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	if err := os.Remove(filepath.Join(blobDir, ids[2]+".blob")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	checked, broken, err := st.VerifyBlobs(ctx, 0, false)
+	if err != nil || checked != 3 || broken != 2 {
+		t.Fatalf("report-only scan: checked=%d broken=%d err=%v", checked, broken, err)
+	}
+	// Report-only leaves the rows in place.
+	if _, broken, _ = st.VerifyBlobs(ctx, time.Millisecond, false); broken != 2 {
+		t.Fatalf("expected rows untouched, broken=%d", broken)
+	}
+
+	if _, broken, err = st.VerifyBlobs(ctx, 0, true); err != nil || broken != 2 {
+		t.Fatalf("repair scan: broken=%d err=%v", broken, err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, ids[1]+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("expected truncated blob removed, err=%v", err)
+	}
+	for _, id := range ids[1:] {
+		if _, _, _, err := st.Consume(ctx, id); !errors.Is(err, app.ErrNotFound) {
+			t.Fatalf("expected tombstoned %s, got %v", id, err)
+		}
+	}
+	checked, broken, err = st.VerifyBlobs(ctx, 0, false)
+	if err != nil || checked != 1 || broken != 0 {
+		t.Fatalf("post-repair scan: checked=%d broken=%d err=%v", checked, broken, err)
+	}
+	_, rc, _, err := st.Consume(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("healthy secret should survive: %v", err)
+	}
+	rc.Close()
+}
+
+func TestStoreVerifyBlobsCanceled(t *testing.T) {
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, fixedClock{now: now}, 1)
+	for _, id := range []string{"99999999999999999999999999999999", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"} {
+		if err := st.Save(context.Background(), id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte("xyz")), 3, now.Add(time.Hour)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	checked, _, err := st.VerifyBlobs(ctx, time.Hour, false)
+	if !errors.Is(err, context.DeadlineExceeded) || checked != 1 {
+		t.Fatalf("expected throttled scan to stop after first blob, checked=%d err=%v", checked, err)
+	}
+}
+
+func TestStoreConsumeGrace(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	ix, _ := sqlite.New(openTestDB(t), sqlite.WithConsumeGrace(30*time.Second))
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	at := func(d time.Duration) *store.Store { return store.New(ix, bs, fixedClock{now: now.Add(d)}, 1) }
+	id := "66666666666666666666666666666666"
+	data := []byte("external")
+	if err := at(0).Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	blobPath := filepath.Join(blobDir, id+".blob")
+	for _, d := range []time.Duration{0, 20 * time.Second} {
+		_, rc, _, err := at(d).Consume(ctx, id)
+		if err != nil {
+			t.Fatalf("read at +%s: %v", d, err)
+		}
+		got, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(got) != string(data) {
+			t.Fatalf("read at +%s: got %q", d, got)
+		}
+		if _, err := os.Stat(blobPath); err != nil {
+			t.Fatalf("blob removed within grace: %v", err)
+		}
+	}
+	if _, _, _, err := at(30*time.Second).Consume(ctx, id); !errors.Is(err, app.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after grace, got %v", err)
+	}
+	if _, err := os.Stat(blobPath); !os.IsNotExist(err) {
+		t.Fatalf("expected blob removed after grace, stat err=%v", err)
+	}
+}
+
+func TestStoreConsumeGraceSweptByJanitor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	ix, _ := sqlite.New(openTestDB(t), sqlite.WithConsumeGrace(30*time.Second))
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, fixedClock{now: now}, 1)
+	id := "77777777777777777777777777777777"
+	if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte("external")), 8, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	_, rc, _, err := st.Consume(ctx, id)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	_ = rc.Close()
+	if n, err := st.DeleteExpired(ctx, now.Add(31*time.Second)); err != nil || n != 1 {
+		t.Fatalf("DeleteExpired: n=%d err=%v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(blobDir, id+".blob")); !os.IsNotExist(err) {
+		t.Fatalf("expected janitor to remove blob, stat err=%v", err)
+	}
+}
+
+func TestStoreSaveDuplicateID(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, fixedClock{now: now}, 4)
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	tests := []struct {
+		name string
+		id   string
+		data string
+	}{
+		{name: "inline", id: "88888888888888888888888888888888", data: "ab"},
+		{name: "external", id: "99999999999999999999999999999999", data: "original"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := st.Save(ctx, tc.id, meta, bytesReader([]byte(tc.data)), int64(len(tc.data)), now.Add(time.Hour)); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if err := st.Save(ctx, tc.id, meta, bytesReader([]byte("clobbered")), 9, now.Add(time.Hour)); !errors.Is(err, app.ErrDuplicateID) {
+				t.Fatalf("expected ErrDuplicateID, got %v", err)
+			}
+			// The original secret is untouched by the colliding write.
+			_, rc, _, err := st.Consume(ctx, tc.id)
+			if err != nil {
+				t.Fatalf("Consume: %v", err)
+			}
+			got, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			if string(got) != tc.data {
+				t.Fatalf("got %q want %q", got, tc.data)
+			}
+		})
+	}
+}
+
+func TestStoreSaveFreshRetriesInline(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	ix, _ := sqlite.New(openTestDB(t))
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, fixedClock{now: now}, 4)
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	taken := "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1"
+	if err := st.Save(ctx, taken, meta, bytesReader([]byte("old")), 3, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	ids := []string{taken, "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2"}
+	next := func() (string, error) {
+		id := ids[0]
+		ids = ids[1:]
+		return id, nil
+	}
+	// The reader yields the payload once; the retry must reuse what was read.
+	id, err := st.SaveFresh(ctx, next, 1, meta, bytesReader([]byte("new")), 3, now.Add(time.Hour))
+	if err != nil || id != "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2" {
+		t.Fatalf("SaveFresh = %q, %v", id, err)
+	}
+	_, rc, _, err := st.Consume(ctx, id)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "new" {
+		t.Fatalf("got %q want %q", got, "new")
+	}
+
+	// External payloads are streamed to disk and cannot be replayed.
+	ids = []string{taken, "c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3"}
+	if _, err := st.SaveFresh(ctx, next, 1, meta, bytesReader([]byte("external")), 8, now.Add(time.Hour)); !errors.Is(err, app.ErrDuplicateID) {
+		t.Fatalf("expected ErrDuplicateID for external collision, got %v", err)
+	}
+}
+
+// TestStoreSaveInlineBufferReuse saves inline secrets of shrinking size back
+// to back so the pooled buffer is reused, and checks none bleed into another.
+func TestStoreSaveInlineBufferReuse(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, fixedClock{now: now}, 64)
+
+	payloads := map[string]string{
+		"a1111111111111111111111111111111": "a-long-inline-payload-that-fills-the-buffer",
+		"b2222222222222222222222222222222": "short",
+		"c3333333333333333333333333333333": "",
+	}
+	for _, id := range []string{"a1111111111111111111111111111111", "b2222222222222222222222222222222", "c3333333333333333333333333333333"} {
+		data := []byte(payloads[id])
+		if err := st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(time.Hour)); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+	}
+	for id, want := range payloads {
+		_, rc, _, err := st.Consume(ctx, id)
+		if err != nil {
+			t.Fatalf("Consume %s: %v", id, err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(got) != want {
+			t.Fatalf("%s: got %q want %q", id, got, want)
+		}
+	}
+}
+
+// BenchmarkStoreSaveInline measures allocations of near-threshold inline
+// saves; the payload buffer comes from a pool rather than a fresh slice.
+func BenchmarkStoreSaveInline(b *testing.B) {
+	const inlineMax = 8192
+	st := store.New(mockIndex{}, mockBlobStore{}, fixedClock{now: time.Now()}, inlineMax)
+	data := bytes.Repeat([]byte{'x'}, inlineMax)
+	r := bytes.NewReader(data)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+	b.ReportAllocs()
+	b.SetBytes(inlineMax)
+	for i := 0; i < b.N; i++ {
+		r.Reset(data)
+		if err := st.Save(ctx, "11111111111111111111111111111111", app.Meta{}, r, inlineMax, expires); err != nil {
+			b.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+// batchCountingIndex counts DeleteExpiredBatch calls on a real index.
+type batchCountingIndex struct {
+	*sqlite.Index
+	batches int
+}
+
+func (b *batchCountingIndex) DeleteExpiredBatch(ctx context.Context, t time.Time, limit int) ([]store.ExpiredRecord, error) {
+	b.batches++
+	return b.Index.DeleteExpiredBatch(ctx, t, limit)
+}
+
+func TestStoreDeleteExpiredBatched(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	db := openTestDB(t)
+	ix, _ := sqlite.New(db)
+	idx := &batchCountingIndex{Index: ix}
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(idx, bs, fixedClock{now: now}, 4, store.WithExpireBatch(100))
+
+	const expired = 250
+	for i := range expired {
+		data := []byte("in")
+		if i%10 == 0 {
+			data = []byte("external-data")
+		}
+		if err := st.Save(ctx, fmt.Sprintf("%032x", i), app.Meta{Version: 1, NonceB64u: "n"}, bytesReader(data), int64(len(data)), now.Add(-time.Minute)); err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+	}
+	live := fmt.Sprintf("%032x", expired)
+	if err := st.Save(ctx, live, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte("ok")), 2, now.Add(time.Hour)); err != nil {
+		t.Fatalf("save live: %v", err)
+	}
+
+	count, err := st.DeleteExpired(ctx, now)
+	if err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if count != expired {
+		t.Fatalf("count = %d, want %d", count, expired)
+	}
+	if idx.batches != 3 {
+		t.Fatalf("batches = %d, want 3", idx.batches)
+	}
+	if left, _ := filepath.Glob(filepath.Join(blobDir, "*.blob")); len(left) != 0 {
+		t.Fatalf("blobs left after expiry: %v", left)
+	}
+	if _, _, _, err := st.Consume(ctx, live); err != nil {
+		t.Fatalf("live consume: %v", err)
+	}
+}
+
+// TestStoreNextExpiry checks the index's earliest expiry is shifted by the
+// clock skew tolerance, matching when DeleteExpired will first remove it.
+func TestStoreNextExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	ix, _ := sqlite.New(openTestDB(t))
+	bs, _ := filesystem.New(t.TempDir())
+	st := store.New(ix, bs, fixedClock{now: now}, 64, store.WithClockSkew(30*time.Second))
+	if next, err := st.NextExpiry(ctx); err != nil || !next.IsZero() {
+		t.Fatalf("empty NextExpiry = %v, %v", next, err)
+	}
+	if err := st.Save(ctx, "abababababababababababababababab", app.Meta{Version: 1, NonceB64u: "n"}, bytes.NewReader([]byte("x")), 1, now.Add(time.Minute)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	next, err := st.NextExpiry(ctx)
+	if err != nil {
+		t.Fatalf("NextExpiry: %v", err)
+	}
+	if want := now.Add(time.Minute + 30*time.Second); !next.Equal(want) {
+		t.Fatalf("NextExpiry = %v, want %v", next, want)
+	}
+
+	plain := store.New(struct{ store.Index }{ix}, bs, fixedClock{now: now}, 64)
+	if _, err := plain.NextExpiry(ctx); !errors.Is(err, store.ErrNextExpiryUnsupported) {
+		t.Fatalf("NextExpiry without NextExpirer = %v", err)
+	}
+}
+
+// blockingInsertIndex holds the first Insert until release is closed.
+type blockingInsertIndex struct {
+	*sqlite.Index
+	entered chan struct{}
+	release chan struct{}
+	once    bool
+}
+
+func (b *blockingInsertIndex) Insert(ctx context.Context, id string, meta app.Meta, inline []byte, external bool, size int64, createdAt, expiresAt time.Time) error {
+	if !b.once {
+		b.once = true
+		close(b.entered)
+		<-b.release
+	}
+	return b.Index.Insert(ctx, id, meta, inline, external, size, createdAt, expiresAt)
+}
+
+// TestStoreInlineMemBudget holds one inline Save mid-insert so the budget is
+// used up, then checks a small payload is forced to blob storage and that
+// inline storage resumes once the first Save finishes.
+func TestStoreInlineMemBudget(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	sx, _ := sqlite.New(openTestDB(t))
+	ix := &blockingInsertIndex{Index: sx, entered: make(chan struct{}), release: make(chan struct{})}
+	blobDir := t.TempDir()
+	bs, _ := filesystem.New(blobDir)
+	st := store.New(ix, bs, fixedClock{now: now}, 64, store.WithInlineMemBudget(12))
+
+	save := func(id, data string) error {
+		return st.Save(ctx, id, app.Meta{Version: 1, NonceB64u: "n"}, bytesReader([]byte(data)), int64(len(data)), now.Add(time.Hour))
+	}
+	isBlob := func(id string) bool {
+		_, err := os.Stat(filepath.Join(blobDir, id+".blob"))
+		return err == nil
+	}
+	const held, forced, after = "11111111111111111111111111111111", "22222222222222222222222222222222", "33333333333333333333333333333333"
+	done := make(chan error, 1)
+	go func() { done <- save(held, "ten bytes!") }()
+	<-ix.entered
+	if err := save(forced, "small"); err != nil {
+		t.Fatalf("Save under pressure: %v", err)
+	}
+	if !isBlob(forced) {
+		t.Fatal("small payload stayed inline with the budget exhausted")
+	}
+	close(ix.release)
+	if err := <-done; err != nil {
+		t.Fatalf("held Save: %v", err)
+	}
+	if err := save(after, "small"); err != nil {
+		t.Fatalf("Save after release: %v", err)
+	}
+	if isBlob(held) || isBlob(after) {
+		t.Fatal("payloads within the budget went to blob storage")
+	}
+	for _, id := range []string{held, forced, after} {
+		_, rc, _, err := st.Consume(ctx, id)
+		if err != nil {
+			t.Fatalf("Consume %s: %v", id, err)
+		}
+		_ = rc.Close()
+	}
+}
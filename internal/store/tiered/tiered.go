@@ -0,0 +1,162 @@
+// Package tiered provides a composite BlobStorage that keeps blobs up to a
+// size threshold on a primary backend and overflows larger ones to a second
+// backend, typically a cheaper or slower volume.
+package tiered
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/haukened/gone/internal/store"
+)
+
+// Ensure BlobStore implements store.BlobStorage and its optional extensions.
+var (
+	_ store.BlobStorage   = (*BlobStore)(nil)
+	_ store.TenantScoper  = (*BlobStore)(nil)
+	_ store.BlobOpener    = (*BlobStore)(nil)
+	_ store.BlobStater    = (*BlobStore)(nil)
+	_ store.SpaceReporter = (*BlobStore)(nil)
+)
+
+// BlobStore routes writes by size between two backends. No marker records
+// where a blob went: reads and deletes try the primary first and fall back to
+// the overflow backend when the primary reports fs.ErrNotExist, so blobs stay
+// reachable if the threshold changes between restarts.
+type BlobStore struct {
+	primary   store.BlobStorage
+	overflow  store.BlobStorage
+	threshold int64
+}
+
+// New returns a BlobStore writing blobs larger than threshold bytes to
+// overflow and everything else to primary.
+func New(primary, overflow store.BlobStorage, threshold int64) *BlobStore {
+	return &BlobStore{primary: primary, overflow: overflow, threshold: threshold}
+}
+
+// tiers returns the backend a blob of size bytes is written to, followed by
+// the other one.
+func (b *BlobStore) tiers(size int64) (target, other store.BlobStorage) {
+	if size > b.threshold {
+		return b.overflow, b.primary
+	}
+	return b.primary, b.overflow
+}
+
+// Write stores the blob on the backend chosen by size. An id already present
+// on the other backend fails with fs.ErrExist when that backend implements
+// store.BlobStater; the target backend enforces the same for itself.
+func (b *BlobStore) Write(id string, r io.Reader, size int64) error {
+	target, other := b.tiers(size)
+	if st, ok := other.(store.BlobStater); ok {
+		if _, err := st.Stat(id); err == nil {
+			return &fs.PathError{Op: "write", Path: id, Err: fs.ErrExist}
+		}
+	}
+	return target.Write(id, r, size)
+}
+
+// Consume returns a delete-on-close reader from whichever backend holds id.
+func (b *BlobStore) Consume(id string) (io.ReadCloser, error) {
+	rc, err := b.primary.Consume(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b.overflow.Consume(id)
+	}
+	return rc, err
+}
+
+// Open reads id without deleting it. Both backends must implement
+// store.BlobOpener.
+func (b *BlobStore) Open(id string) (io.ReadCloser, error) {
+	p, pok := b.primary.(store.BlobOpener)
+	o, ook := b.overflow.(store.BlobOpener)
+	if !pok || !ook {
+		return nil, errors.New("blob storage cannot serve multi-read secrets")
+	}
+	rc, err := p.Open(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.Open(id)
+	}
+	return rc, err
+}
+
+// Stat reports the size of id from whichever backend holds it. Both backends
+// must implement store.BlobStater.
+func (b *BlobStore) Stat(id string) (int64, error) {
+	p, pok := b.primary.(store.BlobStater)
+	o, ook := b.overflow.(store.BlobStater)
+	if !pok || !ook {
+		return 0, errors.New("blob storage cannot stat blobs")
+	}
+	n, err := p.Stat(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.Stat(id)
+	}
+	return n, err
+}
+
+// Delete removes id from whichever backend holds it.
+func (b *BlobStore) Delete(id string) error {
+	err := b.primary.Delete(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b.overflow.Delete(id)
+	}
+	return err
+}
+
+// List returns the union of both backends' blob IDs, so reconciliation sees
+// orphans on either tier.
+func (b *BlobStore) List() ([]string, error) {
+	ids, err := b.primary.List()
+	if err != nil {
+		return nil, err
+	}
+	more, err := b.overflow.List()
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, more...), nil
+}
+
+// ForTenant scopes each backend that implements store.TenantScoper to the
+// tenant; a backend that does not is shared as-is.
+func (b *BlobStore) ForTenant(tenant string) (store.BlobStorage, error) {
+	primary, err := forTenant(b.primary, tenant)
+	if err != nil {
+		return nil, err
+	}
+	overflow, err := forTenant(b.overflow, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return New(primary, overflow, b.threshold), nil
+}
+
+func forTenant(blobs store.BlobStorage, tenant string) (store.BlobStorage, error) {
+	if scoper, ok := blobs.(store.TenantScoper); ok {
+		return scoper.ForTenant(tenant)
+	}
+	return blobs, nil
+}
+
+// FreeBytes reports the lower of the two backends' free space, so a nearly
+// full tier blocks creates even if the blob would land on the other one. Both
+// backends must implement store.SpaceReporter.
+func (b *BlobStore) FreeBytes() (int64, error) {
+	p, pok := b.primary.(store.SpaceReporter)
+	o, ook := b.overflow.(store.SpaceReporter)
+	if !pok || !ook {
+		return 0, errors.New("blob storage cannot report free space")
+	}
+	pf, err := p.FreeBytes()
+	if err != nil {
+		return 0, err
+	}
+	of, err := o.FreeBytes()
+	if err != nil {
+		return 0, err
+	}
+	return min(pf, of), nil
+}
@@ -0,0 +1,206 @@
+package tiered_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/haukened/gone/internal/app"
+	"github.com/haukened/gone/internal/store"
+	"github.com/haukened/gone/internal/store/filesystem"
+	"github.com/haukened/gone/internal/store/sqlite"
+	"github.com/haukened/gone/internal/store/tiered"
+)
+
+const (
+	smallID = "11111111111111111111111111111111"
+	largeID = "22222222222222222222222222222222"
+)
+
+type fixedClock struct{ now time.Time }
+
+func (f fixedClock) Now() time.Time { return f.now }
+
+// newTiers returns a tiered store over two filesystem backends with a 16-byte
+// threshold, plus the two blob directories.
+func newTiers(t *testing.T) (*tiered.BlobStore, string, string) {
+	t.Helper()
+	fastDir, slowDir := t.TempDir(), t.TempDir()
+	fast, err := filesystem.New(fastDir)
+	if err != nil {
+		t.Fatalf("primary: %v", err)
+	}
+	slow, err := filesystem.New(slowDir)
+	if err != nil {
+		t.Fatalf("overflow: %v", err)
+	}
+	return tiered.New(fast, slow, 16), fastDir, slowDir
+}
+
+func blobExists(dir, id string) bool {
+	_, err := os.Stat(filepath.Join(dir, id+".blob"))
+	return err == nil
+}
+
+// backdate ages every blob in dir past the List freshness guard.
+func backdate(t *testing.T, dir string) {
+	t.Helper()
+	old := time.Now().Add(-time.Minute)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	for _, e := range entries {
+		if err := os.Chtimes(filepath.Join(dir, e.Name()), old, old); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+}
+
+func TestRoutesBySize(t *testing.T) {
+	bs, fastDir, slowDir := newTiers(t)
+	small, large := "sixteen bytes!!!", strings.Repeat("L", 17)
+	if err := bs.Write(smallID, strings.NewReader(small), int64(len(small))); err != nil {
+		t.Fatalf("write small: %v", err)
+	}
+	if err := bs.Write(largeID, strings.NewReader(large), int64(len(large))); err != nil {
+		t.Fatalf("write large: %v", err)
+	}
+	if !blobExists(fastDir, smallID) || blobExists(slowDir, smallID) {
+		t.Fatal("small blob not on primary only")
+	}
+	if !blobExists(slowDir, largeID) || blobExists(fastDir, largeID) {
+		t.Fatal("large blob not on overflow only")
+	}
+
+	if n, err := bs.Stat(largeID); err != nil || n != int64(len(large)) {
+		t.Fatalf("Stat large = %d, %v", n, err)
+	}
+	rc, err := bs.Open(largeID)
+	if err != nil {
+		t.Fatalf("Open large: %v", err)
+	}
+	_ = rc.Close()
+	for id, want := range map[string]string{smallID: small, largeID: large} {
+		rc, err := bs.Consume(id)
+		if err != nil {
+			t.Fatalf("Consume %s: %v", id, err)
+		}
+		got, _ := io.ReadAll(rc)
+		if err := rc.Close(); err != nil {
+			t.Fatalf("close %s: %v", id, err)
+		}
+		if string(got) != want {
+			t.Fatalf("Consume %s = %q, want %q", id, got, want)
+		}
+	}
+	if blobExists(fastDir, smallID) || blobExists(slowDir, largeID) {
+		t.Fatal("consumed blobs not deleted")
+	}
+	if _, err := bs.Consume(largeID); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Consume missing = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestWriteExistingOnOtherTier(t *testing.T) {
+	bs, _, _ := newTiers(t)
+	if err := bs.Write(smallID, strings.NewReader("x"), 1); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	large := strings.Repeat("L", 32)
+	if err := bs.Write(smallID, strings.NewReader(large), int64(len(large))); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Write duplicate = %v, want fs.ErrExist", err)
+	}
+}
+
+func TestDeleteAndListBothTiers(t *testing.T) {
+	bs, fastDir, slowDir := newTiers(t)
+	large := strings.Repeat("L", 32)
+	_ = bs.Write(smallID, strings.NewReader("x"), 1)
+	_ = bs.Write(largeID, strings.NewReader(large), int64(len(large)))
+	backdate(t, fastDir)
+	backdate(t, slowDir)
+	ids, err := bs.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{smallID, largeID}) {
+		t.Fatalf("List = %v", ids)
+	}
+	if err := bs.Delete(largeID); err != nil {
+		t.Fatalf("Delete overflow blob: %v", err)
+	}
+	if blobExists(slowDir, largeID) {
+		t.Fatal("overflow blob survived Delete")
+	}
+	if err := bs.Delete(largeID); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Delete missing = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestReconcileAcrossTiers(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gone.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	ix, err := sqlite.New(db)
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	bs, fastDir, slowDir := newTiers(t)
+	st := store.New(ix, bs, fixedClock{now: time.Now()}, 4)
+
+	meta := app.Meta{Version: 1, NonceB64u: "n"}
+	small, large := "0123456789", strings.Repeat("L", 32)
+	expires := time.Now().Add(time.Hour)
+	if err := st.Save(ctx, smallID, meta, strings.NewReader(small), int64(len(small)), expires); err != nil {
+		t.Fatalf("save small: %v", err)
+	}
+	if err := st.Save(ctx, largeID, meta, strings.NewReader(large), int64(len(large)), expires); err != nil {
+		t.Fatalf("save large: %v", err)
+	}
+	orphans := map[string]string{
+		fastDir: "33333333333333333333333333333333",
+		slowDir: "44444444444444444444444444444444",
+	}
+	for dir, id := range orphans {
+		if err := os.WriteFile(filepath.Join(dir, id+".blob"), []byte("orphan"), 0o600); err != nil {
+			t.Fatalf("orphan: %v", err)
+		}
+		backdate(t, dir)
+	}
+
+	if err := st.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	for dir, id := range orphans {
+		if blobExists(dir, id) {
+			t.Fatalf("orphan %s survived reconcile", id)
+		}
+	}
+	if !blobExists(fastDir, smallID) || !blobExists(slowDir, largeID) {
+		t.Fatal("reconcile removed live blobs")
+	}
+	_, rc, _, err := st.Consume(ctx, largeID)
+	if err != nil {
+		t.Fatalf("consume large: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != large {
+		t.Fatalf("consume large = %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Package tracing adapts OpenTelemetry to the app.Tracer port. It is the only
+// package that depends on the OTel SDK; the core packages see just the port.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/haukened/gone/internal/app"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported as the OTel service.name resource attribute.
+const ServiceName = "gone"
+
+var _ app.Tracer = (*Tracer)(nil)
+
+// Tracer implements app.Tracer on top of an OTel trace.Tracer.
+type Tracer struct{ t trace.Tracer }
+
+// New wraps an existing OTel tracer.
+func New(t trace.Tracer) *Tracer { return &Tracer{t: t} }
+
+// Setup creates an OTLP/HTTP exporter for endpoint (host:port or URL) and
+// returns a Tracer plus a shutdown function that flushes pending spans.
+// When endpoint is empty tracing is disabled: a NoopTracer and a no-op
+// shutdown are returned.
+func Setup(ctx context.Context, endpoint string) (app.Tracer, func(context.Context) error, error) {
+	if endpoint == "" {
+		return app.NoopTracer{}, func(context.Context) error { return nil }, nil
+	}
+	exp, err := otlptracehttp.New(ctx, endpointOption(endpoint))
+	if err != nil {
+		return nil, nil, fmt.Errorf("otel exporter: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	return New(tp.Tracer(ServiceName)), tp.Shutdown, nil
+}
+
+// endpointOption selects between a full URL and a bare host:port. A bare
+// host:port is exported to over HTTPS; plaintext collectors need an explicit
+// http:// URL.
+func endpointOption(endpoint string) otlptracehttp.Option {
+	if strings.Contains(endpoint, "://") {
+		return otlptracehttp.WithEndpointURL(endpoint)
+	}
+	return otlptracehttp.WithEndpoint(endpoint)
+}
+
+// Start begins an OTel span as a child of any span in ctx.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...app.Attr) (context.Context, app.Span) {
+	ctx, s := t.t.Start(ctx, name, trace.WithAttributes(convert(attrs)...))
+	return ctx, span{s: s}
+}
+
+// span adapts trace.Span to app.Span.
+type span struct{ s trace.Span }
+
+func (sp span) SetAttrs(attrs ...app.Attr) { sp.s.SetAttributes(convert(attrs)...) }
+
+func (sp span) RecordError(err error) {
+	sp.s.RecordError(err)
+	sp.s.SetStatus(codes.Error, "error")
+}
+
+func (sp span) End() { sp.s.End() }
+
+// convert maps app attributes to OTel key/values. Unsupported value types are
+// rendered with fmt so that a bad attribute never panics a request.
+func convert(attrs []app.Attr) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			out = append(out, attribute.String(a.Key, v))
+		case bool:
+			out = append(out, attribute.Bool(a.Key, v))
+		case int:
+			out = append(out, attribute.Int(a.Key, v))
+		case int64:
+			out = append(out, attribute.Int64(a.Key, v))
+		default:
+			out = append(out, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	return out
+}
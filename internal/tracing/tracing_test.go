@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/haukened/gone/internal/app"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	tr, shutdown, err := Setup(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Setup error: %v", err)
+	}
+	if _, ok := tr.(app.NoopTracer); !ok {
+		t.Fatalf("expected NoopTracer, got %T", tr)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+}
+
+func TestTracerRecordsSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	tr := New(tp.Tracer("test"))
+	ctx, parent := tr.Start(context.Background(), "parent", app.Attr{Key: "k", Value: "v"})
+	_, child := tr.Start(ctx, "child", app.Attr{Key: "n", Value: int64(3)}, app.Attr{Key: "b", Value: true}, app.Attr{Key: "f", Value: 1.5})
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.SetAttrs(app.Attr{Key: "i", Value: 7})
+	parent.End()
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "child" || spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatalf("child span not parented to parent")
+	}
+	if len(spans[0].Attributes()) != 3 || len(spans[1].Attributes()) != 2 {
+		t.Fatalf("unexpected attributes: %v / %v", spans[0].Attributes(), spans[1].Attributes())
+	}
+}
+
+func TestSetupEndpointForms(t *testing.T) {
+	for _, ep := range []string{"collector:4318", "http://collector:4318", "https://collector:4318/v1/traces"} {
+		tr, shutdown, err := Setup(context.Background(), ep)
+		if err != nil || tr == nil {
+			t.Fatalf("Setup(%q) = %v, %v", ep, tr, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("shutdown %q: %v", ep, err)
+		}
+	}
+}
@@ -109,7 +109,11 @@
       showError('Cannot submit empty secret');
       return null;
     }
-    const ttl = ttlSelect.value;
+    if (ttlSelect.validity && !ttlSelect.validity.valid) {
+      showError('Enter a TTL like 17m or 1h30m (' + ttlSelect.title + ')');
+      return null;
+    }
+    const ttl = ttlSelect.value.trim();
     primaryBtn.disabled = true;
     if (errorBox) errorBox.hidden = true;
     return { raw, ttl, t0: performance.now() };